@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/viplounge/platform/internal/testing/fakes"
+)
+
+// fakeapis sobe fakes em memória da Superlógica e da Rede Parcerias para
+// rodar o servidor sem acesso às APIs reais. As falhas são injetadas pela
+// API de controle em /_fakes (ver o pacote fakes).
+func main() {
+	addr := flag.String("addr", "127.0.0.1:9090", "endereço de escuta")
+	fixturesPath := flag.String("fixtures", "", "arquivo JSON de fixtures (vazio = fixtures padrão)")
+	flag.Parse()
+
+	fx := fakes.DefaultFixtures()
+	if *fixturesPath != "" {
+		var err error
+		if fx, err = fakes.LoadFixtures(*fixturesPath); err != nil {
+			log.Fatalf("[FAKEAPIS] %v", err)
+		}
+	}
+	server := fakes.NewServer(fx)
+
+	log.Printf("[FAKEAPIS] %d unidades e %d usuários carregados", len(fx.Superlogica.Units), len(fx.RedeParcerias.Users))
+	log.Printf("[FAKEAPIS] Ouvindo em http://%s - para apontar o servidor:", *addr)
+	env := server.Env("http://" + *addr)
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Printf("export %s=%s\n", k, env[k])
+	}
+
+	srv := &http.Server{Addr: *addr, Handler: logRequests(server)}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("[FAKEAPIS] %v", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	srv.Shutdown(ctx)
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		log.Printf("[FAKEAPIS] %s %s -> %d (%v)", r.Method, r.URL.RequestURI(), rec.status, time.Since(start).Round(time.Millisecond))
+	})
+}
@@ -2,69 +2,302 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/viplounge/platform/internal/adapter/benef"
-	"github.com/viplounge/platform/internal/adapter/redeparcerias"
+	"github.com/viplounge/platform/internal/adapter"
+	"github.com/viplounge/platform/internal/auth"
 	"github.com/viplounge/platform/internal/config"
+	"github.com/viplounge/platform/internal/directory"
+	"github.com/viplounge/platform/internal/domain"
 	"github.com/viplounge/platform/internal/handler"
+	"github.com/viplounge/platform/internal/lifecycle"
+	customMiddleware "github.com/viplounge/platform/internal/middleware"
+	"github.com/viplounge/platform/internal/outbound"
+	"github.com/viplounge/platform/internal/pii"
 	"github.com/viplounge/platform/internal/repository"
+	"github.com/viplounge/platform/internal/retention"
+	"github.com/viplounge/platform/internal/secrets"
 	"github.com/viplounge/platform/internal/service"
 )
 
 func main() {
-	ctx := context.Background()
+	// SIGTERM é enviado pelo Cloud Run em todo scale-down; SIGINT no Ctrl+C local
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
 
 	// 1. Carregar Configuração Agnóstica
+	// CONFIG_STRICT=true recusa iniciar com config.yaml ausente ou inválido
+	strict, _ := strconv.ParseBool(os.Getenv("CONFIG_STRICT"))
+
 	cfg, err := config.Load("config.yaml")
 	if err != nil {
+		if strict {
+			log.Fatalf("FATAL: Erro carregando config.yaml (CONFIG_STRICT): %v", err)
+		}
 		log.Printf("WARN: Erro carregando config.yaml, usando defaults: %v", err)
 		cfg = config.Get()
 	}
+	if err := cfg.Validate(); err != nil {
+		if strict {
+			log.Fatalf("FATAL: %v", err)
+		}
+		log.Printf("WARN: %v", err)
+	}
 	log.Printf("App carregado: %s", cfg.Branding.AppName)
 
 	// 2. Configuração
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
 	projectID := os.Getenv("GOOGLE_CLOUD_PROJECT")
 
 	// 3. Dependências
-	// Repo
-	repo, err := repository.NewFirestoreRepository(ctx, projectID)
+	// Repo (em memória quando o Firestore não está disponível, ex. dev local).
+	// Em produção ou com CONFIG_STRICT o fallback perderia leads e auditoria
+	// a cada restart, então a falha impede a inicialização.
+	var repo repository.Store
+	repo, err = repository.NewFirestoreRepository(context.Background(), projectID)
 	if err != nil {
-		log.Printf("WARN: Firestore init failed (expected in local dev without creds): %v", err)
-	} else {
-		defer repo.Close()
+		if strict || cfg.IsProduction() {
+			log.Fatalf("FATAL: Firestore indisponível, repositório em memória não é aceito em produção/CONFIG_STRICT: %v", err)
+		}
+		log.Printf("WARN: Firestore init failed (expected in local dev without creds), usando repositório em memória: %v", err)
+		repo = repository.NewMemoryRepository()
+	}
+
+	// Jobs em background, drenados no encerramento
+	jobs := lifecycle.NewGroup(context.Background())
+
+	// Hot reload: tenants acompanham cada nova versão da config
+	customMiddleware.ConfigureTenants(cfg)
+	config.Subscribe(customMiddleware.ConfigureTenants)
+	if cfg.Reload.Enabled {
+		watcher := config.NewWatcher("config.yaml", cfg)
+		jobs.Go("config-watcher", func(ctx context.Context) {
+			watcher.Run(ctx, jobs.Stopping())
+		})
 	}
 
-	// Adapter
-	benefAdapter := benef.NewBenefAdapter()
-	partnerAdapter := redeparcerias.NewClient()
+	// Credenciais (env, segredos montados ou Secret Manager)
+	secretProvider, err := secrets.FromConfig(cfg)
+	if err != nil {
+		log.Fatalf("FATAL: %v", err)
+	}
+
+	// Cifragem em repouso dos dados pessoais dos leads (privacy.encryption)
+	protector, err := pii.FromConfig(context.Background(), cfg, secretProvider)
+	if err != nil {
+		log.Fatalf("FATAL: %v", err)
+	}
+	if protector != nil {
+		repo.EnableEncryption(protector)
+		repo.SetLegacyLookup(cfg.Privacy.Encryption.LegacyLookup)
+	}
+
+	// Adapters construídos a partir de Integrations.*.Type
+	adapterOpts := adapter.Options{Secrets: secretProvider, Production: cfg.IsProduction()}
+	benefAdapter, err := adapter.NewSourceRegistry(cfg, adapterOpts)
+	if err != nil {
+		log.Fatalf("FATAL: %v", err)
+	}
+	// Clubes de Benefícios: partner_integration ("default") e integrations.clubs
+	clubs, err := adapter.NewPartnerRegistry(cfg, adapterOpts)
+	if err != nil {
+		log.Fatalf("FATAL: %v", err)
+	}
+	partnerAdapter := clubs.Default()
+
+	// Diretório de moradores: validação responde da cópia local, com a
+	// Superlógica como fallback, e um job mantém a cópia sincronizada
+	var validator domain.BenefValidator = benefAdapter
+	if cfg.Directory.Enabled {
+		// Só entram no diretório os condomínios cuja fonte lista unidades
+		var condos []string
+		for _, condoID := range cfg.DirectoryCondos() {
+			if benefAdapter.ListsMembers(context.Background(), condoID) {
+				condos = append(condos, condoID)
+				continue
+			}
+			log.Printf("WARN: fonte de moradores %q do condomínio %s não lista unidades, condomínio fora do diretório", cfg.TenantSource(condoID), condoID)
+		}
+		if len(condos) > 0 {
+			syncer := directory.NewSyncer(benefAdapter, repo, cfg.Directory.MaxDeparturePercent)
+			interval := time.Duration(cfg.Directory.SyncIntervalMinutes) * time.Minute
+			jobs.Go("directory-sync", func(ctx context.Context) {
+				syncer.Run(ctx, jobs.Stopping(), interval, condos)
+			})
+			validator = directory.NewValidator(benefAdapter, repo, time.Duration(cfg.Directory.MaxStalenessMinutes)*time.Minute)
+		} else {
+			log.Printf("WARN: nenhum condomínio do diretório tem fonte que lista unidades, diretório desabilitado")
+		}
+	}
 
 	// Service
-	svc := service.NewValidationService(repo, benefAdapter, partnerAdapter, cfg)
+	svc := service.NewValidationService(repo, validator, partnerAdapter, cfg)
+	svc.SetClubs(clubs)
+
+	// Lembrar de mim: sempre ligado no service, para tenants[].remember_me
+	// valer no hot reload; sem o segredo nenhuma sessão é emitida
+	sessionSecret := secrets.NewSecret(secretProvider, cfg.RememberMe.SecretRef)
+	if cfg.RememberMe.Enabled {
+		if _, err := sessionSecret.Value(context.Background()); err != nil {
+			log.Printf("WARN: segredo do lembrar de mim (%s) indisponível, sessões não serão emitidas: %v", sessionSecret.Ref(), err)
+		}
+	}
+	svc.SetSessions(repo, sessionSecret)
+
+	// Webhooks de saída: eventos do lead para CRMs e administradoras.
+	// Entregas que falham são reenviadas com backoff por um job periódico.
+	var dispatcher *outbound.Dispatcher
+	if cfg.Webhooks.Outbound.Enabled {
+		dispatcher = outbound.NewDispatcher(repo, secretProvider, jobs)
+		svc.SetPublisher(dispatcher)
+		jobs.Go("outbound-webhook-retry", func(ctx context.Context) {
+			dispatcher.Run(ctx, jobs.Stopping(), 15*time.Second)
+		})
+	}
+
+	// Retenção: expurgo periódico dos leads que passaram do prazo das regras
+	// de privacy.retention (também disparado pela API do suporte)
+	purger := retention.NewPurger(repo, secrets.NewSecret(secretProvider, cfg.Privacy.SecretRef))
+	if cfg.Privacy.Retention.Enabled {
+		interval := time.Duration(cfg.Privacy.Retention.IntervalMinutes) * time.Minute
+		jobs.Go("retention-purge", func(ctx context.Context) {
+			purger.Run(ctx, jobs.Stopping(), interval)
+		})
+	}
 
 	// Handler
-	h := handler.NewHandler(svc, cfg)
-	
+	// API keys e tokens OIDC/JWT acompanham o hot reload de auth.*
+	authn := auth.NewAuthenticator(cfg, secretProvider)
+	config.Subscribe(authn.Configure)
+
+	h := handler.NewHandler(svc, authn, cfg)
+	h.EnableIdempotency(repo)
+	if protector != nil {
+		h.EnableIdempotencySealing(protector)
+	}
+
+	// Notificações da Superlógica: consultam a API ao vivo, nunca o diretório
+	if cfg.Webhooks.Superlogica.Enabled {
+		secret := secrets.NewSecret(secretProvider, cfg.Webhooks.Superlogica.SecretRef)
+		if _, err := secret.Value(context.Background()); err != nil {
+			if cfg.IsProduction() {
+				log.Fatalf("FATAL: segredo do webhook da Superlógica (%s) indisponível: %v", secret.Ref(), err)
+			}
+			log.Printf("WARN: segredo do webhook da Superlógica (%s) indisponível, notificações serão recusadas: %v", secret.Ref(), err)
+		}
+		webhooks := service.NewWebhookService(repo, repo, benefAdapter, partnerAdapter, secret, cfg, jobs)
+		webhooks.SetClubs(clubs)
+		if dispatcher != nil {
+			webhooks.SetPublisher(dispatcher)
+		}
+		h.EnableWebhooks(webhooks)
+	}
+
+	// API do suporte
+	if cfg.Admin.Enabled {
+		admin := service.NewAdminService(repo, repo, partnerAdapter)
+		admin.SetClubs(clubs)
+		admin.EnableSSO(svc)
+		if cfg.Directory.Enabled {
+			admin.EnableDirectory(repo)
+		}
+		if dispatcher != nil {
+			admin.EnableDeliveries(repo, dispatcher)
+			admin.SetPublisher(dispatcher)
+		}
+		// LGPD: sem o segredo os pedidos do titular são recusados
+		privacySecret := secrets.NewSecret(secretProvider, cfg.Privacy.SecretRef)
+		if _, err := privacySecret.Value(context.Background()); err != nil {
+			log.Printf("WARN: segredo da LGPD (%s) indisponível, pedidos do titular serão recusados: %v", privacySecret.Ref(), err)
+		}
+		admin.EnablePrivacy(repo, privacySecret)
+		admin.EnableRetention(purger, repo)
+		admin.EnableConsents(repo)
+		if protector != nil {
+			admin.EnableEncryption(repo)
+		}
+		h.EnableAdmin(admin)
+	}
+
 	// 4. Roteamento API
 	r := chi.NewRouter()
-	
+
 	// Mount API routes PRIMEIRO (Handler contém CORS)
 	r.Mount("/", h.Routes())
 
 	// NÃO montar fileServer aqui - já está no handler
 
+	// 5. Servidor HTTP com timeouts
+	// O contexto base só é cancelado se o prazo de drenagem estourar, para que
+	// cadastros em andamento não sejam interrompidos no meio da chamada.
+	baseCtx, cancelBase := context.WithCancel(context.Background())
+	defer cancelBase()
+
+	srv := &http.Server{
+		Addr:              ":" + cfg.Server.Port,
+		Handler:           r,
+		ReadHeaderTimeout: seconds(cfg.Server.ReadHeaderTimeoutSeconds),
+		ReadTimeout:       seconds(cfg.Server.ReadTimeoutSeconds),
+		WriteTimeout:      seconds(cfg.Server.WriteTimeoutSeconds),
+		IdleTimeout:       seconds(cfg.Server.IdleTimeoutSeconds),
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
+		BaseContext:       func(net.Listener) context.Context { return baseCtx },
+	}
+
 	// 6. Iniciar Servidor
-	log.Printf("🚀 Server '%s' starting on port %s", cfg.Branding.AppName, port)
-	if err := http.ListenAndServe(":"+port, r); err != nil {
-		log.Fatal(err)
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("🚀 Server '%s' starting on port %s", cfg.Branding.AppName, cfg.Server.Port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+		close(serverErr)
+	}()
+
+	select {
+	case err := <-serverErr:
+		if err != nil {
+			log.Fatal(err)
+		}
+	case <-ctx.Done():
+		log.Printf("[SHUTDOWN] Sinal recebido, parando de aceitar requisições...")
+	}
+	stop()
+
+	// 7. Encerramento gracioso: drenar requisições e jobs dentro do prazo
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), seconds(cfg.Server.ShutdownTimeoutSeconds))
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("[SHUTDOWN] Requisições em andamento não finalizaram no prazo: %v", err)
+		cancelBase()
+		srv.Close()
+	} else {
+		log.Printf("[SHUTDOWN] Requisições em andamento finalizadas")
+	}
+
+	if err := jobs.Shutdown(shutdownCtx); err != nil {
+		log.Printf("[SHUTDOWN] %v", err)
+	} else {
+		log.Printf("[SHUTDOWN] Jobs em background finalizados")
+	}
+
+	// 8. Fechar clientes externos
+	if err := repo.Close(); err != nil {
+		log.Printf("[SHUTDOWN] Erro fechando repositório: %v", err)
 	}
-}
 
+	log.Printf("[SHUTDOWN] Servidor encerrado")
+}
 
+func seconds(n int) time.Duration {
+	return time.Duration(n) * time.Second
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/viplounge/platform/internal/config"
+)
+
+func runConfig(args []string) error {
+	if len(args) == 0 || args[0] != "check" {
+		return errors.New("uso: viplounge-admin config check [-config config.yaml]")
+	}
+
+	fs := flag.NewFlagSet("config check", flag.ExitOnError)
+	path := fs.String("config", "config.yaml", "caminho do config.yaml")
+	fs.Parse(args[1:])
+
+	settings, cfg, err := config.Explain(*path)
+	if err != nil {
+		if !config.IsNotExist(err) {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "aviso: %s não encontrado, exibindo defaults + env\n\n", *path)
+		if settings, cfg, err = config.Explain(""); err != nil {
+			return err
+		}
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHAVE\tVALOR\tORIGEM")
+	for _, s := range settings {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Key, s.Value, s.Source)
+	}
+	tw.Flush()
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Println()
+		return err
+	}
+	fmt.Println("\nconfiguração válida")
+	return nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+
+	"github.com/viplounge/platform/internal/adapter"
+	"github.com/viplounge/platform/internal/config"
+	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/importer"
+	"github.com/viplounge/platform/internal/pii"
+	"github.com/viplounge/platform/internal/repository"
+	"github.com/viplounge/platform/internal/secrets"
+	"github.com/viplounge/platform/internal/service"
+)
+
+const importUsage = `uso: viplounge-admin import (-file cpfs.csv|cpfs.xlsx | -condo ID) [opções]
+
+Aplica a árvore de decisão da landing page a cada CPF, sem confirmação de
+e-mail, e cadastra na Rede Parcerias os moradores elegíveis.
+
+  -file       CSV (, ou ;) ou XLSX com coluna "cpf" e opcional "condo_id"
+  -condo      condomínio na Superlógica: sem -file, importa todas as unidades;
+              com -file, é o condomínio das linhas sem condo_id
+  -report     relatório CSV por linha (padrão import-report.csv)
+  -resume     pula as linhas já concluídas no relatório e continua nele
+  -dry-run    só decide, sem cadastrar, revogar ou gravar leads
+  -concurrency, -rate   paralelismo e CPFs por segundo`
+
+// runImport pré-cadastra em lote os moradores de um condomínio
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprintln(os.Stderr, importUsage) }
+	path := fs.String("config", "config.yaml", "caminho do config.yaml")
+	file := fs.String("file", "", "arquivo .csv ou .xlsx com os CPFs")
+	condo := fs.String("condo", "", "ID do condomínio na Superlógica")
+	reportPath := fs.String("report", "import-report.csv", "relatório de resultado por linha")
+	resume := fs.Bool("resume", false, "retomar a partir do relatório existente")
+	dryRun := fs.Bool("dry-run", false, "simular sem alterar nada")
+	concurrency := fs.Int("concurrency", 4, "CPFs processados em paralelo")
+	ratePerSec := fs.Float64("rate", 2, "máximo de CPFs iniciados por segundo (0 = sem limite)")
+	fs.Parse(args)
+
+	if *file == "" && *condo == "" {
+		return errors.New(importUsage)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	cfg, err := config.Load(*path)
+	if err != nil {
+		if !config.IsNotExist(err) {
+			return err
+		}
+		cfg = config.Get()
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	provider, err := secrets.FromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	// Importação sempre exige credenciais completas, como em produção
+	opts := adapter.Options{Secrets: provider, Production: true}
+	validator, err := adapter.NewSourceRegistry(cfg, opts)
+	if err != nil {
+		return err
+	}
+	// Todos os clubes, para cada CPF ir ao clube principal do condomínio dele
+	clubs, err := adapter.NewPartnerRegistry(cfg, opts)
+	if err != nil {
+		return err
+	}
+
+	// Linhas a importar
+	var rows []importer.Row
+	if *file != "" {
+		rows, err = importer.ReadFile(*file, *condo)
+	} else {
+		if !validator.ListsMembers(ctx, *condo) {
+			return fmt.Errorf("fonte de moradores %q do condomínio %s não lista unidades; use -file", cfg.TenantSource(*condo), *condo)
+		}
+		rows, err = importer.FromSuperlogica(ctx, validator, *condo)
+	}
+	if err != nil {
+		return err
+	}
+	rows = importer.Dedupe(rows)
+	log.Printf("[IMPORT] %d CPFs a processar (dry-run=%v)", len(rows), *dryRun)
+
+	// Leads vão para o mesmo repositório do servidor; em dry-run nada é gravado
+	var repo repository.Store
+	if !*dryRun {
+		repo, err = repository.NewFirestoreRepository(ctx, os.Getenv("GOOGLE_CLOUD_PROJECT"))
+		if err != nil {
+			return fmt.Errorf("firestore indisponível (use -dry-run para simular): %w", err)
+		}
+		defer repo.Close()
+	} else {
+		repo = repository.NewMemoryRepository()
+	}
+	protector, err := pii.FromConfig(ctx, cfg, provider)
+	if err != nil {
+		return err
+	}
+	if protector != nil {
+		repo.EnableEncryption(protector)
+		repo.SetLegacyLookup(cfg.Privacy.Encryption.LegacyLookup)
+	}
+
+	done := map[string]bool{}
+	if *resume {
+		if done, err = importer.LoadCheckpoint(*reportPath); err != nil {
+			return err
+		}
+	}
+	report, err := importer.OpenReport(*reportPath, *resume)
+	if err != nil {
+		return err
+	}
+	defer report.Close()
+
+	svc := service.NewValidationService(repo, validator, clubs.Default(), cfg)
+	svc.SetClubs(clubs)
+	runner := &importer.Runner{
+		Service:     svc,
+		Report:      report,
+		Concurrency: *concurrency,
+		RatePerSec:  *ratePerSec,
+		DryRun:      *dryRun,
+	}
+	summary := runner.Run(ctx, rows, done)
+
+	fmt.Printf("\nTotal: %d  Já concluídos (checkpoint): %d\n", summary.Total, summary.Skipped)
+	actions := make([]string, 0, len(summary.Actions))
+	for action := range summary.Actions {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	for _, action := range actions {
+		fmt.Printf("  %-20s %d\n", action, summary.Actions[action])
+	}
+	fmt.Printf("Relatório: %s\n", *reportPath)
+
+	if ctx.Err() != nil {
+		return errors.New("importação interrompida; execute novamente com -resume")
+	}
+	if summary.Actions[domain.ImportActionFailed] > 0 {
+		return fmt.Errorf("%d linhas falharam; corrija e execute novamente com -resume", summary.Actions[domain.ImportActionFailed])
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// viplounge-admin reúne comandos operacionais executados fora do servidor
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "config":
+		err = runConfig(os.Args[2:])
+	case "import":
+		err = runImport(os.Args[2:])
+	case "token":
+		err = runToken(os.Args[2:])
+	case "webhooks":
+		err = runWebhooks(os.Args[2:])
+	case "help", "-h", "--help":
+		usage()
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "comando desconhecido: %s\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "erro: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `Uso: viplounge-admin <comando> [opções]
+
+Comandos:
+  config check    Valida a configuração e imprime os valores efetivos com a origem de cada um
+  import          Pré-cadastra em lote os CPFs de um arquivo ou de um condomínio da Superlógica
+  token           Emite um JWT de teste assinado com auth.jwt.static_key_ref
+  webhooks replay Reprocessa notificações da Superlógica que falharam ou foram perdidas
+`)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/viplounge/platform/internal/auth"
+	"github.com/viplounge/platform/internal/config"
+	"github.com/viplounge/platform/internal/secrets"
+)
+
+// runToken emite um JWT HS256 com a chave estática de auth.jwt.static_key_ref,
+// para testar as rotas administrativas sem o provedor OIDC
+func runToken(args []string) error {
+	fs := flag.NewFlagSet("token", flag.ExitOnError)
+	path := fs.String("config", "config.yaml", "caminho do config.yaml")
+	subject := fs.String("sub", "", "e-mail ou ID do operador")
+	roles := fs.String("roles", string(auth.RoleSupport), "papéis separados por vírgula")
+	tenants := fs.String("tenants", "", "condomínios separados por vírgula (* = todos)")
+	ttl := fs.Duration("ttl", time.Hour, "validade do token")
+	fs.Parse(args)
+
+	if *subject == "" {
+		return errors.New("uso: viplounge-admin token -sub operador@exemplo.com [-roles support] [-tenants 4] [-ttl 1h]")
+	}
+
+	cfg, err := config.Load(*path)
+	if err != nil {
+		if !config.IsNotExist(err) {
+			return err
+		}
+		cfg = config.Get()
+	}
+	jwt := cfg.Auth.JWT
+	if jwt.StaticKeyRef == "" {
+		return errors.New("auth.jwt.static_key_ref não configurado; tokens reais vêm do provedor OIDC")
+	}
+
+	provider, err := secrets.FromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	key, err := secrets.NewSecret(provider, jwt.StaticKeyRef).Value(context.Background())
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	claims := auth.Claims{
+		"sub":            *subject,
+		"iat":            now.Unix(),
+		"exp":            now.Add(*ttl).Unix(),
+		jwt.RolesClaim:   strings.Split(*roles, ","),
+		jwt.TenantsClaim: splitNonEmpty(*tenants),
+	}
+	if jwt.Issuer != "" {
+		claims["iss"] = jwt.Issuer
+	}
+	if jwt.Audience != "" {
+		claims["aud"] = jwt.Audience
+	}
+
+	token, err := auth.SignHS256([]byte(key), claims)
+	if err != nil {
+		return err
+	}
+	fmt.Println(token)
+	return nil
+}
+
+func splitNonEmpty(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
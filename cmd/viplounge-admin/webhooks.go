@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/viplounge/platform/internal/adapter"
+	"github.com/viplounge/platform/internal/config"
+	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/lifecycle"
+	"github.com/viplounge/platform/internal/outbound"
+	"github.com/viplounge/platform/internal/pii"
+	"github.com/viplounge/platform/internal/repository"
+	"github.com/viplounge/platform/internal/secrets"
+	"github.com/viplounge/platform/internal/service"
+)
+
+const webhooksUsage = `uso: viplounge-admin webhooks replay [opções]
+
+Reprocessa notificações da Superlógica gravadas pelo servidor (por padrão as
+que falharam ou não chegaram a ser processadas). Com -file, antes ingere
+notificações perdidas (uma por linha, JSON como enviado pela Superlógica);
+as já recebidas são ignoradas pela chave de idempotência.
+
+  -id         reprocessa só este evento (qualquer situação)
+  -status     received, failed, processed ou ignored
+  -from, -to  período de recebimento (RFC 3339 ou AAAA-MM-DD)
+  -file       arquivo .jsonl com notificações a ingerir
+  -limit      máximo de eventos por situação (padrão 500)`
+
+// runWebhooks reprocessa eventos de webhook
+func runWebhooks(args []string) error {
+	if len(args) == 0 || args[0] != "replay" {
+		return errors.New(webhooksUsage)
+	}
+
+	fs := flag.NewFlagSet("webhooks replay", flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprintln(os.Stderr, webhooksUsage) }
+	path := fs.String("config", "config.yaml", "caminho do config.yaml")
+	id := fs.String("id", "", "ID do evento")
+	status := fs.String("status", "", "situação dos eventos a reprocessar")
+	from := fs.String("from", "", "recebidos a partir de")
+	to := fs.String("to", "", "recebidos até")
+	file := fs.String("file", "", "notificações perdidas a ingerir (.jsonl)")
+	limit := fs.Int("limit", 500, "máximo de eventos por situação")
+	fs.Parse(args[1:])
+
+	filter := domain.WebhookEventFilter{Status: *status, Limit: *limit}
+	var err error
+	if filter.From, err = parseDay(*from, false); err != nil {
+		return fmt.Errorf("-from inválido: %w", err)
+	}
+	if filter.To, err = parseDay(*to, true); err != nil {
+		return fmt.Errorf("-to inválido: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	cfg, err := config.Load(*path)
+	if err != nil {
+		if !config.IsNotExist(err) {
+			return err
+		}
+		cfg = config.Get()
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	provider, err := secrets.FromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	opts := adapter.Options{Secrets: provider, Production: true}
+	validator, err := adapter.NewBenefValidator(cfg, opts)
+	if err != nil {
+		return err
+	}
+	clubs, err := adapter.NewPartnerRegistry(cfg, opts)
+	if err != nil {
+		return err
+	}
+
+	// Os eventos estão no Firestore do servidor
+	repo, err := repository.NewFirestoreRepository(ctx, os.Getenv("GOOGLE_CLOUD_PROJECT"))
+	if err != nil {
+		return fmt.Errorf("firestore indisponível: %w", err)
+	}
+	defer repo.Close()
+	protector, err := pii.FromConfig(ctx, cfg, provider)
+	if err != nil {
+		return err
+	}
+	if protector != nil {
+		repo.EnableEncryption(protector)
+		repo.SetLegacyLookup(cfg.Privacy.Encryption.LegacyLookup)
+	}
+
+	// Sem lifecycle.Group: cada evento é processado na hora
+	secret := secrets.NewSecret(provider, cfg.Webhooks.Superlogica.SecretRef)
+	svc := service.NewWebhookService(repo, repo, validator, clubs.Default(), secret, cfg, nil)
+	svc.SetClubs(clubs)
+
+	// Revogações e cadastros do replay também avisam os webhooks de saída;
+	// as primeiras tentativas terminam antes de o comando sair
+	if cfg.Webhooks.Outbound.Enabled {
+		jobs := lifecycle.NewGroup(ctx)
+		defer func() {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := jobs.Shutdown(shutdownCtx); err != nil {
+				fmt.Fprintf(os.Stderr, "webhooks de saída pendentes ficam para o servidor reenviar: %v\n", err)
+			}
+		}()
+		svc.SetPublisher(outbound.NewDispatcher(repo, provider, jobs))
+	}
+
+	if *file != "" {
+		if err := ingest(ctx, svc, *file); err != nil {
+			return err
+		}
+	}
+
+	if *id != "" {
+		event, err := repo.GetWebhookEvent(ctx, *id)
+		if err != nil {
+			return fmt.Errorf("evento %s: %w", *id, err)
+		}
+		err = svc.Process(ctx, event)
+		fmt.Printf("%s: %s %v\n", event.ID, event.Status, event.Actions)
+		return err
+	}
+
+	summary, err := svc.Replay(ctx, filter)
+	printSummary("Reprocessados", summary)
+	if err != nil {
+		return err
+	}
+	if summary[domain.WebhookStatusFailed] > 0 {
+		return fmt.Errorf("%d eventos continuam falhando; veja o campo error em webhook_events", summary[domain.WebhookStatusFailed])
+	}
+	return nil
+}
+
+// ingest grava e processa as notificações do arquivo, uma por linha
+func ingest(ctx context.Context, svc *service.WebhookService, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	summary := make(map[string]int)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		body := strings.TrimSpace(scanner.Text())
+		if body == "" {
+			continue
+		}
+		event, duplicate, err := svc.Receive(ctx, []byte(body))
+		switch {
+		case event == nil:
+			fmt.Fprintf(os.Stderr, "linha %d: %v\n", line, err)
+			summary["invalid"]++
+		case duplicate:
+			summary["duplicate"]++
+		default:
+			summary[event.Status]++
+		}
+	}
+	printSummary("Ingeridos de "+path, summary)
+	return scanner.Err()
+}
+
+func printSummary(title string, summary map[string]int) {
+	fmt.Printf("%s:\n", title)
+	statuses := make([]string, 0, len(summary))
+	for status := range summary {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	for _, status := range statuses {
+		fmt.Printf("  %-12s %d\n", status, summary[status])
+	}
+}
+
+// parseDay aceita RFC 3339 ou AAAA-MM-DD; em "to" a data cobre o dia inteiro
+func parseDay(v string, endOfDay bool) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err == nil && endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, err
+}
@@ -5,49 +5,56 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
-	"os"
+	"strings"
 	"time"
 
 	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/secrets"
 )
 
+// serviceName identifica a integração nos erros tipados
+const serviceName = "superlogica"
+
 // SuperlogicaAdapter implementa a interface domain.BenefValidator
 type SuperlogicaAdapter struct {
 	apiURL      string
-	appToken    string
-	accessToken string
+	appToken    secrets.Secret
+	accessToken secrets.Secret
+	httpClient  *http.Client
 	// condoID removido pois agora é dinâmico
 }
 
-func NewBenefAdapter() *SuperlogicaAdapter {
+// Settings configuração do adapter, montada a partir de config.NameIntegration.
+// As credenciais são resolvidas a cada chamada, acompanhando rotações.
+type Settings struct {
+	URL         string
+	Timeout     time.Duration
+	AppToken    secrets.Secret
+	AccessToken secrets.Secret
+}
+
+func NewBenefAdapter(settings Settings) *SuperlogicaAdapter {
 	// Base URL sem o endpoint final, pois usaremos múltiplos endpoints
-	url := os.Getenv("SUPERLOGICA_URL")
+	url := settings.URL
 	if url == "" {
 		url = "https://api.superlogica.net/v2/condor"
 	}
-	
-	appToken := os.Getenv("SUPERLOGICA_APP_TOKEN")
-	if appToken == "" {
-		log.Printf("[WARN] SUPERLOGICA_APP_TOKEN não definido! Configure via variável de ambiente no Render.")
-		// Fallback apenas para desenvolvimento local
-		appToken = "74539367-69b7-432a-934f-8d9050bade0c"
-	}
 
-	accessToken := os.Getenv("SUPERLOGICA_ACCESS_TOKEN")
-	if accessToken == "" {
-		log.Printf("[WARN] SUPERLOGICA_ACCESS_TOKEN não definido! Configure via variável de ambiente no Render.")
-		// Fallback apenas para desenvolvimento local
-		accessToken = "d769811d-2d05-4640-b756-b2bae62318cd"
+	timeout := settings.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
 	}
 
-	log.Printf("[SUPERLOGICA] Inicializado - URL: %s", url)
+	log.Printf("[SUPERLOGICA] Inicializado - URL: %s, Timeout: %v", url, timeout)
 
 	return &SuperlogicaAdapter{
-		apiURL:      url,
-		appToken:    appToken,
-		accessToken: accessToken,
+		apiURL:      strings.TrimRight(url, "/"),
+		appToken:    settings.AppToken,
+		accessToken: settings.AccessToken,
+		httpClient:  &http.Client{Timeout: timeout},
 	}
 }
 
@@ -71,7 +78,7 @@ func (s *SuperlogicaAdapter) ValidateMember(ctx context.Context, condoID string,
 		condoID = "-1" // Busca global por padrão
 	}
 	
-	log.Printf("[BENEF] ValidateMember - CondoID: %s, CPF: %s", condoID, cpf)
+	log.Printf("[BENEF] ValidateMember - CondoID: %s, CPF: %s", condoID, domain.MaskCPF(cpf))
 	
 	// Faz a busca (global ou específica)
 	found, lead, err := s.checkUnit(ctx, condoID, cpf)
@@ -82,11 +89,11 @@ func (s *SuperlogicaAdapter) ValidateMember(ctx context.Context, condoID string,
 	}
 	
 	if found {
-		log.Printf("[BENEF] Morador encontrado! Condomínio real: %s, Nome: %s", lead.CondoID, lead.Name)
+		log.Printf("[BENEF] Morador encontrado! Condomínio real: %s", lead.CondoID)
 		return true, lead, nil
 	}
 	
-	log.Printf("[BENEF] Morador não encontrado para CPF: %s", cpf)
+	log.Printf("[BENEF] Morador não encontrado para CPF: %s", domain.MaskCPF(cpf))
 	return false, nil, nil
 }
 
@@ -103,24 +110,26 @@ func (s *SuperlogicaAdapter) checkUnit(ctx context.Context, id string, cpf strin
 	q.Add("exibirDadosDosContatos", "1")
 	req.URL.RawQuery = q.Encode()
 
-	s.addHeaders(req)
+	if err := s.addHeaders(ctx, req); err != nil {
+		return false, nil, err
+	}
 	
-	log.Printf("[BENEF] Chamando API: %s?idCondominio=%s&pesquisa=%s", endpoint, id, cpf)
+	log.Printf("[BENEF] Chamando API: %s?idCondominio=%s&pesquisa=%s", endpoint, id, domain.MaskCPF(cpf))
 
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := s.httpClient.Do(req)
 	if err != nil {
-		return false, nil, err
+		return false, nil, domain.UnavailableError(serviceName, "units", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return false, nil, fmt.Errorf("status %d", resp.StatusCode)
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return false, nil, domain.HTTPError(serviceName, "units", resp.StatusCode, body, resp.Header.Get("Retry-After"))
 	}
 
 	var data UnitResponse
 	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return false, nil, err
+		return false, nil, domain.UnavailableError(serviceName, "units", fmt.Errorf("erro decodificando unidades: %w", err))
 	}
 
 	if len(data) > 0 {
@@ -159,8 +168,87 @@ func (s *SuperlogicaAdapter) checkUnit(ctx context.Context, id string, cpf strin
 	return false, nil, nil
 }
 
-func (s *SuperlogicaAdapter) addHeaders(req *http.Request) {
+func (s *SuperlogicaAdapter) addHeaders(ctx context.Context, req *http.Request) error {
+	appToken, err := s.appToken.Value(ctx)
+	if err != nil {
+		return domain.CredentialError(serviceName, "units", fmt.Errorf("credencial Superlógica app_token (%s) indisponível: %w", s.appToken.Ref(), err))
+	}
+	accessToken, err := s.accessToken.Value(ctx)
+	if err != nil {
+		return domain.CredentialError(serviceName, "units", fmt.Errorf("credencial Superlógica access_token (%s) indisponível: %w", s.accessToken.Ref(), err))
+	}
+
 	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("app_token", s.appToken)
-	req.Header.Add("access_token", s.accessToken)
+	req.Header.Add("app_token", appToken)
+	req.Header.Add("access_token", accessToken)
+	return nil
+}
+
+// Tamanho de página usado ao listar todas as unidades de um condomínio
+const listPageSize = 50
+
+// ListMembers retorna os proprietários de todas as unidades do condomínio,
+// percorrendo as páginas de unidades/index. Unidades sem CPF são ignoradas.
+func (s *SuperlogicaAdapter) ListMembers(ctx context.Context, condoID string) ([]domain.Lead, error) {
+	var members []domain.Lead
+	for page := 1; ; page++ {
+		endpoint := fmt.Sprintf("%s/unidades/index", s.apiURL)
+		req, _ := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+
+		q := req.URL.Query()
+		q.Add("idCondominio", condoID)
+		q.Add("pagina", fmt.Sprint(page))
+		q.Add("itensPorPagina", fmt.Sprint(listPageSize))
+		q.Add("exibirDadosDosContatos", "1")
+		req.URL.RawQuery = q.Encode()
+
+		if err := s.addHeaders(ctx, req); err != nil {
+			return nil, err
+		}
+
+		resp, err := s.httpClient.Do(req)
+		if err != nil {
+			return nil, domain.UnavailableError(serviceName, "list_units", err)
+		}
+		var data UnitResponse
+		if resp.StatusCode != 200 {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+			return nil, fmt.Errorf("unidades do condomínio %s, página %d: %w", condoID, page,
+				domain.HTTPError(serviceName, "list_units", resp.StatusCode, body, resp.Header.Get("Retry-After")))
+		}
+		err = json.NewDecoder(resp.Body).Decode(&data)
+		resp.Body.Close()
+		if err != nil {
+			return nil, domain.UnavailableError(serviceName, "list_units", fmt.Errorf("erro decodificando unidades: %w", err))
+		}
+
+		for _, unit := range data {
+			if unit.CPFProprietario == "" {
+				continue
+			}
+			phone := unit.CelularProprietario
+			if phone == "" {
+				phone = unit.TelefoneProprietario
+			}
+			realCondoID := unit.IDCondominio
+			if realCondoID == "" {
+				realCondoID = condoID
+			}
+			members = append(members, domain.Lead{
+				CPF:              unit.CPFProprietario,
+				CondoID:          realCondoID,
+				Name:             unit.NomeProprietario,
+				Email:            unit.EmailProprietario,
+				Phone:            phone,
+				Origin:           "superlogica_api",
+				SuperlogicaFound: true,
+			})
+		}
+
+		log.Printf("[BENEF] ListMembers - Condomínio %s, página %d: %d unidades", condoID, page, len(data))
+		if len(data) < listPageSize {
+			return members, nil
+		}
+	}
 }
@@ -0,0 +1,76 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+
+	"github.com/viplounge/platform/internal/domain"
+)
+
+var nonDigitRegex = regexp.MustCompile(`\D`)
+
+// namedValidator uma fonte de moradores e seu ID na config
+type namedValidator struct {
+	id        string
+	validator domain.BenefValidator
+}
+
+// CompositeValidator consulta várias fontes em ordem (type "composite"); vale
+// a primeira que encontrar o CPF. Se nenhuma encontrar e alguma falhar, o erro
+// é retornado, para que a falha não seja tratada como "não encontrado".
+type CompositeValidator struct {
+	id      string
+	sources []namedValidator
+}
+
+func (c *CompositeValidator) ValidateMember(ctx context.Context, condoID string, cpf string) (bool, *domain.Lead, error) {
+	var firstErr error
+	for _, source := range c.sources {
+		found, lead, err := source.validator.ValidateMember(ctx, condoID, cpf)
+		if err != nil {
+			log.Printf("[ADAPTER] Fonte %s (em %s) falhou: %v", source.id, c.id, err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("fonte %s: %w", source.id, err)
+			}
+			continue
+		}
+		if found {
+			return true, lead, nil
+		}
+	}
+	return false, nil, firstErr
+}
+
+// ListMembers une a listagem das fontes que listam moradores, sem repetir
+// CPFs. A falha de qualquer uma invalida a listagem inteira, pois uma lista
+// parcial apareceria no diretório como saída de moradores.
+func (c *CompositeValidator) ListMembers(ctx context.Context, condoID string) ([]domain.Lead, error) {
+	var members []domain.Lead
+	seen := map[string]bool{}
+	listed := false
+	for _, source := range c.sources {
+		lister, ok := source.validator.(domain.MemberLister)
+		if !ok {
+			continue
+		}
+		found, err := lister.ListMembers(ctx, condoID)
+		if err != nil {
+			return nil, fmt.Errorf("fonte %s: %w", source.id, err)
+		}
+		listed = true
+		for _, member := range found {
+			cpf := nonDigitRegex.ReplaceAllString(member.CPF, "")
+			if seen[cpf] {
+				continue
+			}
+			seen[cpf] = true
+			members = append(members, member)
+		}
+	}
+	if !listed {
+		return nil, fmt.Errorf("nenhuma fonte de %s lista moradores", c.id)
+	}
+	return members, nil
+}
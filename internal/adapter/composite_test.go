@@ -0,0 +1,134 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/viplounge/platform/internal/domain"
+)
+
+// fixedSource fonte que responde sempre o mesmo e conta as consultas
+type fixedSource struct {
+	members []domain.Lead
+	err     error
+	calls   int
+}
+
+func (s *fixedSource) ValidateMember(ctx context.Context, condoID string, cpf string) (bool, *domain.Lead, error) {
+	s.calls++
+	if s.err != nil {
+		return false, nil, s.err
+	}
+	for _, member := range s.members {
+		if member.CPF == cpf {
+			return true, &member, nil
+		}
+	}
+	return false, nil, nil
+}
+
+// listingSource fixedSource que também lista moradores
+type listingSource struct {
+	*fixedSource
+}
+
+func (s listingSource) ListMembers(ctx context.Context, condoID string) ([]domain.Lead, error) {
+	return s.members, s.err
+}
+
+func composite(sources ...domain.BenefValidator) *CompositeValidator {
+	c := &CompositeValidator{id: "composta"}
+	for i, source := range sources {
+		c.sources = append(c.sources, namedValidator{id: string(rune('a' + i)), validator: source})
+	}
+	return c
+}
+
+func TestCompositeValidateMember(t *testing.T) {
+	down := errors.New("fora do ar")
+	ana := func(name string) []domain.Lead { return []domain.Lead{{CPF: "11144477735", Name: name}} }
+
+	tests := []struct {
+		name      string
+		sources   []*fixedSource
+		wantName  string
+		wantErr   string
+		wantCalls []int
+	}{
+		{
+			name:      "vale a primeira que encontra",
+			sources:   []*fixedSource{{members: ana("primeira")}, {members: ana("segunda")}},
+			wantName:  "primeira",
+			wantCalls: []int{1, 0},
+		},
+		{
+			name:      "falha seguida de fonte que encontra",
+			sources:   []*fixedSource{{err: down}, {members: ana("segunda")}},
+			wantName:  "segunda",
+			wantCalls: []int{1, 1},
+		},
+		{
+			name:      "não encontrado com falha é erro",
+			sources:   []*fixedSource{{}, {err: down}},
+			wantErr:   "fonte b: fora do ar",
+			wantCalls: []int{1, 1},
+		},
+		{
+			name:      "não encontrado em nenhuma",
+			sources:   []*fixedSource{{}, {}},
+			wantCalls: []int{1, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sources []domain.BenefValidator
+			for _, source := range tt.sources {
+				sources = append(sources, source)
+			}
+			found, lead, err := composite(sources...).ValidateMember(context.Background(), "4", "11144477735")
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr || !errors.Is(err, down) {
+					t.Errorf("erro %v, esperado %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Errorf("erro inesperado: %v", err)
+			}
+			if found != (tt.wantName != "") || (found && lead.Name != tt.wantName) {
+				t.Errorf("found=%v lead=%+v, esperado %q", found, lead, tt.wantName)
+			}
+			for i, source := range tt.sources {
+				if source.calls != tt.wantCalls[i] {
+					t.Errorf("fonte %d consultada %d vezes, esperado %d", i, source.calls, tt.wantCalls[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCompositeListMembers(t *testing.T) {
+	ctx := context.Background()
+	first := listingSource{&fixedSource{members: []domain.Lead{{CPF: "111.444.777-35", Name: "Ana"}}}}
+	second := listingSource{&fixedSource{members: []domain.Lead{{CPF: "11144477735", Name: "Ana (repetida)"}, {CPF: "52998224725", Name: "Bruno"}}}}
+
+	// A fonte que não lista é ignorada e o CPF repetido fica com a primeira
+	members, err := composite(&fixedSource{}, first, second).ListMembers(ctx, "4")
+	if err != nil {
+		t.Fatalf("ListMembers: %v", err)
+	}
+	if len(members) != 2 || members[0].Name != "Ana" || members[1].Name != "Bruno" {
+		t.Errorf("moradores %+v", members)
+	}
+
+	broken := listingSource{&fixedSource{err: errors.New("fora do ar")}}
+	if members, err := composite(first, broken).ListMembers(ctx, "4"); err == nil {
+		t.Errorf("listagem parcial aceita: %+v", members)
+	}
+
+	if _, err := composite(&fixedSource{}).ListMembers(ctx, "4"); err == nil || !strings.Contains(err.Error(), "nenhuma fonte") {
+		t.Errorf("sem fonte que lista: %v", err)
+	}
+}
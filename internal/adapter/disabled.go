@@ -0,0 +1,46 @@
+package adapter
+
+import (
+	"context"
+
+	"github.com/viplounge/platform/internal/domain"
+)
+
+// DisabledValidator é usado quando integrations.name_integration.enabled=false.
+// Nenhuma chamada externa é feita e toda consulta falha com
+// domain.ErrIntegrationDisabled: sem a fonte não há decisão, e tratar o CPF
+// como não encontrado revogaria os membros do clube a cada visita.
+type DisabledValidator struct{}
+
+func (DisabledValidator) ValidateMember(ctx context.Context, condoID string, cpf string) (bool, *domain.Lead, error) {
+	return false, nil, domain.ErrIntegrationDisabled
+}
+
+// DisabledPartner é usado quando integrations.partner_integration.enabled=false.
+// Nenhum usuário é encontrado e toda operação de escrita falha com
+// domain.ErrIntegrationDisabled.
+type DisabledPartner struct{}
+
+func (DisabledPartner) FindUserByCPF(ctx context.Context, cpf string) (*domain.PartnerUser, error) {
+	return nil, domain.ErrIntegrationDisabled
+}
+
+func (DisabledPartner) RegisterUser(ctx context.Context, lead *domain.Lead) error {
+	lead.RedeParceriasStatus = domain.PartnerStatusFailed
+	lead.RedeParceriasError = "INTEGRATION_DISABLED"
+	return domain.ErrIntegrationDisabled
+}
+
+func (DisabledPartner) DeleteUser(ctx context.Context, userID string) error {
+	return domain.ErrIntegrationDisabled
+}
+
+func (DisabledPartner) GetSSOToken(ctx context.Context, userIdentifier string) (*domain.SSOToken, error) {
+	return nil, domain.ErrIntegrationDisabled
+}
+
+func (DisabledPartner) RegisterAndGetSSO(ctx context.Context, lead *domain.Lead) (*domain.SSOToken, error) {
+	lead.RedeParceriasStatus = domain.PartnerStatusFailed
+	lead.RedeParceriasError = "INTEGRATION_DISABLED"
+	return nil, domain.ErrIntegrationDisabled
+}
@@ -0,0 +1,289 @@
+// Package filesource lê moradores de uma planilha CSV ou de um arquivo JSON,
+// para condomínios sem ERP integrado.
+package filesource
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/viplounge/platform/internal/adapter/rest"
+	"github.com/viplounge/platform/internal/domain"
+)
+
+var nonDigitRegex = regexp.MustCompile(`\D`)
+
+// Fields nomes das colunas (CSV) ou campos (JSON, com caminhos com ponto)
+type Fields struct {
+	CPF     string
+	Name    string
+	Email   string
+	Phone   string
+	CondoID string
+}
+
+// Settings configuração da fonte, montada a partir de config.NameIntegration
+type Settings struct {
+	// Name identifica a fonte nos logs
+	Name string
+	Path string
+	// Format "csv" ou "json" (vazio = pela extensão)
+	Format      string
+	RecordsPath string
+	Fields      Fields
+}
+
+// Source mantém o arquivo indexado por CPF em memória e o relê quando a data
+// de modificação ou o tamanho mudam. Se a releitura falhar, a última versão
+// válida continua em uso. Implementa domain.BenefValidator e domain.MemberLister.
+type Source struct {
+	settings Settings
+
+	mu      sync.RWMutex
+	byCPF   map[string][]domain.Lead
+	all     []domain.Lead
+	modTime time.Time
+	size    int64
+	loadErr error
+}
+
+// New lê o arquivo. Um arquivo ausente ou inválido não impede a construção:
+// as consultas falham até que ele seja corrigido.
+func New(settings Settings) *Source {
+	if settings.Format == "" {
+		settings.Format = strings.TrimPrefix(strings.ToLower(filepath.Ext(settings.Path)), ".")
+	}
+	f := &settings.Fields
+	f.CPF = orDefault(f.CPF, "cpf")
+	f.Name = orDefault(f.Name, "name")
+	f.Email = orDefault(f.Email, "email")
+	f.Phone = orDefault(f.Phone, "phone")
+
+	s := &Source{settings: settings}
+	if err := s.refresh(); err != nil {
+		log.Printf("[FILE_SOURCE] WARN: %s: %v", settings.Name, err)
+	}
+	return s
+}
+
+// ValidateMember procura o CPF no arquivo
+func (s *Source) ValidateMember(ctx context.Context, condoID string, cpf string) (bool, *domain.Lead, error) {
+	if err := s.refresh(); err != nil {
+		return false, nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, member := range s.byCPF[normalizeCPF(cpf)] {
+		if sameCondo(condoID, member.CondoID) {
+			lead := member
+			if lead.CondoID == "" {
+				lead.CondoID = condoID
+			}
+			return true, &lead, nil
+		}
+	}
+	return false, nil, nil
+}
+
+// ListMembers lista os moradores do condomínio (todos, se o arquivo não tem
+// coluna de condomínio)
+func (s *Source) ListMembers(ctx context.Context, condoID string) ([]domain.Lead, error) {
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var members []domain.Lead
+	for _, member := range s.all {
+		if sameCondo(condoID, member.CondoID) {
+			if member.CondoID == "" {
+				member.CondoID = condoID
+			}
+			members = append(members, member)
+		}
+	}
+	return members, nil
+}
+
+// refresh relê o arquivo se ele mudou desde a última leitura
+func (s *Source) refresh() error {
+	info, err := os.Stat(s.settings.Path)
+	if err != nil {
+		return s.keepPrevious(fmt.Errorf("%s: %w", s.settings.Name, err))
+	}
+
+	s.mu.RLock()
+	unchanged := s.byCPF != nil && info.ModTime().Equal(s.modTime) && info.Size() == s.size
+	s.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	members, err := s.read()
+	if err != nil {
+		return s.keepPrevious(fmt.Errorf("%s: %w", s.settings.Name, err))
+	}
+
+	byCPF := make(map[string][]domain.Lead, len(members))
+	for _, member := range members {
+		byCPF[member.CPF] = append(byCPF[member.CPF], member)
+	}
+
+	s.mu.Lock()
+	s.byCPF, s.all = byCPF, members
+	s.modTime, s.size = info.ModTime(), info.Size()
+	s.loadErr = nil
+	s.mu.Unlock()
+	log.Printf("[FILE_SOURCE] %s: %d moradores carregados de %s", s.settings.Name, len(members), s.settings.Path)
+	return nil
+}
+
+// keepPrevious mantém a última versão válida; sem nenhuma, retorna o erro
+func (s *Source) keepPrevious(err error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loadErr == nil || s.loadErr.Error() != err.Error() {
+		log.Printf("[FILE_SOURCE] WARN: %v", err)
+	}
+	s.loadErr = err
+	if s.byCPF != nil {
+		return nil
+	}
+	return err
+}
+
+func (s *Source) read() ([]domain.Lead, error) {
+	data, err := os.ReadFile(s.settings.Path)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // BOM do Excel
+
+	switch s.settings.Format {
+	case "csv", "txt":
+		return s.readCSV(data)
+	case "json":
+		return s.readJSON(data)
+	default:
+		return nil, fmt.Errorf("formato não suportado: %q (use csv ou json)", s.settings.Format)
+	}
+}
+
+func (s *Source) readCSV(data []byte) ([]domain.Lead, error) {
+	// Excel em pt-BR exporta CSV com ";"
+	firstLine, _, _ := bufio.NewReader(bytes.NewReader(data)).ReadLine()
+	r := csv.NewReader(bytes.NewReader(data))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		r.Comma = ';'
+	}
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("erro lendo csv: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	f := s.settings.Fields
+	if _, ok := columns[strings.ToLower(f.CPF)]; !ok {
+		return nil, fmt.Errorf("cabeçalho sem a coluna de CPF %q", f.CPF)
+	}
+	cell := func(row []string, name string) string {
+		i, ok := columns[strings.ToLower(name)]
+		if name == "" || !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	var members []domain.Lead
+	for _, row := range rows[1:] {
+		member := domain.Lead{
+			CPF:     normalizeCPF(cell(row, f.CPF)),
+			Name:    cell(row, f.Name),
+			Email:   cell(row, f.Email),
+			Phone:   cell(row, f.Phone),
+			CondoID: cell(row, f.CondoID),
+		}
+		if member.CPF != "" {
+			members = append(members, member)
+		}
+	}
+	return members, nil
+}
+
+func (s *Source) readJSON(data []byte) ([]domain.Lead, error) {
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("erro lendo json: %w", err)
+	}
+	list, ok := rest.Lookup(decoded, s.settings.RecordsPath).([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("json sem lista de moradores em %q", s.settings.RecordsPath)
+	}
+
+	f := s.settings.Fields
+	field := func(item interface{}, path string) string {
+		if path == "" {
+			return ""
+		}
+		return strings.TrimSpace(rest.AsString(rest.Lookup(item, path)))
+	}
+
+	var members []domain.Lead
+	for _, item := range list {
+		member := domain.Lead{
+			CPF:     normalizeCPF(field(item, f.CPF)),
+			Name:    field(item, f.Name),
+			Email:   field(item, f.Email),
+			Phone:   field(item, f.Phone),
+			CondoID: field(item, f.CondoID),
+		}
+		if member.CPF != "" {
+			members = append(members, member)
+		}
+	}
+	return members, nil
+}
+
+// normalizeCPF devolve só os dígitos, recompondo zeros à esquerda que a
+// planilha perde quando a coluna é numérica
+func normalizeCPF(cpf string) string {
+	digits := nonDigitRegex.ReplaceAllString(cpf, "")
+	if digits != "" && len(digits) < 11 {
+		digits = strings.Repeat("0", 11-len(digits)) + digits
+	}
+	return digits
+}
+
+// sameCondo indica se o morador pertence ao condomínio pedido ("" e "-1" =
+// busca global; morador sem condomínio vale para todos)
+func sameCondo(requested, member string) bool {
+	return requested == "" || requested == "-1" || member == "" || member == requested
+}
+
+func orDefault(val, def string) string {
+	if val == "" {
+		return def
+	}
+	return val
+}
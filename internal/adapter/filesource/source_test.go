@@ -0,0 +1,72 @@
+package filesource
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// rewrite grava o arquivo com data de modificação à frente da anterior,
+// para a mudança ser vista mesmo em sistemas de arquivos de baixa resolução
+func rewrite(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+}
+
+func TestSourceReloadsOnChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "moradores.csv")
+	now := time.Now()
+	rewrite(t, path, "CPF;Nome;Condominio\n111.444.777-35;Ana;4\n", now.Add(-time.Hour))
+
+	source := New(Settings{Name: "planilha", Path: path, Fields: Fields{Name: "Nome", CondoID: "Condominio"}})
+	ctx := context.Background()
+
+	if found, lead, err := source.ValidateMember(ctx, "4", "11144477735"); !found || err != nil || lead.Name != "Ana" {
+		t.Fatalf("primeira leitura: %v %+v %v", found, lead, err)
+	}
+
+	// Proprietário trocado na planilha
+	rewrite(t, path, "CPF;Nome;Condominio\n52998224725;Bruno;4\n", now)
+	if found, _, _ := source.ValidateMember(ctx, "4", "11144477735"); found {
+		t.Errorf("morador removido da planilha continua encontrado")
+	}
+	if found, lead, err := source.ValidateMember(ctx, "4", "52998224725"); !found || err != nil || lead.Name != "Bruno" {
+		t.Errorf("morador novo: %v %+v %v", found, lead, err)
+	}
+
+	// Planilha quebrada: a última versão válida continua em uso
+	rewrite(t, path, "Nome;Email\nCarla;carla@example.com\n", now.Add(time.Hour))
+	if found, _, err := source.ValidateMember(ctx, "4", "52998224725"); !found || err != nil {
+		t.Errorf("versão anterior descartada: %v %v", found, err)
+	}
+}
+
+func TestSourceWithoutValidFile(t *testing.T) {
+	source := New(Settings{Name: "planilha", Path: filepath.Join(t.TempDir(), "ausente.csv")})
+	if _, _, err := source.ValidateMember(context.Background(), "4", "11144477735"); err == nil {
+		t.Errorf("arquivo ausente sem erro: seria tratado como morador não encontrado")
+	}
+}
+
+func TestSourceJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "moradores.json")
+	rewrite(t, path, `{"data":{"items":[{"doc":"1144477735","contato":{"email":"ana@example.com"}},{"doc":""}]}}`, time.Now())
+
+	source := New(Settings{Name: "json", Path: path, RecordsPath: "data.items", Fields: Fields{CPF: "doc", Email: "contato.email"}})
+	members, err := source.ListMembers(context.Background(), "7")
+	if err != nil {
+		t.Fatalf("ListMembers: %v", err)
+	}
+	// Sem campo de condomínio o morador vale para o condomínio pedido, e o
+	// zero à esquerda perdido pela planilha é recomposto
+	if len(members) != 1 || members[0].CPF != "01144477735" || members[0].CondoID != "7" || members[0].Email != "ana@example.com" {
+		t.Errorf("moradores %+v", members)
+	}
+}
@@ -4,29 +4,33 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"net/url"
-	"os"
 	"regexp"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/secrets"
 )
 
+// serviceName identifica a integração nos erros tipados
+const serviceName = "rede_parcerias"
+
 // RedeParceriasClient integra com a API de Clube de Benefícios
 type RedeParceriasClient struct {
 	baseURL      string
-	clientID     string
-	clientSecret string
+	clientID     secrets.Secret
+	clientSecret secrets.Secret
 	httpClient   *http.Client
 
 	// Token fixo (se fornecido, pula OAuth2)
-	fixedToken string
+	fixedToken secrets.Secret
 
 	// Cache do token (para OAuth2)
 	tokenMu  sync.RWMutex
@@ -34,51 +38,51 @@ type RedeParceriasClient struct {
 	tokenExp time.Time
 }
 
-func NewClient() *RedeParceriasClient {
-	baseURL := os.Getenv("REDE_PARCERIAS_URL")
+// Settings configuração do client, montada a partir de config.PartnerIntegration.
+// As credenciais são resolvidas quando usadas, acompanhando rotações.
+type Settings struct {
+	URL          string
+	Timeout      time.Duration
+	ClientID     secrets.Secret
+	ClientSecret secrets.Secret
+	// BearerToken fixo (se resolvido, pula OAuth2)
+	BearerToken secrets.Secret
+}
+
+func NewClient(settings Settings) *RedeParceriasClient {
+	baseURL := settings.URL
 	if baseURL == "" {
 		// PRODUÇÃO
 		baseURL = "https://infratech.clubeparcerias.com.br/api-client/v1"
 	}
 
-	// OPÇÃO 1: Token fixo via variável de ambiente (para casos especiais)
-	fixedToken := os.Getenv("REDE_PARCERIAS_BEARER_TOKEN")
-
-	// OPÇÃO 2 (RECOMENDADO): OAuth2 client_credentials - gera token automaticamente
-	clientID := os.Getenv("REDE_PARCERIAS_CLIENT_ID")
-	if clientID == "" {
-		log.Printf("[WARN] REDE_PARCERIAS_CLIENT_ID não definido! Configure via variável de ambiente no Render.")
-		// Fallback apenas para desenvolvimento local
-		clientID = "a08bba23-53aa-46ad-9565-ce7e1fdb169c"
-	}
-
-	clientSecret := os.Getenv("REDE_PARCERIAS_CLIENT_SECRET")
-	if clientSecret == "" {
-		log.Printf("[WARN] REDE_PARCERIAS_CLIENT_SECRET não definido! Configure via variável de ambiente no Render.")
-		// Fallback apenas para desenvolvimento local
-		clientSecret = "D9pgDnw3VwqOtij9Y5aU181zXmwOQyuMEWSVZqwh"
+	timeout := settings.Timeout
+	if timeout <= 0 {
+		timeout = 20 * time.Second
 	}
 
 	authMode := "OAuth2"
-	if fixedToken != "" {
+	if _, err := settings.BearerToken.Value(context.Background()); err == nil {
 		authMode = "Token Fixo"
 	}
-	log.Printf("[REDE_PARCERIAS] Inicializado - URL: %s, Auth: %s", baseURL, authMode)
+	log.Printf("[REDE_PARCERIAS] Inicializado - URL: %s, Auth: %s, Timeout: %v", baseURL, authMode, timeout)
 
 	return &RedeParceriasClient{
 		baseURL:      strings.TrimRight(baseURL, "/"),
-		fixedToken:   fixedToken, // Vazio = usar OAuth2 automaticamente
-		clientID:     clientID,
-		clientSecret: clientSecret,
-		httpClient:   &http.Client{Timeout: 20 * time.Second},
+		fixedToken:   settings.BearerToken, // Não resolvido = usar OAuth2 automaticamente
+		clientID:     settings.ClientID,
+		clientSecret: settings.ClientSecret,
+		httpClient:   &http.Client{Timeout: timeout},
 	}
 }
 
 // getToken obtém token para autenticação
 func (c *RedeParceriasClient) getToken(ctx context.Context) (string, error) {
 	// PRIORIDADE 1: Token fixo
-	if c.fixedToken != "" {
-		return c.fixedToken, nil
+	if token, err := c.fixedToken.Value(ctx); err == nil {
+		return token, nil
+	} else if c.fixedToken.Configured() && !errors.Is(err, secrets.ErrNotFound) {
+		return "", domain.CredentialError(serviceName, "auth", fmt.Errorf("erro lendo token fixo: %w", err))
 	}
 
 	// PRIORIDADE 2: Cache
@@ -91,10 +95,19 @@ func (c *RedeParceriasClient) getToken(ctx context.Context) (string, error) {
 	c.tokenMu.RUnlock()
 
 	// PRIORIDADE 3: OAuth2
+	clientID, err := c.clientID.Value(ctx)
+	if err != nil {
+		return "", domain.CredentialError(serviceName, "auth", fmt.Errorf("credencial client_id (%s) indisponível: %w", c.clientID.Ref(), err))
+	}
+	clientSecret, err := c.clientSecret.Value(ctx)
+	if err != nil {
+		return "", domain.CredentialError(serviceName, "auth", fmt.Errorf("credencial client_secret (%s) indisponível: %w", c.clientSecret.Ref(), err))
+	}
+
 	payload := map[string]string{
 		"grant_type":    "client_credentials",
-		"client_id":     c.clientID,
-		"client_secret": c.clientSecret,
+		"client_id":     clientID,
+		"client_secret": clientSecret,
 		"scope":         "*",
 	}
 
@@ -111,13 +124,18 @@ func (c *RedeParceriasClient) getToken(ctx context.Context) (string, error) {
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("erro na chamada auth: %w", err)
+		return "", domain.UnavailableError(serviceName, "auth", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
 		respBody, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("auth falhou: HTTP %d - %s", resp.StatusCode, string(respBody))
+		authErr := domain.HTTPError(serviceName, "auth", resp.StatusCode, respBody, resp.Header.Get("Retry-After"))
+		// Qualquer recusa do /auth é problema de credencial
+		if resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			authErr.Kind = domain.ErrUnauthorized
+		}
+		return "", authErr
 	}
 
 	var result struct {
@@ -127,7 +145,7 @@ func (c *RedeParceriasClient) getToken(ctx context.Context) (string, error) {
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("erro decodificando auth response: %w", err)
+		return "", domain.UnavailableError(serviceName, "auth", fmt.Errorf("erro decodificando auth response: %w", err))
 	}
 
 	// Cache com margem de 5 min
@@ -139,6 +157,17 @@ func (c *RedeParceriasClient) getToken(ctx context.Context) (string, error) {
 	return result.AccessToken, nil
 }
 
+// statusError classifica a resposta de erro. Um 401 descarta o token em
+// cache, para que a próxima chamada autentique de novo.
+func (c *RedeParceriasClient) statusError(op string, resp *http.Response, body []byte) *domain.IntegrationError {
+	if resp.StatusCode == http.StatusUnauthorized {
+		c.tokenMu.Lock()
+		c.token = ""
+		c.tokenMu.Unlock()
+	}
+	return domain.HTTPError(serviceName, op, resp.StatusCode, body, resp.Header.Get("Retry-After"))
+}
+
 // FindUserByCPF verifica se um usuário existe na Rede Parcerias
 func (c *RedeParceriasClient) FindUserByCPF(ctx context.Context, cpf string) (*domain.PartnerUser, error) {
 	token, err := c.getToken(ctx)
@@ -149,7 +178,7 @@ func (c *RedeParceriasClient) FindUserByCPF(ctx context.Context, cpf string) (*d
 	cpfClean := regexp.MustCompile(`\D`).ReplaceAllString(cpf, "")
 	reqURL := fmt.Sprintf("%s/users?search=%s&limit=5", c.baseURL, cpfClean)
 
-	log.Printf("[REDE_PARCERIAS] Buscando CPF: %s", cpfClean)
+	log.Printf("[REDE_PARCERIAS] Buscando CPF: %s", domain.MaskCPF(cpfClean))
 
 	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 	if err != nil {
@@ -161,15 +190,15 @@ func (c *RedeParceriasClient) FindUserByCPF(ctx context.Context, cpf string) (*d
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("erro buscando usuário: %w", err)
+		return nil, domain.UnavailableError(serviceName, "find_user", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, _ := io.ReadAll(resp.Body)
-	log.Printf("[REDE_PARCERIAS] FindUser Response: %d - %s", resp.StatusCode, string(respBody))
+	log.Printf("[REDE_PARCERIAS] FindUser Response: %d (%d bytes)", resp.StatusCode, len(respBody))
 
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("erro HTTP %d ao buscar usuário", resp.StatusCode)
+		return nil, c.statusError("find_user", resp, respBody)
 	}
 
 	var result struct {
@@ -184,14 +213,14 @@ func (c *RedeParceriasClient) FindUserByCPF(ctx context.Context, cpf string) (*d
 	}
 
 	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("erro decodificando response: %w", err)
+		return nil, domain.UnavailableError(serviceName, "find_user", fmt.Errorf("erro decodificando response: %w", err))
 	}
 
 	// Verificar se encontrou o CPF exato
 	for _, user := range result.Data {
 		userCPF := regexp.MustCompile(`\D`).ReplaceAllString(user.CPF, "")
 		if userCPF == cpfClean {
-			log.Printf("[REDE_PARCERIAS] Usuário encontrado: ID=%s, Email=%s", user.ID, user.Email)
+			log.Printf("[REDE_PARCERIAS] Usuário encontrado: ID=%s", user.ID)
 			return &domain.PartnerUser{
 				ID:        user.ID,
 				Name:      user.Name,
@@ -203,7 +232,7 @@ func (c *RedeParceriasClient) FindUserByCPF(ctx context.Context, cpf string) (*d
 		}
 	}
 
-	log.Printf("[REDE_PARCERIAS] CPF %s não encontrado", cpfClean)
+	log.Printf("[REDE_PARCERIAS] CPF %s não encontrado", domain.MaskCPF(cpfClean))
 	return nil, nil
 }
 
@@ -251,16 +280,16 @@ func (c *RedeParceriasClient) RegisterUser(ctx context.Context, lead *domain.Lea
 				phoneClean[2:7], 
 				phoneClean[7:11])
 			payload["cellphone"] = formattedPhone
-			log.Printf("[REDE_PARCERIAS] Celular formatado: %s (original: %s)", formattedPhone, lead.Phone)
+			log.Printf("[REDE_PARCERIAS] Celular formatado")
 		} else {
-			log.Printf("[REDE_PARCERIAS] Celular inválido ou não é celular: %s (length: %d)", phoneClean, len(phoneClean))
+			log.Printf("[REDE_PARCERIAS] Celular inválido ou não é celular (length: %d)", len(phoneClean))
 		}
 	}
 
 	body, _ := json.Marshal(payload)
 	reqURL := fmt.Sprintf("%s/users", c.baseURL)
 
-	log.Printf("[REDE_PARCERIAS] Cadastrando usuário: %s", string(body))
+	log.Printf("[REDE_PARCERIAS] Cadastrando usuário: CPF %s", domain.MaskCPF(lead.CPF))
 
 	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, bytes.NewBuffer(body))
 	if err != nil {
@@ -276,7 +305,7 @@ func (c *RedeParceriasClient) RegisterUser(ctx context.Context, lead *domain.Lea
 	if err != nil {
 		lead.RedeParceriasError = fmt.Sprintf("NETWORK_ERROR: %v", err)
 		lead.RedeParceriasStatus = domain.PartnerStatusFailed
-		return fmt.Errorf("erro na chamada: %w", err)
+		return domain.UnavailableError(serviceName, "register_user", err)
 	}
 	defer resp.Body.Close()
 
@@ -284,7 +313,7 @@ func (c *RedeParceriasClient) RegisterUser(ctx context.Context, lead *domain.Lea
 	lead.RedeParceriasResponseMs = time.Since(startTime).Milliseconds()
 	lead.RedeParceriasAttempts++
 
-	log.Printf("[REDE_PARCERIAS] RegisterUser Response: %d - %s", resp.StatusCode, string(respBody))
+	log.Printf("[REDE_PARCERIAS] RegisterUser Response: %d (%d bytes)", resp.StatusCode, len(respBody))
 
 	// Sucesso: 200 ou 201
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
@@ -320,20 +349,26 @@ func (c *RedeParceriasClient) RegisterUser(ctx context.Context, lead *domain.Lea
 			log.Printf("[REDE_PARCERIAS] Usuário já existe (422)")
 			lead.RedeParceriasStatus = domain.PartnerStatusRegistered
 			lead.RedeParceriasError = "USER_ALREADY_EXISTS"
-			return nil
+			return &domain.IntegrationError{Service: serviceName, Op: "register_user", Kind: domain.ErrPartnerUserExists, Status: resp.StatusCode, Detail: errorResp.Message}
 		}
 		
 		// É erro de validação - tentar novamente sem o campo problemático
 		log.Printf("[REDE_PARCERIAS] Erro de validação 422: %s", errorResp.Message)
 		lead.RedeParceriasStatus = domain.PartnerStatusFailed
 		lead.RedeParceriasError = fmt.Sprintf("VALIDATION_ERROR: %s", errorResp.Message)
-		return fmt.Errorf("erro de validação: %s", errorResp.Message)
+		return &domain.IntegrationError{
+			Service: serviceName,
+			Op:      "register_user",
+			Kind:    domain.ErrValidation,
+			Status:  resp.StatusCode,
+			Err:     &domain.ValidationError{Message: errorResp.Message, Fields: errorResp.Errors},
+		}
 	}
 
 	// Erro
 	lead.RedeParceriasStatus = domain.PartnerStatusFailed
 	lead.RedeParceriasError = fmt.Sprintf("HTTP_%d: %s", resp.StatusCode, string(respBody))
-	return fmt.Errorf("erro cadastrando: HTTP %d - %s", resp.StatusCode, string(respBody))
+	return c.statusError("register_user", resp, respBody)
 }
 
 // GetSSOToken gera token SSO para login automático
@@ -348,7 +383,7 @@ func (c *RedeParceriasClient) GetSSOToken(ctx context.Context, userIdentifier st
 	encodedID := url.QueryEscape(userIdentifier)
 	reqURL := fmt.Sprintf("%s/sso-token?user_id=%s", c.baseURL, encodedID)
 
-	log.Printf("[REDE_PARCERIAS] Gerando SSO para: %s", userIdentifier)
+	log.Printf("[REDE_PARCERIAS] Gerando SSO para: %s", maskIdentifier(userIdentifier))
 
 	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 	if err != nil {
@@ -360,38 +395,48 @@ func (c *RedeParceriasClient) GetSSOToken(ctx context.Context, userIdentifier st
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("erro gerando SSO token: %w", err)
+		return nil, domain.UnavailableError(serviceName, "sso", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, _ := io.ReadAll(resp.Body)
-	log.Printf("[REDE_PARCERIAS] SSO Response: %d - %s", resp.StatusCode, string(respBody))
+	// O corpo de sucesso carrega o token: só o status vai para o log
+	log.Printf("[REDE_PARCERIAS] SSO Response: %d", resp.StatusCode)
 
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("erro HTTP %d: %s", resp.StatusCode, string(respBody))
+		return nil, c.statusError("sso", resp, respBody)
 	}
 
 	var result domain.SSOToken
 	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("erro decodificando SSO response: %w", err)
+		return nil, domain.UnavailableError(serviceName, "sso", fmt.Errorf("erro decodificando SSO response: %w", err))
 	}
 
-	log.Printf("[REDE_PARCERIAS] SSO Token gerado! Redirect: %s", result.Redirect)
+	log.Printf("[REDE_PARCERIAS] SSO Token gerado! Redirect: %s", domain.RedactURL(result.Redirect))
 	return &result, nil
 }
 
+// maskIdentifier identificador do SSO para log: o e-mail só com o domínio;
+// o UUID do usuário não identifica a pessoa e fica
+func maskIdentifier(id string) string {
+	if at := strings.LastIndex(id, "@"); at >= 0 {
+		return "***" + id[at:]
+	}
+	return id
+}
+
 // RegisterAndGetSSO é o método principal que faz o fluxo completo:
 // 1. Cadastra o usuário (com authorized:true)
 // 2. Gera o SSO Token usando o EMAIL
 // 3. Retorna a URL de redirect para login automático
 func (c *RedeParceriasClient) RegisterAndGetSSO(ctx context.Context, lead *domain.Lead) (*domain.SSOToken, error) {
 	log.Printf("[REDE_PARCERIAS] === FLUXO COMPLETO: RegisterAndGetSSO ===")
-	log.Printf("[REDE_PARCERIAS] Nome: %s, Email: %s, CPF: %s", lead.Name, lead.Email, lead.CPF)
+	log.Printf("[REDE_PARCERIAS] CPF: %s", domain.MaskCPF(lead.CPF))
 
 	// PASSO 1: Cadastrar usuário
 	if err := c.RegisterUser(ctx, lead); err != nil {
 		// Se não for erro de "já existe", retorna o erro
-		if lead.RedeParceriasError != "USER_ALREADY_EXISTS" {
+		if !errors.Is(err, domain.ErrPartnerUserExists) {
 			return nil, fmt.Errorf("erro no cadastro: %w", err)
 		}
 		log.Printf("[REDE_PARCERIAS] Usuário já existia, continuando para SSO...")
@@ -401,7 +446,7 @@ func (c *RedeParceriasClient) RegisterAndGetSSO(ctx context.Context, lead *domai
 	// A API aceita tanto UUID quanto email como user_id
 	ssoIdentifier := lead.Email
 	if ssoIdentifier == "" {
-		return nil, fmt.Errorf("email é obrigatório para gerar SSO")
+		return nil, &domain.ValidationError{Message: "email é obrigatório para gerar SSO", Fields: map[string][]string{"email": {"obrigatório"}}}
 	}
 
 	sso, err := c.GetSSOToken(ctx, ssoIdentifier)
@@ -419,7 +464,7 @@ func (c *RedeParceriasClient) RegisterAndGetSSO(ctx context.Context, lead *domai
 		}
 	}
 
-	log.Printf("[REDE_PARCERIAS] === SUCESSO! Redirect URL: %s ===", sso.Redirect)
+	log.Printf("[REDE_PARCERIAS] === SUCESSO! Redirect URL: %s ===", domain.RedactURL(sso.Redirect))
 	return sso, nil
 }
 
@@ -444,15 +489,15 @@ func (c *RedeParceriasClient) DeleteUser(ctx context.Context, userID string) err
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("erro deletando usuário: %w", err)
+		return domain.UnavailableError(serviceName, "delete_user", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, _ := io.ReadAll(resp.Body)
-	log.Printf("[REDE_PARCERIAS] DeleteUser Response: %d - %s", resp.StatusCode, string(respBody))
+	log.Printf("[REDE_PARCERIAS] DeleteUser Response: %d (%d bytes)", resp.StatusCode, len(respBody))
 
 	if resp.StatusCode != 200 && resp.StatusCode != 404 {
-		return fmt.Errorf("erro HTTP %d: %s", resp.StatusCode, string(respBody))
+		return c.statusError("delete_user", resp, respBody)
 	}
 
 	return nil
@@ -0,0 +1,486 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/viplounge/platform/internal/adapter/benef"
+	"github.com/viplounge/platform/internal/adapter/filesource"
+	"github.com/viplounge/platform/internal/adapter/redeparcerias"
+	"github.com/viplounge/platform/internal/adapter/rest"
+	"github.com/viplounge/platform/internal/config"
+	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/secrets"
+)
+
+// Options dependências compartilhadas pelas factories
+type Options struct {
+	// Secrets resolve as referências *_ref das credenciais
+	Secrets secrets.Provider
+	// Production exige que todas as credenciais estejam disponíveis na construção
+	Production bool
+}
+
+// ValidatorFactory constrói a fonte de moradores para um Integrations.NameIntegration.Type
+type ValidatorFactory func(cfg config.NameIntegration, opts Options) (domain.BenefValidator, error)
+
+// PartnerFactory constrói o Clube de Benefícios para um Integrations.PartnerIntegration.Type
+type PartnerFactory func(cfg config.PartnerIntegration, opts Options) (domain.PartnerService, error)
+
+var (
+	mu                 sync.RWMutex
+	validatorFactories = map[string]ValidatorFactory{}
+	partnerFactories   = map[string]PartnerFactory{}
+)
+
+func init() {
+	RegisterValidator("superlogica", newSuperlogica)
+	RegisterValidator("file", newFileSource)
+	RegisterValidator("http", newHTTPSource)
+	RegisterPartner("rede_parcerias", newRedeParcerias)
+	RegisterPartner("rest", newRESTPartner)
+}
+
+// RegisterValidator registra um tipo de fonte de moradores
+func RegisterValidator(typ string, factory ValidatorFactory) {
+	mu.Lock()
+	defer mu.Unlock()
+	validatorFactories[typ] = factory
+}
+
+// RegisterPartner registra um tipo de Clube de Benefícios
+func RegisterPartner(typ string, factory PartnerFactory) {
+	mu.Lock()
+	defer mu.Unlock()
+	partnerFactories[typ] = factory
+}
+
+// NewBenefValidator constrói as fontes de moradores e retorna o
+// SourceRegistry, que encaminha cada consulta à fonte do condomínio
+// (tenants[].source, ou Integrations.NameIntegration)
+func NewBenefValidator(cfg *config.Config, opts Options) (domain.BenefValidator, error) {
+	return NewSourceRegistry(cfg, opts)
+}
+
+func newValidator(field string, integration config.NameIntegration, opts Options) (domain.BenefValidator, error) {
+	if !integration.Enabled {
+		log.Printf("[ADAPTER] Fonte de moradores %s desabilitada na config", field)
+		return DisabledValidator{}, nil
+	}
+
+	mu.RLock()
+	factory, ok := validatorFactories[integration.Type]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%s.type %q desconhecido (registrados: %s, composite)", field, integration.Type, keys(validatorFactories))
+	}
+	return factory(integration, opts)
+}
+
+// SourceRegistry guarda as fontes de moradores da config (name_integration
+// como "default" e integrations.sources) e encaminha cada consulta à fonte do
+// condomínio por tenants[].source, acompanhando o hot reload dos tenants. As
+// fontes em si são construídas na inicialização.
+type SourceRegistry struct {
+	sources map[string]domain.BenefValidator
+}
+
+// NewSourceRegistry constrói todas as fontes configuradas; as do type
+// "composite" depois das fontes que elas consultam
+func NewSourceRegistry(cfg *config.Config, opts Options) (*SourceRegistry, error) {
+	r := &SourceRegistry{sources: map[string]domain.BenefValidator{}}
+
+	type pending struct {
+		field       string
+		integration config.NameIntegration
+	}
+	all := []pending{{"integrations.name_integration", cfg.Integrations.NameIntegration}}
+	all[0].integration.ID = config.DefaultSourceID
+	for i, source := range cfg.Integrations.Sources {
+		all = append(all, pending{fmt.Sprintf("integrations.sources[%d]", i), source})
+	}
+
+	var composites []pending
+	for _, p := range all {
+		if p.integration.Enabled && p.integration.Type == "composite" {
+			composites = append(composites, p)
+			continue
+		}
+		validator, err := newValidator(p.field, p.integration, opts)
+		if err != nil {
+			return nil, err
+		}
+		r.sources[p.integration.ID] = validator
+	}
+
+	// Compostas podem consultar outras compostas: constrói em rodadas até
+	// não haver progresso (o que restar tem referência circular)
+	for len(composites) > 0 {
+		var next []pending
+		for _, p := range composites {
+			composite := &CompositeValidator{id: p.integration.ID}
+			ready := true
+			for _, id := range p.integration.Sources {
+				validator, ok := r.sources[id]
+				if !ok {
+					ready = false
+					break
+				}
+				composite.sources = append(composite.sources, namedValidator{id: id, validator: validator})
+			}
+			if !ready {
+				next = append(next, p)
+				continue
+			}
+			r.sources[p.integration.ID] = composite
+		}
+		if len(next) == len(composites) {
+			return nil, fmt.Errorf("%s: fontes %v inexistentes ou com referência circular", next[0].field, next[0].integration.Sources)
+		}
+		composites = next
+	}
+
+	if len(r.sources) > 1 {
+		log.Printf("[ADAPTER] %d fontes de moradores configuradas", len(r.sources))
+	}
+	return r, nil
+}
+
+// Default retorna a fonte de integrations.name_integration
+func (r *SourceRegistry) Default() domain.BenefValidator {
+	return r.sources[config.DefaultSourceID]
+}
+
+// For retorna a fonte do condomínio segundo a config da requisição. Fontes
+// adicionadas por reload sem reiniciar o servidor são ignoradas.
+func (r *SourceRegistry) For(ctx context.Context, condoID string) domain.BenefValidator {
+	cfg := config.FromContext(ctx)
+	if cfg == nil {
+		cfg = config.Get()
+	}
+	id := cfg.TenantSource(condoID)
+	if source, ok := r.sources[id]; ok {
+		return source
+	}
+	log.Printf("[ADAPTER] Fonte %q do condomínio %s não foi construída (reinicie o servidor)", id, condoID)
+	return r.Default()
+}
+
+// ValidateMember consulta a fonte do condomínio
+func (r *SourceRegistry) ValidateMember(ctx context.Context, condoID string, cpf string) (bool, *domain.Lead, error) {
+	return r.For(ctx, condoID).ValidateMember(ctx, condoID, cpf)
+}
+
+// ListMembers lista o condomínio na sua fonte, se ela listar moradores
+func (r *SourceRegistry) ListMembers(ctx context.Context, condoID string) ([]domain.Lead, error) {
+	lister, ok := r.For(ctx, condoID).(domain.MemberLister)
+	if !ok {
+		return nil, fmt.Errorf("a fonte de moradores do condomínio %s não lista unidades", condoID)
+	}
+	return lister.ListMembers(ctx, condoID)
+}
+
+// ListsMembers indica se a fonte do condomínio consegue listar moradores
+// (diretório e importação sem arquivo)
+func (r *SourceRegistry) ListsMembers(ctx context.Context, condoID string) bool {
+	return listsMembers(r.For(ctx, condoID))
+}
+
+// listsMembers implementar MemberLister não basta: a fonte http só lista com
+// http.list e a composta só com alguma fonte que liste
+func listsMembers(validator domain.BenefValidator) bool {
+	switch v := validator.(type) {
+	case *rest.Source:
+		return v.Lists()
+	case *CompositeValidator:
+		for _, source := range v.sources {
+			if listsMembers(source.validator) {
+				return true
+			}
+		}
+		return false
+	case domain.MemberLister:
+		return true
+	}
+	return false
+}
+
+// NewPartnerService constrói o PartnerService a partir de Integrations.PartnerIntegration
+// (o clube "default")
+func NewPartnerService(cfg *config.Config, opts Options) (domain.PartnerService, error) {
+	return newPartner("integrations.partner_integration", cfg.Integrations.PartnerIntegration, opts)
+}
+
+func newPartner(field string, integration config.PartnerIntegration, opts Options) (domain.PartnerService, error) {
+	if !integration.Enabled {
+		log.Printf("[ADAPTER] Clube de Benefícios %s desabilitado na config", field)
+		return DisabledPartner{}, nil
+	}
+
+	mu.RLock()
+	factory, ok := partnerFactories[integration.Type]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%s.type %q desconhecido (registrados: %s)", field, integration.Type, keys(partnerFactories))
+	}
+	return factory(integration, opts)
+}
+
+// PartnerRegistry guarda os Clubes de Benefícios da config (partner_integration
+// como "default" e integrations.clubs) e resolve os clubes de cada condomínio
+// por tenants[].clubs, acompanhando o hot reload dos tenants. Os clubes em si
+// são construídos na inicialização.
+type PartnerRegistry struct {
+	clubs map[string]domain.Club
+}
+
+// NewPartnerRegistry constrói todos os clubes configurados
+func NewPartnerRegistry(cfg *config.Config, opts Options) (*PartnerRegistry, error) {
+	r := &PartnerRegistry{clubs: map[string]domain.Club{}}
+
+	partner, err := NewPartnerService(cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+	name := cfg.Integrations.PartnerIntegration.Name
+	if name == "" {
+		name = "Clube de Benefícios"
+	}
+	r.clubs[domain.DefaultClubID] = domain.Club{ID: domain.DefaultClubID, Name: name, Partner: partner}
+
+	for i, integration := range cfg.Integrations.Clubs {
+		partner, err := newPartner(fmt.Sprintf("integrations.clubs[%d]", i), integration, opts)
+		if err != nil {
+			return nil, err
+		}
+		name := integration.Name
+		if name == "" {
+			name = integration.ID
+		}
+		r.clubs[integration.ID] = domain.Club{ID: integration.ID, Name: name, Partner: partner}
+	}
+	if len(r.clubs) > 1 {
+		log.Printf("[ADAPTER] %d Clubes de Benefícios configurados", len(r.clubs))
+	}
+	return r, nil
+}
+
+// Default retorna o clube de integrations.partner_integration
+func (r *PartnerRegistry) Default() domain.PartnerService {
+	return r.clubs[domain.DefaultClubID].Partner
+}
+
+// Club retorna um clube pelo ID
+func (r *PartnerRegistry) Club(id string) (domain.Club, bool) {
+	club, ok := r.clubs[id]
+	return club, ok
+}
+
+// Clubs retorna todos os clubes construídos, o default primeiro e os demais
+// na ordem dos IDs
+func (r *PartnerRegistry) Clubs() []domain.Club {
+	ids := make([]string, 0, len(r.clubs))
+	for id := range r.clubs {
+		if id != domain.DefaultClubID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	clubs := []domain.Club{r.clubs[domain.DefaultClubID]}
+	for _, id := range ids {
+		clubs = append(clubs, r.clubs[id])
+	}
+	return clubs
+}
+
+// ClubsFor retorna os clubes do condomínio segundo a config da requisição.
+// Clubes adicionados por reload sem reiniciar o servidor são ignorados.
+func (r *PartnerRegistry) ClubsFor(ctx context.Context, condoID string) []domain.Club {
+	cfg := config.FromContext(ctx)
+	if cfg == nil {
+		cfg = config.Get()
+	}
+
+	var clubs []domain.Club
+	for _, id := range cfg.TenantClubs(condoID) {
+		club, ok := r.clubs[id]
+		if !ok {
+			log.Printf("[ADAPTER] Clube %q do condomínio %s não foi construído (reinicie o servidor)", id, condoID)
+			continue
+		}
+		clubs = append(clubs, club)
+	}
+	if len(clubs) == 0 {
+		clubs = append(clubs, r.clubs[domain.DefaultClubID])
+	}
+	return clubs
+}
+
+func newSuperlogica(cfg config.NameIntegration, opts Options) (domain.BenefValidator, error) {
+	appToken := secrets.NewSecret(opts.Secrets, cfg.Superlogica.AppTokenRef)
+	accessToken := secrets.NewSecret(opts.Secrets, cfg.Superlogica.AccessTokenRef)
+
+	if err := requireSecrets(opts, "superlogica", appToken, accessToken); err != nil {
+		return nil, err
+	}
+
+	return benef.NewBenefAdapter(benef.Settings{
+		URL:         cfg.URL,
+		Timeout:     time.Duration(cfg.TimeoutSeconds) * time.Second,
+		AppToken:    appToken,
+		AccessToken: accessToken,
+	}), nil
+}
+
+func newFileSource(cfg config.NameIntegration, opts Options) (domain.BenefValidator, error) {
+	f := cfg.File.Fields
+	return filesource.New(filesource.Settings{
+		Name:        cfg.ID,
+		Path:        cfg.File.Path,
+		Format:      cfg.File.Format,
+		RecordsPath: cfg.File.RecordsPath,
+		Fields: filesource.Fields{
+			CPF:     f.CPF,
+			Name:    f.Name,
+			Email:   f.Email,
+			Phone:   f.Phone,
+			CondoID: f.CondoID,
+		},
+	}), nil
+}
+
+func newHTTPSource(cfg config.NameIntegration, opts Options) (domain.BenefValidator, error) {
+	auth, err := restAuth(opts, "http:"+cfg.ID, cfg.HTTP.Auth)
+	if err != nil {
+		return nil, err
+	}
+	f := cfg.HTTP.Fields
+	return rest.NewSource(rest.SourceSettings{
+		Name:        cfg.ID,
+		URL:         cfg.URL,
+		Timeout:     time.Duration(cfg.TimeoutSeconds) * time.Second,
+		Auth:        auth,
+		Lookup:      rest.Endpoint{Method: cfg.HTTP.Lookup.Method, Path: cfg.HTTP.Lookup.Path},
+		List:        rest.Endpoint{Method: cfg.HTTP.List.Method, Path: cfg.HTTP.List.Path},
+		RecordsPath: cfg.HTTP.RecordsPath,
+		Fields: rest.SourceFields{
+			CPF:     f.CPF,
+			Name:    f.Name,
+			Email:   f.Email,
+			Phone:   f.Phone,
+			CondoID: f.CondoID,
+		},
+	}), nil
+}
+
+func newRedeParcerias(cfg config.PartnerIntegration, opts Options) (domain.PartnerService, error) {
+	clientID := secrets.NewSecret(opts.Secrets, cfg.RedeParcerias.ClientIDRef)
+	clientSecret := secrets.NewSecret(opts.Secrets, cfg.RedeParcerias.ClientSecretRef)
+	bearerToken := secrets.NewSecret(opts.Secrets, cfg.RedeParcerias.BearerTokenRef)
+
+	// Com token fixo disponível o OAuth2 não é usado
+	if _, err := bearerToken.Value(context.Background()); err != nil {
+		if err := requireSecrets(opts, "rede_parcerias", clientID, clientSecret); err != nil {
+			return nil, err
+		}
+	}
+
+	return redeparcerias.NewClient(redeparcerias.Settings{
+		URL:          cfg.URL,
+		Timeout:      time.Duration(cfg.TimeoutSeconds) * time.Second,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		BearerToken:  bearerToken,
+	}), nil
+}
+
+func newRESTPartner(cfg config.PartnerIntegration, opts Options) (domain.PartnerService, error) {
+	auth, err := restAuth(opts, "rest:"+cfg.ID, cfg.REST.Auth)
+	if err != nil {
+		return nil, err
+	}
+
+	name := cfg.Name
+	if name == "" {
+		name = cfg.ID
+	}
+	endpoint := func(e config.RESTEndpoint) rest.Endpoint {
+		return rest.Endpoint{Method: e.Method, Path: e.Path}
+	}
+	f := cfg.REST.Fields
+	return rest.NewPartner(rest.Settings{
+		Name:     name,
+		URL:      cfg.URL,
+		Timeout:  time.Duration(cfg.TimeoutSeconds) * time.Second,
+		Auth:     auth,
+		FindUser: endpoint(cfg.REST.FindUser),
+		Register: endpoint(cfg.REST.Register),
+		Delete:   endpoint(cfg.REST.Delete),
+		SSO:      endpoint(cfg.REST.SSO),
+		Fields: rest.Fields{
+			UsersPath:   f.UsersPath,
+			ID:          f.ID,
+			Name:        f.Name,
+			Email:       f.Email,
+			CPF:         f.CPF,
+			Phone:       f.Phone,
+			Active:      f.Active,
+			SSOToken:    f.SSOToken,
+			SSORedirect: f.SSORedirect,
+		},
+		RegisterExtra:       cfg.REST.RegisterExtra,
+		AlreadyExistsStatus: cfg.REST.AlreadyExistsStatus,
+	}), nil
+}
+
+// restAuth monta a autenticação das integrações genéricas
+func restAuth(opts Options, integration string, cfg config.RESTAuth) (rest.Auth, error) {
+	auth := rest.Auth{
+		Type:     cfg.Type,
+		Header:   cfg.Header,
+		Username: cfg.Username,
+		Token:    secrets.NewSecret(opts.Secrets, cfg.TokenRef),
+	}
+	if cfg.Type != "" && cfg.Type != "none" {
+		if err := requireSecrets(opts, integration, auth.Token); err != nil {
+			return rest.Auth{}, err
+		}
+	}
+	return auth, nil
+}
+
+// requireSecrets verifica se as credenciais resolvem. Em produção a ausência
+// impede a inicialização; fora dela apenas gera um aviso (sem expor valores).
+func requireSecrets(opts Options, integration string, required ...secrets.Secret) error {
+	var missing []string
+	for _, secret := range required {
+		if _, err := secret.Value(context.Background()); err != nil {
+			missing = append(missing, fmt.Sprintf("%s (%v)", secret.Ref(), err))
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if opts.Production {
+		return fmt.Errorf("%s: credenciais ausentes em produção: %s", integration, strings.Join(missing, "; "))
+	}
+	log.Printf("[WARN] %s: credenciais ausentes, chamadas à API vão falhar: %s", integration, strings.Join(missing, "; "))
+	return nil
+}
+
+func keys[T any](m map[string]T) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,181 @@
+package adapter
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/viplounge/platform/internal/adapter/rest"
+	"github.com/viplounge/platform/internal/config"
+	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/secrets"
+)
+
+var testOpts = Options{Secrets: secrets.EnvProvider{}}
+
+// sourcesConfig config com uma fonte por condomínio, a "default" desligada
+func sourcesConfig(sources ...config.NameIntegration) *config.Config {
+	cfg := &config.Config{}
+	cfg.Integrations.Sources = sources
+	for _, source := range sources {
+		cfg.Tenants = append(cfg.Tenants, config.Tenant{ID: source.ID, Source: source.ID})
+	}
+	return cfg
+}
+
+func httpSource(id, list string) config.NameIntegration {
+	source := config.NameIntegration{ID: id, Enabled: true, Type: "http", URL: "http://localhost"}
+	source.HTTP.Lookup.Path = "/residents?cpf={cpf}"
+	source.HTTP.List.Path = list
+	return source
+}
+
+func compositeSource(id string, sources ...string) config.NameIntegration {
+	return config.NameIntegration{ID: id, Enabled: true, Type: "composite", Sources: sources}
+}
+
+func TestSourceRegistryListsMembers(t *testing.T) {
+	cfg := sourcesConfig(
+		httpSource("com-lista", "/residents"),
+		httpSource("sem-lista", ""),
+		compositeSource("composta-com-lista", "sem-lista", "com-lista"),
+		compositeSource("composta-sem-lista", "sem-lista", "default"),
+	)
+	registry, err := NewSourceRegistry(cfg, testOpts)
+	if err != nil {
+		t.Fatalf("NewSourceRegistry: %v", err)
+	}
+	ctx := config.WithSnapshot(context.Background(), cfg)
+
+	want := map[string]bool{
+		"com-lista":          true,
+		"sem-lista":          false,
+		"composta-com-lista": true,
+		"composta-sem-lista": false,
+		"99":                 false, // sem tenant: fonte default, desligada
+	}
+	for condoID, lists := range want {
+		if got := registry.ListsMembers(ctx, condoID); got != lists {
+			t.Errorf("condomínio %s: lista=%v, esperado %v", condoID, got, lists)
+		}
+	}
+}
+
+func TestNewSourceRegistry(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources []config.NameIntegration
+		wantErr string
+	}{
+		{
+			// A composta pode vir antes das fontes que ela consulta
+			name: "composta de composta",
+			sources: []config.NameIntegration{
+				compositeSource("externa", "interna", "default"),
+				compositeSource("interna", "erp"),
+				httpSource("erp", ""),
+			},
+		},
+		{
+			name: "referência circular",
+			sources: []config.NameIntegration{
+				compositeSource("a", "b"),
+				compositeSource("b", "a"),
+			},
+			wantErr: "referência circular",
+		},
+		{
+			name:    "fonte inexistente",
+			sources: []config.NameIntegration{compositeSource("a", "erp")},
+			wantErr: "inexistentes",
+		},
+		{
+			name:    "type desconhecido",
+			sources: []config.NameIntegration{{ID: "x", Enabled: true, Type: "sap"}},
+			wantErr: `type "sap" desconhecido`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry, err := NewSourceRegistry(sourcesConfig(tt.sources...), testOpts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("erro %v, esperado %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewSourceRegistry: %v", err)
+			}
+			for _, source := range tt.sources {
+				if registry.sources[source.ID] == nil {
+					t.Errorf("fonte %s não construída", source.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestSourceRegistryFor(t *testing.T) {
+	cfg := sourcesConfig(httpSource("erp", ""))
+	registry, err := NewSourceRegistry(cfg, testOpts)
+	if err != nil {
+		t.Fatalf("NewSourceRegistry: %v", err)
+	}
+
+	// Tenant apontado por reload para uma fonte que não foi construída
+	reloaded := sourcesConfig(httpSource("erp", ""))
+	reloaded.Tenants = append(reloaded.Tenants, config.Tenant{ID: "9", Source: "nova"})
+	ctx := config.WithSnapshot(context.Background(), reloaded)
+
+	if _, ok := registry.For(ctx, "erp").(*rest.Source); !ok {
+		t.Errorf("condomínio erp: %T", registry.For(ctx, "erp"))
+	}
+	for _, condoID := range []string{"9", "99"} {
+		if source := registry.For(ctx, condoID); source != registry.Default() {
+			t.Errorf("condomínio %s: %T, esperado a fonte default", condoID, source)
+		}
+	}
+}
+
+func TestNewPartnerRegistry(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Integrations.PartnerIntegration = config.PartnerIntegration{Name: "Clube Padrão"}
+	restClub := func(id string) config.PartnerIntegration {
+		return config.PartnerIntegration{ID: id, Enabled: true, Type: "rest", URL: "http://localhost"}
+	}
+	cfg.Integrations.Clubs = []config.PartnerIntegration{restClub("ouro"), restClub("bronze")}
+	cfg.Tenants = []config.Tenant{{ID: "4", Clubs: []string{"ouro", "inexistente", domain.DefaultClubID}}}
+
+	registry, err := NewPartnerRegistry(cfg, testOpts)
+	if err != nil {
+		t.Fatalf("NewPartnerRegistry: %v", err)
+	}
+	if _, ok := registry.Default().(DisabledPartner); !ok {
+		t.Errorf("default desligado: %T", registry.Default())
+	}
+
+	ids := func(clubs []domain.Club) string {
+		var out []string
+		for _, club := range clubs {
+			out = append(out, club.ID)
+		}
+		return strings.Join(out, ",")
+	}
+	if got := ids(registry.Clubs()); got != "default,bronze,ouro" {
+		t.Errorf("Clubs: %s", got)
+	}
+	ctx := config.WithSnapshot(context.Background(), cfg)
+	if got := ids(registry.ClubsFor(ctx, "4")); got != "ouro,default" {
+		t.Errorf("clubes do condomínio 4: %s", got)
+	}
+	if got := ids(registry.ClubsFor(ctx, "7")); got != "default" {
+		t.Errorf("condomínio sem clubs: %s", got)
+	}
+
+	cfg.Integrations.Clubs = append(cfg.Integrations.Clubs, config.PartnerIntegration{ID: "x", Enabled: true, Type: "soap"})
+	if _, err := NewPartnerRegistry(cfg, testOpts); err == nil || !strings.Contains(err.Error(), "integrations.clubs[2]") {
+		t.Errorf("type desconhecido: %v", err)
+	}
+}
@@ -0,0 +1,264 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/viplounge/platform/internal/domain"
+)
+
+// Partner integra um Clube de Benefícios simples descrito por mapeamento
+// (config.RESTPartnerSettings), sem código específico do fornecedor
+type Partner struct {
+	name       string
+	baseURL    string
+	settings   Settings
+	httpClient *http.Client
+}
+
+// Fields nomes dos campos no clube; os de resposta aceitam caminhos com ponto
+type Fields struct {
+	UsersPath   string
+	ID          string
+	Name        string
+	Email       string
+	CPF         string
+	Phone       string
+	Active      string
+	SSOToken    string
+	SSORedirect string
+}
+
+// Settings configuração do provider, montada a partir de config.PartnerIntegration
+type Settings struct {
+	// Name identifica o clube nos logs
+	Name    string
+	URL     string
+	Timeout time.Duration
+
+	Auth Auth
+
+	FindUser Endpoint
+	Register Endpoint
+	Delete   Endpoint
+	SSO      Endpoint
+
+	Fields              Fields
+	RegisterExtra       map[string]interface{}
+	AlreadyExistsStatus []int
+}
+
+func NewPartner(settings Settings) *Partner {
+	timeout := settings.Timeout
+	if timeout <= 0 {
+		timeout = 20 * time.Second
+	}
+	settings = withDefaults(settings)
+
+	log.Printf("[REST_PARTNER] %s inicializado - URL: %s, Auth: %s, Timeout: %v", settings.Name, settings.URL, settings.Auth.Type, timeout)
+
+	return &Partner{
+		name:       settings.Name,
+		baseURL:    strings.TrimRight(settings.URL, "/"),
+		settings:   settings,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+func withDefaults(s Settings) Settings {
+	if s.Auth.Type == "" {
+		s.Auth.Type = "none"
+	}
+	s.FindUser.Method = orDefault(s.FindUser.Method, "GET")
+	s.Register.Method = orDefault(s.Register.Method, "POST")
+	s.Delete.Method = orDefault(s.Delete.Method, "DELETE")
+	s.SSO.Method = orDefault(s.SSO.Method, "GET")
+
+	f := &s.Fields
+	f.ID = orDefault(f.ID, "id")
+	f.Name = orDefault(f.Name, "name")
+	f.Email = orDefault(f.Email, "email")
+	f.CPF = orDefault(f.CPF, "cpf")
+	f.Phone = orDefault(f.Phone, "phone")
+	f.Active = orDefault(f.Active, "active")
+	f.SSOToken = orDefault(f.SSOToken, "token")
+	f.SSORedirect = orDefault(f.SSORedirect, "redirect")
+
+	if len(s.AlreadyExistsStatus) == 0 {
+		s.AlreadyExistsStatus = []int{http.StatusConflict}
+	}
+	return s
+}
+
+// FindUserByCPF busca o usuário pelo CPF. 404 ou lista sem o CPF exato = não encontrado.
+func (p *Partner) FindUserByCPF(ctx context.Context, cpf string) (*domain.PartnerUser, error) {
+	cpfClean := nonDigitRegex.ReplaceAllString(cpf, "")
+
+	resp, err := p.do(ctx, "find_user", p.settings.FindUser, map[string]string{"cpf": cpfClean}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.status == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.status < 200 || resp.status > 299 {
+		return nil, resp.failure(p.name, "find_user")
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(resp.body, &decoded); err != nil {
+		return nil, domain.UnavailableError(p.name, "find_user", fmt.Errorf("erro decodificando response: %w", err))
+	}
+
+	for _, item := range records(decoded, p.settings.Fields.UsersPath) {
+		user := p.toUser(item)
+		if user == nil || nonDigitRegex.ReplaceAllString(user.CPF, "") != cpfClean {
+			continue
+		}
+		log.Printf("[REST_PARTNER] %s: usuário encontrado: ID=%s", p.name, user.ID)
+		return user, nil
+	}
+	return nil, nil
+}
+
+// RegisterUser cadastra o lead. Os status de AlreadyExistsStatus retornam
+// domain.ErrPartnerUserExists com o lead marcado como cadastrado (como a Rede Parcerias).
+func (p *Partner) RegisterUser(ctx context.Context, lead *domain.Lead) error {
+	fields := p.settings.Fields
+	payload := map[string]interface{}{}
+	for k, v := range p.settings.RegisterExtra {
+		payload[k] = v
+	}
+	payload[fields.Name] = strings.TrimSpace(lead.Name)
+	payload[fields.Email] = strings.TrimSpace(lead.Email)
+	payload[fields.CPF] = nonDigitRegex.ReplaceAllString(lead.CPF, "")
+	if phone := nonDigitRegex.ReplaceAllString(lead.Phone, ""); phone != "" {
+		payload[fields.Phone] = phone
+	}
+
+	start := time.Now()
+	resp, err := p.do(ctx, "register_user", p.settings.Register, nil, payload)
+	lead.RedeParceriasResponseMs = time.Since(start).Milliseconds()
+	lead.RedeParceriasAttempts++
+	if err != nil {
+		lead.RedeParceriasStatus = domain.PartnerStatusFailed
+		lead.RedeParceriasError = fmt.Sprintf("NETWORK_ERROR: %v", err)
+		return err
+	}
+	status := resp.status
+
+	log.Printf("[REST_PARTNER] %s: RegisterUser Response: %d", p.name, status)
+
+	if status >= 200 && status < 300 {
+		var decoded interface{}
+		if json.Unmarshal(resp.body, &decoded) == nil {
+			if id := AsString(Lookup(decoded, fields.ID)); id != "" {
+				lead.RedeParceriasUserID = id
+			}
+		}
+		lead.RedeParceriasStatus = domain.PartnerStatusRegistered
+		lead.RedeParceriasError = ""
+		return nil
+	}
+
+	for _, exists := range p.settings.AlreadyExistsStatus {
+		if status == exists {
+			log.Printf("[REST_PARTNER] %s: usuário já existe (%d)", p.name, status)
+			lead.RedeParceriasStatus = domain.PartnerStatusRegistered
+			lead.RedeParceriasError = "USER_ALREADY_EXISTS"
+			failure := resp.failure(p.name, "register_user")
+			failure.Kind = domain.ErrPartnerUserExists
+			return failure
+		}
+	}
+
+	lead.RedeParceriasStatus = domain.PartnerStatusFailed
+	lead.RedeParceriasError = fmt.Sprintf("HTTP_%d: %s", status, truncate(resp.body))
+	return resp.failure(p.name, "register_user")
+}
+
+// DeleteUser remove o usuário; 404 conta como já removido
+func (p *Partner) DeleteUser(ctx context.Context, userID string) error {
+	resp, err := p.do(ctx, "delete_user", p.settings.Delete, map[string]string{"id": userID}, nil)
+	if err != nil {
+		return err
+	}
+	log.Printf("[REST_PARTNER] %s: DeleteUser Response: %d", p.name, resp.status)
+	if (resp.status < 200 || resp.status > 299) && resp.status != http.StatusNotFound {
+		return resp.failure(p.name, "delete_user")
+	}
+	return nil
+}
+
+// GetSSOToken gera o acesso ao clube para o ID ou e-mail do usuário
+func (p *Partner) GetSSOToken(ctx context.Context, userIdentifier string) (*domain.SSOToken, error) {
+	resp, err := p.do(ctx, "sso", p.settings.SSO, map[string]string{"identifier": userIdentifier, "id": userIdentifier}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.status < 200 || resp.status > 299 {
+		return nil, resp.failure(p.name, "sso")
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(resp.body, &decoded); err != nil {
+		return nil, domain.UnavailableError(p.name, "sso", fmt.Errorf("erro decodificando SSO response: %w", err))
+	}
+	sso := &domain.SSOToken{
+		Token:    AsString(Lookup(decoded, p.settings.Fields.SSOToken)),
+		Redirect: AsString(Lookup(decoded, p.settings.Fields.SSORedirect)),
+	}
+	if sso.Redirect == "" {
+		return nil, domain.UnavailableError(p.name, "sso", fmt.Errorf("resposta do SSO sem o campo %q", p.settings.Fields.SSORedirect))
+	}
+	return sso, nil
+}
+
+// RegisterAndGetSSO cadastra (ou reaproveita o cadastro existente) e gera o SSO
+// pelo e-mail, com o ID como alternativa
+func (p *Partner) RegisterAndGetSSO(ctx context.Context, lead *domain.Lead) (*domain.SSOToken, error) {
+	if err := p.RegisterUser(ctx, lead); err != nil && !errors.Is(err, domain.ErrPartnerUserExists) {
+		return nil, fmt.Errorf("erro no cadastro: %w", err)
+	}
+	if lead.Email == "" {
+		return nil, &domain.ValidationError{Message: "email é obrigatório para gerar SSO", Fields: map[string][]string{"email": {"obrigatório"}}}
+	}
+
+	sso, err := p.GetSSOToken(ctx, lead.Email)
+	if err != nil && lead.RedeParceriasUserID != "" {
+		sso, err = p.GetSSOToken(ctx, lead.RedeParceriasUserID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro gerando SSO: %w", err)
+	}
+	return sso, nil
+}
+
+func (p *Partner) do(ctx context.Context, op string, endpoint Endpoint, params map[string]string, payload interface{}) (reply, error) {
+	return call(ctx, p.httpClient, p.baseURL, p.settings.Auth, p.name, op, endpoint, params, payload)
+}
+
+func (p *Partner) toUser(item interface{}) *domain.PartnerUser {
+	if _, ok := item.(map[string]interface{}); !ok {
+		return nil
+	}
+	fields := p.settings.Fields
+	user := &domain.PartnerUser{
+		ID:        AsString(Lookup(item, fields.ID)),
+		Name:      AsString(Lookup(item, fields.Name)),
+		Email:     AsString(Lookup(item, fields.Email)),
+		CPF:       AsString(Lookup(item, fields.CPF)),
+		Cellphone: AsString(Lookup(item, fields.Phone)),
+		Active:    true,
+	}
+	if active, ok := Lookup(item, fields.Active).(bool); ok {
+		user.Active = active
+	}
+	return user
+}
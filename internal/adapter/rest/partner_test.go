@@ -0,0 +1,137 @@
+package rest_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/viplounge/platform/internal/adapter/rest"
+	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/secrets"
+)
+
+// clubAPI clube com nomes de campos próprios, autenticado por bearer
+func clubAPI(t *testing.T, registerStatus int, registered *map[string]interface{}) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/members", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token-do-clube" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			if r.URL.Query().Get("doc") != "11144477735" {
+				w.Write([]byte(`{"result":{"items":[]}}`))
+				return
+			}
+			// Busca parcial: o CPF exato é o segundo item
+			w.Write([]byte(`{"result":{"items":[
+				{"uid":6,"full_name":"Outro","doc":"111.444.777-00"},
+				{"uid":7,"full_name":"Ana","mail":"ana@example.com","doc":"111.444.777-35","mobile":"11987654321","enabled":false}
+			]}}`))
+		case http.MethodPost:
+			json.NewDecoder(r.Body).Decode(registered)
+			w.WriteHeader(registerStatus)
+			if registerStatus == http.StatusCreated {
+				w.Write([]byte(`{"uid":"m-52998224725"}`))
+			}
+		}
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newClub(url string, alreadyExists ...int) *rest.Partner {
+	return rest.NewPartner(rest.Settings{
+		Name:     "clube-teste",
+		URL:      url,
+		Auth:     rest.Auth{Type: "bearer", Token: secrets.Static("token-do-clube")},
+		FindUser: rest.Endpoint{Path: "/members?doc={cpf}"},
+		Register: rest.Endpoint{Path: "/members"},
+		Fields: rest.Fields{
+			UsersPath: "result.items",
+			ID:        "uid",
+			Name:      "full_name",
+			Email:     "mail",
+			CPF:       "doc",
+			Phone:     "mobile",
+			Active:    "enabled",
+		},
+		RegisterExtra:       map[string]interface{}{"plan": "vip"},
+		AlreadyExistsStatus: alreadyExists,
+	})
+}
+
+func TestPartnerFieldMapping(t *testing.T) {
+	var registered map[string]interface{}
+	club := newClub(clubAPI(t, http.StatusCreated, &registered).URL)
+	ctx := context.Background()
+
+	user, err := club.FindUserByCPF(ctx, "111.444.777-35")
+	if err != nil {
+		t.Fatalf("FindUserByCPF: %v", err)
+	}
+	want := domain.PartnerUser{ID: "7", Name: "Ana", Email: "ana@example.com", CPF: "111.444.777-35", Cellphone: "11987654321", Active: false}
+	if user == nil || *user != want {
+		t.Errorf("usuário %+v, esperado %+v", user, want)
+	}
+	if user, err := club.FindUserByCPF(ctx, "52998224725"); user != nil || err != nil {
+		t.Errorf("CPF ausente: %+v (%v)", user, err)
+	}
+
+	lead := &domain.Lead{CPF: "529.982.247-25", Name: " Bruno ", Email: "bruno@example.com", Phone: "(11) 91234-5678"}
+	if err := club.RegisterUser(ctx, lead); err != nil {
+		t.Fatalf("RegisterUser: %v", err)
+	}
+	wantPayload := map[string]interface{}{"full_name": "Bruno", "mail": "bruno@example.com", "doc": "52998224725", "mobile": "11912345678", "plan": "vip"}
+	for k, v := range wantPayload {
+		if registered[k] != v {
+			t.Errorf("cadastro: %s=%v, esperado %v (%v)", k, registered[k], v, registered)
+		}
+	}
+	if lead.RedeParceriasUserID != "m-52998224725" || lead.RedeParceriasStatus != domain.PartnerStatusRegistered {
+		t.Errorf("lead após cadastro: id=%q status=%q", lead.RedeParceriasUserID, lead.RedeParceriasStatus)
+	}
+}
+
+func TestPartnerAlreadyExistsStatus(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		alreadyExists []int
+		exists        bool
+	}{
+		{name: "409 por padrão", status: http.StatusConflict, exists: true},
+		{name: "status configurado", status: http.StatusUnprocessableEntity, alreadyExists: []int{http.StatusUnprocessableEntity}, exists: true},
+		{name: "409 fora da lista configurada", status: http.StatusConflict, alreadyExists: []int{http.StatusUnprocessableEntity}},
+		{name: "erro do clube", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var registered map[string]interface{}
+			club := newClub(clubAPI(t, tt.status, &registered).URL, tt.alreadyExists...)
+			lead := &domain.Lead{CPF: "52998224725", Name: "Bruno", Email: "bruno@example.com"}
+
+			err := club.RegisterUser(context.Background(), lead)
+			if err == nil {
+				t.Fatalf("status %d sem erro", tt.status)
+			}
+			if errors.Is(err, domain.ErrPartnerUserExists) != tt.exists {
+				t.Errorf("ErrPartnerUserExists=%v, esperado %v (%v)", !tt.exists, tt.exists, err)
+			}
+			wantStatus := domain.PartnerStatusFailed
+			if tt.exists {
+				wantStatus = domain.PartnerStatusRegistered
+			}
+			if lead.RedeParceriasStatus != wantStatus {
+				t.Errorf("status do lead %q, esperado %q", lead.RedeParceriasStatus, wantStatus)
+			}
+		})
+	}
+}
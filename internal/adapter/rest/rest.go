@@ -0,0 +1,178 @@
+// Package rest implementa integrações genéricas descritas por mapeamento na
+// config: um Clube de Benefícios simples (Partner) e uma fonte de moradores
+// em API JSON (Source).
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/secrets"
+)
+
+// Tamanho máximo de resposta lida da API
+const maxResponseBytes = 1 << 20
+
+var nonDigitRegex = regexp.MustCompile(`\D`)
+
+// Auth autenticação das chamadas
+type Auth struct {
+	// Type "none", "bearer", "header" ou "basic"
+	Type     string
+	Header   string
+	Username string
+	// Token token (bearer/header) ou senha (basic), resolvido a cada chamada
+	Token secrets.Secret
+}
+
+func (a Auth) apply(ctx context.Context, req *http.Request) error {
+	if a.Type == "" || a.Type == "none" {
+		return nil
+	}
+	token, err := a.Token.Value(ctx)
+	if err != nil {
+		return fmt.Errorf("credencial %s indisponível: %w", a.Token.Ref(), err)
+	}
+	switch a.Type {
+	case "bearer":
+		req.Header.Set("Authorization", "Bearer "+token)
+	case "header":
+		req.Header.Set(a.Header, token)
+	case "basic":
+		req.SetBasicAuth(a.Username, token)
+	}
+	return nil
+}
+
+// Endpoint método e path de uma operação. O path aceita {nome} para os
+// parâmetros da operação (ex. {cpf}, {id}), substituídos já escapados.
+type Endpoint struct {
+	Method string
+	Path   string
+}
+
+// reply resposta de uma chamada
+type reply struct {
+	status int
+	body   []byte
+	header http.Header
+}
+
+// failure classifica uma resposta de erro de service/op
+func (r reply) failure(service, op string) *domain.IntegrationError {
+	return domain.HTTPError(service, op, r.status, r.body, r.header.Get("Retry-After"))
+}
+
+// call monta a URL do endpoint, autentica e executa a chamada. Erros de
+// credencial e de rede já vêm tipados (domain.ErrUnauthorized,
+// domain.ErrUnavailable); service/op identificam a chamada nesses erros.
+func call(ctx context.Context, client *http.Client, baseURL string, auth Auth, service, op string, endpoint Endpoint, params map[string]string, payload interface{}) (reply, error) {
+	path := endpoint.Path
+	for key, val := range params {
+		path = strings.ReplaceAll(path, "{"+key+"}", url.PathEscape(val))
+	}
+
+	var reqBody io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return reply{}, err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, endpoint.Method, baseURL+path, reqBody)
+	if err != nil {
+		return reply{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if err := auth.apply(ctx, req); err != nil {
+		return reply{}, domain.CredentialError(service, op, err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return reply{}, domain.UnavailableError(service, op, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return reply{}, domain.UnavailableError(service, op, err)
+	}
+	return reply{status: resp.StatusCode, body: body, header: resp.Header}, nil
+}
+
+// Lookup percorre um caminho com ponto ("data.user.id"); índices numéricos
+// acessam listas. Caminho vazio retorna o próprio valor.
+func Lookup(v interface{}, path string) interface{} {
+	if path == "" {
+		return v
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			v = node[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil
+			}
+			v = node[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+// AsString converte IDs numéricos e textos para string
+func AsString(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// records extrai a lista em path; um objeto único vira lista de um item
+func records(decoded interface{}, path string) []interface{} {
+	found := Lookup(decoded, path)
+	if list, ok := found.([]interface{}); ok {
+		return list
+	}
+	if found == nil {
+		return nil
+	}
+	return []interface{}{found}
+}
+
+func truncate(body []byte) string {
+	if len(body) > 512 {
+		return string(body[:512])
+	}
+	return string(body)
+}
+
+func orDefault(val, def string) string {
+	if val == "" {
+		return def
+	}
+	return val
+}
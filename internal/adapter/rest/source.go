@@ -0,0 +1,171 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/viplounge/platform/internal/domain"
+)
+
+// ErrListUnsupported a fonte não tem endpoint de listagem configurado
+var ErrListUnsupported = errors.New("fonte sem endpoint de listagem (http.list)")
+
+// SourceFields nomes dos campos de um morador; aceitam caminhos com ponto.
+// Sem CondoID, o morador vale para qualquer condomínio que use a fonte.
+type SourceFields struct {
+	CPF     string
+	Name    string
+	Email   string
+	Phone   string
+	CondoID string
+}
+
+// SourceSettings configuração da fonte, montada a partir de config.NameIntegration
+type SourceSettings struct {
+	// Name identifica a fonte nos logs
+	Name    string
+	URL     string
+	Timeout time.Duration
+	Auth    Auth
+
+	// Lookup busca por CPF; List (opcional) lista o condomínio. Os paths
+	// aceitam {cpf} e {condo_id}.
+	Lookup      Endpoint
+	List        Endpoint
+	RecordsPath string
+	Fields      SourceFields
+}
+
+// Source consulta moradores em uma API JSON descrita por mapeamento
+// (config.HTTPSourceSettings). Implementa domain.BenefValidator e, com List
+// configurado, domain.MemberLister.
+type Source struct {
+	settings   SourceSettings
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewSource(settings SourceSettings) *Source {
+	timeout := settings.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	if settings.Auth.Type == "" {
+		settings.Auth.Type = "none"
+	}
+	settings.Lookup.Method = orDefault(settings.Lookup.Method, "GET")
+	settings.List.Method = orDefault(settings.List.Method, "GET")
+	f := &settings.Fields
+	f.CPF = orDefault(f.CPF, "cpf")
+	f.Name = orDefault(f.Name, "name")
+	f.Email = orDefault(f.Email, "email")
+	f.Phone = orDefault(f.Phone, "phone")
+
+	log.Printf("[HTTP_SOURCE] %s inicializada - URL: %s, Auth: %s, Timeout: %v", settings.Name, settings.URL, settings.Auth.Type, timeout)
+
+	return &Source{
+		settings:   settings,
+		baseURL:    strings.TrimRight(settings.URL, "/"),
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// ValidateMember busca o CPF na API. 404 ou lista sem o CPF exato = não encontrado.
+func (s *Source) ValidateMember(ctx context.Context, condoID string, cpf string) (bool, *domain.Lead, error) {
+	start := time.Now()
+	cpfClean := nonDigitRegex.ReplaceAllString(cpf, "")
+
+	members, err := s.fetch(ctx, "lookup", s.settings.Lookup, condoID, cpfClean)
+	if err != nil {
+		return false, nil, err
+	}
+	for _, member := range members {
+		if nonDigitRegex.ReplaceAllString(member.CPF, "") != cpfClean || !sameCondo(condoID, member.CondoID) {
+			continue
+		}
+		member.SuperlogicaResponseMs = time.Since(start).Milliseconds()
+		log.Printf("[HTTP_SOURCE] %s: morador encontrado no condomínio %s", s.settings.Name, member.CondoID)
+		return true, &member, nil
+	}
+	return false, nil, nil
+}
+
+// Lists indica se a fonte tem endpoint de listagem configurado
+func (s *Source) Lists() bool {
+	return s.settings.List.Path != ""
+}
+
+// ListMembers lista os moradores do condomínio pelo endpoint List
+func (s *Source) ListMembers(ctx context.Context, condoID string) ([]domain.Lead, error) {
+	if !s.Lists() {
+		return nil, ErrListUnsupported
+	}
+	members, err := s.fetch(ctx, "list", s.settings.List, condoID, "")
+	if err != nil {
+		return nil, err
+	}
+	out := members[:0]
+	for _, member := range members {
+		if sameCondo(condoID, member.CondoID) {
+			out = append(out, member)
+		}
+	}
+	return out, nil
+}
+
+func (s *Source) fetch(ctx context.Context, op string, endpoint Endpoint, condoID, cpf string) ([]domain.Lead, error) {
+	params := map[string]string{"cpf": cpf, "condo_id": condoID}
+	resp, err := call(ctx, s.httpClient, s.baseURL, s.settings.Auth, s.settings.Name, op, endpoint, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.status == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.status < 200 || resp.status > 299 {
+		return nil, resp.failure(s.settings.Name, op)
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(resp.body, &decoded); err != nil {
+		return nil, domain.UnavailableError(s.settings.Name, op, fmt.Errorf("erro decodificando response: %w", err))
+	}
+
+	var members []domain.Lead
+	for _, item := range records(decoded, s.settings.RecordsPath) {
+		if member, ok := s.toLead(item, condoID); ok {
+			members = append(members, member)
+		}
+	}
+	return members, nil
+}
+
+func (s *Source) toLead(item interface{}, condoID string) (domain.Lead, bool) {
+	if _, ok := item.(map[string]interface{}); !ok {
+		return domain.Lead{}, false
+	}
+	f := s.settings.Fields
+	lead := domain.Lead{
+		CPF:     AsString(Lookup(item, f.CPF)),
+		Name:    strings.TrimSpace(AsString(Lookup(item, f.Name))),
+		Email:   strings.TrimSpace(AsString(Lookup(item, f.Email))),
+		Phone:   strings.TrimSpace(AsString(Lookup(item, f.Phone))),
+		CondoID: condoID,
+	}
+	if f.CondoID != "" {
+		lead.CondoID = AsString(Lookup(item, f.CondoID))
+	}
+	return lead, lead.CPF != ""
+}
+
+// sameCondo indica se o morador pertence ao condomínio pedido ("" e "-1" =
+// busca global)
+func sameCondo(requested, member string) bool {
+	return requested == "" || requested == "-1" || member == "" || member == requested
+}
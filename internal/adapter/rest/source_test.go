@@ -0,0 +1,100 @@
+package rest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/viplounge/platform/internal/adapter/rest"
+	"github.com/viplounge/platform/internal/domain"
+)
+
+// erpAPI ERP com moradores em data.residents e o condomínio em unit.condo
+func erpAPI(t *testing.T) *httptest.Server {
+	t.Helper()
+	residents := `{"data":{"residents":[
+		{"documento":"111.444.777-35","nome":" Ana ","email":"ana@example.com","unit":{"condo":4}},
+		{"documento":"52998224725","nome":"Bruno","unit":{"condo":"7"}}
+	]}}`
+	mux := http.NewServeMux()
+	mux.HandleFunc("/residents", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("cpf") {
+		case "00000000000":
+			w.WriteHeader(http.StatusNotFound)
+		case "99999999999":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Write([]byte(residents))
+		}
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newERPSource(url, list string) *rest.Source {
+	return rest.NewSource(rest.SourceSettings{
+		Name:        "erp-teste",
+		URL:         url,
+		Lookup:      rest.Endpoint{Path: "/residents?cpf={cpf}&condo={condo_id}"},
+		List:        rest.Endpoint{Path: list},
+		RecordsPath: "data.residents",
+		Fields:      rest.SourceFields{CPF: "documento", Name: "nome", CondoID: "unit.condo"},
+	})
+}
+
+func TestSourceValidateMember(t *testing.T) {
+	source := newERPSource(erpAPI(t).URL, "")
+
+	tests := []struct {
+		name    string
+		condoID string
+		cpf     string
+		want    *domain.Lead
+		wantErr bool
+	}{
+		{name: "morador do condomínio", condoID: "4", cpf: "11144477735", want: &domain.Lead{CPF: "111.444.777-35", Name: "Ana", Email: "ana@example.com", CondoID: "4"}},
+		{name: "outro condomínio", condoID: "7", cpf: "11144477735"},
+		{name: "busca global", condoID: "-1", cpf: "529.982.247-25", want: &domain.Lead{CPF: "52998224725", Name: "Bruno", CondoID: "7"}},
+		{name: "404", condoID: "4", cpf: "00000000000"},
+		{name: "falha da API", condoID: "4", cpf: "99999999999", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, lead, err := source.ValidateMember(context.Background(), tt.condoID, tt.cpf)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("erro %v, esperado erro=%v", err, tt.wantErr)
+			}
+			if found != (tt.want != nil) {
+				t.Fatalf("found=%v, esperado %v", found, tt.want != nil)
+			}
+			if tt.want != nil {
+				lead.SuperlogicaResponseMs = 0
+				if !reflect.DeepEqual(lead, tt.want) {
+					t.Errorf("lead %+v, esperado %+v", lead, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSourceListMembers(t *testing.T) {
+	server := erpAPI(t)
+	ctx := context.Background()
+
+	if _, err := newERPSource(server.URL, "").ListMembers(ctx, "4"); !errors.Is(err, rest.ErrListUnsupported) {
+		t.Errorf("sem http.list: %v, esperado ErrListUnsupported", err)
+	}
+
+	members, err := newERPSource(server.URL, "/residents?condo={condo_id}").ListMembers(ctx, "7")
+	if err != nil {
+		t.Fatalf("ListMembers: %v", err)
+	}
+	if len(members) != 1 || members[0].CPF != "52998224725" {
+		t.Errorf("moradores do condomínio 7: %+v", members)
+	}
+}
@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/viplounge/platform/internal/config"
+	"github.com/viplounge/platform/internal/secrets"
+)
+
+var (
+	// ErrNoCredentials a requisição não trouxe credenciais
+	ErrNoCredentials = errors.New("credenciais ausentes")
+	// ErrInvalidAPIKey a API key não corresponde a nenhuma configurada
+	ErrInvalidAPIKey = errors.New("API key inválida")
+)
+
+// Authenticator identifica o Principal de uma requisição a partir de
+// "Authorization: Bearer <token|api key>" ou do header X-API-Key
+type Authenticator struct {
+	provider secrets.Provider
+	state    atomic.Pointer[authState]
+}
+
+type authState struct {
+	apiKeys     []apiKey
+	jwt         *JWTVerifier
+	jwksURL     string
+	jwks        *JWKS
+	rolesClaim  string
+	tenantClaim string
+}
+
+type apiKey struct {
+	id      string
+	secret  secrets.Secret
+	roles   []Role
+	tenants []string
+}
+
+// NewAuthenticator monta o autenticador a partir de cfg.Auth
+func NewAuthenticator(cfg *config.Config, provider secrets.Provider) *Authenticator {
+	a := &Authenticator{provider: provider}
+	a.Configure(cfg)
+	return a
+}
+
+// Configure aplica uma nova versão de cfg.Auth (hot reload de API keys e
+// parâmetros do JWT). O cache do JWKS é mantido se a URL não mudou.
+func (a *Authenticator) Configure(cfg *config.Config) {
+	prev := a.state.Load()
+	state := &authState{
+		rolesClaim:  cfg.Auth.JWT.RolesClaim,
+		tenantClaim: cfg.Auth.JWT.TenantsClaim,
+	}
+
+	for _, key := range cfg.Auth.APIKeys {
+		roles := make([]Role, 0, len(key.Roles))
+		for _, role := range key.Roles {
+			roles = append(roles, Role(role))
+		}
+		state.apiKeys = append(state.apiKeys, apiKey{
+			id:      key.ID,
+			secret:  secrets.NewSecret(a.provider, key.KeyRef),
+			roles:   roles,
+			tenants: key.Tenants,
+		})
+	}
+
+	if jwt := cfg.Auth.JWT; jwt.Enabled {
+		skew := time.Duration(jwt.ClockSkewSeconds) * time.Second
+		if jwt.StaticKeyRef != "" {
+			log.Printf("[AUTH] JWT com chave estática (HS256) - apenas para testes/dev")
+			state.jwt = NewJWTVerifier(NewStaticKey(secrets.NewSecret(a.provider, jwt.StaticKeyRef)), []string{"HS256"}, jwt.Issuer, jwt.Audience, skew)
+		} else {
+			state.jwksURL = jwt.JWKSURL
+			if prev != nil && prev.jwksURL == jwt.JWKSURL && prev.jwks != nil {
+				state.jwks = prev.jwks
+			} else {
+				state.jwks = NewJWKS(jwt.JWKSURL, time.Duration(jwt.JWKSCacheSeconds)*time.Second)
+			}
+			state.jwt = NewJWTVerifier(state.jwks, []string{"RS256", "ES256"}, jwt.Issuer, jwt.Audience, skew)
+		}
+	}
+
+	a.state.Store(state)
+	log.Printf("[AUTH] %d API keys configuradas, JWT habilitado=%v", len(state.apiKeys), state.jwt != nil)
+}
+
+// Authenticate retorna o Principal da requisição, ErrNoCredentials se não
+// houver credenciais ou um erro se as credenciais forem inválidas
+func (a *Authenticator) Authenticate(r *http.Request) (*Principal, error) {
+	credential := r.Header.Get("X-API-Key")
+	if credential == "" {
+		authz := r.Header.Get("Authorization")
+		if !strings.HasPrefix(authz, "Bearer ") {
+			return nil, ErrNoCredentials
+		}
+		credential = strings.TrimSpace(strings.TrimPrefix(authz, "Bearer "))
+	}
+	if credential == "" {
+		return nil, ErrNoCredentials
+	}
+
+	state := a.state.Load()
+
+	// JWT tem três segmentos separados por ponto; API keys não
+	if strings.Count(credential, ".") == 2 && state.jwt != nil {
+		return state.principalFromJWT(r.Context(), credential)
+	}
+	return state.principalFromAPIKey(r.Context(), credential)
+}
+
+func (s *authState) principalFromAPIKey(ctx context.Context, credential string) (*Principal, error) {
+	for _, key := range s.apiKeys {
+		expected, err := key.secret.Value(ctx)
+		if err != nil || expected == "" {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(credential), []byte(expected)) == 1 {
+			return &Principal{
+				Subject: key.id,
+				Method:  MethodAPIKey,
+				Roles:   key.roles,
+				Tenants: key.tenants,
+			}, nil
+		}
+	}
+	return nil, ErrInvalidAPIKey
+}
+
+func (s *authState) principalFromJWT(ctx context.Context, token string) (*Principal, error) {
+	claims, err := s.jwt.Verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := claims.String("email")
+	if subject == "" {
+		subject = claims.String("sub")
+	}
+	var roles []Role
+	for _, role := range claims.Strings(s.rolesClaim) {
+		if KnownRole(role) {
+			roles = append(roles, Role(role))
+		}
+	}
+	return &Principal{
+		Subject: subject,
+		Method:  MethodJWT,
+		Roles:   roles,
+		Tenants: claims.Strings(s.tenantClaim),
+	}, nil
+}
@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/viplounge/platform/internal/config"
+	"github.com/viplounge/platform/internal/secrets"
+)
+
+const testStaticKey = "segredo-jwt-de-teste"
+
+// authConfig uma API key de suporte do condomínio 4 e JWT com chave estática
+func authConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.Auth.APIKeys = []config.APIKey{{
+		ID:      "atendimento",
+		KeyRef:  "TEST_SUPPORT_API_KEY",
+		Roles:   []string{"support"},
+		Tenants: []string{"4"},
+	}}
+	cfg.Auth.JWT = config.JWTAuth{
+		Enabled:          true,
+		Issuer:           testIssuer,
+		Audience:         testAudience,
+		StaticKeyRef:     "TEST_JWT_STATIC_KEY",
+		ClockSkewSeconds: 30,
+		RolesClaim:       "roles",
+		TenantsClaim:     "tenants",
+	}
+	return cfg
+}
+
+func newTestAuthenticator(t *testing.T) *Authenticator {
+	t.Helper()
+	t.Setenv("TEST_SUPPORT_API_KEY", "chave-do-atendimento")
+	t.Setenv("TEST_JWT_STATIC_KEY", testStaticKey)
+	return NewAuthenticator(authConfig(), secrets.EnvProvider{})
+}
+
+func TestAuthenticate(t *testing.T) {
+	authn := newTestAuthenticator(t)
+	now := time.Now()
+	claims := with(validClaims(now), "roles", []interface{}{"tenant_admin", "root"})
+	claims["tenants"] = "4 7"
+	token := func(c Claims) string {
+		signed, err := SignHS256([]byte(testStaticKey), c)
+		if err != nil {
+			t.Fatalf("SignHS256: %v", err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name        string
+		header      string
+		value       string
+		wantErr     error
+		wantSubject string
+		wantRole    Role
+		tenant      string // condomínio que o principal deve ver
+		otherTenant string // condomínio que o principal não deve ver
+	}{
+		{
+			name:        "API key no X-API-Key",
+			header:      "X-API-Key",
+			value:       "chave-do-atendimento",
+			wantSubject: "atendimento",
+			wantRole:    RoleSupport,
+			tenant:      "4",
+			otherTenant: "7",
+		},
+		{
+			name:        "API key como bearer",
+			header:      "Authorization",
+			value:       "Bearer chave-do-atendimento",
+			wantSubject: "atendimento",
+			wantRole:    RoleSupport,
+			tenant:      "4",
+			otherTenant: "9",
+		},
+		{
+			name:        "JWT",
+			header:      "Authorization",
+			value:       "Bearer " + token(claims),
+			wantSubject: "suporte@example.com",
+			wantRole:    RoleTenantAdmin,
+			tenant:      "7",
+			otherTenant: "9",
+		},
+		{
+			name:    "JWT expirado",
+			header:  "Authorization",
+			value:   "Bearer " + token(with(claims, "exp", float64(now.Add(-time.Hour).Unix()))),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "JWT assinado com outra chave",
+			header:  "Authorization",
+			value:   "Bearer " + signToken(t, "HS256", "", []byte("outra-chave"), claims),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "JWT RS256 no modo de chave estática",
+			header:  "Authorization",
+			value:   "Bearer " + signToken(t, "RS256", "rsa", testKeys.rsa, claims),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "API key desconhecida",
+			header:  "X-API-Key",
+			value:   "chave-errada",
+			wantErr: ErrInvalidAPIKey,
+		},
+		{
+			name:    "sem credenciais",
+			wantErr: ErrNoCredentials,
+		},
+		{
+			name:    "Authorization sem bearer",
+			header:  "Authorization",
+			value:   "Basic dXNlcjpwYXNz",
+			wantErr: ErrNoCredentials,
+		},
+		{
+			name:    "bearer vazio",
+			header:  "Authorization",
+			value:   "Bearer   ",
+			wantErr: ErrNoCredentials,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/v1/admin/leads", nil)
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+
+			principal, err := authn.Authenticate(r)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("erro %v, esperado %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate: %v", err)
+			}
+			if principal.Subject != tt.wantSubject || len(principal.Roles) != 1 || principal.Roles[0] != tt.wantRole {
+				t.Errorf("principal %+v", principal)
+			}
+			if !principal.CanAccessTenant(tt.tenant) {
+				t.Errorf("principal sem acesso ao condomínio %s", tt.tenant)
+			}
+			if principal.CanAccessTenant(tt.otherTenant) {
+				t.Errorf("principal com acesso ao condomínio %s", tt.otherTenant)
+			}
+		})
+	}
+}
+
+func TestPrincipalTenants(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal Principal
+		tenant    string
+		want      bool
+	}{
+		{"condomínio concedido", Principal{Roles: []Role{RoleSupport}, Tenants: []string{"4"}}, "4", true},
+		{"outro condomínio", Principal{Roles: []Role{RoleTenantAdmin}, Tenants: []string{"4"}}, "7", false},
+		{"sem condomínios", Principal{Roles: []Role{RoleTenantAdmin}}, "4", false},
+		{"curinga", Principal{Roles: []Role{RoleSupport}, Tenants: []string{AllTenants}}, "7", true},
+		{"admin da plataforma", Principal{Roles: []Role{RolePlatformAdmin}}, "7", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.principal.CanAccessTenant(tt.tenant); got != tt.want {
+				t.Errorf("CanAccessTenant(%s) = %v, esperado %v", tt.tenant, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestConfigureKeepsJWKS o reload mantém o cache do JWKS se a URL não mudou
+func TestConfigureKeepsJWKS(t *testing.T) {
+	cfg := authConfig()
+	cfg.Auth.JWT.StaticKeyRef = ""
+	cfg.Auth.JWT.JWKSURL = "https://auth.example.com/.well-known/jwks.json"
+	authn := NewAuthenticator(cfg, secrets.EnvProvider{})
+	jwks := authn.state.Load().jwks
+
+	authn.Configure(cfg)
+	if authn.state.Load().jwks != jwks {
+		t.Errorf("cache do JWKS descartado sem mudança de URL")
+	}
+
+	cfg.Auth.JWT.JWKSURL = "https://outro.example.com/jwks.json"
+	authn.Configure(cfg)
+	if authn.state.Load().jwks == jwks {
+		t.Errorf("cache do JWKS mantido com outra URL")
+	}
+}
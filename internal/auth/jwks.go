@@ -0,0 +1,218 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/viplounge/platform/internal/secrets"
+)
+
+// Intervalo mínimo entre buscas forçadas por kid desconhecido
+const jwksMinRefresh = 30 * time.Second
+
+// JWKS busca as chaves públicas do provedor OIDC e as mantém em cache.
+// Um kid desconhecido força nova busca (rotação de chaves no provedor).
+type JWKS struct {
+	url    string
+	ttl    time.Duration
+	client *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]interface{}
+	fetchedAt time.Time
+	// inflight busca em andamento, compartilhada pelas chamadas simultâneas
+	inflight *jwksFetch
+}
+
+// jwksFetch resultado de uma busca, publicado ao fechar done
+type jwksFetch struct {
+	done chan struct{}
+	err  error
+}
+
+func NewJWKS(url string, ttl time.Duration) *JWKS {
+	return &JWKS{
+		url:    url,
+		ttl:    ttl,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (j *JWKS) Key(ctx context.Context, alg, kid string) (interface{}, error) {
+	j.mu.Lock()
+	seen := j.fetchedAt
+	_, known := j.keys[kid]
+	j.mu.Unlock()
+
+	age := time.Since(seen)
+	if age > j.ttl || (!known && age > jwksMinRefresh) {
+		if err := j.refresh(ctx, seen); err != nil {
+			j.mu.Lock()
+			cached := j.keys != nil
+			j.mu.Unlock()
+			if !cached {
+				return nil, err
+			}
+			log.Printf("[AUTH] Falha ao renovar JWKS, usando chaves em cache: %v", err)
+		}
+	}
+
+	j.mu.Lock()
+	key, ok := j.keys[kid]
+	j.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: kid %q", ErrUnknownKey, kid)
+	}
+	return key, nil
+}
+
+// refresh busca o JWKS sem segurar o lock: quem chega durante a busca
+// espera o resultado dela em vez de buscar de novo, e quem viu o cache em
+// seen não busca se outra chamada já o renovou depois
+func (j *JWKS) refresh(ctx context.Context, seen time.Time) error {
+	j.mu.Lock()
+	if j.fetchedAt.After(seen) {
+		j.mu.Unlock()
+		return nil
+	}
+	if call := j.inflight; call != nil {
+		j.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	call := &jwksFetch{done: make(chan struct{})}
+	j.inflight = call
+	j.mu.Unlock()
+
+	keys, err := j.fetch(ctx)
+
+	j.mu.Lock()
+	if err == nil {
+		j.keys = keys
+		j.fetchedAt = time.Now()
+	}
+	j.inflight = nil
+	j.mu.Unlock()
+
+	call.err = err
+	close(call.done)
+	return err
+}
+
+func (j *JWKS) fetch(ctx context.Context) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", j.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("erro buscando JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("erro buscando JWKS: HTTP %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&set); err != nil {
+		return nil, fmt.Errorf("erro decodificando JWKS: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			log.Printf("[AUTH] Ignorando chave %q do JWKS: %v", jwk.Kid, err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	log.Printf("[AUTH] JWKS atualizado: %d chaves", len(keys))
+	return keys, nil
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("curva %q não suportada", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("kty %q não suportado", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// StaticKey usa um segredo HMAC compartilhado, para testes e dev local
+type StaticKey struct {
+	secret secrets.Secret
+}
+
+func NewStaticKey(secret secrets.Secret) *StaticKey {
+	return &StaticKey{secret: secret}
+}
+
+func (s *StaticKey) Key(ctx context.Context, alg, kid string) (interface{}, error) {
+	val, err := s.secret.Value(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("chave estática %s indisponível: %w", s.secret.Ref(), err)
+	}
+	return []byte(val), nil
+}
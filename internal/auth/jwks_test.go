@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// jwksServer publica as chaves de teste e conta as buscas; com gate a
+// resposta espera o canal ser fechado
+type jwksServer struct {
+	hits    atomic.Int32
+	status  atomic.Int32
+	arrived chan struct{}
+	gate    chan struct{}
+}
+
+func newJWKSServer(t *testing.T) (*jwksServer, *httptest.Server) {
+	t.Helper()
+	srv := &jwksServer{arrived: make(chan struct{}, 16)}
+	srv.status.Store(http.StatusOK)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		srv.hits.Add(1)
+		srv.arrived <- struct{}{}
+		if srv.gate != nil {
+			<-srv.gate
+		}
+		if status := int(srv.status.Load()); status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{
+			rsaJWK("rsa", &testKeys.rsa.PublicKey),
+			ecJWK("ec", &testKeys.ec.PublicKey),
+			{"kty": "RSA", "kid": "cifragem", "use": "enc", "n": "AQAB", "e": "AQAB"},
+			{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": "AQAB"},
+		}})
+	}))
+	t.Cleanup(server.Close)
+	return srv, server
+}
+
+func b64(n *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(n.Bytes())
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{"kty": "RSA", "kid": kid, "use": "sig", "n": b64(key.N), "e": b64(big.NewInt(int64(key.E)))}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) map[string]string {
+	return map[string]string{"kty": "EC", "kid": kid, "crv": "P-256", "x": b64(key.X), "y": b64(key.Y)}
+}
+
+func TestJWKSKey(t *testing.T) {
+	srv, server := newJWKSServer(t)
+	jwks := NewJWKS(server.URL, time.Hour)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		kid      string
+		age      time.Duration // idade do cache antes da chamada
+		failing  bool
+		wantErr  error
+		wantHits int32
+	}{
+		{name: "primeira busca", kid: "rsa", age: 2 * time.Hour, wantHits: 1},
+		{name: "EC em cache", kid: "ec", age: time.Minute, wantHits: 1},
+		{name: "chave de cifragem ignorada", kid: "cifragem", wantErr: ErrUnknownKey, wantHits: 1},
+		{name: "kid desconhecido força nova busca", kid: "ed", age: time.Minute, wantErr: ErrUnknownKey, wantHits: 2},
+		{name: "TTL vencido renova", kid: "rsa", age: 2 * time.Hour, wantHits: 3},
+		{name: "falha na renovação usa o cache", kid: "rsa", age: 2 * time.Hour, failing: true, wantHits: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwks.mu.Lock()
+			jwks.fetchedAt = time.Now().Add(-tt.age)
+			jwks.mu.Unlock()
+			if tt.failing {
+				srv.status.Store(http.StatusInternalServerError)
+				defer srv.status.Store(http.StatusOK)
+			}
+
+			key, err := jwks.Key(ctx, "RS256", tt.kid)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("erro %v, esperado %v", err, tt.wantErr)
+				}
+			} else if err != nil || key == nil {
+				t.Errorf("Key: %v (%v)", key, err)
+			}
+			if hits := srv.hits.Load(); hits != tt.wantHits {
+				t.Errorf("%d buscas, esperadas %d", hits, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestJWKSUnavailableWithoutCache(t *testing.T) {
+	srv, server := newJWKSServer(t)
+	srv.status.Store(http.StatusServiceUnavailable)
+
+	_, err := NewJWKS(server.URL, time.Hour).Key(context.Background(), "RS256", "rsa")
+	if err == nil || errors.Is(err, ErrUnknownKey) {
+		t.Errorf("erro %v, esperada a falha da busca", err)
+	}
+}
+
+// TestJWKSRefreshOutsideLock a busca não bloqueia quem usa o cache, e as
+// chamadas que chegam durante ela não repetem a busca
+func TestJWKSRefreshOutsideLock(t *testing.T) {
+	srv, server := newJWKSServer(t)
+	jwks := NewJWKS(server.URL, time.Hour)
+	ctx := context.Background()
+	if _, err := jwks.Key(ctx, "RS256", "rsa"); err != nil {
+		t.Fatalf("Key: %v", err)
+	}
+	<-srv.arrived
+
+	srv.gate = make(chan struct{})
+	jwks.mu.Lock()
+	jwks.fetchedAt = time.Now().Add(-time.Minute)
+	jwks.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			jwks.Key(ctx, "RS256", "desconhecido")
+		}()
+	}
+	<-srv.arrived
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := jwks.Key(ctx, "RS256", "rsa")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Key em cache: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Key em cache bloqueada pela busca do JWKS")
+	}
+
+	close(srv.gate)
+	wg.Wait()
+	if hits := srv.hits.Load(); hits != 2 {
+		t.Errorf("%d buscas, esperadas 2", hits)
+	}
+}
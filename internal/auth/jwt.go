@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidToken token malformado, com assinatura inválida ou expirado
+	ErrInvalidToken = errors.New("token inválido")
+	// ErrUnknownKey kid não encontrado no JWKS
+	ErrUnknownKey = errors.New("chave de assinatura desconhecida")
+)
+
+// KeySource fornece a chave pública (ou segredo HMAC) de um kid
+type KeySource interface {
+	Key(ctx context.Context, alg, kid string) (interface{}, error)
+}
+
+// Claims são as claims de um token já verificado
+type Claims map[string]interface{}
+
+// String retorna uma claim textual
+func (c Claims) String(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+// Strings aceita a claim como lista ou como texto separado por espaços/vírgulas
+func (c Claims) Strings(name string) []string {
+	switch v := c[name].(type) {
+	case string:
+		return strings.FieldsFunc(v, func(r rune) bool { return r == ' ' || r == ',' })
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func (c Claims) time(name string) (time.Time, bool) {
+	v, ok := c[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(v), 0), true
+}
+
+// JWTVerifier valida assinatura, emissor, audiência e validade de tokens JWT
+type JWTVerifier struct {
+	keys      KeySource
+	algs      map[string]bool
+	issuer    string
+	audience  string
+	clockSkew time.Duration
+	now       func() time.Time
+}
+
+// NewJWTVerifier aceita apenas os algoritmos em algs, evitando que um token
+// HS256 seja verificado com uma chave pública RSA (confusão de algoritmo)
+func NewJWTVerifier(keys KeySource, algs []string, issuer, audience string, clockSkew time.Duration) *JWTVerifier {
+	allowed := make(map[string]bool, len(algs))
+	for _, alg := range algs {
+		allowed[alg] = true
+	}
+	return &JWTVerifier{
+		keys:      keys,
+		algs:      allowed,
+		issuer:    issuer,
+		audience:  audience,
+		clockSkew: clockSkew,
+		now:       time.Now,
+	}
+}
+
+// Verify retorna as claims de um token válido
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: formato", ErrInvalidToken)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+	if !v.algs[header.Alg] {
+		return nil, fmt.Errorf("%w: algoritmo %q não aceito", ErrInvalidToken, header.Alg)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: assinatura: %v", ErrInvalidToken, err)
+	}
+	key, err := v.keys.Key(ctx, header.Alg, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], sig); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	if err := v.validateClaims(claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return claims, nil
+}
+
+func (v *JWTVerifier) validateClaims(claims Claims) error {
+	now := v.now()
+
+	exp, ok := claims.time("exp")
+	if !ok {
+		return errors.New("exp ausente")
+	}
+	if now.After(exp.Add(v.clockSkew)) {
+		return errors.New("token expirado")
+	}
+	if nbf, ok := claims.time("nbf"); ok && now.Add(v.clockSkew).Before(nbf) {
+		return errors.New("token ainda não válido")
+	}
+	if v.issuer != "" && claims.String("iss") != v.issuer {
+		return fmt.Errorf("emissor %q não aceito", claims.String("iss"))
+	}
+	if v.audience != "" {
+		found := false
+		for _, aud := range claims.Strings("aud") {
+			if aud == v.audience {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.New("audiência não aceita")
+		}
+	}
+	return nil
+}
+
+func verifySignature(alg string, key interface{}, signingInput string, sig []byte) error {
+	digest := sha256.Sum256([]byte(signingInput))
+
+	switch alg {
+	case "RS256":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("chave incompatível com RS256")
+		}
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig)
+
+	case "ES256":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(sig) != 64 {
+			return errors.New("chave ou assinatura incompatível com ES256")
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return errors.New("assinatura inválida")
+		}
+		return nil
+
+	case "HS256":
+		secret, ok := key.([]byte)
+		if !ok {
+			return errors.New("chave incompatível com HS256")
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(mac.Sum(nil), sig) {
+			return errors.New("assinatura inválida")
+		}
+		return nil
+	}
+	return fmt.Errorf("algoritmo %q não suportado", alg)
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// SignHS256 emite um token HS256, usado no modo de chave estática para
+// gerar tokens de teste e em ferramentas locais
+func SignHS256(secret []byte, claims Claims) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
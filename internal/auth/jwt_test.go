@@ -0,0 +1,270 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "https://auth.example.com/"
+	testAudience = "viplounge-admin"
+)
+
+// keySet KeySource fixo por kid
+type keySet map[string]interface{}
+
+func (k keySet) Key(ctx context.Context, alg, kid string) (interface{}, error) {
+	key, ok := k[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: kid %q", ErrUnknownKey, kid)
+	}
+	return key, nil
+}
+
+// testKeys um par RSA e um EC gerados uma vez por execução
+var testKeys = func() struct {
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+} {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	return struct {
+		rsa *rsa.PrivateKey
+		ec  *ecdsa.PrivateKey
+	}{rsaKey, ecKey}
+}()
+
+// signToken assina claims com alg; key é *rsa.PrivateKey, *ecdsa.PrivateKey
+// ou []byte (HS256)
+func signToken(t *testing.T, alg, kid string, key interface{}, claims Claims) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+
+	var sig []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		s, err := rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatalf("assinatura RS256: %v", err)
+		}
+		sig = s
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatalf("assinatura ES256: %v", err)
+		}
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(input))
+		sig = mac.Sum(nil)
+	default:
+		t.Fatalf("chave %T não suportada", key)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// validClaims claims aceitas pelo verifier dos testes
+func validClaims(now time.Time) Claims {
+	return Claims{
+		"iss":   testIssuer,
+		"aud":   []interface{}{"outro", testAudience},
+		"sub":   "user-1",
+		"email": "suporte@example.com",
+		"exp":   float64(now.Add(time.Hour).Unix()),
+	}
+}
+
+func with(claims Claims, name string, value interface{}) Claims {
+	out := Claims{}
+	for k, v := range claims {
+		out[k] = v
+	}
+	if value == nil {
+		delete(out, name)
+	} else {
+		out[name] = value
+	}
+	return out
+}
+
+func TestJWTVerify(t *testing.T) {
+	now := time.Now()
+	claims := validClaims(now)
+	rsaPublic := &testKeys.rsa.PublicKey
+	keys := keySet{"rsa": rsaPublic, "ec": &testKeys.ec.PublicKey}
+
+	// Confusão de algoritmo: HS256 com a chave pública RSA (conhecida de
+	// todos) como segredo HMAC
+	publicDER, _ := x509.MarshalPKIXPublicKey(rsaPublic)
+
+	tests := []struct {
+		name    string
+		algs    []string
+		token   string
+		wantErr error
+	}{
+		{
+			name:  "RS256 válido",
+			token: signToken(t, "RS256", "rsa", testKeys.rsa, claims),
+		},
+		{
+			name:  "ES256 válido",
+			token: signToken(t, "ES256", "ec", testKeys.ec, claims),
+		},
+		{
+			name:  "audiência como texto",
+			token: signToken(t, "RS256", "rsa", testKeys.rsa, with(claims, "aud", testAudience)),
+		},
+		{
+			name:  "expirado dentro da tolerância de relógio",
+			token: signToken(t, "RS256", "rsa", testKeys.rsa, with(claims, "exp", float64(now.Add(-30*time.Second).Unix()))),
+		},
+		{
+			name:    "HS256 assinado com a chave pública RSA",
+			token:   signToken(t, "HS256", "rsa", publicDER, claims),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "HS256 aceito mas a chave do kid é RSA",
+			algs:    []string{"RS256", "HS256"},
+			token:   signToken(t, "HS256", "rsa", publicDER, claims),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "alg none",
+			token:   noneToken(claims),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "RS256 com kid de chave EC",
+			token:   signToken(t, "RS256", "ec", testKeys.rsa, claims),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "expirado",
+			token:   signToken(t, "RS256", "rsa", testKeys.rsa, with(claims, "exp", float64(now.Add(-time.Hour).Unix()))),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "sem exp",
+			token:   signToken(t, "RS256", "rsa", testKeys.rsa, with(claims, "exp", nil)),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "ainda não válido",
+			token:   signToken(t, "RS256", "rsa", testKeys.rsa, with(claims, "nbf", float64(now.Add(time.Hour).Unix()))),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "outro emissor",
+			token:   signToken(t, "RS256", "rsa", testKeys.rsa, with(claims, "iss", "https://evil.example.com/")),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "outra audiência",
+			token:   signToken(t, "RS256", "rsa", testKeys.rsa, with(claims, "aud", "outro")),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "claims adulteradas",
+			token:   tamper(signToken(t, "RS256", "rsa", testKeys.rsa, claims), with(claims, "email", "admin@example.com")),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "kid desconhecido",
+			token:   signToken(t, "RS256", "outra", testKeys.rsa, claims),
+			wantErr: ErrUnknownKey,
+		},
+		{
+			name:    "formato",
+			token:   "abc.def",
+			wantErr: ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			algs := tt.algs
+			if algs == nil {
+				algs = []string{"RS256", "ES256"}
+			}
+			verifier := NewJWTVerifier(keys, algs, testIssuer, testAudience, time.Minute)
+			verifier.now = func() time.Time { return now }
+
+			got, err := verifier.Verify(context.Background(), tt.token)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("erro %v, esperado %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if got.String("email") != "suporte@example.com" {
+				t.Errorf("claims: %v", got)
+			}
+		})
+	}
+}
+
+// noneToken token sem assinatura (alg none)
+func noneToken(claims Claims) string {
+	header, _ := json.Marshal(map[string]string{"alg": "none", "kid": "rsa"})
+	payload, _ := json.Marshal(claims)
+	return base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload) + "."
+}
+
+// tamper troca as claims de um token mantendo header e assinatura
+func tamper(token string, claims Claims) string {
+	parts := strings.Split(token, ".")
+	payload, _ := json.Marshal(claims)
+	return parts[0] + "." + base64.RawURLEncoding.EncodeToString(payload) + "." + parts[2]
+}
+
+func TestClaimsStrings(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  []string
+	}{
+		{"lista", []interface{}{"4", "7", 9}, []string{"4", "7"}},
+		{"espaços", "support tenant_admin", []string{"support", "tenant_admin"}},
+		{"vírgulas", "4,7, 9", []string{"4", "7", "9"}},
+		{"ausente", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Claims{"roles": tt.value}.Strings("roles")
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Strings = %v, esperado %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+)
+
+// Role papel de quem acessa as rotas administrativas
+type Role string
+
+const (
+	// RoleSupport atendimento: consulta leads e gera acessos
+	RoleSupport Role = "support"
+	// RoleTenantAdmin administradora: também revoga/restaura, só nos seus condomínios
+	RoleTenantAdmin Role = "tenant_admin"
+	// RolePlatformAdmin equipe da plataforma: acesso total a todos os tenants
+	RolePlatformAdmin Role = "platform_admin"
+)
+
+// KnownRole indica se o papel existe
+func KnownRole(role string) bool {
+	switch Role(role) {
+	case RoleSupport, RoleTenantAdmin, RolePlatformAdmin:
+		return true
+	}
+	return false
+}
+
+// AllTenants em Tenants libera todos os condomínios
+const AllTenants = "*"
+
+// Métodos de autenticação
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+)
+
+// Principal é quem fez a requisição autenticada
+type Principal struct {
+	Subject string   // ID da API key ou e-mail/sub do token
+	Method  string   // api_key ou jwt
+	Roles   []Role   // papéis concedidos
+	Tenants []string // condomínios visíveis ("*" = todos)
+}
+
+// Actor identifica o principal no log de auditoria
+func (p *Principal) Actor() string {
+	return fmt.Sprintf("%s:%s", p.Method, p.Subject)
+}
+
+// HasRole indica se o principal tem algum dos papéis
+func (p *Principal) HasRole(roles ...Role) bool {
+	for _, have := range p.Roles {
+		for _, want := range roles {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SeesAllTenants indica se o principal não está restrito a condomínios
+func (p *Principal) SeesAllTenants() bool {
+	if p.HasRole(RolePlatformAdmin) {
+		return true
+	}
+	for _, tenant := range p.Tenants {
+		if tenant == AllTenants {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAccessTenant indica se o principal pode ver dados do condomínio
+func (p *Principal) CanAccessTenant(tenantID string) bool {
+	if p.SeesAllTenants() {
+		return true
+	}
+	for _, tenant := range p.Tenants {
+		if tenant == tenantID {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// WithPrincipal guarda o principal autenticado no contexto
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext retorna o principal autenticado, ou nil
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
@@ -1,15 +1,24 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Config estrutura agnóstica de configuração
 type Config struct {
+	// Ambiente: "development", "staging" ou "production".
+	// Em produção credenciais ausentes impedem o servidor de iniciar.
+	Environment string `yaml:"environment"`
+
 	// Branding
 	Branding struct {
 		AppName        string `yaml:"app_name"`
@@ -75,16 +84,14 @@ type Config struct {
 
 	// Integrações (agnósticas)
 	Integrations struct {
-		NameIntegration struct {
-			Enabled bool   `yaml:"enabled"`
-			Type    string `yaml:"type"` // "superlogica", "custom", etc
-			URL     string `yaml:"url"`
-		} `yaml:"name_integration"`
-		PartnerIntegration struct {
-			Enabled bool   `yaml:"enabled"`
-			Type    string `yaml:"type"` // "rede_parcerias", "custom", etc
-			URL     string `yaml:"url"`
-		} `yaml:"partner_integration"`
+		NameIntegration NameIntegration `yaml:"name_integration"`
+		// Fontes de moradores adicionais, atribuídas aos condomínios por
+		// tenants[].source. name_integration é a fonte "default".
+		Sources            []NameIntegration  `yaml:"sources"`
+		PartnerIntegration PartnerIntegration `yaml:"partner_integration"`
+		// Clubes adicionais, atribuídos aos condomínios por tenants[].clubs.
+		// partner_integration é o clube "default".
+		Clubs []PartnerIntegration `yaml:"clubs"`
 	} `yaml:"integrations"`
 
 	// Database
@@ -92,12 +99,378 @@ type Config struct {
 		Type           string `yaml:"type"` // "firestore", "postgres", "mongodb"
 		CollectionName string `yaml:"collection_name"`
 	} `yaml:"database"`
+
+	// Tenants - domínios atendidos e o condomínio (ID na Superlógica) de cada um.
+	// Hosts não listados usam a busca global (-1).
+	Tenants []Tenant `yaml:"tenants"`
+
+	// Hot reload do config.yaml (e de uma fonte remota opcional)
+	Reload struct {
+		Enabled         bool   `yaml:"enabled"`
+		IntervalSeconds int    `yaml:"interval_seconds"`
+		RemoteURL       string `yaml:"remote_url"` // YAML aplicado sobre o arquivo local
+	} `yaml:"reload"`
+
+	// Segredos - de onde vêm as credenciais referenciadas em *_ref
+	Secrets struct {
+		Provider        string `yaml:"provider"`          // "env", "file", "gsm" - usado em refs sem prefixo
+		FileDir         string `yaml:"file_dir"`          // diretório dos segredos montados (provider file)
+		GCPProject      string `yaml:"gcp_project"`       // projeto do Secret Manager para nomes curtos
+		CacheTTLSeconds int    `yaml:"cache_ttl_seconds"` // intervalo para perceber rotação sem reiniciar
+	} `yaml:"secrets"`
+
+	// Servidor HTTP
+	Server struct {
+		Port                     string `yaml:"port"`
+		ReadHeaderTimeoutSeconds int    `yaml:"read_header_timeout_seconds"`
+		ReadTimeoutSeconds       int    `yaml:"read_timeout_seconds"`
+		WriteTimeoutSeconds      int    `yaml:"write_timeout_seconds"`
+		IdleTimeoutSeconds       int    `yaml:"idle_timeout_seconds"`
+		MaxHeaderBytes           int    `yaml:"max_header_bytes"`
+		ShutdownTimeoutSeconds   int    `yaml:"shutdown_timeout_seconds"` // prazo para drenar requests e jobs no SIGTERM
+	} `yaml:"server"`
+
+	// API administrativa do suporte (/admin/v1)
+	Admin struct {
+		Enabled bool `yaml:"enabled"`
+	} `yaml:"admin"`
+
+	// Autenticação das rotas não públicas (API keys e/ou tokens OIDC/JWT)
+	Auth struct {
+		APIKeys []APIKey `yaml:"api_keys"`
+		JWT     JWTAuth  `yaml:"jwt"`
+	} `yaml:"auth"`
+
+	// Diretório local de moradores sincronizado da Superlógica
+	Directory struct {
+		Enabled             bool     `yaml:"enabled"`
+		CondoIDs            []string `yaml:"condo_ids"` // vazio = IDs dos tenants (exceto -1)
+		SyncIntervalMinutes int      `yaml:"sync_interval_minutes"`
+		MaxStalenessMinutes int      `yaml:"max_staleness_minutes"` // acima disso a validação consulta a Superlógica
+		MaxDeparturePercent int      `yaml:"max_departure_percent"` // saídas acima disso abortam a sincronização (0 = sem limite)
+	} `yaml:"directory"`
+
+	// Idempotency-Key nas rotas de ativação: a primeira resposta é repetida
+	// para reenvios com a mesma chave dentro da janela
+	Idempotency struct {
+		Enabled       bool `yaml:"enabled"`
+		WindowMinutes int  `yaml:"window_minutes"`
+	} `yaml:"idempotency"`
+
+	// SSO: a ativação responde um handle de uso único (/v1/sso/{handle}) no
+	// lugar da URL do clube com o token
+	SSO struct {
+		HandleTTLSeconds int `yaml:"handle_ttl_seconds"`
+	} `yaml:"sso"`
+
+	// Lembrar de mim: cookie assinado emitido na ativação, com o qual o
+	// morador gera um novo acesso (/v1/sso/refresh) sem digitar CPF e e-mail
+	RememberMe struct {
+		Enabled         bool   `yaml:"enabled"` // padrão dos condomínios; tenants[].remember_me sobrepõe
+		SecretRef       string `yaml:"secret_ref"`
+		CookieName      string `yaml:"cookie_name"`
+		TTLDays         int    `yaml:"ttl_days"`
+		RevalidateHours int    `yaml:"revalidate_hours"` // intervalo entre novas consultas à Superlógica
+	} `yaml:"remember_me"`
+
+	// LGPD: pedidos do titular dos dados (exportação e eliminação pela API
+	// do suporte). secret_ref assina a auditoria dos pedidos e gera o
+	// pseudônimo que substitui o CPF nos registros anonimizados.
+	Privacy struct {
+		SecretRef  string     `yaml:"secret_ref"`
+		Consent    Consent    `yaml:"consent"`
+		Retention  Retention  `yaml:"retention"`
+		Encryption Encryption `yaml:"encryption"`
+	} `yaml:"privacy"`
+
+	// Webhooks: notificações recebidas da Superlógica e eventos do lead
+	// enviados a CRMs e administradoras
+	Webhooks struct {
+		Superlogica SuperlogicaWebhook `yaml:"superlogica"`
+		Outbound    OutboundWebhooks   `yaml:"outbound"`
+	} `yaml:"webhooks"`
+
+	// problemas encontrados durante o carregamento (env vars inválidas,
+	// campos desconhecidos no YAML), reportados por Validate
+	loadProblems []string
+}
+
+// Consent finalidades de consentimento aceitas em /v1/validate e
+// /v1/confirm-email
+type Consent struct {
+	// Versions versão atual do texto de cada finalidade (finalidade → versão),
+	// gravada no lead quando a landing page não informa a que exibiu
+	Versions map[string]string `yaml:"versions"`
+}
+
+// Retention expurgo periódico dos leads antigos. Cada lead segue a primeira
+// regra que o atende; lead sem regra é mantido.
+type Retention struct {
+	Enabled         bool            `yaml:"enabled"`
+	IntervalMinutes int             `yaml:"interval_minutes"`
+	MaxPerRun       int             `yaml:"max_per_run"` // leads por execução; o restante fica para a próxima
+	Rules           []RetentionRule `yaml:"rules"`
+	// WebhookDays prazo das notificações recebidas e das entregas de webhook
+	// (0 = mantidas), contado da criação
+	WebhookDays int `yaml:"webhook_days"`
+}
+
+// RetentionRule prazo de retenção dos leads com o status, cenário e origem
+// informados (vazio = qualquer um), contado a partir de updated_at
+type RetentionRule struct {
+	Name     string `yaml:"name"`
+	Status   string `yaml:"status"`   // PENDING, APPROVED, REJECTED, ERROR
+	Scenario string `yaml:"scenario"` // not_found, revoked_user, error, ...
+	Origin   string `yaml:"origin"`   // landing_page, email_confirmation, admin, ...
+	Days     int    `yaml:"days"`
+	Action   string `yaml:"action"` // delete ou pseudonymize
+}
+
+// Encryption cifragem em envelope dos dados pessoais dos leads (CPF, nome,
+// e-mail, telefone): a KEK fica no KMS e cifra as chaves de dados
+type Encryption struct {
+	Enabled bool   `yaml:"enabled"`
+	KMS     string `yaml:"kms"`      // local (arquivo de chaves, dev) ou gcp (Cloud KMS)
+	KeyFile string `yaml:"key_file"` // local: {"primary": "id", "keys": {"id": "<base64 de 32 bytes>"}}
+	KMSKey  string `yaml:"kms_key"`  // gcp: projects/.../locations/.../keyRings/.../cryptoKeys/...
+	// HashKeyRef chave do hash do CPF, usado nas buscas e no ID do documento
+	HashKeyRef        string `yaml:"hash_key_ref"`
+	DataKeyTTLMinutes int    `yaml:"data_key_ttl_minutes"` // validade de cada chave de dados
+	// LegacyLookup busca também pelo CPF em claro os leads gravados antes da
+	// cifragem; desligar quando a recifragem não migrar mais nenhum
+	LegacyLookup bool `yaml:"legacy_lookup"`
+}
+
+// Tenant mapeia domínios para um condomínio
+type Tenant struct {
+	ID    string   `yaml:"id"` // ID do condomínio na Superlógica ("-1" = busca global)
+	Name  string   `yaml:"name"`
+	Hosts []string `yaml:"hosts"`
+	// Clubs IDs dos Clubes de Benefícios do condomínio, o principal primeiro
+	// (vazio = apenas o "default"). Com mais de um, a ativação cadastra o
+	// morador em todos e o frontend exibe o seletor de clubes.
+	Clubs []string `yaml:"clubs"`
+	// Source fonte de moradores do condomínio (vazio = "default")
+	Source string `yaml:"source"`
+	// RememberMe liga ou desliga o "lembrar de mim" no condomínio (vazio =
+	// remember_me.enabled)
+	RememberMe *bool `yaml:"remember_me"`
+}
+
+// DefaultSourceID identifica a fonte de integrations.name_integration
+const DefaultSourceID = "default"
+
+// NameIntegration configura a fonte de moradores usada para validar o CPF
+type NameIntegration struct {
+	ID             string `yaml:"id"` // em integrations.sources; name_integration é sempre "default"
+	Enabled        bool   `yaml:"enabled"`
+	Type           string `yaml:"type"` // "superlogica", "file", "http", "composite"
+	URL            string `yaml:"url"`
+	TimeoutSeconds int    `yaml:"timeout_seconds"`
+
+	Superlogica SuperlogicaSettings `yaml:"superlogica"`
+	File        FileSourceSettings  `yaml:"file"`
+	HTTP        HTTPSourceSettings  `yaml:"http"`
+	// Sources fontes consultadas em ordem pelo type "composite"; vale a
+	// primeira que encontrar o CPF
+	Sources []string `yaml:"sources"`
+}
+
+// FileSourceSettings planilha de moradores (type "file"), relida quando o
+// arquivo muda. O CSV precisa de cabeçalho; o JSON é uma lista de objetos.
+type FileSourceSettings struct {
+	Path        string       `yaml:"path"`
+	Format      string       `yaml:"format"`       // "csv" ou "json" (vazio = pela extensão)
+	RecordsPath string       `yaml:"records_path"` // JSON: caminho da lista (vazio = raiz)
+	Fields      SourceFields `yaml:"fields"`
+}
+
+// HTTPSourceSettings API JSON de moradores de outro ERP (type "http"). Os
+// paths aceitam {cpf} e {condo_id}.
+type HTTPSourceSettings struct {
+	Auth        RESTAuth     `yaml:"auth"`
+	Lookup      RESTEndpoint `yaml:"lookup"`       // ex. GET /residents?cpf={cpf}&condo={condo_id}
+	List        RESTEndpoint `yaml:"list"`         // opcional, habilita diretório e importação
+	RecordsPath string       `yaml:"records_path"` // lista de moradores na resposta (vazio = corpo)
+	Fields      SourceFields `yaml:"fields"`
+}
+
+// SourceFields nomes das colunas/campos de uma fonte de moradores. Vazios
+// assumem cpf, name, email, phone e condo_id. Sem coluna de condomínio, todo
+// morador da fonte vale para qualquer condomínio que a use.
+type SourceFields struct {
+	CPF     string `yaml:"cpf"`
+	Name    string `yaml:"name"`
+	Email   string `yaml:"email"`
+	Phone   string `yaml:"phone"`
+	CondoID string `yaml:"condo_id"`
+}
+
+// SuperlogicaSettings credenciais da API Superlógica.
+// Os campos *Ref guardam uma referência ("env:NOME", "file:NOME", "gsm:NOME"
+// ou apenas NOME no provedor padrão), nunca o segredo.
+type SuperlogicaSettings struct {
+	AppTokenRef    string `yaml:"app_token_ref"`
+	AccessTokenRef string `yaml:"access_token_ref"`
 }
 
-var globalConfig *Config
+// DefaultClubID identifica o clube de integrations.partner_integration
+const DefaultClubID = "default"
 
-// Load carrega a configuração de arquivo YAML e env vars
+// PartnerIntegration configura um Clube de Benefícios
+type PartnerIntegration struct {
+	ID             string `yaml:"id"`   // em integrations.clubs; partner_integration é sempre "default"
+	Name           string `yaml:"name"` // exibido no seletor de clubes
+	Enabled        bool   `yaml:"enabled"`
+	Type           string `yaml:"type"` // "rede_parcerias", "rest", etc
+	URL            string `yaml:"url"`
+	TimeoutSeconds int    `yaml:"timeout_seconds"`
+
+	RedeParcerias RedeParceriasSettings `yaml:"rede_parcerias"`
+	REST          RESTPartnerSettings   `yaml:"rest"`
+}
+
+// RESTPartnerSettings descreve a API de um clube simples (type "rest") por
+// mapeamento: autenticação, endpoints e nomes dos campos. Os paths aceitam
+// {cpf}, {id} e {identifier}; os campos da resposta aceitam caminhos com
+// ponto (ex. "data.id").
+type RESTPartnerSettings struct {
+	Auth RESTAuth `yaml:"auth"`
+
+	FindUser RESTEndpoint `yaml:"find_user"` // ex. GET /users?cpf={cpf}
+	Register RESTEndpoint `yaml:"register"`  // ex. POST /users
+	Delete   RESTEndpoint `yaml:"delete"`    // ex. DELETE /users/{id}
+	SSO      RESTEndpoint `yaml:"sso"`       // ex. GET /users/{identifier}/sso
+
+	Fields RESTFieldMapping `yaml:"fields"`
+	// RegisterExtra campos fixos enviados no cadastro (ex. authorized: true)
+	RegisterExtra map[string]interface{} `yaml:"register_extra"`
+	// AlreadyExistsStatus status do cadastro que indicam usuário já cadastrado
+	AlreadyExistsStatus []int `yaml:"already_exists_status"`
+}
+
+// RESTAuth autenticação das integrações genéricas
+type RESTAuth struct {
+	Type     string `yaml:"type"`      // "none", "bearer", "header", "basic"
+	Header   string `yaml:"header"`    // cabeçalho do type "header" (ex. X-Api-Key)
+	Username string `yaml:"username"`  // usuário do type "basic"
+	TokenRef string `yaml:"token_ref"` // token (bearer/header) ou senha (basic)
+}
+
+// RESTEndpoint método e path (relativo à URL do clube) de uma operação
+type RESTEndpoint struct {
+	Method string `yaml:"method"`
+	Path   string `yaml:"path"`
+}
+
+// RESTFieldMapping nomes dos campos no clube. Vazios assumem o nome padrão
+// (id, name, email, cpf, phone, active, token, redirect).
+type RESTFieldMapping struct {
+	UsersPath   string `yaml:"users_path"` // lista de usuários na busca (vazio = corpo)
+	ID          string `yaml:"id"`
+	Name        string `yaml:"name"`
+	Email       string `yaml:"email"`
+	CPF         string `yaml:"cpf"`
+	Phone       string `yaml:"phone"`
+	Active      string `yaml:"active"`
+	SSOToken    string `yaml:"sso_token"`
+	SSORedirect string `yaml:"sso_redirect"`
+}
+
+// RedeParceriasSettings credenciais da API Rede Parcerias.
+// Com BearerTokenRef definido o token fixo é usado e o OAuth2 é ignorado.
+type RedeParceriasSettings struct {
+	ClientIDRef     string `yaml:"client_id_ref"`
+	ClientSecretRef string `yaml:"client_secret_ref"`
+	BearerTokenRef  string `yaml:"bearer_token_ref"`
+}
+
+// SuperlogicaWebhook autentica as notificações de mudança de unidade.
+// Com Auth "hmac" o cabeçalho traz o HMAC-SHA256 (hex) do corpo; com "token"
+// traz o próprio segredo (ou ?token= na URL cadastrada na Superlógica).
+// Com TimestampHeader (só hmac) o HMAC cobre "<timestamp>.<corpo>" e
+// entregas fora de ToleranceSeconds são recusadas.
+type SuperlogicaWebhook struct {
+	Enabled          bool   `yaml:"enabled"`
+	Auth             string `yaml:"auth"` // "hmac" ou "token"
+	SecretRef        string `yaml:"secret_ref"`
+	SignatureHeader  string `yaml:"signature_header"`
+	TimestampHeader  string `yaml:"timestamp_header"`
+	ToleranceSeconds int    `yaml:"tolerance_seconds"`
+}
+
+// OutboundWebhooks entrega os eventos do lead aos endpoints cadastrados.
+// Os endpoints acompanham o hot reload; falhas são reenviadas com backoff
+// exponencial até MaxAttempts.
+type OutboundWebhooks struct {
+	Enabled               bool               `yaml:"enabled"`
+	MaxAttempts           int                `yaml:"max_attempts"`
+	InitialBackoffSeconds int                `yaml:"initial_backoff_seconds"`
+	MaxBackoffSeconds     int                `yaml:"max_backoff_seconds"`
+	TimeoutSeconds        int                `yaml:"timeout_seconds"`
+	Endpoints             []OutboundEndpoint `yaml:"endpoints"`
+}
+
+// OutboundEndpoint destino de eventos. Cada entrega é assinada com
+// HMAC-SHA256 usando o segredo de SecretRef.
+type OutboundEndpoint struct {
+	ID        string   `yaml:"id"`
+	URL       string   `yaml:"url"`
+	SecretRef string   `yaml:"secret_ref"`
+	Events    []string `yaml:"events"`  // ex. lead.activated, lead.revoked
+	Tenants   []string `yaml:"tenants"` // condomínios ("*" = todos)
+}
+
+// APIKey credencial estática de uma integração ou operador.
+// Tenants restringe os condomínios visíveis ("*" = todos).
+type APIKey struct {
+	ID      string   `yaml:"id"`
+	KeyRef  string   `yaml:"key_ref"`
+	Roles   []string `yaml:"roles"`
+	Tenants []string `yaml:"tenants"`
+}
+
+// JWTAuth valida bearer tokens emitidos pelo provedor OIDC.
+// Com StaticKeyRef os tokens são HS256 assinados com essa chave (testes/local).
+type JWTAuth struct {
+	Enabled          bool   `yaml:"enabled"`
+	Issuer           string `yaml:"issuer"`
+	Audience         string `yaml:"audience"`
+	JWKSURL          string `yaml:"jwks_url"`
+	JWKSCacheSeconds int    `yaml:"jwks_cache_seconds"`
+	StaticKeyRef     string `yaml:"static_key_ref"`
+	RolesClaim       string `yaml:"roles_claim"`
+	TenantsClaim     string `yaml:"tenants_claim"`
+	ClockSkewSeconds int    `yaml:"clock_skew_seconds"`
+}
+
+var (
+	// loadMu serializa carregamentos, pois os helpers de env vars usam estado do pacote
+	loadMu sync.Mutex
+	// getenv permite desligar as env vars ao calcular a origem de cada valor
+	getenv = os.Getenv
+	// envProblems acumula erros de parse das env vars do carregamento atual
+	envProblems []string
+)
+
+// Load carrega a configuração de arquivo YAML e env vars e a publica como
+// configuração atual (ver Get e Subscribe)
 func Load(configPath string) (*Config, error) {
+	cfg, err := build(configPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	publish(cfg)
+	return cfg, nil
+}
+
+// build monta uma Config sem publicá-la. overlay é um YAML adicional (fonte
+// remota) aplicado depois do arquivo.
+func build(configPath string, overlay []byte) (*Config, error) {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+
+	envProblems = nil
 	cfg := &Config{}
 
 	// 1. Carregar defaults
@@ -109,24 +482,34 @@ func Load(configPath string) (*Config, error) {
 			return nil, fmt.Errorf("erro ao carregar config YAML: %w", err)
 		}
 	}
+	if overlay != nil {
+		if err := applyYAML("remote", overlay, cfg); err != nil {
+			return nil, fmt.Errorf("erro ao carregar config remota: %w", err)
+		}
+	}
 
 	// 3. Sobrescrever com variáveis de ambiente
 	loadFromEnv(cfg)
 
-	globalConfig = cfg
+	cfg.loadProblems = append(cfg.loadProblems, envProblems...)
+	envProblems = nil
+
 	return cfg, nil
 }
 
-// Get retorna a instância global de config
-func Get() *Config {
-	if globalConfig == nil {
-		globalConfig = &Config{}
-		setDefaults(globalConfig)
-	}
-	return globalConfig
+// IsNotExist indica se o erro de Load foi causado por config.yaml ausente
+func IsNotExist(err error) bool {
+	return errors.Is(err, os.ErrNotExist)
+}
+
+// IsProduction indica se o ambiente exige configuração completa
+func (c *Config) IsProduction() bool {
+	return c.Environment == "production"
 }
 
 func setDefaults(cfg *Config) {
+	cfg.Environment = getEnvOrDefault("APP_ENV", "development")
+
 	// Branding
 	cfg.Branding.AppName = getEnvOrDefault("APP_NAME", "mobile")
 	cfg.Branding.AppSubtitle = getEnvOrDefault("APP_SUBTITLE", "Acesso Exclusivo")
@@ -176,25 +559,198 @@ func setDefaults(cfg *Config) {
 
 	// Security
 	cfg.Security.CORSAllowedOrigins = []string{"*"}
-	if origins := os.Getenv("CORS_ORIGINS"); origins != "" {
+	if origins := getenv("CORS_ORIGINS"); origins != "" {
 		// Parse comma-separated origins
-		cfg.Security.CORSAllowedOrigins = []string{origins}
+		cfg.Security.CORSAllowedOrigins = splitList(origins)
 	}
 	cfg.Security.RequireHTTPS = getEnvOrDefaultBool("REQUIRE_HTTPS", false)
 	cfg.Security.CSPEnabled = getEnvOrDefaultBool("CSP_ENABLED", false)
 
 	// Integrations
-	cfg.Integrations.NameIntegration.Enabled = true
+	cfg.Integrations.NameIntegration.Enabled = getEnvOrDefaultBool("SUPERLOGICA_ENABLED", true)
 	cfg.Integrations.NameIntegration.Type = "superlogica"
 	cfg.Integrations.NameIntegration.URL = getEnvOrDefault("SUPERLOGICA_URL", "https://api.superlogica.net/v2/condor")
+	cfg.Integrations.NameIntegration.TimeoutSeconds = getEnvOrDefaultInt("SUPERLOGICA_TIMEOUT_SECONDS", 5)
+	cfg.Integrations.NameIntegration.Superlogica.AppTokenRef = "SUPERLOGICA_APP_TOKEN"
+	cfg.Integrations.NameIntegration.Superlogica.AccessTokenRef = "SUPERLOGICA_ACCESS_TOKEN"
 
 	cfg.Integrations.PartnerIntegration.Enabled = true
 	cfg.Integrations.PartnerIntegration.Type = "rede_parcerias"
-	cfg.Integrations.PartnerIntegration.URL = getEnvOrDefault("REDE_PARCERIAS_URL", "https://api.staging.clubeparcerias.com.br/api-client/v1")
+	cfg.Integrations.PartnerIntegration.URL = getEnvOrDefault("REDE_PARCERIAS_URL", "https://infratech.clubeparcerias.com.br/api-client/v1")
+	cfg.Integrations.PartnerIntegration.TimeoutSeconds = getEnvOrDefaultInt("REDE_PARCERIAS_TIMEOUT_SECONDS", 20)
+	cfg.Integrations.PartnerIntegration.RedeParcerias.ClientIDRef = "REDE_PARCERIAS_CLIENT_ID"
+	cfg.Integrations.PartnerIntegration.RedeParcerias.ClientSecretRef = "REDE_PARCERIAS_CLIENT_SECRET"
+	cfg.Integrations.PartnerIntegration.RedeParcerias.BearerTokenRef = "REDE_PARCERIAS_BEARER_TOKEN"
 
 	// Database
 	cfg.Database.Type = getEnvOrDefault("DB_TYPE", "firestore")
 	cfg.Database.CollectionName = getEnvOrDefault("DB_COLLECTION_NAME", "leads")
+
+	// Tenants
+	cfg.Tenants = []Tenant{
+		{ID: "4", Name: "VIP Lounge", Hosts: []string{"viplounge.com.br", "www.viplounge.com.br"}},
+		{ID: "-1", Name: "Mobile", Hosts: []string{"viplounge.mobile.adm.br"}},
+	}
+
+	// Reload
+	cfg.Reload.Enabled = getEnvOrDefaultBool("CONFIG_RELOAD", true)
+	cfg.Reload.IntervalSeconds = getEnvOrDefaultInt("CONFIG_RELOAD_INTERVAL_SECONDS", 10)
+	cfg.Reload.RemoteURL = getEnvOrDefault("CONFIG_REMOTE_URL", "")
+
+	// Secrets
+	cfg.Secrets.Provider = getEnvOrDefault("SECRETS_PROVIDER", "env")
+	cfg.Secrets.FileDir = getEnvOrDefault("SECRETS_DIR", "/secrets")
+	cfg.Secrets.GCPProject = getEnvOrDefault("GOOGLE_CLOUD_PROJECT", "")
+	cfg.Secrets.CacheTTLSeconds = getEnvOrDefaultInt("SECRETS_CACHE_TTL_SECONDS", 300)
+
+	// Server
+	// O cadastro completo na Rede Parcerias pode encadear várias chamadas de 20s,
+	// por isso o write timeout é mais folgado que o read timeout.
+	// O Cloud Run envia SIGKILL 10s após o SIGTERM.
+	cfg.Server.Port = getEnvOrDefault("PORT", "8080")
+	cfg.Server.ReadHeaderTimeoutSeconds = getEnvOrDefaultInt("SERVER_READ_HEADER_TIMEOUT_SECONDS", 5)
+	cfg.Server.ReadTimeoutSeconds = getEnvOrDefaultInt("SERVER_READ_TIMEOUT_SECONDS", 15)
+	cfg.Server.WriteTimeoutSeconds = getEnvOrDefaultInt("SERVER_WRITE_TIMEOUT_SECONDS", 90)
+	cfg.Server.IdleTimeoutSeconds = getEnvOrDefaultInt("SERVER_IDLE_TIMEOUT_SECONDS", 120)
+	cfg.Server.MaxHeaderBytes = getEnvOrDefaultInt("SERVER_MAX_HEADER_BYTES", 1<<16)
+	cfg.Server.ShutdownTimeoutSeconds = getEnvOrDefaultInt("SHUTDOWN_TIMEOUT_SECONDS", 9)
+
+	// Admin
+	cfg.Admin.Enabled = getEnvOrDefaultBool("ADMIN_API_ENABLED", true)
+
+	// Auth
+	cfg.Auth.APIKeys = []APIKey{
+		{ID: "admin", KeyRef: "ADMIN_API_KEY", Roles: []string{"platform_admin"}, Tenants: []string{"*"}},
+	}
+	cfg.Auth.JWT.Enabled = getEnvOrDefaultBool("AUTH_JWT_ENABLED", false)
+	cfg.Auth.JWT.Issuer = getEnvOrDefault("AUTH_JWT_ISSUER", "")
+	cfg.Auth.JWT.Audience = getEnvOrDefault("AUTH_JWT_AUDIENCE", "")
+	cfg.Auth.JWT.JWKSURL = getEnvOrDefault("AUTH_JWKS_URL", "")
+	cfg.Auth.JWT.JWKSCacheSeconds = 3600
+	cfg.Auth.JWT.RolesClaim = "roles"
+	cfg.Auth.JWT.TenantsClaim = "tenants"
+	cfg.Auth.JWT.ClockSkewSeconds = 60
+
+	// Directory
+	cfg.Directory.Enabled = getEnvOrDefaultBool("DIRECTORY_ENABLED", false)
+	cfg.Directory.SyncIntervalMinutes = getEnvOrDefaultInt("DIRECTORY_SYNC_INTERVAL_MINUTES", 60)
+	cfg.Directory.MaxStalenessMinutes = getEnvOrDefaultInt("DIRECTORY_MAX_STALENESS_MINUTES", 180)
+	cfg.Directory.MaxDeparturePercent = getEnvOrDefaultInt("DIRECTORY_MAX_DEPARTURE_PERCENT", 20)
+
+	// Idempotency
+	cfg.Idempotency.Enabled = getEnvOrDefaultBool("IDEMPOTENCY_ENABLED", true)
+	cfg.Idempotency.WindowMinutes = getEnvOrDefaultInt("IDEMPOTENCY_WINDOW_MINUTES", 15)
+
+	// SSO
+	cfg.SSO.HandleTTLSeconds = getEnvOrDefaultInt("SSO_HANDLE_TTL_SECONDS", 120)
+
+	// Lembrar de mim
+	cfg.RememberMe.Enabled = getEnvOrDefaultBool("REMEMBER_ME_ENABLED", false)
+	cfg.RememberMe.SecretRef = "REMEMBER_ME_SECRET"
+	cfg.RememberMe.CookieName = "vl_session"
+	cfg.RememberMe.TTLDays = getEnvOrDefaultInt("REMEMBER_ME_TTL_DAYS", 30)
+	cfg.RememberMe.RevalidateHours = getEnvOrDefaultInt("REMEMBER_ME_REVALIDATE_HOURS", 24)
+
+	// LGPD
+	cfg.Privacy.SecretRef = "PRIVACY_SECRET"
+	cfg.Privacy.Consent.Versions = map[string]string{
+		"marketing": getEnvOrDefault("CONSENT_MARKETING_VERSION", "v1"),
+	}
+	cfg.Privacy.Encryption.Enabled = getEnvOrDefaultBool("PII_ENCRYPTION_ENABLED", false)
+	cfg.Privacy.Encryption.KMS = getEnvOrDefault("PII_KMS", "local")
+	cfg.Privacy.Encryption.KeyFile = getEnvOrDefault("PII_KEY_FILE", "keys/pii-keys.json")
+	cfg.Privacy.Encryption.KMSKey = getEnvOrDefault("PII_KMS_KEY", "")
+	cfg.Privacy.Encryption.HashKeyRef = "PII_HASH_KEY"
+	cfg.Privacy.Encryption.DataKeyTTLMinutes = 60
+	cfg.Privacy.Encryption.LegacyLookup = getEnvOrDefaultBool("PII_LEGACY_LOOKUP", true)
+	cfg.Privacy.Retention.Enabled = getEnvOrDefaultBool("RETENTION_ENABLED", false)
+	cfg.Privacy.Retention.IntervalMinutes = getEnvOrDefaultInt("RETENTION_INTERVAL_MINUTES", 1440)
+	cfg.Privacy.Retention.MaxPerRun = getEnvOrDefaultInt("RETENTION_MAX_PER_RUN", 5000)
+	cfg.Privacy.Retention.WebhookDays = getEnvOrDefaultInt("RETENTION_WEBHOOK_DAYS", 90)
+
+	// Webhooks
+	cfg.Webhooks.Superlogica.Enabled = getEnvOrDefaultBool("SUPERLOGICA_WEBHOOK_ENABLED", false)
+	cfg.Webhooks.Superlogica.Auth = getEnvOrDefault("SUPERLOGICA_WEBHOOK_AUTH", "hmac")
+	cfg.Webhooks.Superlogica.SecretRef = "SUPERLOGICA_WEBHOOK_SECRET"
+	cfg.Webhooks.Superlogica.SignatureHeader = "X-Superlogica-Signature"
+	cfg.Webhooks.Superlogica.ToleranceSeconds = getEnvOrDefaultInt("SUPERLOGICA_WEBHOOK_TOLERANCE_SECONDS", 300)
+	cfg.Webhooks.Outbound.Enabled = getEnvOrDefaultBool("OUTBOUND_WEBHOOKS_ENABLED", false)
+	cfg.Webhooks.Outbound.MaxAttempts = getEnvOrDefaultInt("OUTBOUND_WEBHOOKS_MAX_ATTEMPTS", 8)
+	cfg.Webhooks.Outbound.InitialBackoffSeconds = 30
+	cfg.Webhooks.Outbound.MaxBackoffSeconds = 3600
+	cfg.Webhooks.Outbound.TimeoutSeconds = 10
+}
+
+// Partner retorna a configuração do clube pelo ID ("default" =
+// partner_integration)
+func (c *Config) Partner(id string) (PartnerIntegration, bool) {
+	if id == "" || id == DefaultClubID {
+		return c.Integrations.PartnerIntegration, true
+	}
+	for _, club := range c.Integrations.Clubs {
+		if club.ID == id {
+			return club, true
+		}
+	}
+	return PartnerIntegration{}, false
+}
+
+// Source retorna a configuração da fonte de moradores pelo ID ("default" =
+// name_integration)
+func (c *Config) Source(id string) (NameIntegration, bool) {
+	if id == "" || id == DefaultSourceID {
+		return c.Integrations.NameIntegration, true
+	}
+	for _, source := range c.Integrations.Sources {
+		if source.ID == id {
+			return source, true
+		}
+	}
+	return NameIntegration{}, false
+}
+
+// TenantSource retorna o ID da fonte de moradores do condomínio
+func (c *Config) TenantSource(condoID string) string {
+	for _, tenant := range c.Tenants {
+		if tenant.ID == condoID && tenant.Source != "" {
+			return tenant.Source
+		}
+	}
+	return DefaultSourceID
+}
+
+// TenantClubs retorna os IDs dos clubes do condomínio, o principal primeiro
+func (c *Config) TenantClubs(condoID string) []string {
+	for _, tenant := range c.Tenants {
+		if tenant.ID == condoID && len(tenant.Clubs) > 0 {
+			return tenant.Clubs
+		}
+	}
+	return []string{DefaultClubID}
+}
+
+// RememberMeEnabled indica se o condomínio emite a sessão "lembrar de mim"
+func (c *Config) RememberMeEnabled(condoID string) bool {
+	for _, tenant := range c.Tenants {
+		if tenant.ID == condoID && tenant.RememberMe != nil {
+			return *tenant.RememberMe
+		}
+	}
+	return c.RememberMe.Enabled
+}
+
+// DirectoryCondos retorna os condomínios sincronizados no diretório
+func (c *Config) DirectoryCondos() []string {
+	if len(c.Directory.CondoIDs) > 0 {
+		return c.Directory.CondoIDs
+	}
+	var condos []string
+	for _, tenant := range c.Tenants {
+		if tenant.ID != "" && tenant.ID != "-1" {
+			condos = append(condos, tenant.ID)
+		}
+	}
+	return condos
 }
 
 func loadFromYAML(filePath string, cfg *Config) error {
@@ -202,47 +758,91 @@ func loadFromYAML(filePath string, cfg *Config) error {
 	if err != nil {
 		return err
 	}
+	return applyYAML(filePath, data, cfg)
+}
 
+func applyYAML(name string, data []byte, cfg *Config) error {
 	if err := yaml.Unmarshal(data, cfg); err != nil {
 		return err
 	}
 
+	// Segunda passada estrita apenas para apontar campos desconhecidos (typos)
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&Config{}); err != nil && !errors.Is(err, io.EOF) {
+		cfg.loadProblems = append(cfg.loadProblems, fmt.Sprintf("%s: %v", name, err))
+	}
+
 	return nil
 }
 
 func loadFromEnv(cfg *Config) {
 	// Sobrescrever com env vars (menor prioridade que YAML)
-	if val := os.Getenv("APP_NAME"); val != "" {
+	if val := getenv("APP_NAME"); val != "" {
 		cfg.Branding.AppName = val
 	}
-	if val := os.Getenv("COMPANY_NAME"); val != "" {
+	if val := getenv("COMPANY_NAME"); val != "" {
 		cfg.Branding.CompanyName = val
 	}
-	if val := os.Getenv("THEME_COLOR"); val != "" {
+	if val := getenv("THEME_COLOR"); val != "" {
 		cfg.Branding.ThemeColor = val
 	}
+	// URLs das integrações definidas por env var vencem o YAML, como os
+	// adapters faziam antes de serem construídos a partir da config
+	if val := getenv("SUPERLOGICA_URL"); val != "" {
+		cfg.Integrations.NameIntegration.URL = val
+	}
+	if val := getenv("REDE_PARCERIAS_URL"); val != "" {
+		cfg.Integrations.PartnerIntegration.URL = val
+	}
+	if val := getenv("APP_ENV"); val != "" {
+		cfg.Environment = val
+	}
+	// PORT é definido pela plataforma (Cloud Run/Render) e sempre vence
+	if val := getenv("PORT"); val != "" {
+		cfg.Server.Port = val
+	}
 }
 
 // Helper functions
 func getEnvOrDefault(key, defaultVal string) string {
-	if val := os.Getenv(key); val != "" {
+	if val := getenv(key); val != "" {
 		return val
 	}
 	return defaultVal
 }
 
 func getEnvOrDefaultBool(key string, defaultVal bool) bool {
-	if val := os.Getenv(key); val != "" {
-		b, _ := strconv.ParseBool(val)
+	if val := getenv(key); val != "" {
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			envProblems = append(envProblems, fmt.Sprintf("%s=%q não é um booleano válido", key, val))
+			return defaultVal
+		}
 		return b
 	}
 	return defaultVal
 }
 
 func getEnvOrDefaultInt(key string, defaultVal int) int {
-	if val := os.Getenv(key); val != "" {
-		i, _ := strconv.Atoi(val)
+	if val := getenv(key); val != "" {
+		i, err := strconv.Atoi(val)
+		if err != nil {
+			envProblems = append(envProblems, fmt.Sprintf("%s=%q não é um inteiro válido", key, val))
+			return defaultVal
+		}
 		return i
 	}
 	return defaultVal
 }
+
+// splitList separa valores por vírgula, ignorando espaços e itens vazios
+func splitList(val string) []string {
+	var items []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
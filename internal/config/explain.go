@@ -0,0 +1,213 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Origem de um valor da configuração efetiva
+const (
+	SourceDefault = "default"
+	SourceYAML    = "yaml"
+	SourceEnv     = "env"
+)
+
+// Setting descreve um valor da configuração efetiva e de onde ele veio
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+	Secret bool
+}
+
+// Explain carrega a configuração como Load faz e retorna cada valor folha
+// com sua origem (default, YAML ou env). Valores marcados com a tag
+// `secret:"true"` e credenciais embutidas em URLs são mascarados.
+func Explain(configPath string) ([]Setting, *Config, error) {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+
+	// Defaults puros, sem nenhuma env var
+	getenv = func(string) string { return "" }
+	pure := &Config{}
+	setDefaults(pure)
+	getenv = os.Getenv
+	envProblems = nil
+
+	// Defaults com env vars (setDefaults lê env vars diretamente)
+	withEnv := &Config{}
+	setDefaults(withEnv)
+	envProblems = nil
+
+	// Defaults + YAML
+	withYAML := &Config{}
+	setDefaults(withYAML)
+	yamlKeys := map[string]bool{}
+	if configPath != "" {
+		if err := loadFromYAML(configPath, withYAML); err != nil {
+			return nil, nil, fmt.Errorf("erro ao carregar config YAML: %w", err)
+		}
+		data, _ := os.ReadFile(configPath)
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err == nil {
+			collectYAMLKeys(&node, "", yamlKeys)
+		}
+	}
+
+	// Configuração efetiva
+	final := &Config{}
+	*final = *withYAML
+	loadFromEnv(final)
+	final.loadProblems = append(final.loadProblems, envProblems...)
+	envProblems = nil
+
+	pureVals := flatten(pure)
+	envVals := flatten(withEnv)
+	yamlVals := flatten(withYAML)
+	finalVals := flatten(final)
+
+	settings := make([]Setting, 0, len(finalVals))
+	for key, fv := range finalVals {
+		source := SourceDefault
+		switch {
+		case fv.value != yamlVals[key].value:
+			source = SourceEnv
+		case yamlKeys[key]:
+			source = SourceYAML
+		case envVals[key].value != pureVals[key].value:
+			source = SourceEnv
+		}
+
+		value := fv.value
+		if fv.secret && value != "" {
+			value = "********"
+		} else {
+			value = maskURLCredentials(value)
+		}
+
+		settings = append(settings, Setting{
+			Key:    key,
+			Value:  value,
+			Source: source,
+			Secret: fv.secret,
+		})
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+
+	return settings, final, nil
+}
+
+type flatValue struct {
+	value  string
+	secret bool
+}
+
+// flatten converte a Config em um mapa "caminho.yaml" -> valor
+func flatten(cfg *Config) map[string]flatValue {
+	out := map[string]flatValue{}
+	flattenValue(reflect.ValueOf(cfg).Elem(), "", false, out)
+	return out
+}
+
+func flattenValue(v reflect.Value, prefix string, secret bool, out map[string]flatValue) {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			flattenValue(v.Field(i), joinKey(prefix, name), secret || field.Tag.Get("secret") == "true", out)
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		if len(keys) == 0 {
+			out[prefix] = flatValue{value: "{}", secret: secret}
+			return
+		}
+		for _, k := range keys {
+			flattenValue(v.MapIndex(k), joinKey(prefix, fmt.Sprint(k.Interface())), secret, out)
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Struct {
+			for i := 0; i < v.Len(); i++ {
+				flattenValue(v.Index(i), fmt.Sprintf("%s[%d]", prefix, i), secret, out)
+			}
+			if v.Len() == 0 {
+				out[prefix] = flatValue{value: "[]", secret: secret}
+			}
+			return
+		}
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = fmt.Sprint(v.Index(i).Interface())
+		}
+		out[prefix] = flatValue{value: "[" + strings.Join(items, ", ") + "]", secret: secret}
+	case reflect.Ptr:
+		if v.IsNil() {
+			out[prefix] = flatValue{value: "", secret: secret}
+			return
+		}
+		flattenValue(v.Elem(), prefix, secret, out)
+	default:
+		out[prefix] = flatValue{value: fmt.Sprint(v.Interface()), secret: secret}
+	}
+}
+
+func joinKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// collectYAMLKeys registra os caminhos com valor definido no arquivo YAML.
+// Mapas só contam pelas suas folhas; listas de escalares contam como um todo.
+func collectYAMLKeys(node *yaml.Node, prefix string, keys map[string]bool) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			collectYAMLKeys(child, prefix, keys)
+		}
+	case yaml.MappingNode:
+		if len(node.Content) == 0 {
+			keys[prefix] = true
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			collectYAMLKeys(node.Content[i+1], joinKey(prefix, node.Content[i].Value), keys)
+		}
+	case yaml.SequenceNode:
+		keys[prefix] = true
+		for i, child := range node.Content {
+			if child.Kind == yaml.MappingNode {
+				collectYAMLKeys(child, fmt.Sprintf("%s[%d]", prefix, i), keys)
+			}
+		}
+	default:
+		keys[prefix] = true
+	}
+}
+
+// maskURLCredentials esconde usuário/senha embutidos em URLs
+func maskURLCredentials(val string) string {
+	if !strings.Contains(val, "@") || !strings.Contains(val, "://") {
+		return val
+	}
+	u, err := url.Parse(val)
+	if err != nil || u.User == nil {
+		return val
+	}
+	u.User = url.User("****")
+	return u.String()
+}
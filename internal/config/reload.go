@@ -0,0 +1,239 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	current atomic.Pointer[Config]
+
+	subsMu      sync.Mutex
+	subscribers []func(cfg *Config)
+)
+
+// Get retorna a configuração atual. O ponteiro retornado nunca é alterado
+// depois de publicado: um reload publica uma nova instância.
+func Get() *Config {
+	if cfg := current.Load(); cfg != nil {
+		return cfg
+	}
+	cfg := &Config{}
+	loadMu.Lock()
+	setDefaults(cfg)
+	loadMu.Unlock()
+	current.CompareAndSwap(nil, cfg)
+	return current.Load()
+}
+
+// Subscribe registra fn para ser chamada a cada nova configuração publicada
+func Subscribe(fn func(cfg *Config)) {
+	subsMu.Lock()
+	defer subsMu.Unlock()
+	subscribers = append(subscribers, fn)
+}
+
+// publish troca a configuração atual e notifica os assinantes
+func publish(cfg *Config) {
+	current.Store(cfg)
+
+	subsMu.Lock()
+	subs := append([]func(*Config){}, subscribers...)
+	subsMu.Unlock()
+
+	for _, fn := range subs {
+		fn(cfg)
+	}
+}
+
+type snapshotKey struct{}
+
+// WithSnapshot fixa cfg no contexto para que uma requisição inteira use a
+// mesma versão da configuração, mesmo que um reload aconteça no meio dela
+func WithSnapshot(ctx context.Context, cfg *Config) context.Context {
+	return context.WithValue(ctx, snapshotKey{}, cfg)
+}
+
+// FromContext retorna a configuração fixada no contexto, ou nil
+func FromContext(ctx context.Context) *Config {
+	cfg, _ := ctx.Value(snapshotKey{}).(*Config)
+	return cfg
+}
+
+// Seções lidas apenas na inicialização; mudanças exigem restart
+var restartOnlyPrefixes = []string{"environment", "server.", "integrations.", "secrets.", "database.", "reload.", "admin.", "directory.", "webhooks.superlogica.", "webhooks.outbound.enabled", "privacy.retention.enabled", "privacy.retention.interval_minutes", "privacy.encryption."}
+
+// Watcher observa o config.yaml (e opcionalmente uma URL remota) e publica
+// novas versões válidas. Uma versão inválida é descartada e a última
+// configuração boa continua em uso.
+type Watcher struct {
+	path      string
+	remoteURL string
+	interval  time.Duration
+	client    *http.Client
+
+	fileHash   [32]byte
+	remoteData []byte
+	remoteETag string
+}
+
+// NewWatcher cria um watcher com os parâmetros de cfg.Reload
+func NewWatcher(path string, cfg *Config) *Watcher {
+	w := &Watcher{
+		path:      path,
+		remoteURL: cfg.Reload.RemoteURL,
+		interval:  time.Duration(cfg.Reload.IntervalSeconds) * time.Second,
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		w.fileHash = sha256.Sum256(data)
+	}
+	return w
+}
+
+// Run verifica mudanças a cada intervalo até ctx ser cancelado ou stop fechar
+func (w *Watcher) Run(ctx context.Context, stop <-chan struct{}) {
+	log.Printf("[CONFIG] Observando %s a cada %v", w.path, w.interval)
+	if w.remoteURL != "" {
+		if _, err := w.Check(ctx); err != nil {
+			log.Printf("[CONFIG] %v", err)
+		}
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case <-ticker.C:
+			if _, err := w.Check(ctx); err != nil {
+				log.Printf("[CONFIG] %v", err)
+			}
+		}
+	}
+}
+
+// Check recarrega a configuração se alguma fonte mudou. Retorna true quando
+// uma nova versão foi publicada.
+func (w *Watcher) Check(ctx context.Context) (bool, error) {
+	changed := false
+
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return false, fmt.Errorf("reload ignorado, erro lendo %s: %w", w.path, err)
+	}
+	if hash := sha256.Sum256(data); hash != w.fileHash {
+		w.fileHash = hash
+		changed = true
+	}
+
+	if w.remoteURL != "" {
+		remoteChanged, err := w.fetchRemote(ctx)
+		if err != nil {
+			log.Printf("[CONFIG] Fonte remota indisponível, mantendo última versão: %v", err)
+		}
+		changed = changed || remoteChanged
+	}
+
+	if !changed {
+		return false, nil
+	}
+
+	next, err := build(w.path, w.remoteData)
+	if err != nil {
+		return false, fmt.Errorf("reload rejeitado, mantendo configuração anterior: %w", err)
+	}
+	if err := next.Validate(); err != nil {
+		return false, fmt.Errorf("reload rejeitado, mantendo configuração anterior: %w", err)
+	}
+
+	prev := Get()
+	changedKeys := diffKeys(prev, next)
+	publish(next)
+
+	log.Printf("[CONFIG] Configuração recarregada: %d valores alterados %v", len(changedKeys), changedKeys)
+	if restart := restartOnly(changedKeys); len(restart) > 0 {
+		log.Printf("[CONFIG] WARN: %v só têm efeito após reiniciar o servidor", restart)
+	}
+	return true, nil
+}
+
+func (w *Watcher) fetchRemote(ctx context.Context) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", w.remoteURL, nil)
+	if err != nil {
+		return false, err
+	}
+	if w.remoteETag != "" {
+		req.Header.Set("If-None-Match", w.remoteETag)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("config remota: HTTP %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return false, err
+	}
+	w.remoteETag = resp.Header.Get("ETag")
+	if bytes.Equal(data, w.remoteData) {
+		return false, nil
+	}
+	w.remoteData = data
+	return true, nil
+}
+
+// diffKeys lista as chaves com valor diferente entre duas configurações
+func diffKeys(prev, next *Config) []string {
+	a, b := flatten(prev), flatten(next)
+	var keys []string
+	for key, val := range b {
+		if a[key] != val {
+			keys = append(keys, key)
+		}
+	}
+	for key := range a {
+		if _, ok := b[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func restartOnly(keys []string) []string {
+	var out []string
+	for _, key := range keys {
+		for _, prefix := range restartOnlyPrefixes {
+			if key == strings.TrimSuffix(prefix, ".") || strings.HasPrefix(key, prefix) {
+				out = append(out, key)
+				break
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+// TestWatcherRejectsInvalidConfig um arquivo inválido não é publicado e a
+// última configuração boa continua em uso
+func TestWatcherRejectsInvalidConfig(t *testing.T) {
+	path := writeConfig(t, "behavior:\n  language: pt-BR\n")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	watcher := NewWatcher(path, cfg)
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		content     string
+		wantErr     bool
+		wantChanged bool
+		wantLang    string
+	}{
+		{name: "sem mudança", content: "behavior:\n  language: pt-BR\n", wantLang: "pt-BR"},
+		{name: "idioma inválido", content: "behavior:\n  language: fr-FR\n", wantErr: true, wantLang: "pt-BR"},
+		{name: "YAML quebrado", content: "behavior: [\n", wantErr: true, wantLang: "pt-BR"},
+		{name: "versão válida", content: "behavior:\n  language: en-US\n", wantChanged: true, wantLang: "en-US"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("config.yaml: %v", err)
+			}
+
+			changed, err := watcher.Check(ctx)
+			if (err != nil) != tt.wantErr || changed != tt.wantChanged {
+				t.Errorf("Check = %v, %v", changed, err)
+			}
+			if lang := Get().Behavior.Language; lang != tt.wantLang {
+				t.Errorf("idioma publicado %s, esperado %s", lang, tt.wantLang)
+			}
+		})
+	}
+}
@@ -0,0 +1,535 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Idiomas com mensagens disponíveis no frontend
+var supportedLanguages = map[string]bool{
+	"pt-BR": true,
+	"en-US": true,
+	"es-ES": true,
+}
+
+var supportedEnvironments = map[string]bool{
+	"development": true,
+	"staging":     true,
+	"production":  true,
+}
+
+var supportedSecretProviders = map[string]bool{
+	"env":  true,
+	"file": true,
+	"gsm":  true,
+}
+
+// Tipos de banco com repositório implementado
+var supportedDatabases = map[string]bool{
+	"firestore": true,
+}
+
+// Papéis das rotas administrativas (ver pacote auth)
+var knownRoles = map[string]bool{
+	"support":        true,
+	"tenant_admin":   true,
+	"platform_admin": true,
+}
+
+// Eventos aceitos nos webhooks de saída (ver domain.KnownEvents)
+var eventNames = []string{"lead.validated", "lead.activated", "lead.revoked", "partner.registration_failed", "lead.consent_withdrawn"}
+
+var knownEvents = func() map[string]bool {
+	m := make(map[string]bool, len(eventNames))
+	for _, name := range eventNames {
+		m[name] = true
+	}
+	return m
+}()
+
+// Autenticação aceita pelos clubes type "rest" ("" = none)
+var restAuthTypes = map[string]bool{
+	"":       true,
+	"none":   true,
+	"bearer": true,
+	"header": true,
+	"basic":  true,
+}
+
+var hexColorRegex = regexp.MustCompile(`^#?([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+var consentPurposeRegex = regexp.MustCompile(`^[a-z_]+$`)
+
+var kmsKeyRegex = regexp.MustCompile(`^projects/[^/]+/locations/[^/]+/keyRings/[^/]+/cryptoKeys/[^/]+$`)
+
+// ValidationError agrega todos os problemas encontrados na configuração
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("configuração inválida (%d problemas):\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// Validate verifica a configuração completa e reporta todos os problemas de
+// uma vez, em vez de parar no primeiro. Retorna *ValidationError ou nil.
+func (c *Config) Validate() error {
+	v := &validator{}
+	v.problems = append(v.problems, c.loadProblems...)
+
+	if !supportedEnvironments[c.Environment] {
+		v.add("environment", "ambiente desconhecido %q (development, staging, production)", c.Environment)
+	}
+
+	// Branding
+	v.color("branding.theme_color", c.Branding.ThemeColor)
+	v.color("branding.secondary_color", c.Branding.SecondaryColor)
+	v.urlOrPath("branding.logo_url", c.Branding.LogoURL)
+	v.urlOrPath("branding.side_image_url", c.Branding.SideImageURL)
+	if c.Branding.CompanyEmail != "" && !strings.Contains(c.Branding.CompanyEmail, "@") {
+		v.add("branding.company_email", "e-mail inválido %q", c.Branding.CompanyEmail)
+	}
+
+	// Behavior
+	if !supportedLanguages[c.Behavior.Language] {
+		v.add("behavior.language", "idioma desconhecido %q (suportados: pt-BR, en-US, es-ES)", c.Behavior.Language)
+	}
+	v.optionalURL("behavior.redirect_url_on_success", c.Behavior.RedirectURLOnSuccess)
+	v.optionalURL("behavior.redirect_url_on_error", c.Behavior.RedirectURLOnError)
+	v.nonNegative("behavior.auto_close_modal_seconds", c.Behavior.AutoCloseModalSeconds)
+
+	// Validation
+	v.nonNegative("validation.max_retries", c.Validation.MaxRetries)
+	for i, d := range c.Validation.RetryDelayMs {
+		v.nonNegative(fmt.Sprintf("validation.retry_delay_ms[%d]", i), d)
+	}
+
+	// Security
+	if len(c.Security.CORSAllowedOrigins) == 0 {
+		v.add("security.cors_allowed_origins", "lista vazia (use \"*\" para liberar todas)")
+	}
+	for i, origin := range c.Security.CORSAllowedOrigins {
+		v.origin(fmt.Sprintf("security.cors_allowed_origins[%d]", i), origin)
+	}
+
+	// Integrations
+	sources := map[string]bool{DefaultSourceID: true}
+	for i, source := range c.Integrations.Sources {
+		field := fmt.Sprintf("integrations.sources[%d]", i)
+		v.required(field+".id", source.ID)
+		if sources[source.ID] {
+			v.add(field+".id", "fonte %q duplicada", source.ID)
+		}
+		sources[source.ID] = true
+	}
+	v.source("integrations.name_integration", c.Integrations.NameIntegration, sources)
+	for i, source := range c.Integrations.Sources {
+		v.source(fmt.Sprintf("integrations.sources[%d]", i), source, sources)
+	}
+	v.partner("integrations.partner_integration", c.Integrations.PartnerIntegration)
+	clubs := map[string]bool{DefaultClubID: true}
+	for i, club := range c.Integrations.Clubs {
+		field := fmt.Sprintf("integrations.clubs[%d]", i)
+		v.required(field+".id", club.ID)
+		if clubs[club.ID] {
+			v.add(field+".id", "clube %q duplicado", club.ID)
+		}
+		clubs[club.ID] = true
+		v.partner(field, club)
+	}
+
+	// Tenants
+	seenHosts := map[string]string{}
+	for i, tenant := range c.Tenants {
+		field := fmt.Sprintf("tenants[%d]", i)
+		v.required(field+".id", tenant.ID)
+		if len(tenant.Hosts) == 0 {
+			v.add(field+".hosts", "lista vazia")
+		}
+		for _, host := range tenant.Hosts {
+			host = strings.ToLower(host)
+			if prev, dup := seenHosts[host]; dup {
+				v.add(field+".hosts", "host %q já mapeado para o tenant %s", host, prev)
+			}
+			seenHosts[host] = tenant.ID
+		}
+		if tenant.Source != "" && !sources[tenant.Source] {
+			v.add(field+".source", "fonte %q não existe em integrations.sources", tenant.Source)
+		}
+		for _, club := range tenant.Clubs {
+			if !clubs[club] {
+				v.add(field+".clubs", "clube %q não existe em integrations.clubs", club)
+			}
+		}
+	}
+
+	// Reload
+	if c.Reload.Enabled && c.Reload.IntervalSeconds <= 0 {
+		v.add("reload.interval_seconds", "deve ser positivo com reload habilitado (%d)", c.Reload.IntervalSeconds)
+	}
+	v.optionalURL("reload.remote_url", c.Reload.RemoteURL)
+
+	// Secrets
+	if !supportedSecretProviders[c.Secrets.Provider] {
+		v.add("secrets.provider", "provedor desconhecido %q (env, file, gsm)", c.Secrets.Provider)
+	}
+	v.nonNegative("secrets.cache_ttl_seconds", c.Secrets.CacheTTLSeconds)
+
+	// Database
+	if !supportedDatabases[c.Database.Type] {
+		v.add("database.type", "tipo %q não suportado (suportado: firestore)", c.Database.Type)
+	}
+	v.required("database.collection_name", c.Database.CollectionName)
+
+	// Server
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		v.add("server.port", "porta inválida %q", c.Server.Port)
+	}
+	v.nonNegative("server.read_header_timeout_seconds", c.Server.ReadHeaderTimeoutSeconds)
+	v.nonNegative("server.read_timeout_seconds", c.Server.ReadTimeoutSeconds)
+	v.nonNegative("server.write_timeout_seconds", c.Server.WriteTimeoutSeconds)
+	v.nonNegative("server.idle_timeout_seconds", c.Server.IdleTimeoutSeconds)
+	v.nonNegative("server.max_header_bytes", c.Server.MaxHeaderBytes)
+	v.nonNegative("server.shutdown_timeout_seconds", c.Server.ShutdownTimeoutSeconds)
+
+	// Auth
+	seenKeys := map[string]bool{}
+	for i, key := range c.Auth.APIKeys {
+		field := fmt.Sprintf("auth.api_keys[%d]", i)
+		v.required(field+".id", key.ID)
+		v.required(field+".key_ref", key.KeyRef)
+		if seenKeys[key.ID] {
+			v.add(field+".id", "id %q duplicado", key.ID)
+		}
+		seenKeys[key.ID] = true
+		v.roles(field+".roles", key.Roles)
+	}
+	if jwt := c.Auth.JWT; jwt.Enabled {
+		if jwt.JWKSURL == "" && jwt.StaticKeyRef == "" {
+			v.add("auth.jwt", "informe jwks_url ou static_key_ref")
+		}
+		if jwt.JWKSURL != "" && jwt.StaticKeyRef != "" {
+			v.add("auth.jwt", "jwks_url e static_key_ref são exclusivos")
+		}
+		if jwt.StaticKeyRef != "" && c.IsProduction() {
+			v.add("auth.jwt.static_key_ref", "chave estática não é permitida em produção")
+		}
+		v.optionalURL("auth.jwt.jwks_url", jwt.JWKSURL)
+		v.required("auth.jwt.roles_claim", jwt.RolesClaim)
+		v.required("auth.jwt.tenants_claim", jwt.TenantsClaim)
+		v.nonNegative("auth.jwt.jwks_cache_seconds", jwt.JWKSCacheSeconds)
+		v.nonNegative("auth.jwt.clock_skew_seconds", jwt.ClockSkewSeconds)
+	}
+
+	// Idempotency
+	if c.Idempotency.Enabled && c.Idempotency.WindowMinutes <= 0 {
+		v.add("idempotency.window_minutes", "deve ser positivo com a idempotência habilitada (%d)", c.Idempotency.WindowMinutes)
+	}
+
+	// SSO
+	if c.SSO.HandleTTLSeconds <= 0 {
+		v.add("sso.handle_ttl_seconds", "deve ser positivo (%d)", c.SSO.HandleTTLSeconds)
+	}
+
+	// Lembrar de mim: ligado no padrão ou em algum condomínio
+	rememberMe := c.RememberMe.Enabled
+	for _, tenant := range c.Tenants {
+		if tenant.RememberMe != nil && *tenant.RememberMe {
+			rememberMe = true
+		}
+	}
+	if rememberMe {
+		if c.RememberMe.SecretRef == "" {
+			v.add("remember_me.secret_ref", "obrigatório com o lembrar de mim habilitado")
+		}
+		if c.RememberMe.CookieName == "" {
+			v.add("remember_me.cookie_name", "obrigatório com o lembrar de mim habilitado")
+		}
+		if c.RememberMe.TTLDays <= 0 {
+			v.add("remember_me.ttl_days", "deve ser positivo (%d)", c.RememberMe.TTLDays)
+		}
+		if c.RememberMe.RevalidateHours <= 0 {
+			v.add("remember_me.revalidate_hours", "deve ser positivo (%d)", c.RememberMe.RevalidateHours)
+		}
+	}
+
+	// LGPD: os pedidos do titular passam pela API do suporte
+	if c.Admin.Enabled {
+		v.required("privacy.secret_ref", c.Privacy.SecretRef)
+	}
+	for purpose, version := range c.Privacy.Consent.Versions {
+		// A finalidade vira caminho de campo no Firestore (consents.<finalidade>)
+		if !consentPurposeRegex.MatchString(purpose) {
+			v.add("privacy.consent.versions", "finalidade inválida %q (letras minúsculas e _)", purpose)
+		}
+		if version == "" {
+			v.add("privacy.consent.versions."+purpose, "versão vazia")
+		}
+	}
+	if encryption := c.Privacy.Encryption; encryption.Enabled {
+		switch encryption.KMS {
+		case "local":
+			v.required("privacy.encryption.key_file", encryption.KeyFile)
+			if c.IsProduction() {
+				v.add("privacy.encryption.kms", "local (arquivo de chaves) não é aceito em produção")
+			}
+		case "gcp":
+			if !kmsKeyRegex.MatchString(encryption.KMSKey) {
+				v.add("privacy.encryption.kms_key", "esperado projects/.../locations/.../keyRings/.../cryptoKeys/... (%q)", encryption.KMSKey)
+			}
+		default:
+			v.add("privacy.encryption.kms", "KMS desconhecido %q (local, gcp)", encryption.KMS)
+		}
+		v.required("privacy.encryption.hash_key_ref", encryption.HashKeyRef)
+		if encryption.DataKeyTTLMinutes <= 0 {
+			v.add("privacy.encryption.data_key_ttl_minutes", "deve ser positivo (%d)", encryption.DataKeyTTLMinutes)
+		}
+	}
+	if retention := c.Privacy.Retention; retention.Enabled {
+		if retention.IntervalMinutes <= 0 {
+			v.add("privacy.retention.interval_minutes", "deve ser positivo com a retenção habilitada (%d)", retention.IntervalMinutes)
+		}
+		if retention.MaxPerRun <= 0 {
+			v.add("privacy.retention.max_per_run", "deve ser positivo com a retenção habilitada (%d)", retention.MaxPerRun)
+		}
+		if retention.WebhookDays < 0 {
+			v.add("privacy.retention.webhook_days", "não pode ser negativo (%d)", retention.WebhookDays)
+		}
+		names := map[string]bool{}
+		for i, rule := range retention.Rules {
+			field := fmt.Sprintf("privacy.retention.rules[%d]", i)
+			if rule.Days <= 0 {
+				v.add(field+".days", "deve ser positivo (%d)", rule.Days)
+			}
+			if rule.Action != "delete" && rule.Action != "pseudonymize" {
+				v.add(field+".action", "ação desconhecida %q (delete, pseudonymize)", rule.Action)
+			}
+			if rule.Action == "pseudonymize" {
+				v.required("privacy.secret_ref", c.Privacy.SecretRef)
+			}
+			if rule.Name != "" && names[rule.Name] {
+				v.add(field+".name", "duplicado: %s", rule.Name)
+			}
+			names[rule.Name] = true
+		}
+	}
+
+	// Directory
+	if c.Directory.Enabled {
+		if c.Directory.SyncIntervalMinutes <= 0 {
+			v.add("directory.sync_interval_minutes", "deve ser positivo com o diretório habilitado (%d)", c.Directory.SyncIntervalMinutes)
+		}
+		if c.Directory.MaxStalenessMinutes < c.Directory.SyncIntervalMinutes {
+			v.add("directory.max_staleness_minutes", "menor que o intervalo de sincronização (%d < %d)", c.Directory.MaxStalenessMinutes, c.Directory.SyncIntervalMinutes)
+		}
+		if c.Directory.MaxDeparturePercent < 0 || c.Directory.MaxDeparturePercent > 100 {
+			v.add("directory.max_departure_percent", "deve estar entre 0 e 100 (%d)", c.Directory.MaxDeparturePercent)
+		}
+		for i, id := range c.Directory.CondoIDs {
+			if id == "" || id == "-1" {
+				v.add(fmt.Sprintf("directory.condo_ids[%d]", i), "ID de condomínio inválido %q", id)
+			}
+		}
+		if len(c.DirectoryCondos()) == 0 {
+			v.add("directory.condo_ids", "nenhum condomínio a sincronizar (informe condo_ids ou tenants com ID)")
+		}
+	}
+
+	// Webhooks
+	if wh := c.Webhooks.Superlogica; wh.Enabled {
+		if wh.Auth != "hmac" && wh.Auth != "token" {
+			v.add("webhooks.superlogica.auth", "modo desconhecido %q (hmac, token)", wh.Auth)
+		}
+		v.required("webhooks.superlogica.secret_ref", wh.SecretRef)
+		v.required("webhooks.superlogica.signature_header", wh.SignatureHeader)
+		if wh.TimestampHeader != "" {
+			if wh.Auth != "hmac" {
+				v.add("webhooks.superlogica.timestamp_header", "exige auth hmac")
+			}
+			if wh.ToleranceSeconds <= 0 {
+				v.add("webhooks.superlogica.tolerance_seconds", "deve ser positivo (%d)", wh.ToleranceSeconds)
+			}
+		}
+	}
+	if out := c.Webhooks.Outbound; out.Enabled {
+		if out.MaxAttempts <= 0 {
+			v.add("webhooks.outbound.max_attempts", "deve ser positivo (%d)", out.MaxAttempts)
+		}
+		if out.InitialBackoffSeconds <= 0 || out.MaxBackoffSeconds < out.InitialBackoffSeconds {
+			v.add("webhooks.outbound", "backoff inválido (inicial %ds, máximo %ds)", out.InitialBackoffSeconds, out.MaxBackoffSeconds)
+		}
+		v.nonNegative("webhooks.outbound.timeout_seconds", out.TimeoutSeconds)
+		seenEndpoints := map[string]bool{}
+		for i, endpoint := range out.Endpoints {
+			field := fmt.Sprintf("webhooks.outbound.endpoints[%d]", i)
+			v.required(field+".id", endpoint.ID)
+			if seenEndpoints[endpoint.ID] {
+				v.add(field+".id", "id %q duplicado", endpoint.ID)
+			}
+			seenEndpoints[endpoint.ID] = true
+			v.absoluteURL(field+".url", endpoint.URL)
+			if c.IsProduction() && !strings.HasPrefix(endpoint.URL, "https://") {
+				v.add(field+".url", "endpoint sem HTTPS não é permitido em produção")
+			}
+			v.required(field+".secret_ref", endpoint.SecretRef)
+			if len(endpoint.Events) == 0 {
+				v.add(field+".events", "lista vazia")
+			}
+			for _, event := range endpoint.Events {
+				if !knownEvents[event] {
+					v.add(field+".events", "evento desconhecido %q (%s)", event, strings.Join(eventNames, ", "))
+				}
+			}
+			if len(endpoint.Tenants) == 0 {
+				v.add(field+".tenants", "lista vazia (use \"*\" para todos)")
+			}
+		}
+	}
+
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: v.problems}
+}
+
+// validator acumula problemas no formato "campo: mensagem"
+type validator struct {
+	problems []string
+}
+
+func (v *validator) add(field, format string, args ...interface{}) {
+	v.problems = append(v.problems, field+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *validator) roles(field string, roles []string) {
+	if len(roles) == 0 {
+		v.add(field, "lista vazia")
+	}
+	for _, role := range roles {
+		if !knownRoles[role] {
+			v.add(field, "papel desconhecido %q (support, tenant_admin, platform_admin)", role)
+		}
+	}
+}
+
+// partner valida um Clube de Benefícios habilitado
+func (v *validator) partner(field string, p PartnerIntegration) {
+	if !p.Enabled {
+		return
+	}
+	v.required(field+".type", p.Type)
+	v.absoluteURL(field+".url", p.URL)
+	v.nonNegative(field+".timeout_seconds", p.TimeoutSeconds)
+	if p.Type != "rest" {
+		return
+	}
+	v.restAuth(field+".rest.auth", p.REST.Auth)
+	v.required(field+".rest.find_user.path", p.REST.FindUser.Path)
+	v.required(field+".rest.register.path", p.REST.Register.Path)
+	v.required(field+".rest.delete.path", p.REST.Delete.Path)
+	v.required(field+".rest.sso.path", p.REST.SSO.Path)
+}
+
+// source valida uma fonte de moradores habilitada
+func (v *validator) source(field string, s NameIntegration, sources map[string]bool) {
+	if !s.Enabled {
+		return
+	}
+	v.required(field+".type", s.Type)
+	v.nonNegative(field+".timeout_seconds", s.TimeoutSeconds)
+	switch s.Type {
+	case "file":
+		v.required(field+".file.path", s.File.Path)
+		if s.File.Format != "" && s.File.Format != "csv" && s.File.Format != "json" {
+			v.add(field+".file.format", "formato desconhecido %q (csv, json)", s.File.Format)
+		}
+	case "http":
+		v.absoluteURL(field+".url", s.URL)
+		v.restAuth(field+".http.auth", s.HTTP.Auth)
+		v.required(field+".http.lookup.path", s.HTTP.Lookup.Path)
+	case "composite":
+		if len(s.Sources) == 0 {
+			v.add(field+".sources", "lista vazia")
+		}
+		for _, id := range s.Sources {
+			if !sources[id] {
+				v.add(field+".sources", "fonte %q não existe em integrations.sources", id)
+			}
+			if id == s.ID || (s.ID == "" && id == DefaultSourceID) {
+				v.add(field+".sources", "a fonte não pode consultar a si mesma")
+			}
+		}
+	default:
+		v.absoluteURL(field+".url", s.URL)
+	}
+}
+
+// restAuth valida a autenticação de uma integração genérica
+func (v *validator) restAuth(field string, auth RESTAuth) {
+	if !restAuthTypes[auth.Type] {
+		v.add(field+".type", "tipo desconhecido %q (none, bearer, header, basic)", auth.Type)
+	}
+	if auth.Type != "" && auth.Type != "none" {
+		v.required(field+".token_ref", auth.TokenRef)
+	}
+	if auth.Type == "header" {
+		v.required(field+".header", auth.Header)
+	}
+	if auth.Type == "basic" {
+		v.required(field+".username", auth.Username)
+	}
+}
+
+func (v *validator) required(field, val string) {
+	if strings.TrimSpace(val) == "" {
+		v.add(field, "obrigatório")
+	}
+}
+
+func (v *validator) nonNegative(field string, val int) {
+	if val < 0 {
+		v.add(field, "não pode ser negativo (%d)", val)
+	}
+}
+
+func (v *validator) color(field, val string) {
+	if !hexColorRegex.MatchString(val) {
+		v.add(field, "cor hex inválida %q (ex: 0066cc)", val)
+	}
+}
+
+func (v *validator) absoluteURL(field, val string) {
+	u, err := url.Parse(val)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.add(field, "URL inválida %q (esperado http(s)://host/...)", val)
+	}
+}
+
+func (v *validator) optionalURL(field, val string) {
+	if val != "" {
+		v.absoluteURL(field, val)
+	}
+}
+
+// urlOrPath aceita URL absoluta ou caminho servido pelo próprio backend
+func (v *validator) urlOrPath(field, val string) {
+	if val == "" || strings.HasPrefix(val, "/") {
+		return
+	}
+	v.absoluteURL(field, val)
+}
+
+// origin valida uma origem CORS: "*" ou scheme://host[:porta], sem path
+func (v *validator) origin(field, val string) {
+	if val == "*" {
+		return
+	}
+	u, err := url.Parse(val)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+		(u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		v.add(field, "origem inválida %q (esperado scheme://host[:porta])", val)
+	}
+}
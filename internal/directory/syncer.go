@@ -0,0 +1,210 @@
+package directory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/viplounge/platform/internal/domain"
+)
+
+// Syncer copia as unidades dos condomínios configurados para o diretório
+// local e registra quem entrou, saiu ou mudou de dados desde a última
+// sincronização.
+type Syncer struct {
+	lister domain.MemberLister
+	repo   domain.DirectoryRepository
+	// maxDeparturePercent aborta a sincronização quando uma fração grande dos
+	// moradores some de uma vez (listagem parcial, filtro errado na API),
+	// para não gerar uma onda de revogações. 0 desliga a proteção.
+	maxDeparturePercent int
+}
+
+func NewSyncer(lister domain.MemberLister, repo domain.DirectoryRepository, maxDeparturePercent int) *Syncer {
+	return &Syncer{
+		lister:              lister,
+		repo:                repo,
+		maxDeparturePercent: maxDeparturePercent,
+	}
+}
+
+// Run sincroniza os condomínios imediatamente e depois a cada interval, até
+// stop ser fechado
+func (s *Syncer) Run(ctx context.Context, stop <-chan struct{}, interval time.Duration, condos []string) {
+	log.Printf("[DIRECTORY] Sincronizando %d condomínios a cada %v", len(condos), interval)
+	s.SyncAll(ctx, condos)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.SyncAll(ctx, condos)
+		}
+	}
+}
+
+// SyncAll sincroniza cada condomínio; a falha de um não impede os demais
+func (s *Syncer) SyncAll(ctx context.Context, condos []string) {
+	for _, condoID := range condos {
+		if ctx.Err() != nil {
+			return
+		}
+		if _, err := s.SyncCondo(ctx, condoID); err != nil {
+			log.Printf("[DIRECTORY] Condomínio %s: %v", condoID, err)
+		}
+	}
+}
+
+// SyncCondo lista as unidades do condomínio na Superlógica e aplica a
+// diferença no diretório. Em caso de erro o diretório fica como estava e só
+// o estado da tentativa é gravado.
+func (s *Syncer) SyncCondo(ctx context.Context, condoID string) (*domain.DirectorySync, error) {
+	start := time.Now()
+	state := domain.DirectorySync{CondoID: condoID, AttemptAt: start}
+	if prev, err := s.repo.GetSyncState(ctx, condoID); err == nil {
+		state.SyncedAt = prev.SyncedAt
+	} else if !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
+	}
+
+	members, err := s.lister.ListMembers(ctx, condoID)
+	if err != nil {
+		return s.fail(ctx, state, fmt.Errorf("listagem de unidades falhou: %w", err))
+	}
+	current, err := s.repo.ListResidents(ctx, condoID)
+	if err != nil {
+		return s.fail(ctx, state, err)
+	}
+
+	residents, changes := diff(condoID, current, members, start)
+
+	active := 0
+	for _, resident := range current {
+		if resident.Active {
+			active++
+		}
+	}
+	for _, change := range changes {
+		switch change.Type {
+		case domain.ResidentAdded:
+			state.Added++
+		case domain.ResidentDeparted:
+			state.Departed++
+		case domain.ResidentUpdated:
+			state.Updated++
+		}
+	}
+	state.Residents = active + state.Added - state.Departed
+
+	if active > 0 && len(members) == 0 {
+		return s.fail(ctx, state, fmt.Errorf("superlógica retornou 0 unidades para %d moradores ativos; sincronização ignorada", active))
+	}
+	if s.maxDeparturePercent > 0 && active > 0 && state.Departed*100 > active*s.maxDeparturePercent {
+		return s.fail(ctx, state, fmt.Errorf("%d de %d moradores sairiam de uma vez (limite %d%%); sincronização ignorada", state.Departed, active, s.maxDeparturePercent))
+	}
+
+	state.SyncedAt = start
+	state.DurationMs = time.Since(start).Milliseconds()
+	if err := s.repo.ApplySync(ctx, state, residents, changes); err != nil {
+		return s.fail(ctx, state, err)
+	}
+
+	log.Printf("[DIRECTORY] Condomínio %s sincronizado: %d moradores (+%d, -%d, ~%d) em %dms",
+		condoID, state.Residents, state.Added, state.Departed, state.Updated, state.DurationMs)
+	return &state, nil
+}
+
+func (s *Syncer) fail(ctx context.Context, state domain.DirectorySync, err error) (*domain.DirectorySync, error) {
+	state.Error = err.Error()
+	state.DurationMs = time.Since(state.AttemptAt).Milliseconds()
+	if saveErr := s.repo.SaveSyncState(ctx, state); saveErr != nil {
+		log.Printf("[DIRECTORY] Erro gravando estado de %s: %v", state.CondoID, saveErr)
+	}
+	return &state, err
+}
+
+// diff compara o diretório atual com a listagem da Superlógica e retorna os
+// moradores a gravar e as mudanças detectadas
+func diff(condoID string, current []domain.Resident, members []domain.Lead, now time.Time) ([]domain.Resident, []domain.ResidentChange) {
+	existing := make(map[string]domain.Resident, len(current))
+	for _, resident := range current {
+		existing[resident.CPF] = resident
+	}
+
+	var residents []domain.Resident
+	var changes []domain.ResidentChange
+	record := func(resident domain.Resident, changeType string) {
+		residents = append(residents, resident)
+		changes = append(changes, domain.ResidentChange{
+			CondoID:  condoID,
+			CPF:      resident.CPF,
+			Type:     changeType,
+			Resident: resident,
+			// Instantes distintos (1µs, precisão do Firestore) para que o
+			// feed possa ser paginado por "since" sem pular mudanças
+			DetectedAt: now.Add(time.Duration(len(changes)) * time.Microsecond),
+		})
+	}
+
+	seen := make(map[string]bool, len(members))
+	for _, member := range members {
+		cpf := onlyDigits(member.CPF)
+		// Proprietário de várias unidades aparece uma vez
+		if cpf == "" || seen[cpf] {
+			continue
+		}
+		seen[cpf] = true
+
+		listed := domain.Resident{
+			CondoID: condoID,
+			CPF:     cpf,
+			Name:    strings.TrimSpace(member.Name),
+			Email:   strings.TrimSpace(member.Email),
+			Phone:   strings.TrimSpace(member.Phone),
+			Active:  true,
+		}
+
+		prev, ok := existing[cpf]
+		switch {
+		case !ok:
+			listed.FirstSeenAt = now
+			listed.UpdatedAt = now
+			record(listed, domain.ResidentAdded)
+		case !prev.Active:
+			// Voltou ao condomínio
+			listed.FirstSeenAt = prev.FirstSeenAt
+			listed.UpdatedAt = now
+			record(listed, domain.ResidentAdded)
+		case prev.Name != listed.Name || prev.Email != listed.Email || prev.Phone != listed.Phone:
+			listed.FirstSeenAt = prev.FirstSeenAt
+			listed.UpdatedAt = now
+			record(listed, domain.ResidentUpdated)
+		}
+	}
+
+	for _, prev := range current {
+		if prev.Active && !seen[prev.CPF] {
+			prev.Active = false
+			prev.DepartedAt = now
+			prev.UpdatedAt = now
+			record(prev, domain.ResidentDeparted)
+		}
+	}
+	return residents, changes
+}
+
+var nonDigitRegex = regexp.MustCompile(`\D`)
+
+func onlyDigits(s string) string {
+	return nonDigitRegex.ReplaceAllString(s, "")
+}
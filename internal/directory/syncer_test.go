@@ -0,0 +1,140 @@
+package directory
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/repository"
+)
+
+// superlogica fonte ao vivo com as unidades de cada condomínio; err simula
+// a API fora do ar
+type superlogica struct {
+	units map[string][]domain.Lead
+	err   error
+	calls int
+}
+
+func (s *superlogica) ListMembers(ctx context.Context, condoID string) ([]domain.Lead, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.units[condoID], nil
+}
+
+func (s *superlogica) ValidateMember(ctx context.Context, condoID, cpf string) (bool, *domain.Lead, error) {
+	s.calls++
+	if s.err != nil {
+		return false, nil, s.err
+	}
+	for condo, units := range s.units {
+		for _, unit := range units {
+			if onlyDigits(unit.CPF) == onlyDigits(cpf) && (condoID == "-1" || condoID == condo) {
+				lead := unit
+				lead.CondoID = condo
+				lead.Origin = "superlogica"
+				return true, &lead, nil
+			}
+		}
+	}
+	return false, nil, nil
+}
+
+func TestSyncCondo(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	live := &superlogica{units: map[string][]domain.Lead{"4": {
+		{CPF: "111.444.777-35", Name: "Ana", Email: "ana@example.com"},
+		{CPF: "52998224725", Name: "Bruno"},
+		// Proprietário de duas unidades
+		{CPF: "529.982.247-25", Name: "Bruno"},
+		{CPF: "22233344405", Name: "Carla"},
+	}}}
+	syncer := NewSyncer(live, repo, 50)
+
+	first, err := syncer.SyncCondo(ctx, "4")
+	if err != nil {
+		t.Fatalf("primeira sincronização: %v", err)
+	}
+	if first.Added != 3 || first.Residents != 3 {
+		t.Errorf("primeira sincronização: %+v", first)
+	}
+
+	// Ana muda o e-mail, Carla sai e Diego entra
+	live.units["4"] = []domain.Lead{
+		{CPF: "11144477735", Name: "Ana", Email: "ana@novo.example.com"},
+		{CPF: "52998224725", Name: "Bruno"},
+		{CPF: "39053344705", Name: "Diego"},
+	}
+	second, err := syncer.SyncCondo(ctx, "4")
+	if err != nil {
+		t.Fatalf("segunda sincronização: %v", err)
+	}
+	if second.Added != 1 || second.Departed != 1 || second.Updated != 1 || second.Residents != 3 {
+		t.Errorf("segunda sincronização: %+v", second)
+	}
+	changes, _ := repo.ListChanges(ctx, domain.ResidentChangeFilter{CondoID: "4"})
+	if len(changes) != 6 {
+		t.Errorf("%d mudanças no feed, esperadas 6", len(changes))
+	}
+	residents, _ := repo.FindResidents(ctx, "22233344405")
+	if len(residents) != 1 || residents[0].Active || residents[0].DepartedAt.IsZero() {
+		t.Errorf("morador que saiu: %+v", residents)
+	}
+}
+
+// TestSyncCondoGuards listagens suspeitas não alteram o diretório
+func TestSyncCondoGuards(t *testing.T) {
+	tests := []struct {
+		name    string
+		units   []domain.Lead
+		err     error
+		wantErr string
+	}{
+		{name: "superlógica fora do ar", err: errors.New("timeout"), wantErr: "listagem de unidades falhou"},
+		{name: "listagem vazia", units: nil, wantErr: "0 unidades"},
+		{name: "saída em massa", units: []domain.Lead{{CPF: "11144477735", Name: "Ana"}}, wantErr: "sairiam de uma vez"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := repository.NewMemoryRepository()
+			live := &superlogica{units: map[string][]domain.Lead{"4": {
+				{CPF: "11144477735", Name: "Ana"},
+				{CPF: "52998224725", Name: "Bruno"},
+				{CPF: "22233344405", Name: "Carla"},
+			}}}
+			syncer := NewSyncer(live, repo, 50)
+			first, err := syncer.SyncCondo(ctx, "4")
+			if err != nil {
+				t.Fatalf("primeira sincronização: %v", err)
+			}
+
+			live.units["4"], live.err = tt.units, tt.err
+			if _, err := syncer.SyncCondo(ctx, "4"); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("erro %v, esperado %q", err, tt.wantErr)
+			}
+			state, _ := repo.GetSyncState(ctx, "4")
+			if state.Error == "" || !state.SyncedAt.Equal(first.SyncedAt) {
+				t.Errorf("estado depois da falha: %+v", state)
+			}
+			if residents, _ := repo.ListResidents(ctx, "4"); countActive(residents) != 3 {
+				t.Errorf("diretório alterado: %+v", residents)
+			}
+		})
+	}
+}
+
+func countActive(residents []domain.Resident) int {
+	active := 0
+	for _, resident := range residents {
+		if resident.Active {
+			active++
+		}
+	}
+	return active
+}
@@ -0,0 +1,112 @@
+package directory
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/viplounge/platform/internal/domain"
+)
+
+// Validator implementa domain.BenefValidator consultando primeiro o
+// diretório local e usando a Superlógica como fallback:
+//
+//   - morador ativo em condomínio sincronizado há menos de maxStaleness:
+//     responde do diretório, sem chamada externa;
+//   - demais casos: consulta a Superlógica ao vivo;
+//   - Superlógica fora do ar: responde com o registro ativo do diretório,
+//     mesmo desatualizado, em vez de negar o acesso.
+//
+// Um CPF ausente do diretório sempre vai à Superlógica, pois pode ter
+// entrado depois da última sincronização ou ser de um condomínio não
+// sincronizado.
+type Validator struct {
+	live         domain.BenefValidator
+	repo         domain.DirectoryRepository
+	maxStaleness time.Duration
+}
+
+func NewValidator(live domain.BenefValidator, repo domain.DirectoryRepository, maxStaleness time.Duration) *Validator {
+	return &Validator{
+		live:         live,
+		repo:         repo,
+		maxStaleness: maxStaleness,
+	}
+}
+
+func (v *Validator) ValidateMember(ctx context.Context, condoID string, cpf string) (bool, *domain.Lead, error) {
+	start := time.Now()
+	resident, fresh := v.lookup(ctx, condoID, cpf)
+	if resident != nil && fresh {
+		log.Printf("[DIRECTORY] Morador encontrado no diretório - Condomínio: %s", resident.CondoID)
+		return true, residentLead(*resident, cpf, time.Since(start)), nil
+	}
+
+	found, lead, err := v.live.ValidateMember(ctx, condoID, cpf)
+	if err != nil && resident != nil {
+		log.Printf("[DIRECTORY] Superlógica indisponível (%v), usando diretório do condomínio %s", err, resident.CondoID)
+		return true, residentLead(*resident, cpf, time.Since(start)), nil
+	}
+	return found, lead, err
+}
+
+// ListMembers repassa para a fonte ao vivo (importação e sincronização)
+func (v *Validator) ListMembers(ctx context.Context, condoID string) ([]domain.Lead, error) {
+	lister, ok := v.live.(domain.MemberLister)
+	if !ok {
+		return nil, errors.New("fonte de moradores não lista unidades")
+	}
+	return lister.ListMembers(ctx, condoID)
+}
+
+// lookup busca um registro ativo do CPF no condomínio pedido (ou em qualquer
+// um, na busca global) e indica se a sincronização dele está dentro do prazo.
+// Erros do diretório só são logados: a Superlógica continua respondendo.
+func (v *Validator) lookup(ctx context.Context, condoID, cpf string) (*domain.Resident, bool) {
+	residents, err := v.repo.FindResidents(ctx, cpf)
+	if err != nil {
+		log.Printf("[DIRECTORY] Erro consultando diretório: %v", err)
+		return nil, false
+	}
+
+	var match *domain.Resident
+	for i := range residents {
+		resident := residents[i]
+		if !resident.Active {
+			continue
+		}
+		if condoID != "" && condoID != "-1" && resident.CondoID != condoID {
+			continue
+		}
+		state, err := v.repo.GetSyncState(ctx, resident.CondoID)
+		if err != nil {
+			if !errors.Is(err, domain.ErrNotFound) {
+				log.Printf("[DIRECTORY] Erro consultando sincronização de %s: %v", resident.CondoID, err)
+			}
+			continue
+		}
+		if time.Since(state.SyncedAt) <= v.maxStaleness {
+			return &resident, true
+		}
+		if match == nil {
+			match = &resident
+		}
+	}
+	return match, false
+}
+
+func residentLead(resident domain.Resident, cpf string, elapsed time.Duration) *domain.Lead {
+	return &domain.Lead{
+		CPF:                   cpf,
+		CondoID:               resident.CondoID,
+		Name:                  resident.Name,
+		Email:                 resident.Email,
+		Phone:                 resident.Phone,
+		Status:                domain.StatusApproved,
+		Origin:                "resident_directory",
+		SuperlogicaFound:      true,
+		SuperlogicaResponseMs: elapsed.Milliseconds(),
+		RedeParceriasStatus:   domain.PartnerStatusPending,
+	}
+}
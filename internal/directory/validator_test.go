@@ -0,0 +1,58 @@
+package directory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/repository"
+)
+
+func TestValidator(t *testing.T) {
+	tests := []struct {
+		name       string
+		condoID    string
+		cpf        string
+		syncedAgo  time.Duration
+		liveErr    error
+		wantFound  bool
+		wantOrigin string
+		wantCalls  int
+		wantErr    bool
+	}{
+		{name: "diretório em dia", condoID: "4", cpf: "111.444.777-35", syncedAgo: time.Hour, wantFound: true, wantOrigin: "resident_directory"},
+		{name: "busca global no diretório", condoID: "-1", cpf: "11144477735", syncedAgo: time.Hour, wantFound: true, wantOrigin: "resident_directory"},
+		{name: "diretório desatualizado", condoID: "4", cpf: "11144477735", syncedAgo: 48 * time.Hour, wantFound: true, wantOrigin: "superlogica", wantCalls: 1},
+		{name: "desatualizado com a superlógica fora", condoID: "4", cpf: "11144477735", syncedAgo: 48 * time.Hour, liveErr: errors.New("timeout"), wantFound: true, wantOrigin: "resident_directory", wantCalls: 1},
+		{name: "outro condomínio", condoID: "7", cpf: "11144477735", syncedAgo: time.Hour, wantCalls: 1},
+		{name: "CPF fora do diretório", condoID: "4", cpf: "52998224725", syncedAgo: time.Hour, wantFound: true, wantOrigin: "superlogica", wantCalls: 1},
+		{name: "fora do diretório com a superlógica fora", condoID: "4", cpf: "52998224725", syncedAgo: time.Hour, liveErr: errors.New("timeout"), wantCalls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := repository.NewMemoryRepository()
+			synced := time.Now().Add(-tt.syncedAgo)
+			repo.ApplySync(ctx, domain.DirectorySync{CondoID: "4", SyncedAt: synced},
+				[]domain.Resident{{CondoID: "4", CPF: "11144477735", Name: "Ana", Active: true}}, nil)
+			live := &superlogica{err: tt.liveErr, units: map[string][]domain.Lead{"4": {
+				{CPF: "11144477735", Name: "Ana"},
+				{CPF: "52998224725", Name: "Bruno"},
+			}}}
+
+			found, lead, err := NewValidator(live, repo, 24*time.Hour).ValidateMember(ctx, tt.condoID, tt.cpf)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("erro %v, esperado erro=%v", err, tt.wantErr)
+			}
+			if found != tt.wantFound || live.calls != tt.wantCalls {
+				t.Errorf("found=%v com %d consultas, esperado %v com %d", found, live.calls, tt.wantFound, tt.wantCalls)
+			}
+			if tt.wantOrigin != "" && (lead == nil || lead.Origin != tt.wantOrigin || lead.CondoID != "4") {
+				t.Errorf("lead %+v, esperada origem %s", lead, tt.wantOrigin)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotFound é retornado pelos repositórios e integrações quando o registro não existe
+var ErrNotFound = errors.New("registro não encontrado")
+
+// ErrForbidden é retornado quando o operador não tem acesso ao tenant do registro
+var ErrForbidden = errors.New("acesso negado")
+
+// Ações registradas no log de auditoria do suporte
+const (
+	AuditActionSearchLeads = "lead.search"
+	AuditActionViewLead    = "lead.view"
+	AuditActionReregister  = "lead.reregister"
+	AuditActionGenerateSSO = "lead.generate_sso"
+	AuditActionRevoke      = "lead.revoke"
+	AuditActionRestore     = "lead.restore"
+	AuditActionListAudit   = "audit.list"
+)
+
+// LeadID é o ID do documento do lead: um por condomínio + CPF
+func LeadID(condoID, cpf string) string {
+	return fmt.Sprintf("%s_%s", condoID, cpf)
+}
+
+// LeadFilter critérios de busca de leads pelo suporte.
+// Campos vazios não filtram; From/To comparam com UpdatedAt.
+type LeadFilter struct {
+	CPF      string
+	TenantID string
+	Status   string
+	From     time.Time
+	To       time.Time
+	Limit    int
+}
+
+// LeadAttempt é uma fotografia do lead a cada passagem pelo fluxo
+// (validação, confirmação de e-mail, ação do suporte)
+type LeadAttempt struct {
+	ID         string    `json:"id" firestore:"-"`
+	LeadID     string    `json:"lead_id" firestore:"lead_id"`
+	Lead       Lead      `json:"lead" firestore:"lead"`
+	RecordedAt time.Time `json:"recorded_at" firestore:"recorded_at"`
+}
+
+// AuditEvent registra uma ação administrativa
+type AuditEvent struct {
+	ID        string                 `json:"id" firestore:"-"`
+	Actor     string                 `json:"actor" firestore:"actor"`
+	Action    string                 `json:"action" firestore:"action"`
+	TenantID  string                 `json:"tenant_id,omitempty" firestore:"tenant_id,omitempty"`
+	LeadID    string                 `json:"lead_id,omitempty" firestore:"lead_id,omitempty"`
+	CPF       string                 `json:"cpf,omitempty" firestore:"cpf,omitempty"`
+	Reason    string                 `json:"reason,omitempty" firestore:"reason,omitempty"`
+	Success   bool                   `json:"success" firestore:"success"`
+	Error     string                 `json:"error,omitempty" firestore:"error,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty" firestore:"details,omitempty"`
+	CreatedAt time.Time              `json:"created_at" firestore:"created_at"`
+	// Signature HMAC do evento, nos pedidos do titular dos dados (LGPD)
+	Signature string `json:"signature,omitempty" firestore:"signature,omitempty"`
+}
+
+// AuditFilter critérios de consulta do log de auditoria
+type AuditFilter struct {
+	Actor    string
+	TenantID string
+	LeadID   string
+	CPF      string
+	From     time.Time
+	To       time.Time
+	Limit    int
+}
+
+// LeadStore amplia o LeadRepository com as consultas usadas pelo suporte
+type LeadStore interface {
+	LeadRepository
+	GetLead(ctx context.Context, id string) (*Lead, error)
+	SearchLeads(ctx context.Context, filter LeadFilter) ([]Lead, error)
+	ListAttempts(ctx context.Context, leadID string) ([]LeadAttempt, error)
+}
+
+// AuditRepository persiste o log de auditoria das ações administrativas
+type AuditRepository interface {
+	SaveAudit(ctx context.Context, event AuditEvent) error
+	ListAudit(ctx context.Context, filter AuditFilter) ([]AuditEvent, error)
+}
+
+// LeadDetails é a visão completa de um lead para o suporte
+type LeadDetails struct {
+	Lead     *Lead         `json:"lead"`
+	Attempts []LeadAttempt `json:"attempts"`
+
+	// Situação atual na Rede Parcerias, consultada na hora
+	PartnerUser  *PartnerUser `json:"partner_user,omitempty"`
+	PartnerError string       `json:"partner_error,omitempty"`
+
+	Audit []AuditEvent `json:"audit"`
+}
+
+// AdminActionResult é o retorno das ações manuais do suporte
+type AdminActionResult struct {
+	Lead        *Lead  `json:"lead"`
+	RedirectURL string `json:"redirect_url,omitempty"`
+	Message     string `json:"message"`
+}
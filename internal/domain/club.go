@@ -0,0 +1,34 @@
+package domain
+
+import "context"
+
+// DefaultClubID identifica o clube de integrations.partner_integration
+const DefaultClubID = "default"
+
+// ScenarioClubPicker ativado em mais de um clube: o frontend exibe o seletor
+// com o acesso de cada um
+const ScenarioClubPicker = "club_picker"
+
+// Club um Clube de Benefícios e a integração que o atende
+type Club struct {
+	ID      string
+	Name    string
+	Partner PartnerService
+}
+
+// ClubAccess resultado da ativação em um clube, exibido no seletor
+type ClubAccess struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	UserID      string `json:"user_id,omitempty"`
+	RedirectURL string `json:"redirect_url,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// ClubRegistry resolve os clubes de cada condomínio
+type ClubRegistry interface {
+	// ClubsFor retorna os clubes do condomínio, o principal primeiro
+	ClubsFor(ctx context.Context, condoID string) []Club
+	// Clubs retorna todos os clubes configurados, o default primeiro
+	Clubs() []Club
+}
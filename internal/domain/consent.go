@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Finalidades de consentimento aceitas na landing page
+const (
+	// ConsentMarketing contato da VIP Lounge com ofertas e novidades
+	// (ShowMarketing1/ShowMarketing2)
+	ConsentMarketing = "marketing"
+)
+
+// Origem do registro de consentimento sem lead novo: retirada pelo titular
+const ConsentOriginWithdrawal = "consent_withdrawal"
+
+// AuditActionExportMarketing exportação dos leads que aceitaram contato
+const AuditActionExportMarketing = "marketing.export"
+
+// ConsentChoice escolha do visitante para uma finalidade, com a versão do
+// texto exibido (vazio = versão atual da configuração)
+type ConsentChoice struct {
+	Purpose string `json:"purpose"`
+	Granted bool   `json:"granted"`
+	Version string `json:"version,omitempty"`
+}
+
+// Consent situação do consentimento de uma finalidade no lead. Recusa e
+// retirada mantêm a versão e a data do último aceite; o histórico completo
+// fica nas tentativas do lead.
+type Consent struct {
+	Granted     bool      `json:"granted" firestore:"granted"`
+	Version     string    `json:"version,omitempty" firestore:"version,omitempty"`
+	GrantedAt   time.Time `json:"granted_at,omitempty" firestore:"granted_at,omitempty"`
+	WithdrawnAt time.Time `json:"withdrawn_at,omitempty" firestore:"withdrawn_at,omitempty"`
+	// Origin fluxo da última escolha (landing_page, email_confirmation,
+	// consent_withdrawal)
+	Origin string `json:"origin,omitempty" firestore:"origin,omitempty"`
+}
+
+// Record aplica a escolha feita em at
+func (c Consent) Record(choice ConsentChoice, origin string, at time.Time) Consent {
+	c.Origin = origin
+	if choice.Granted {
+		c.Granted = true
+		c.Version = choice.Version
+		c.GrantedAt = at
+		c.WithdrawnAt = time.Time{}
+	} else {
+		c.Granted = false
+		c.WithdrawnAt = at
+	}
+	return c
+}
+
+// Consented indica se o lead aceitou a finalidade e não retirou o aceite
+func (l Lead) Consented(purpose string) bool {
+	return l.Consents[purpose].Granted
+}
+
+// MergeConsents completa os consentimentos de lead com os já gravados:
+// o lead regravado sem escolha de uma finalidade mantém a anterior.
+// Usado pelos repositórios no Save; o mapa do lead é substituído, não
+// alterado.
+func MergeConsents(lead *Lead, stored map[string]Consent) {
+	if len(stored) == 0 {
+		return
+	}
+	merged := make(map[string]Consent, len(stored)+len(lead.Consents))
+	for purpose, consent := range stored {
+		merged[purpose] = consent
+	}
+	for purpose, consent := range lead.Consents {
+		if previous, ok := stored[purpose]; ok && !consent.Granted && consent.GrantedAt.IsZero() {
+			consent.Version, consent.GrantedAt = previous.Version, previous.GrantedAt
+		}
+		merged[purpose] = consent
+	}
+	lead.Consents = merged
+}
+
+// ConsentWithdrawalRequest pedido do titular para retirar consentimentos
+type ConsentWithdrawalRequest struct {
+	CPF string `json:"cpf"`
+	// Purposes finalidades retiradas (vazio = todas)
+	Purposes []string `json:"purposes,omitempty"`
+}
+
+// ConsentWithdrawalResponse resposta da retirada, a mesma com ou sem lead
+// do CPF, para não revelar quem passou pela landing page
+type ConsentWithdrawalResponse struct {
+	Message string `json:"message"`
+}
+
+// MarketingContact lead exportado para contato: só os dados necessários
+type MarketingContact struct {
+	CPF       string    `json:"cpf"`
+	CondoID   string    `json:"condo_id"`
+	Name      string    `json:"name,omitempty"`
+	Email     string    `json:"email,omitempty"`
+	Phone     string    `json:"phone,omitempty"`
+	Version   string    `json:"consent_version"`
+	GrantedAt time.Time `json:"consent_granted_at"`
+}
+
+// ConsentRepository consulta os leads pelo consentimento
+type ConsentRepository interface {
+	// ConsentingLeads leads que aceitaram a finalidade, do tenant informado
+	// (vazio = todos)
+	ConsentingLeads(ctx context.Context, purpose, tenantID string) ([]Lead, error)
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Tipos de mudança detectados entre sincronizações do diretório
+const (
+	ResidentAdded    = "added"
+	ResidentDeparted = "departed"
+	ResidentUpdated  = "updated"
+)
+
+// AuditActionDirectoryChanges consulta ao feed de mudanças do diretório
+const AuditActionDirectoryChanges = "directory.changes"
+
+// Resident é um proprietário de unidade copiado da Superlógica para o
+// diretório local. O CPF é guardado só com dígitos. Quem sai do condomínio
+// continua no diretório com Active=false.
+type Resident struct {
+	ID          string    `json:"id" firestore:"-"` // LeadID(condo, cpf)
+	CondoID     string    `json:"condo_id" firestore:"condo_id"`
+	CPF         string    `json:"cpf" firestore:"cpf"`
+	Name        string    `json:"name,omitempty" firestore:"name,omitempty"`
+	Email       string    `json:"email,omitempty" firestore:"email,omitempty"`
+	Phone       string    `json:"phone,omitempty" firestore:"phone,omitempty"`
+	Active      bool      `json:"active" firestore:"active"`
+	FirstSeenAt time.Time `json:"first_seen_at" firestore:"first_seen_at"`
+	UpdatedAt   time.Time `json:"updated_at" firestore:"updated_at"`
+	DepartedAt  time.Time `json:"departed_at,omitempty" firestore:"departed_at"`
+}
+
+// ResidentChange é uma entrada do feed de mudanças. Saídas (departed) são
+// candidatas à revogação na Rede Parcerias.
+type ResidentChange struct {
+	ID         string    `json:"id" firestore:"-"`
+	CondoID    string    `json:"condo_id" firestore:"condo_id"`
+	CPF        string    `json:"cpf" firestore:"cpf"`
+	Type       string    `json:"type" firestore:"type"`
+	Resident   Resident  `json:"resident" firestore:"resident"`
+	DetectedAt time.Time `json:"detected_at" firestore:"detected_at"`
+}
+
+// ResidentChangeFilter filtra o feed de mudanças (Since exclusivo)
+type ResidentChangeFilter struct {
+	CondoID string
+	Type    string
+	Since   time.Time
+	Limit   int
+}
+
+// DirectorySync estado da última sincronização de um condomínio
+type DirectorySync struct {
+	CondoID    string    `json:"condo_id" firestore:"condo_id"`
+	SyncedAt   time.Time `json:"synced_at" firestore:"synced_at"` // última sincronização bem-sucedida
+	AttemptAt  time.Time `json:"attempt_at" firestore:"attempt_at"`
+	Residents  int       `json:"residents" firestore:"residents"`
+	Added      int       `json:"added" firestore:"added"`
+	Departed   int       `json:"departed" firestore:"departed"`
+	Updated    int       `json:"updated" firestore:"updated"`
+	Error      string    `json:"error,omitempty" firestore:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms" firestore:"duration_ms"`
+}
+
+// DirectoryRepository persiste o diretório de moradores
+type DirectoryRepository interface {
+	// FindResidents retorna os registros do CPF em todos os condomínios
+	FindResidents(ctx context.Context, cpf string) ([]Resident, error)
+	// ListResidents retorna os registros (ativos e que saíram) do condomínio
+	ListResidents(ctx context.Context, condoID string) ([]Resident, error)
+	// ApplySync grava os moradores alterados, as mudanças e o estado da
+	// sincronização do condomínio
+	ApplySync(ctx context.Context, state DirectorySync, residents []Resident, changes []ResidentChange) error
+	// SaveSyncState grava só o estado (ex.: sincronização que falhou)
+	SaveSyncState(ctx context.Context, state DirectorySync) error
+	// GetSyncState retorna ErrNotFound se o condomínio nunca sincronizou
+	GetSyncState(ctx context.Context, condoID string) (*DirectorySync, error)
+	// ListChanges retorna o feed de mudanças, mais antigas primeiro
+	ListChanges(ctx context.Context, filter ResidentChangeFilter) ([]ResidentChange, error)
+}
@@ -0,0 +1,57 @@
+package domain
+
+import "context"
+
+// AuditActionRotateKeys recifragem dos leads pela API do suporte
+const AuditActionRotateKeys = "encryption.rotate"
+
+// SealedPII dados pessoais do lead (CPF, nome, e-mail, telefone) cifrados
+// em envelope: a chave de dados cifra os campos e a KEK do KMS cifra a
+// chave de dados
+type SealedPII struct {
+	// KeyID KEK que cifrou a chave de dados (versão no KMS)
+	KeyID   string `firestore:"key_id"`
+	DataKey []byte `firestore:"data_key"`
+	// Ciphertext nonce + AES-256-GCM dos campos, autenticado com o ID do
+	// documento (não pode ser copiado para outro lead)
+	Ciphertext []byte `firestore:"ciphertext"`
+}
+
+// DataSealer cifra em envelope outros dados pessoais gravados (respostas
+// guardadas pela idempotência, payloads de webhook), com as chaves dos leads
+type DataSealer interface {
+	// SealData cifra plaintext; aad (ex.: o ID do documento) precisa ser o
+	// mesmo em OpenData
+	SealData(ctx context.Context, plaintext, aad []byte) (*SealedPII, error)
+	OpenData(ctx context.Context, sealed *SealedPII, aad []byte) ([]byte, error)
+}
+
+// LeadSealer cifra os dados pessoais dos leads gravados (pii.Protector)
+type LeadSealer interface {
+	// HashCPF hash com chave do CPF (só dígitos): mesmo CPF, mesmo hash
+	HashCPF(ctx context.Context, cpf string) (string, error)
+	// Seal retorna o lead como é gravado: sem os dados pessoais em claro,
+	// com CPFHash e PII preenchidos
+	Seal(ctx context.Context, lead Lead) (Lead, error)
+	// Open decifra os dados pessoais de um lead lido (sem PII não faz nada)
+	Open(ctx context.Context, lead *Lead) error
+	// Current indica se o lead gravado está cifrado com a KEK primária
+	Current(ctx context.Context, lead Lead) (bool, error)
+}
+
+// KeyRotation resultado de uma recifragem dos leads
+type KeyRotation struct {
+	Scanned int `json:"scanned"`
+	// Resealed leads cifrados de novo com a KEK primária
+	Resealed int `json:"resealed"`
+	// Migrated leads em claro (gravados antes da cifragem) movidos para o
+	// documento com o hash do CPF
+	Migrated         int  `json:"migrated"`
+	AttemptsResealed int  `json:"attempts_resealed"`
+	Complete         bool `json:"complete"` // false = parou no limite; execute de novo
+}
+
+// LeadKeyRotator recifra os leads que não estão com a KEK primária
+type LeadKeyRotator interface {
+	RotateLeadKeys(ctx context.Context, limit int) (*KeyRotation, error)
+}
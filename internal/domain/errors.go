@@ -0,0 +1,167 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Categorias de falha das integrações. Os adapters retornam um
+// *IntegrationError (ou um erro que o envolve) com uma destas categorias, além
+// de ErrNotFound; o serviço e os handlers decidem por
+// errors.Is, nunca pelo texto do erro.
+var (
+	// ErrUnauthorized a integração recusou as credenciais (ou elas não estão disponíveis)
+	ErrUnauthorized = errors.New("credenciais recusadas pela integração")
+
+	// ErrRateLimited a integração limitou as requisições (HTTP 429)
+	ErrRateLimited = errors.New("limite de requisições da integração atingido")
+
+	// ErrUnavailable a integração está fora do ar, lenta ou respondeu algo inesperado
+	ErrUnavailable = errors.New("integração indisponível")
+
+	// ErrValidation os dados foram recusados; detalhes em *ValidationError
+	ErrValidation = errors.New("dados inválidos")
+
+	// ErrPartnerUserExists o clube de benefícios já tem o usuário (CPF ou
+	// e-mail cadastrado). Diferente de ErrAlreadyExists, que é conflito de
+	// chave no repositório.
+	ErrPartnerUserExists = errors.New("usuário já cadastrado no clube")
+)
+
+// IntegrationError falha de uma chamada a um sistema externo
+type IntegrationError struct {
+	// Service integração ("superlogica", "rede_parcerias" ou o nome da fonte/clube)
+	Service string
+	// Op operação (ex.: "find_user", "register_user")
+	Op string
+	// Kind categoria: ErrNotFound, ErrAlreadyExists, ErrPartnerUserExists,
+	// ErrUnauthorized, ErrRateLimited, ErrUnavailable ou ErrValidation
+	Kind error
+	// Status HTTP recebido (0 = sem resposta)
+	Status int
+	// Detail trecho da resposta, para o log
+	Detail string
+	// RetryAfter espera pedida pela integração (Retry-After), se informada
+	RetryAfter time.Duration
+	// Err causa original (rede, credencial, decodificação, *ValidationError)
+	Err error
+}
+
+func (e *IntegrationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s: %v", e.Service, e.Op, e.Kind)
+	if e.Status != 0 {
+		fmt.Fprintf(&b, " (HTTP %d)", e.Status)
+	}
+	if e.Detail != "" {
+		b.WriteString(": " + e.Detail)
+	}
+	if e.Err != nil {
+		b.WriteString(": " + e.Err.Error())
+	}
+	return b.String()
+}
+
+// Unwrap expõe a categoria e a causa para errors.Is/errors.As
+func (e *IntegrationError) Unwrap() []error {
+	errs := []error{e.Kind}
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	return errs
+}
+
+// ValidationError dados recusados, com as mensagens de cada campo
+type ValidationError struct {
+	Message string
+	// Fields mensagens por campo (pode ser vazio)
+	Fields map[string][]string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return ErrValidation.Error()
+}
+
+// Is faz errors.Is(err, ErrValidation) valer para qualquer *ValidationError
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// KindForStatus categoria de uma resposta HTTP de erro
+func KindForStatus(status int) error {
+	switch {
+	case status == 401 || status == 403:
+		return ErrUnauthorized
+	case status == 404:
+		return ErrNotFound
+	case status == 409:
+		return ErrAlreadyExists
+	case status == 400 || status == 422:
+		return ErrValidation
+	case status == 429:
+		return ErrRateLimited
+	default:
+		// 408, 5xx e qualquer status que o client não sabe tratar
+		return ErrUnavailable
+	}
+}
+
+// HTTPError classifica uma resposta de erro da integração. retryAfter é o
+// header Retry-After (vazio se ausente).
+func HTTPError(service, op string, status int, body []byte, retryAfter string) *IntegrationError {
+	detail := strings.TrimSpace(string(body))
+	if len(detail) > 512 {
+		detail = detail[:512]
+	}
+	return &IntegrationError{
+		Service:    service,
+		Op:         op,
+		Kind:       KindForStatus(status),
+		Status:     status,
+		Detail:     detail,
+		RetryAfter: parseRetryAfter(retryAfter),
+	}
+}
+
+// UnavailableError falha sem resposta utilizável (rede, timeout, resposta
+// fora do formato)
+func UnavailableError(service, op string, err error) *IntegrationError {
+	return &IntegrationError{Service: service, Op: op, Kind: ErrUnavailable, Err: err}
+}
+
+// CredentialError credencial da integração indisponível
+func CredentialError(service, op string, err error) *IntegrationError {
+	return &IntegrationError{Service: service, Op: op, Kind: ErrUnauthorized, Err: err}
+}
+
+// RetryAfterOf espera pedida pela integração na cadeia de err (0 = não informada)
+func RetryAfterOf(err error) time.Duration {
+	var integration *IntegrationError
+	if errors.As(err, &integration) {
+		return integration.RetryAfter
+	}
+	return 0
+}
+
+// parseRetryAfter aceita segundos ou data HTTP
+func parseRetryAfter(header string) time.Duration {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := time.Parse(time.RFC1123, header); err == nil {
+		if wait := time.Until(at); wait > 0 {
+			return wait.Round(time.Second)
+		}
+	}
+	return 0
+}
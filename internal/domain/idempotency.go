@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Situação de uma chave de idempotência
+const (
+	IdempotencyInProgress = "in_progress"
+	IdempotencyCompleted  = "completed"
+)
+
+// IdempotencyRecord primeira resposta de uma requisição enviada com o header
+// Idempotency-Key. Repetições com a mesma chave recebem essa resposta em vez
+// de executar a ativação de novo.
+type IdempotencyRecord struct {
+	// ID hash da rota e da chave (a chave do cliente não é gravada)
+	ID string `json:"id" firestore:"-"`
+	// Fingerprint hash do corpo: a mesma chave com outro corpo é recusada
+	Fingerprint    string `json:"fingerprint" firestore:"fingerprint"`
+	Status         string `json:"status" firestore:"status"`
+	ResponseStatus int    `json:"response_status,omitempty" firestore:"response_status,omitempty"`
+	ContentType    string `json:"content_type,omitempty" firestore:"content_type,omitempty"`
+	// ResponseBody e SetCookies resposta repetida no replay (o cookie é o da
+	// sessão "lembrar de mim"); com a cifragem dos leads ligada ficam só em
+	// SealedResponse. O registro some com a janela (TTL de expires_at), por
+	// isso não entra no expurgo nem nos pedidos do titular.
+	ResponseBody   []byte     `json:"response_body,omitempty" firestore:"response_body,omitempty"`
+	SetCookies     []string   `json:"set_cookies,omitempty" firestore:"set_cookies,omitempty"`
+	SealedResponse *SealedPII `json:"-" firestore:"sealed_response,omitempty"`
+	CreatedAt      time.Time  `json:"created_at" firestore:"created_at"`
+	// ExpiresAt fim da janela de replay (no Firestore, campo da política de TTL)
+	ExpiresAt time.Time `json:"expires_at" firestore:"expires_at"`
+}
+
+// Expired indica que a janela de replay passou e a chave pode ser reusada
+func (r *IdempotencyRecord) Expired(now time.Time) bool {
+	return !r.ExpiresAt.IsZero() && now.After(r.ExpiresAt)
+}
+
+// IdempotencyRepository guarda as chaves de idempotência. Compartilhado
+// entre as instâncias, para uma repetição que cai em outra instância também
+// ser reconhecida.
+type IdempotencyRepository interface {
+	// CreateIdempotencyRecord reserva a chave; ErrAlreadyExists se já existe
+	CreateIdempotencyRecord(ctx context.Context, record IdempotencyRecord) error
+	// SaveIdempotencyRecord grava a resposta da chave reservada
+	SaveIdempotencyRecord(ctx context.Context, record IdempotencyRecord) error
+	// ReplaceExpiredIdempotencyRecord reserva de novo uma chave cuja janela
+	// encerrou antes de record.CreatedAt, conferindo no mesmo passo da
+	// gravação; ErrAlreadyExists se outra requisição a reservou antes
+	ReplaceExpiredIdempotencyRecord(ctx context.Context, record IdempotencyRecord) error
+	// GetIdempotencyRecord busca a chave; ErrNotFound se não existe
+	GetIdempotencyRecord(ctx context.Context, id string) (*IdempotencyRecord, error)
+	// DeleteIdempotencyRecord libera a chave (ex.: falha que pode ser repetida)
+	DeleteIdempotencyRecord(ctx context.Context, id string) error
+}
@@ -0,0 +1,26 @@
+package domain
+
+import "context"
+
+// Ações da pré-inscrição em lote (importação de um condomínio)
+const (
+	ImportActionRegistered        = "registered"         // cadastrado na Rede Parcerias
+	ImportActionAlreadyRegistered = "already_registered" // já tinha acesso, nada a fazer
+	ImportActionRevoked           = "revoked"            // não é mais condômino, acesso revogado
+	ImportActionSkipped           = "skipped"            // não encontrado em nenhum sistema
+	ImportActionFailed            = "failed"             // erro; a linha pode ser retomada
+)
+
+// PreRegistration é o resultado da árvore de decisão para um CPF importado
+type PreRegistration struct {
+	Lead     Lead   `json:"lead"`
+	Scenario string `json:"scenario"`
+	Action   string `json:"action"`
+	DryRun   bool   `json:"dry_run"`
+}
+
+// MemberLister é implementado pelas fontes de moradores capazes de listar
+// todas as unidades de um condomínio
+type MemberLister interface {
+	ListMembers(ctx context.Context, condoID string) ([]Lead, error)
+}
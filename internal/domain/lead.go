@@ -2,9 +2,13 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+// ErrIntegrationDisabled é retornado pelas integrações desligadas na config
+var ErrIntegrationDisabled = errors.New("integração desabilitada")
+
 // Status do Lead
 const (
 	StatusPending  = "PENDING"
@@ -57,6 +61,8 @@ type Lead struct {
 	// Status
 	Status string `json:"status" firestore:"status"`
 	Origin string `json:"origin" firestore:"origin"`
+	// Scenario cenário da última passagem pelo fluxo (regras de retenção)
+	Scenario string `json:"scenario,omitempty" firestore:"scenario,omitempty"`
 
 	// Superlogica Metrics
 	SuperlogicaFound      bool  `json:"superlogica_found" firestore:"superlogica_found"`
@@ -69,12 +75,21 @@ type Lead struct {
 	RedeParceriasError      string `json:"rede_parcerias_error" firestore:"rede_parcerias_error,omitempty"`
 	RedeParceriasResponseMs int64  `json:"rede_parcerias_response_ms" firestore:"rede_parcerias_response_ms"`
 
+	// Consents consentimentos por finalidade (ConsentMarketing, ...)
+	Consents map[string]Consent `json:"consents,omitempty" firestore:"consents,omitempty"`
+
 	// Auditoria
 	CreatedAt time.Time `json:"created_at" firestore:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" firestore:"updated_at"`
 
 	// Metadata
 	Metadata map[string]interface{} `json:"metadata,omitempty" firestore:"metadata,omitempty"`
+
+	// Cifragem em repouso, preenchida pelo repositório (nunca sai na API):
+	// hash do CPF, que também compõe o ID do documento, e dados pessoais
+	// cifrados
+	CPFHash string     `json:"-" firestore:"cpf_hash,omitempty"`
+	PII     *SealedPII `json:"-" firestore:"pii,omitempty"`
 }
 
 // PartnerUser representa um usuário na Rede Parcerias
@@ -97,12 +112,20 @@ type SSOToken struct {
 type ValidationRequest struct {
 	CPF     string `json:"cpf"`
 	CondoID string `json:"condo_id"`
+	// Consents escolhas de consentimento feitas na landing page
+	Consents []ConsentChoice `json:"consents,omitempty"`
 }
 
 // EmailConfirmationRequest é o payload para confirmar o e-mail (segunda etapa)
 type EmailConfirmationRequest struct {
 	CPF   string `json:"cpf"`
 	Email string `json:"email"`
+	// CondoID define os clubes do condomínio (preenchido pelo tenant do host)
+	CondoID string `json:"condo_id,omitempty"`
+	// ClubID ativa apenas um dos clubes do condomínio (vazio = todos)
+	ClubID string `json:"club_id,omitempty"`
+	// Consents escolhas de consentimento feitas na confirmação
+	Consents []ConsentChoice `json:"consents,omitempty"`
 }
 
 // ValidationResponse é a resposta completa para o Frontend
@@ -122,10 +145,19 @@ type ValidationResponse struct {
 	// Segurança adicional - email mascarado para validação em duas etapas
 	EmailHint string `json:"email_hint,omitempty"`
 
-	// SSO para redirecionamento (se aplicável)
-	SSOToken    string `json:"sso_token,omitempty"`
+	// Acesso ao clube (se aplicável): SSOPath + handle de uso único; o token
+	// do clube fica no servidor
 	RedirectURL string `json:"redirect_url,omitempty"`
 
+	// Seletor de clubes (cenário club_picker): acesso em cada clube do condomínio
+	Clubs []ClubAccess `json:"clubs,omitempty"`
+
+	// Session cookie "lembrar de mim" da ativação: vai no Set-Cookie, nunca
+	// no JSON
+	Session string `json:"-"`
+	// RememberMe sessão "lembrar de mim" emitida (o cookie é httpOnly)
+	RememberMe bool `json:"remember_me,omitempty"`
+
 	// Flags para o frontend
 	ShowActivateButton bool `json:"show_activate_button,omitempty"`
 	ShowMarketing1     bool `json:"show_marketing_1,omitempty"`
@@ -134,23 +166,30 @@ type ValidationResponse struct {
 
 // LeadRepository define como salvamos os leads (Porta de Saída)
 type LeadRepository interface {
+	// Save grava o lead e uma tentativa. Consentimentos já gravados que o
+	// lead não traz são mantidos (MergeConsents).
 	Save(ctx context.Context, lead Lead) error
 }
 
-// BenefValidator define o contrato para validar um CPF na Superlógica
+// BenefValidator define o contrato para validar um CPF na Superlógica.
+// CPF não encontrado é (false, nil, nil); um erro significa que a consulta
+// falhou e nada se sabe sobre o CPF.
 type BenefValidator interface {
 	ValidateMember(ctx context.Context, condoID string, cpf string) (bool, *Lead, error)
 }
 
-// PartnerService define o contrato para o Clube de Benefícios
+// PartnerService define o contrato para o Clube de Benefícios.
+// Falhas vêm como erros tipados (ver errors.go).
 type PartnerService interface {
-	// Verificar se usuário existe
+	// Verificar se usuário existe (nil, nil = não cadastrado)
 	FindUserByCPF(ctx context.Context, cpf string) (*PartnerUser, error)
 
-	// Cadastrar novo usuário (com authorized:true)
+	// Cadastrar novo usuário (com authorized:true). Se o clube informar que
+	// o usuário já existe, retorna ErrPartnerUserExists com o lead marcado como
+	// cadastrado.
 	RegisterUser(ctx context.Context, lead *Lead) error
 
-	// Deletar/desativar usuário
+	// Deletar/desativar usuário (já removido não é erro)
 	DeleteUser(ctx context.Context, userID string) error
 
 	// Gerar token SSO para redirecionamento
@@ -0,0 +1,31 @@
+package domain
+
+import "regexp"
+
+var (
+	// digitRun sequência de dígitos com a pontuação do CPF em texto livre
+	digitRun = regexp.MustCompile(`\d[\d.-]*\d`)
+	// cpfFormat CPF com ou sem máscara
+	cpfFormat = regexp.MustCompile(`^(\d{11}|\d{3}\.\d{3}\.\d{3}-\d{2})$`)
+	nonDigit  = regexp.MustCompile(`\D`)
+)
+
+// MaskCPF CPF para log: só os 3 primeiros e os 2 últimos dígitos
+func MaskCPF(cpf string) string {
+	digits := nonDigit.ReplaceAllString(cpf, "")
+	if len(digits) < 6 {
+		return "***"
+	}
+	return digits[:3] + ".***.***-" + digits[len(digits)-2:]
+}
+
+// MaskCPFs mascara todo CPF em um texto livre (URLs do log de acesso,
+// respostas de APIs). Sequências de outro tamanho, como datas, ficam.
+func MaskCPFs(s string) string {
+	return digitRun.ReplaceAllStringFunc(s, func(run string) string {
+		if !cpfFormat.MatchString(run) {
+			return run
+		}
+		return MaskCPF(run)
+	})
+}
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Eventos do ciclo de vida do lead enviados aos webhooks de saída
+const (
+	EventLeadValidated             = "lead.validated"
+	EventLeadActivated             = "lead.activated"
+	EventLeadRevoked               = "lead.revoked"
+	EventPartnerRegistrationFailed = "partner.registration_failed"
+	EventConsentWithdrawn          = "lead.consent_withdrawn"
+)
+
+// KnownEvents lista os eventos que um endpoint pode assinar
+var KnownEvents = []string{
+	EventLeadValidated,
+	EventLeadActivated,
+	EventLeadRevoked,
+	EventPartnerRegistrationFailed,
+	EventConsentWithdrawn,
+}
+
+// Situação de uma entrega de webhook
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusDelivered = "delivered"
+	DeliveryStatusFailed    = "failed" // tentativas esgotadas
+)
+
+// AuditActionRedeliver reenvio manual de uma entrega
+const AuditActionRedeliver = "webhook.redeliver"
+
+// OutboundEvent é o corpo enviado aos endpoints
+type OutboundEvent struct {
+	ID         string                 `json:"id"`
+	Type       string                 `json:"type"`
+	TenantID   string                 `json:"tenant_id"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	Data       map[string]interface{} `json:"data"`
+}
+
+// EventPublisher publica eventos para os webhooks de saída. Publish não
+// bloqueia o fluxo de quem chama: a entrega acontece em background.
+type EventPublisher interface {
+	Publish(ctx context.Context, event OutboundEvent)
+}
+
+// WebhookDelivery registro de uma entrega a um endpoint (log de entregas)
+type WebhookDelivery struct {
+	ID             string    `json:"id" firestore:"-"`
+	EventID        string    `json:"event_id" firestore:"event_id"`
+	EventType      string    `json:"event_type" firestore:"event_type"`
+	TenantID       string    `json:"tenant_id" firestore:"tenant_id"`
+	EndpointID     string    `json:"endpoint_id" firestore:"endpoint_id"`
+	URL            string    `json:"url" firestore:"url"`
+	Payload        string    `json:"payload" firestore:"payload"`
+	Status         string    `json:"status" firestore:"status"`
+	Attempts       int       `json:"attempts" firestore:"attempts"`
+	LastStatusCode int       `json:"last_status_code,omitempty" firestore:"last_status_code"`
+	LastError      string    `json:"last_error,omitempty" firestore:"last_error,omitempty"`
+	NextAttemptAt  time.Time `json:"next_attempt_at,omitempty" firestore:"next_attempt_at"`
+	CreatedAt      time.Time `json:"created_at" firestore:"created_at"`
+	DeliveredAt    time.Time `json:"delivered_at,omitempty" firestore:"delivered_at"`
+	// CPFs do titular do evento, para os pedidos do titular; gravados como
+	// hash e o payload só em SealedPayload com a cifragem dos leads ligada
+	CPFs          []string   `json:"-" firestore:"cpfs,omitempty"`
+	SealedPayload *SealedPII `json:"-" firestore:"sealed_payload,omitempty"`
+}
+
+// DeliveryFilter filtra o log de entregas. DueBefore seleciona as pendentes
+// cuja próxima tentativa já venceu.
+type DeliveryFilter struct {
+	TenantID   string
+	EventType  string
+	EndpointID string
+	Status     string
+	From       time.Time
+	To         time.Time
+	DueBefore  time.Time
+	Limit      int
+}
+
+// DeliveryRepository persiste o log de entregas
+type DeliveryRepository interface {
+	SaveDelivery(ctx context.Context, delivery WebhookDelivery) error
+	GetDelivery(ctx context.Context, id string) (*WebhookDelivery, error)
+	// ListDeliveries retorna as mais recentes primeiro (com DueBefore, as
+	// mais antigas, na ordem de reenvio)
+	ListDeliveries(ctx context.Context, filter DeliveryFilter) ([]WebhookDelivery, error)
+}
@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// Pedidos do titular dos dados (LGPD, art. 18) registrados na auditoria
+const (
+	AuditActionExportSubject = "subject.export"
+	AuditActionEraseSubject  = "subject.erase"
+)
+
+// PseudonymPrefix marca o valor que substitui o CPF nos registros anonimizados
+const PseudonymPrefix = "anon-"
+
+// Pseudonym pseudônimo do CPF (só dígitos) nos registros anonimizados: HMAC
+// com o segredo da LGPD, o mesmo a cada cálculo, para os registros de um
+// titular continuarem ligados entre si
+func Pseudonym(secret, cpf string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("cpf\n" + cpf))
+	return PseudonymPrefix + hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// IsPseudonym indica se o "CPF" de um registro já é um pseudônimo
+func IsPseudonym(cpf string) bool {
+	return strings.HasPrefix(cpf, PseudonymPrefix)
+}
+
+// SubjectData é tudo o que a plataforma guarda sobre um CPF
+type SubjectData struct {
+	CPF        string    `json:"cpf"`
+	ExportedAt time.Time `json:"exported_at"`
+
+	Leads    []Lead        `json:"leads"`
+	Attempts []LeadAttempt `json:"attempts"`
+	Audit    []AuditEvent  `json:"audit"`
+
+	// Diretório de moradores (cópia da Superlógica)
+	Residents        []Resident       `json:"residents"`
+	DirectoryChanges []ResidentChange `json:"directory_changes"`
+
+	Sessions []Session `json:"sessions"`
+
+	// Notificações da Superlógica e eventos enviados aos webhooks de saída
+	WebhookEvents     []WebhookEvent    `json:"webhook_events"`
+	WebhookDeliveries []WebhookDelivery `json:"webhook_deliveries"`
+
+	// Cadastro no clube default, consultado na hora
+	PartnerUser  *PartnerUser `json:"partner_user,omitempty"`
+	PartnerError string       `json:"partner_error,omitempty"`
+	// Clubs cadastro em cada Clube de Benefícios configurado
+	Clubs []SubjectClub `json:"clubs"`
+}
+
+// SubjectClub cadastro do CPF em um Clube de Benefícios
+type SubjectClub struct {
+	ClubID string       `json:"club_id"`
+	User   *PartnerUser `json:"user,omitempty"`
+	// Deleted cadastro removido na eliminação (cascade_partner)
+	Deleted bool   `json:"deleted,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// SubjectErasure é o resultado da eliminação dos dados de um CPF
+type SubjectErasure struct {
+	// Pseudonym substitui o CPF nos leads e na auditoria mantidos anonimizados
+	Pseudonym string `json:"pseudonym"`
+
+	LeadsAnonymized  int `json:"leads_anonymized"`
+	AttemptsDeleted  int `json:"attempts_deleted"`
+	AuditAnonymized  int `json:"audit_anonymized"`
+	ResidentsDeleted int `json:"residents_deleted"`
+	ChangesDeleted   int `json:"changes_deleted"`
+	SessionsDeleted  int `json:"sessions_deleted"`
+	// Notificações recebidas e entregas de webhook com o CPF no payload
+	WebhookEventsDeleted int `json:"webhook_events_deleted"`
+	DeliveriesDeleted    int `json:"deliveries_deleted"`
+
+	// Cadastro removido em algum clube (cascade_partner); Clubs traz o
+	// resultado de cada um
+	PartnerUserDeleted bool          `json:"partner_user_deleted"`
+	PartnerError       string        `json:"partner_error,omitempty"`
+	Clubs              []SubjectClub `json:"clubs,omitempty"`
+
+	// Retained dados que não são indexados por CPF e saem só pelo prazo de
+	// validade (respostas idempotentes, acessos SSO)
+	Retained []string `json:"retained,omitempty"`
+}
+
+// SubjectRepository localiza e elimina os dados de um CPF em todas as coleções
+type SubjectRepository interface {
+	// ExportSubject reúne leads, tentativas, auditoria, diretório, sessões e
+	// webhooks do CPF (com ou sem máscara)
+	ExportSubject(ctx context.Context, cpf string) (*SubjectData, error)
+	// EraseSubject apaga as tentativas, o diretório, as sessões e os
+	// webhooks do CPF e mantém leads e auditoria com o pseudônimo no lugar do CPF, sem nome,
+	// e-mail e telefone
+	EraseSubject(ctx context.Context, cpf, pseudonym string) (*SubjectErasure, error)
+}
+
+// AnonymizeLead retira do lead tudo que identifica o morador; status,
+// origem e métricas ficam para os relatórios
+func AnonymizeLead(lead Lead, pseudonym string) Lead {
+	lead.CPF = pseudonym
+	lead.Name = ""
+	lead.Email = ""
+	lead.Phone = ""
+	lead.RedeParceriasUserID = ""
+	lead.RedeParceriasError = ""
+	lead.Metadata = nil
+	// O pseudônimo não identifica o morador: o lead anonimizado fica em claro
+	lead.CPFHash = ""
+	lead.PII = nil
+	return lead
+}
+
+// AnonymizeAuditEvent troca o CPF e o lead do evento pelo pseudônimo
+func AnonymizeAuditEvent(event AuditEvent, pseudonym string) AuditEvent {
+	event.CPF = pseudonym
+	if i := strings.LastIndex(event.LeadID, "_"); i >= 0 {
+		event.LeadID = LeadID(event.LeadID[:i], pseudonym)
+	}
+	return event
+}
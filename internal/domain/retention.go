@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Ações das regras de retenção
+const (
+	// RetentionDelete apaga o lead e o histórico de tentativas
+	RetentionDelete = "delete"
+	// RetentionPseudonymize mantém o lead para os relatórios, com o
+	// pseudônimo no lugar do CPF e sem nome, e-mail e telefone; o histórico
+	// de tentativas é apagado
+	RetentionPseudonymize = "pseudonymize"
+)
+
+// AuditActionRetentionRun expurgo disparado pela API do suporte
+const AuditActionRetentionRun = "retention.run"
+
+// RetentionCriteria seleciona os leads de uma regra; campos vazios não filtram
+type RetentionCriteria struct {
+	Status   string
+	Scenario string
+	Origin   string
+}
+
+// Matches indica se o lead atende os critérios
+func (c RetentionCriteria) Matches(lead Lead) bool {
+	return (c.Status == "" || lead.Status == c.Status) &&
+		(c.Scenario == "" || lead.Scenario == c.Scenario) &&
+		(c.Origin == "" || lead.Origin == c.Origin)
+}
+
+// RetentionReport resultado de uma execução do expurgo
+type RetentionReport struct {
+	ID         string    `json:"id" firestore:"-"`
+	StartedAt  time.Time `json:"started_at" firestore:"started_at"`
+	FinishedAt time.Time `json:"finished_at" firestore:"finished_at"`
+	// Trigger "schedule" ou o operador que pediu a execução
+	Trigger string `json:"trigger" firestore:"trigger"`
+	// DryRun só contou o que seria expurgado
+	DryRun bool                  `json:"dry_run" firestore:"dry_run"`
+	Rules  []RetentionRuleResult `json:"rules" firestore:"rules"`
+	// Webhooks expurgo das notificações recebidas e das entregas de webhook
+	// (nil com privacy.retention.webhook_days = 0)
+	Webhooks *WebhookRetentionResult `json:"webhooks,omitempty" firestore:"webhooks,omitempty"`
+}
+
+// WebhookRetentionResult notificações e entregas apagadas pelo prazo
+type WebhookRetentionResult struct {
+	Days              int       `json:"days" firestore:"days"`
+	Cutoff            time.Time `json:"cutoff" firestore:"cutoff"`
+	EventsDeleted     int       `json:"events_deleted" firestore:"events_deleted"`
+	DeliveriesDeleted int       `json:"deliveries_deleted" firestore:"deliveries_deleted"`
+	Complete          bool      `json:"complete" firestore:"complete"`
+	Error             string    `json:"error,omitempty" firestore:"error,omitempty"`
+}
+
+// RetentionRuleResult resultado de uma regra
+type RetentionRuleResult struct {
+	Rule   string    `json:"rule" firestore:"rule"`
+	Key    string    `json:"key" firestore:"key"`
+	Action string    `json:"action" firestore:"action"`
+	Days   int       `json:"days" firestore:"days"`
+	Cutoff time.Time `json:"cutoff" firestore:"cutoff"`
+
+	Scanned         int `json:"scanned" firestore:"scanned"`
+	Deleted         int `json:"deleted" firestore:"deleted"`
+	Pseudonymized   int `json:"pseudonymized" firestore:"pseudonymized"`
+	AttemptsDeleted int `json:"attempts_deleted" firestore:"attempts_deleted"`
+	// ByCondo leads expurgados por condomínio
+	ByCondo map[string]int `json:"by_condo,omitempty" firestore:"by_condo,omitempty"`
+
+	// Through updated_at do último lead processado: a próxima execução
+	// continua daí, sem reler os leads já pseudonimizados
+	Through time.Time `json:"through,omitempty" firestore:"through,omitempty"`
+	// Complete a regra chegou ao corte; false = parou no limite por execução
+	Complete bool   `json:"complete" firestore:"complete"`
+	Error    string `json:"error,omitempty" firestore:"error,omitempty"`
+}
+
+// RetentionRepository dá ao expurgo acesso aos leads e aos relatórios
+type RetentionRepository interface {
+	// StaleLeads leads dos critérios com updated_at depois de after e antes
+	// de before, mais antigos primeiro
+	StaleLeads(ctx context.Context, criteria RetentionCriteria, after, before time.Time, limit int) ([]Lead, error)
+	// PurgeLead apaga o lead e as tentativas; com pseudonym grava no lugar o
+	// lead anonimizado (AnonymizeLead). Retorna as tentativas apagadas.
+	PurgeLead(ctx context.Context, lead Lead, pseudonym string) (int, error)
+
+	// PurgeWebhooks apaga até limit notificações recebidas e entregas de
+	// webhook anteriores a before (com dryRun só conta)
+	PurgeWebhooks(ctx context.Context, before time.Time, limit int, dryRun bool) (events, deliveries int, err error)
+
+	SaveRetentionReport(ctx context.Context, report RetentionReport) error
+	// ListRetentionReports relatórios mais recentes primeiro
+	ListRetentionReports(ctx context.Context, limit int) ([]RetentionReport, error)
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Session sessão "lembrar de mim" de um morador já ativado. O cookie
+// assinado carrega só o identificador; CPF, condomínio e cadastro no clube
+// ficam no servidor, para a sessão poder ser revogada.
+type Session struct {
+	// ID hash do identificador do cookie (o identificador não é gravado)
+	ID      string `json:"id" firestore:"-"`
+	CPF     string `json:"cpf" firestore:"cpf"`
+	CondoID string `json:"condo_id" firestore:"condo_id"`
+	// LeadCondoID condomínio do lead, de onde vêm os clubes: na busca
+	// global é o condomínio real do morador, não o do host
+	LeadCondoID string `json:"lead_condo_id,omitempty" firestore:"lead_condo_id,omitempty"`
+	// Clubs clubes ativados e o ID do morador em cada um
+	Clubs     []SessionClub `json:"clubs" firestore:"clubs"`
+	CreatedAt time.Time     `json:"created_at" firestore:"created_at"`
+	// ValidatedAt última confirmação do CPF na Superlógica
+	ValidatedAt time.Time `json:"validated_at" firestore:"validated_at"`
+	// ExpiresAt fim da sessão (no Firestore, campo da política de TTL)
+	ExpiresAt time.Time `json:"expires_at" firestore:"expires_at"`
+}
+
+// SessionClub cadastro do morador em um clube
+type SessionClub struct {
+	ClubID        string `json:"club_id" firestore:"club_id"`
+	PartnerUserID string `json:"partner_user_id" firestore:"partner_user_id"`
+}
+
+// SessionRepository guarda as sessões "lembrar de mim"
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session Session) error
+	// GetSession busca a sessão; ErrNotFound se não existe ou foi revogada
+	GetSession(ctx context.Context, id string) (*Session, error)
+	// SaveSession atualiza a sessão (ex.: nova validação na Superlógica)
+	SaveSession(ctx context.Context, session Session) error
+	// DeleteSession revoga a sessão (já removida não é erro)
+	DeleteSession(ctx context.Context, id string) error
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// SSOPath rota que troca o handle de acesso pelo redirecionamento ao clube
+const SSOPath = "/v1/sso/"
+
+// SSOGrant acesso gerado pelo clube, guardado no servidor até o morador ser
+// redirecionado. O frontend recebe só o handle (SSOPath + handle); a URL do
+// clube, que carrega o token, nunca sai na resposta JSON.
+type SSOGrant struct {
+	// ID hash do handle (o handle em si não é gravado)
+	ID       string `json:"id" firestore:"-"`
+	Redirect string `json:"-" firestore:"redirect"`
+	ClubID   string `json:"club_id" firestore:"club_id"`
+	// CondoID condomínio da ativação
+	CondoID   string    `json:"condo_id,omitempty" firestore:"condo_id,omitempty"`
+	CreatedAt time.Time `json:"created_at" firestore:"created_at"`
+	// ExpiresAt fim da validade (no Firestore, campo da política de TTL)
+	ExpiresAt time.Time `json:"expires_at" firestore:"expires_at"`
+}
+
+// SSOGrantRepository guarda os acessos até o redirecionamento. Compartilhado
+// entre as instâncias: a ativação e o redirecionamento podem cair em
+// instâncias diferentes.
+type SSOGrantRepository interface {
+	CreateSSOGrant(ctx context.Context, grant SSOGrant) error
+	// ConsumeSSOGrant busca e remove o acesso numa única operação: uma
+	// segunda chamada com o mesmo ID recebe ErrNotFound
+	ConsumeSSOGrant(ctx context.Context, id string) (*SSOGrant, error)
+}
+
+// RedactURL só o esquema e o host da URL, para log: o token de SSO pode vir
+// na query ou no caminho
+func RedactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "(url inválida)"
+	}
+	return u.Scheme + "://" + u.Host + "/..."
+}
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrAlreadyExists é retornado ao criar um registro cuja chave já existe
+// (no repositório ou um 409 da integração; usuário já cadastrado no clube é
+// ErrPartnerUserExists)
+var ErrAlreadyExists = errors.New("registro já existe")
+
+// Situação de um evento recebido por webhook
+const (
+	WebhookStatusReceived  = "received"
+	WebhookStatusProcessed = "processed"
+	WebhookStatusIgnored   = "ignored"
+	WebhookStatusFailed    = "failed"
+)
+
+// Mudanças de morador extraídas de uma notificação da Superlógica
+const (
+	ResidentMoveIn  = "move_in"
+	ResidentMoveOut = "move_out"
+)
+
+// WebhookEvent é uma notificação recebida, guardada antes de ser processada.
+// O ID é a chave de idempotência: uma entrega repetida não gera nova ação.
+type WebhookEvent struct {
+	ID          string    `json:"id" firestore:"-"`
+	Source      string    `json:"source" firestore:"source"` // "superlogica"
+	Type        string    `json:"type" firestore:"type"`     // evento informado pela origem
+	TenantID    string    `json:"tenant_id,omitempty" firestore:"tenant_id,omitempty"`
+	Payload     string    `json:"payload" firestore:"payload"` // corpo original, para replay
+	Status      string    `json:"status" firestore:"status"`
+	Actions     []string  `json:"actions,omitempty" firestore:"actions,omitempty"` // ex. "move_out:123***:revoked"
+	Error       string    `json:"error,omitempty" firestore:"error,omitempty"`
+	Attempts    int       `json:"attempts" firestore:"attempts"`
+	ReceivedAt  time.Time `json:"received_at" firestore:"received_at"`
+	ProcessedAt time.Time `json:"processed_at,omitempty" firestore:"processed_at"`
+	// CPFs citados na notificação (proprietário atual e anterior), como em
+	// WebhookDelivery
+	CPFs          []string   `json:"-" firestore:"cpfs,omitempty"`
+	SealedPayload *SealedPII `json:"-" firestore:"sealed_payload,omitempty"`
+}
+
+// WebhookEventFilter filtra eventos para consulta e replay
+type WebhookEventFilter struct {
+	Source string
+	Status string
+	From   time.Time
+	To     time.Time
+	Limit  int
+}
+
+// WebhookEventRepository persiste os eventos recebidos
+type WebhookEventRepository interface {
+	// CreateWebhookEvent grava um evento novo; ErrAlreadyExists se o ID já existe
+	CreateWebhookEvent(ctx context.Context, event WebhookEvent) error
+	UpdateWebhookEvent(ctx context.Context, event WebhookEvent) error
+	GetWebhookEvent(ctx context.Context, id string) (*WebhookEvent, error)
+	// ListWebhookEvents retorna os eventos mais antigos primeiro (ordem de replay)
+	ListWebhookEvents(ctx context.Context, filter WebhookEventFilter) ([]WebhookEvent, error)
+}
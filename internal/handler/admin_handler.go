@@ -0,0 +1,518 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/viplounge/platform/internal/auth"
+	"github.com/viplounge/platform/internal/domain"
+	customMiddleware "github.com/viplounge/platform/internal/middleware"
+	"github.com/viplounge/platform/internal/service"
+)
+
+// adminActionRequest é o corpo das ações manuais do suporte
+type adminActionRequest struct {
+	Reason string `json:"reason"`
+}
+
+// EnableAdmin liga o grupo /admin/v1
+func (h *Handler) EnableAdmin(admin *service.AdminService) {
+	h.admin = admin
+}
+
+// adminRoutes monta as rotas do suporte. A autenticação já aconteceu no
+// router principal; aqui só se verifica o papel.
+func (h *Handler) adminRoutes() http.Handler {
+	r := chi.NewRouter()
+
+	// Consulta e geração de acesso: qualquer operador
+	r.Group(func(r chi.Router) {
+		r.Use(customMiddleware.RequireRole(auth.RoleSupport, auth.RoleTenantAdmin, auth.RolePlatformAdmin))
+		r.Get("/leads", h.handleAdminSearchLeads)
+		r.Post("/leads/search", h.handleAdminSearchLeads)
+		r.Get("/leads/{id}", h.handleAdminLeadDetails)
+		r.Post("/leads/{id}/register", h.idempotent(h.handleAdminAction(h.admin.Reregister)))
+		r.Post("/leads/{id}/sso", h.idempotent(h.handleAdminAction(h.admin.GenerateSSO)))
+	})
+
+	// Revogar/restaurar e auditoria: administradora ou plataforma
+	r.Group(func(r chi.Router) {
+		r.Use(customMiddleware.RequireRole(auth.RoleTenantAdmin, auth.RolePlatformAdmin))
+		r.Post("/leads/{id}/revoke", h.handleAdminAction(h.admin.Revoke))
+		r.Post("/leads/{id}/restore", h.handleAdminAction(h.admin.Restore))
+		r.Get("/audit", h.handleAdminAudit)
+		r.Post("/audit/search", h.handleAdminAudit)
+		if h.admin.DirectoryEnabled() {
+			r.Get("/directory/changes", h.handleAdminDirectoryChanges)
+		}
+		if h.admin.ConsentsEnabled() {
+			r.Get("/marketing/contacts", h.handleAdminMarketingContacts)
+		}
+		if h.admin.DeliveriesEnabled() {
+			r.Get("/webhooks/deliveries", h.handleAdminDeliveries)
+			r.Get("/webhooks/deliveries/{id}", h.handleAdminDelivery)
+			r.Post("/webhooks/deliveries/{id}/redeliver", h.handleAdminRedeliver)
+		}
+	})
+
+	// Pedidos do titular dos dados (LGPD): abrangem todos os condomínios
+	if h.admin.PrivacyEnabled() {
+		r.Group(func(r chi.Router) {
+			r.Use(customMiddleware.RequireRole(auth.RolePlatformAdmin))
+			r.Post("/subjects/export", h.handleAdminExportSubject)
+			r.Post("/subjects/erase", h.handleAdminEraseSubject)
+			if h.admin.RetentionEnabled() {
+				r.Get("/retention/reports", h.handleAdminRetentionReports)
+				r.Post("/retention/run", h.handleAdminRetentionRun)
+			}
+		})
+	}
+
+	// Recifragem dos leads com a KEK primária: só a plataforma
+	if h.admin.EncryptionEnabled() {
+		r.Group(func(r chi.Router) {
+			r.Use(customMiddleware.RequireRole(auth.RolePlatformAdmin))
+			r.Post("/encryption/rotate", h.handleAdminRotateKeys)
+		})
+	}
+
+	return r
+}
+
+// GET /admin/v1/leads?tenant=&status=&from=&to=&limit=
+// POST /admin/v1/leads/search {"cpf", "tenant", "status", "from", "to", "limit"}
+func (h *Handler) handleAdminSearchLeads(w http.ResponseWriter, r *http.Request) {
+	q, ok := searchQuery(w, r)
+	if !ok {
+		return
+	}
+	from, to, limit, err := parseRange(q)
+	if err != nil {
+		writeErrorCode(w, r, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	filter := domain.LeadFilter{
+		CPF:      q.Get("cpf"),
+		TenantID: q.Get("tenant"),
+		Status:   q.Get("status"),
+		From:     from,
+		To:       to,
+		Limit:    limit,
+	}
+	leads, err := h.admin.SearchLeads(r.Context(), auth.FromContext(r.Context()), filter)
+	if err != nil {
+		writeAdminError(w, r, err)
+		return
+	}
+	if leads == nil {
+		leads = []domain.Lead{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"leads": leads})
+}
+
+// GET /admin/v1/leads/{id}
+func (h *Handler) handleAdminLeadDetails(w http.ResponseWriter, r *http.Request) {
+	details, err := h.admin.LeadDetails(r.Context(), auth.FromContext(r.Context()), chi.URLParam(r, "id"))
+	if err != nil {
+		writeAdminError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, details)
+}
+
+// POST /admin/v1/leads/{id}/{register,sso,revoke,restore}
+func (h *Handler) handleAdminAction(action func(ctx context.Context, p *auth.Principal, leadID, reason string) (*domain.AdminActionResult, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req adminActionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			invalidRequestBody(w, r)
+			return
+		}
+		if req.Reason == "" {
+			writeErrorCode(w, r, http.StatusBadRequest, codeInvalidRequest, "Reason is required")
+			return
+		}
+
+		result, err := action(r.Context(), auth.FromContext(r.Context()), chi.URLParam(r, "id"), req.Reason)
+		if err != nil {
+			writeAdminError(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// GET /admin/v1/audit?actor=&tenant=&lead_id=&from=&to=&limit=
+// POST /admin/v1/audit/search {"cpf", "actor", "tenant", "lead_id", "from", "to", "limit"}
+func (h *Handler) handleAdminAudit(w http.ResponseWriter, r *http.Request) {
+	q, ok := searchQuery(w, r)
+	if !ok {
+		return
+	}
+	from, to, limit, err := parseRange(q)
+	if err != nil {
+		writeErrorCode(w, r, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	filter := domain.AuditFilter{
+		Actor:    q.Get("actor"),
+		TenantID: q.Get("tenant"),
+		LeadID:   q.Get("lead_id"),
+		CPF:      q.Get("cpf"),
+		From:     from,
+		To:       to,
+		Limit:    limit,
+	}
+	events, err := h.admin.ListAudit(r.Context(), auth.FromContext(r.Context()), filter)
+	if err != nil {
+		writeAdminError(w, r, err)
+		return
+	}
+	if events == nil {
+		events = []domain.AuditEvent{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"events": events})
+}
+
+// GET /admin/v1/directory/changes?tenant=&type=&since=&limit=
+func (h *Handler) handleAdminDirectoryChanges(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	since, err := parseDate(q.Get("since"), false)
+	if err != nil {
+		writeErrorCode(w, r, http.StatusBadRequest, codeInvalidRequest, "invalid 'since' date")
+		return
+	}
+	_, _, limit, err := parseRangeQuery(r)
+	if err != nil {
+		writeErrorCode(w, r, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+	switch q.Get("type") {
+	case "", domain.ResidentAdded, domain.ResidentDeparted, domain.ResidentUpdated:
+	default:
+		writeErrorCode(w, r, http.StatusBadRequest, codeInvalidRequest, "invalid 'type' (added, departed, updated)")
+		return
+	}
+
+	filter := domain.ResidentChangeFilter{
+		CondoID: q.Get("tenant"),
+		Type:    q.Get("type"),
+		Since:   since,
+		Limit:   limit,
+	}
+	changes, err := h.admin.ListDirectoryChanges(r.Context(), auth.FromContext(r.Context()), filter)
+	if err != nil {
+		writeAdminError(w, r, err)
+		return
+	}
+	if changes == nil {
+		changes = []domain.ResidentChange{}
+	}
+	// "next" permite ao consumidor continuar de onde parou
+	next := since
+	if len(changes) > 0 {
+		next = changes[len(changes)-1].DetectedAt
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"changes": changes, "next_since": next})
+}
+
+// GET /admin/v1/webhooks/deliveries?tenant=&event=&endpoint=&status=&from=&to=&limit=
+func (h *Handler) handleAdminDeliveries(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	from, to, limit, err := parseRangeQuery(r)
+	if err != nil {
+		writeErrorCode(w, r, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	filter := domain.DeliveryFilter{
+		TenantID:   q.Get("tenant"),
+		EventType:  q.Get("event"),
+		EndpointID: q.Get("endpoint"),
+		Status:     q.Get("status"),
+		From:       from,
+		To:         to,
+		Limit:      limit,
+	}
+	deliveries, err := h.admin.ListDeliveries(r.Context(), auth.FromContext(r.Context()), filter)
+	if err != nil {
+		writeAdminError(w, r, err)
+		return
+	}
+	if deliveries == nil {
+		deliveries = []domain.WebhookDelivery{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"deliveries": deliveries})
+}
+
+// GET /admin/v1/webhooks/deliveries/{id}
+func (h *Handler) handleAdminDelivery(w http.ResponseWriter, r *http.Request) {
+	delivery, err := h.admin.GetDelivery(r.Context(), auth.FromContext(r.Context()), chi.URLParam(r, "id"))
+	if err != nil {
+		writeAdminError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, delivery)
+}
+
+// POST /admin/v1/webhooks/deliveries/{id}/redeliver
+func (h *Handler) handleAdminRedeliver(w http.ResponseWriter, r *http.Request) {
+	var req adminActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidRequestBody(w, r)
+		return
+	}
+	if req.Reason == "" {
+		writeErrorCode(w, r, http.StatusBadRequest, codeInvalidRequest, "Reason is required")
+		return
+	}
+
+	delivery, err := h.admin.Redeliver(r.Context(), auth.FromContext(r.Context()), chi.URLParam(r, "id"), req.Reason)
+	if err != nil {
+		writeAdminError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, delivery)
+}
+
+// subjectRequest corpo dos pedidos do titular: o CPF vai no corpo, fora da
+// URL gravada no log de acesso
+type subjectRequest struct {
+	CPF    string `json:"cpf"`
+	Reason string `json:"reason"`
+	// CascadePartner remove também o cadastro nos clubes (só na eliminação)
+	CascadePartner bool `json:"cascade_partner"`
+}
+
+// POST /admin/v1/subjects/export {"cpf", "reason"}
+func (h *Handler) handleAdminExportSubject(w http.ResponseWriter, r *http.Request) {
+	var req subjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidRequestBody(w, r)
+		return
+	}
+	if req.Reason == "" {
+		writeErrorCode(w, r, http.StatusBadRequest, codeInvalidRequest, "Reason is required")
+		return
+	}
+
+	data, err := h.admin.ExportSubject(r.Context(), auth.FromContext(r.Context()), req.CPF, req.Reason)
+	if err != nil {
+		writeAdminError(w, r, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Disposition", `attachment; filename="titular-`+data.ExportedAt.Format("20060102T150405")+`.json"`)
+	writeJSON(w, http.StatusOK, data)
+}
+
+// POST /admin/v1/subjects/erase {"cpf", "reason", "cascade_partner"}
+func (h *Handler) handleAdminEraseSubject(w http.ResponseWriter, r *http.Request) {
+	var req subjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidRequestBody(w, r)
+		return
+	}
+	if req.Reason == "" {
+		writeErrorCode(w, r, http.StatusBadRequest, codeInvalidRequest, "Reason is required")
+		return
+	}
+
+	erasure, err := h.admin.EraseSubject(r.Context(), auth.FromContext(r.Context()), req.CPF, req.Reason, req.CascadePartner)
+	if err != nil {
+		writeAdminError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, erasure)
+}
+
+// retentionRunRequest corpo da execução manual do expurgo
+type retentionRunRequest struct {
+	Reason string `json:"reason"`
+	// DryRun só conta o que seria expurgado
+	DryRun bool `json:"dry_run"`
+}
+
+// GET /admin/v1/retention/reports?limit=
+func (h *Handler) handleAdminRetentionReports(w http.ResponseWriter, r *http.Request) {
+	_, _, limit, err := parseRangeQuery(r)
+	if err != nil {
+		writeErrorCode(w, r, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+	reports, err := h.admin.ListRetentionReports(r.Context(), auth.FromContext(r.Context()), limit)
+	if err != nil {
+		writeAdminError(w, r, err)
+		return
+	}
+	if reports == nil {
+		reports = []domain.RetentionReport{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"reports": reports})
+}
+
+// POST /admin/v1/retention/run
+func (h *Handler) handleAdminRetentionRun(w http.ResponseWriter, r *http.Request) {
+	var req retentionRunRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidRequestBody(w, r)
+		return
+	}
+	if req.Reason == "" {
+		writeErrorCode(w, r, http.StatusBadRequest, codeInvalidRequest, "Reason is required")
+		return
+	}
+
+	report, err := h.admin.RunRetention(r.Context(), auth.FromContext(r.Context()), req.Reason, req.DryRun)
+	if err != nil {
+		writeAdminError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// rotateKeysRequest corpo da recifragem dos leads
+type rotateKeysRequest struct {
+	Reason string `json:"reason"`
+	// Limit documentos regravados nesta execução (0 = padrão)
+	Limit int `json:"limit"`
+}
+
+// POST /admin/v1/encryption/rotate
+func (h *Handler) handleAdminRotateKeys(w http.ResponseWriter, r *http.Request) {
+	var req rotateKeysRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidRequestBody(w, r)
+		return
+	}
+	if req.Reason == "" {
+		writeErrorCode(w, r, http.StatusBadRequest, codeInvalidRequest, "Reason is required")
+		return
+	}
+	if req.Limit < 0 {
+		writeErrorCode(w, r, http.StatusBadRequest, codeInvalidRequest, "invalid 'limit'")
+		return
+	}
+
+	rotation, err := h.admin.RotateLeadKeys(r.Context(), auth.FromContext(r.Context()), req.Reason, req.Limit)
+	if err != nil {
+		writeAdminError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rotation)
+}
+
+// GET /admin/v1/marketing/contacts?purpose=&tenant=&format=csv
+func (h *Handler) handleAdminMarketingContacts(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	purpose := q.Get("purpose")
+	if purpose == "" {
+		purpose = domain.ConsentMarketing
+	}
+	format := q.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		writeErrorCode(w, r, http.StatusBadRequest, codeInvalidRequest, "invalid 'format' (json, csv)")
+		return
+	}
+
+	contacts, err := h.admin.ExportMarketingContacts(r.Context(), auth.FromContext(r.Context()), purpose, q.Get("tenant"))
+	if err != nil {
+		writeAdminError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	if format != "csv" {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"contacts": contacts})
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="contatos-`+purpose+`.csv"`)
+	out := csv.NewWriter(w)
+	out.Write([]string{"cpf", "condo_id", "name", "email", "phone", "consent_version", "consent_granted_at"})
+	for _, c := range contacts {
+		out.Write([]string{c.CPF, c.CondoID, c.Name, c.Email, c.Phone, c.Version, c.GrantedAt.UTC().Format(time.RFC3339)})
+	}
+	out.Flush()
+}
+
+// parseRangeQuery lê from/to (RFC 3339 ou AAAA-MM-DD) e limit da query
+func parseRangeQuery(r *http.Request) (from, to time.Time, limit int, err error) {
+	return parseRange(r.URL.Query())
+}
+
+// parseRange lê from, to e limit dos filtros de uma busca
+func parseRange(q url.Values) (from, to time.Time, limit int, err error) {
+	if from, err = parseDate(q.Get("from"), false); err != nil {
+		return from, to, 0, errors.New("invalid 'from' date")
+	}
+	if to, err = parseDate(q.Get("to"), true); err != nil {
+		return from, to, 0, errors.New("invalid 'to' date")
+	}
+	if v := q.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 0 {
+			return from, to, 0, errors.New("invalid 'limit'")
+		}
+	}
+	return from, to, limit, nil
+}
+
+// searchQuery filtros de uma busca do suporte: da query string no GET ou do
+// corpo JSON no POST .../search. O CPF só é aceito no corpo, para não ficar
+// na URL gravada no log de acesso.
+func searchQuery(w http.ResponseWriter, r *http.Request) (url.Values, bool) {
+	q := r.URL.Query()
+	if r.Method != http.MethodPost {
+		if q.Has("cpf") {
+			writeErrorCode(w, r, http.StatusBadRequest, codeInvalidRequest, "Send 'cpf' in the body of POST "+r.URL.Path+"/search")
+			return nil, false
+		}
+		return q, true
+	}
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		invalidRequestBody(w, r)
+		return nil, false
+	}
+	for k, v := range body {
+		if v != nil {
+			q.Set(k, fmt.Sprint(v))
+		}
+	}
+	return q, true
+}
+
+// parseDate aceita data sem hora; em "to" ela cobre o dia inteiro
+func parseDate(v string, endOfDay bool) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
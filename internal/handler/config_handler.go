@@ -3,8 +3,6 @@ package handler
 import (
 	"encoding/json"
 	"net/http"
-
-	"github.com/viplounge/platform/internal/config"
 )
 
 // ConfigResponse estrutura de resposta do config endpoint
@@ -51,11 +49,16 @@ type ConfigResponse struct {
 		ShowSideImage        bool `json:"show_side_image"`
 		AutoCloseModalSeconds int `json:"auto_close_modal_seconds"`
 	} `json:"behavior"`
+
+	// Consent versão atual do texto de cada finalidade de consentimento
+	Consent struct {
+		Versions map[string]string `json:"versions"`
+	} `json:"consent"`
 }
 
 // handleConfig retorna a configuração para o frontend
 func (h *Handler) handleConfig(w http.ResponseWriter, r *http.Request) {
-	cfg := config.Get()
+	cfg := h.config(r)
 
 	resp := ConfigResponse{
 		Branding: struct {
@@ -136,6 +139,7 @@ func (h *Handler) handleConfig(w http.ResponseWriter, r *http.Request) {
 			AutoCloseModalSeconds: cfg.Behavior.AutoCloseModalSeconds,
 		},
 	}
+	resp.Consent.Versions = cfg.Privacy.Consent.Versions
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/viplounge/platform/internal/config"
+	"github.com/viplounge/platform/internal/domain"
+)
+
+// consentWithdrawnMessage resposta da retirada, com ou sem lead do CPF
+const consentWithdrawnMessage = "Consentimento retirado. Você não receberá mais contatos para as finalidades informadas."
+
+// checkConsents confere as finalidades com privacy.consent.versions
+func checkConsents(problems fieldErrors, cfg *config.Config, choices []domain.ConsentChoice) {
+	for i, choice := range choices {
+		if _, ok := cfg.Privacy.Consent.Versions[choice.Purpose]; !ok {
+			problems.add(fmt.Sprintf("consents[%d].purpose", i), "Finalidade desconhecida.")
+		}
+	}
+}
+
+// POST /v1/consent/withdraw
+func (h *Handler) handleConsentWithdraw(w http.ResponseWriter, r *http.Request) {
+	cfg := h.config(r)
+
+	var req domain.ConsentWithdrawalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidRequestBody(w, r)
+		return
+	}
+
+	problems := fieldErrors{}
+	if !cpfRegex.MatchString(req.CPF) {
+		problems.add("cpf", "CPF inválido.")
+	}
+	for i, purpose := range req.Purposes {
+		if _, ok := cfg.Privacy.Consent.Versions[purpose]; !ok {
+			problems.add(fmt.Sprintf("purposes[%d]", i), "Finalidade desconhecida.")
+		}
+	}
+	if err := problems.err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if _, err := h.svc.WithdrawConsent(r.Context(), req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, domain.ConsentWithdrawalResponse{Message: consentWithdrawnMessage})
+}
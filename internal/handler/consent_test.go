@@ -0,0 +1,107 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/viplounge/platform/internal/domain"
+)
+
+func grantMarketing() []domain.ConsentChoice {
+	return []domain.ConsentChoice{{Purpose: domain.ConsentMarketing, Granted: true}}
+}
+
+func (e *scenarioEnv) marketingContacts(t *testing.T) []domain.MarketingContact {
+	t.Helper()
+	resp, body := e.adminDo(t, "GET", "/admin/v1/marketing/contacts?purpose=marketing", nil)
+	var out struct {
+		Contacts []domain.MarketingContact `json:"contacts"`
+	}
+	if resp.StatusCode != http.StatusOK || json.Unmarshal(body, &out) != nil {
+		t.Fatalf("exportação: status %d: %s", resp.StatusCode, body)
+	}
+	return out.Contacts
+}
+
+// TestMarketingConsent grava o aceite do visitante e do morador (só depois
+// de confirmar o e-mail), exporta apenas quem aceitou e respeita a retirada
+func TestMarketingConsent(t *testing.T) {
+	env := newScenarioEnv(t)
+
+	env.post(t, "/v1/validate", domain.ValidationRequest{CPF: cpfUnknown, Consents: grantMarketing()})
+	visitor := env.lead(t, cpfUnknown)
+	if !visitor.Consented(domain.ConsentMarketing) || visitor.Consents[domain.ConsentMarketing].Version != "v1" {
+		t.Fatalf("aceite do visitante não gravado: %+v", visitor.Consents)
+	}
+
+	// Qualquer um digita o CPF do morador: o aceite espera o e-mail
+	env.post(t, "/v1/validate", domain.ValidationRequest{CPF: cpfMember, Consents: grantMarketing()})
+	if env.lead(t, cpfMember).Consented(domain.ConsentMarketing) {
+		t.Fatalf("aceite do morador gravado antes da confirmação do e-mail")
+	}
+	env.post(t, "/v1/confirm-email", domain.EmailConfirmationRequest{CPF: cpfMember, Email: memberEmail, Consents: grantMarketing()})
+	env.post(t, "/v1/confirm-email", domain.EmailConfirmationRequest{CPF: cpfNewResident, Email: newEmail})
+
+	// Nova passagem sem escolha mantém o aceite
+	env.post(t, "/v1/validate", domain.ValidationRequest{CPF: cpfUnknown})
+	if !env.lead(t, cpfUnknown).Consented(domain.ConsentMarketing) {
+		t.Fatalf("aceite perdido ao regravar o lead")
+	}
+
+	contacts := env.marketingContacts(t)
+	byCPF := map[string]domain.MarketingContact{}
+	for _, contact := range contacts {
+		byCPF[contact.CPF] = contact
+	}
+	if len(contacts) != 2 || byCPF[cpfMember].Email != memberEmail || byCPF[cpfUnknown].CPF == "" {
+		t.Fatalf("exportação: %+v", contacts)
+	}
+
+	resp, body := env.adminDo(t, "GET", "/admin/v1/marketing/contacts?format=csv", nil)
+	rows, err := csv.NewReader(strings.NewReader(string(body))).ReadAll()
+	if resp.StatusCode != http.StatusOK || err != nil || len(rows) != 3 || rows[0][3] != "email" {
+		t.Fatalf("exportação CSV: status %d (%v): %s", resp.StatusCode, err, body)
+	}
+
+	resp, body = env.do(t, "POST", "/v1/consent/withdraw", domain.ConsentWithdrawalRequest{CPF: cpfMember})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("retirada: status %d: %s", resp.StatusCode, body)
+	}
+	// A validação e a confirmação gravam leads separados; a retirada vale
+	// para o que tinha o aceite
+	leads, err := env.repo.SearchLeads(context.Background(), domain.LeadFilter{CPF: cpfMember})
+	if err != nil {
+		t.Fatalf("SearchLeads: %v", err)
+	}
+	withdrawn := 0
+	for _, lead := range leads {
+		consent, ok := lead.Consents[domain.ConsentMarketing]
+		if consent.Granted {
+			t.Errorf("lead %s continua com o aceite", lead.CondoID)
+		}
+		if ok && !consent.WithdrawnAt.IsZero() && !consent.GrantedAt.IsZero() && consent.Origin == domain.ConsentOriginWithdrawal {
+			withdrawn++
+		}
+	}
+	if withdrawn != 1 {
+		t.Errorf("retirada não gravada: %+v", leads)
+	}
+	if contacts := env.marketingContacts(t); len(contacts) != 1 || contacts[0].CPF != cpfUnknown {
+		t.Errorf("exportação após a retirada: %+v", contacts)
+	}
+
+	// CPF sem lead recebe a mesma resposta
+	_, unknown := env.do(t, "POST", "/v1/consent/withdraw", domain.ConsentWithdrawalRequest{CPF: "39053344705"})
+	if string(unknown) != string(body) {
+		t.Errorf("retirada de CPF sem lead respondeu %s, esperado %s", unknown, body)
+	}
+
+	resp, _ = env.do(t, "POST", "/v1/consent/withdraw", domain.ConsentWithdrawalRequest{CPF: cpfMember, Purposes: []string{"sorteios"}})
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("finalidade desconhecida: status %d, esperado 422", resp.StatusCode)
+	}
+}
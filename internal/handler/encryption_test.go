@@ -0,0 +1,228 @@
+package handler_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/pii"
+	"github.com/viplounge/platform/internal/secrets"
+)
+
+// writeKeyFile grava o arquivo de chaves do KMS local; a chave de cada ID é
+// sempre a mesma, para regravar o arquivo com uma KEK nova
+func writeKeyFile(t *testing.T, path, primary string, ids ...string) {
+	t.Helper()
+	keys := map[string]string{}
+	for _, id := range ids {
+		key := sha256.Sum256([]byte("kek-" + id))
+		keys[id] = base64.StdEncoding.EncodeToString(key[:])
+	}
+	data, _ := json.Marshal(map[string]interface{}{"primary": primary, "keys": keys})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("arquivo de chaves: %v", err)
+	}
+}
+
+func newProtector(t *testing.T, keyFile string) *pii.Protector {
+	t.Helper()
+	kms, err := pii.NewLocalKeyFile(keyFile)
+	if err != nil {
+		t.Fatalf("KMS local: %v", err)
+	}
+	return pii.NewProtector(kms, secrets.Static("chave-do-hash-do-cpf"), time.Hour)
+}
+
+// rawLead lê o lead como está gravado, sem decifrar
+func (e *scenarioEnv) rawLead(t *testing.T, protector *pii.Protector, condoID, cpf string) (*domain.Lead, []domain.LeadAttempt) {
+	t.Helper()
+	ctx := context.Background()
+	hash, err := protector.HashCPF(ctx, cpf)
+	if err != nil {
+		t.Fatalf("HashCPF: %v", err)
+	}
+	e.repo.EnableEncryption(nil)
+	defer e.repo.EnableEncryption(protector)
+
+	lead, err := e.repo.GetLead(ctx, domain.LeadID(condoID, hash))
+	if err != nil {
+		t.Fatalf("lead gravado de %s: %v", cpf, err)
+	}
+	attempts, err := e.repo.ListAttempts(ctx, domain.LeadID(condoID, hash))
+	if err != nil {
+		t.Fatalf("tentativas gravadas de %s: %v", cpf, err)
+	}
+	return lead, attempts
+}
+
+// TestLeadPIIEncryptedAtRest ativa um morador e confere que o lead gravado
+// não tem CPF, nome, e-mail nem telefone em claro, enquanto as leituras e a
+// API do suporte (pelo ID condo_cpf) continuam vendo os dados
+func TestLeadPIIEncryptedAtRest(t *testing.T) {
+	env := newScenarioEnv(t)
+	env.post(t, "/v1/confirm-email", domain.EmailConfirmationRequest{CPF: cpfNewResident, Email: newEmail})
+
+	lead := env.lead(t, cpfNewResident)
+	if lead == nil || lead.Email != newEmail || lead.CPF != cpfNewResident {
+		t.Fatalf("lead decifrado: %+v", lead)
+	}
+
+	protector := newProtector(t, env.keyFile)
+	raw, attempts := env.rawLead(t, protector, lead.CondoID, cpfNewResident)
+	if raw.CPF != "" || raw.Name != "" || raw.Email != "" || raw.Phone != "" {
+		t.Errorf("dados pessoais em claro no lead gravado: %+v", raw)
+	}
+	if raw.PII == nil || raw.PII.KeyID != "2026-01" || raw.CPFHash == "" {
+		t.Errorf("lead gravado sem cifragem: %+v", raw)
+	}
+	if len(attempts) == 0 {
+		t.Fatalf("nenhuma tentativa gravada")
+	}
+	for _, attempt := range attempts {
+		if attempt.Lead.CPF != "" || attempt.Lead.Email != "" || attempt.Lead.PII == nil {
+			t.Errorf("tentativa %s em claro: %+v", attempt.ID, attempt.Lead)
+		}
+	}
+
+	resp, body := env.adminDo(t, "GET", "/admin/v1/leads/"+domain.LeadID(lead.CondoID, cpfNewResident), nil)
+	var details domain.LeadDetails
+	if resp.StatusCode != http.StatusOK || json.Unmarshal(body, &details) != nil {
+		t.Fatalf("detalhes do lead: status %d: %s", resp.StatusCode, body)
+	}
+	if details.Lead.Email != newEmail || len(details.Attempts) != len(attempts) || details.Attempts[0].Lead.Email != newEmail {
+		t.Errorf("detalhes do lead: %+v", details)
+	}
+}
+
+// TestKeyRotationReseals migra um lead gravado antes da cifragem e, depois
+// de trocar a KEK primária, recifra tudo até a KEK antiga poder sair do
+// arquivo de chaves
+func TestKeyRotationReseals(t *testing.T) {
+	env := newScenarioEnv(t)
+	ctx := context.Background()
+	env.post(t, "/v1/confirm-email", domain.EmailConfirmationRequest{CPF: cpfNewResident, Email: newEmail})
+
+	// Lead da época sem cifragem, no ID condo_cpf
+	legacy := domain.Lead{CPF: "222.333.444-05", CondoID: "4", Email: "antigo@example.com", Status: domain.StatusApproved, UpdatedAt: time.Now()}
+	env.repo.EnableEncryption(nil)
+	if err := env.repo.Save(ctx, legacy); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	writeKeyFile(t, env.keyFile, "2026-10", "2026-01", "2026-10")
+	env.repo.EnableEncryption(newProtector(t, env.keyFile))
+
+	rotate := func(limit int) domain.KeyRotation {
+		t.Helper()
+		resp, body := env.adminDo(t, "POST", "/admin/v1/encryption/rotate", map[string]interface{}{"reason": "rotação anual", "limit": limit})
+		var rotation domain.KeyRotation
+		if resp.StatusCode != http.StatusOK || json.Unmarshal(body, &rotation) != nil {
+			t.Fatalf("rotate: status %d: %s", resp.StatusCode, body)
+		}
+		return rotation
+	}
+
+	// O lead migrado leva junto as tentativas
+	if partial := rotate(1); partial.Complete || partial.Resealed+partial.Migrated != 1 {
+		t.Errorf("recifragem com limite 1: %+v", partial)
+	}
+	full := rotate(0)
+	if !full.Complete || full.Migrated+full.Resealed != 1 || full.AttemptsResealed == 0 {
+		t.Errorf("recifragem: %+v", full)
+	}
+	if again := rotate(0); !again.Complete || again.Resealed+again.Migrated+again.AttemptsResealed != 0 {
+		t.Errorf("segunda recifragem regravou documentos: %+v", again)
+	}
+
+	// Só a KEK nova: tudo continua legível
+	writeKeyFile(t, env.keyFile, "2026-10", "2026-10")
+	protector := newProtector(t, env.keyFile)
+	env.repo.EnableEncryption(protector)
+
+	migrated := env.lead(t, legacy.CPF)
+	if migrated == nil || migrated.Email != legacy.Email || migrated.CPF != legacy.CPF {
+		t.Fatalf("lead migrado: %+v", migrated)
+	}
+	if _, err := env.repo.GetLead(ctx, domain.LeadID("4", legacy.CPF)); err != nil {
+		t.Errorf("lead migrado pelo ID condo_cpf: %v", err)
+	}
+	raw, attempts := env.rawLead(t, protector, "4", legacy.CPF)
+	if raw.PII == nil || raw.PII.KeyID != "2026-10" || len(attempts) != 1 || attempts[0].Lead.Email != "" {
+		t.Errorf("lead migrado gravado: %+v (%d tentativas)", raw, len(attempts))
+	}
+	if lead := env.lead(t, cpfNewResident); lead == nil || lead.Email != newEmail {
+		t.Errorf("lead recifrado: %+v", lead)
+	}
+
+	events, err := env.repo.ListAudit(ctx, domain.AuditFilter{})
+	if err != nil {
+		t.Fatalf("ListAudit: %v", err)
+	}
+	rotations := 0
+	for _, event := range events {
+		if event.Action == domain.AuditActionRotateKeys {
+			rotations++
+		}
+	}
+	if rotations != 3 {
+		t.Errorf("esperadas 3 recifragens na auditoria, encontradas %d", rotations)
+	}
+}
+
+// TestLegacyLeadMigration o lead gravado em claro no ID condo_cpf só é
+// achado pelo CPF com legacy_lookup até a recifragem movê-lo para o
+// condo_<hash>; depois disso a busca em claro pode ser desligada
+func TestLegacyLeadMigration(t *testing.T) {
+	env := newScenarioEnv(t)
+	ctx := context.Background()
+	protector := newProtector(t, env.keyFile)
+
+	legacy := domain.Lead{CPF: "222.333.444-05", CondoID: "4", Email: "antigo@example.com", Status: domain.StatusApproved, UpdatedAt: time.Now()}
+	env.repo.EnableEncryption(nil)
+	if err := env.repo.Save(ctx, legacy); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	env.repo.EnableEncryption(protector)
+
+	env.repo.SetLegacyLookup(false)
+	if lead := env.lead(t, legacy.CPF); lead != nil {
+		t.Errorf("lead em claro achado sem legacy_lookup: %+v", lead)
+	}
+	env.repo.SetLegacyLookup(true)
+	if lead := env.lead(t, legacy.CPF); lead == nil || lead.Email != legacy.Email {
+		t.Fatalf("lead em claro com legacy_lookup: %+v", lead)
+	}
+
+	resp, body := env.adminDo(t, "POST", "/admin/v1/encryption/rotate", map[string]interface{}{"reason": "migração dos leads em claro"})
+	var rotation domain.KeyRotation
+	if resp.StatusCode != http.StatusOK || json.Unmarshal(body, &rotation) != nil {
+		t.Fatalf("rotate: status %d: %s", resp.StatusCode, body)
+	}
+	if !rotation.Complete || rotation.Migrated != 1 {
+		t.Errorf("recifragem: %+v", rotation)
+	}
+
+	// O documento condo_cpf saiu; o lead está cifrado no condo_<hash>
+	raw, _ := env.rawLead(t, protector, "4", legacy.CPF)
+	if raw.CPF != "" || raw.Email != "" || raw.PII == nil {
+		t.Errorf("lead migrado em claro: %+v", raw)
+	}
+	env.repo.EnableEncryption(nil)
+	_, err := env.repo.GetLead(ctx, domain.LeadID("4", legacy.CPF))
+	env.repo.EnableEncryption(protector)
+	if !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("documento condo_cpf depois da migração: %v", err)
+	}
+
+	env.repo.SetLegacyLookup(false)
+	if lead := env.lead(t, legacy.CPF); lead == nil || lead.Email != legacy.Email || lead.CPF != legacy.CPF {
+		t.Errorf("lead migrado sem legacy_lookup: %+v", lead)
+	}
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/viplounge/platform/internal/domain"
+)
+
+// ErrorResponse corpo JSON de toda resposta de erro da API
+type ErrorResponse struct {
+	Error ErrorDetail `json:"error"`
+}
+
+type ErrorDetail struct {
+	// Code identificador estável para o frontend decidir o que exibir
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Fields mensagens por campo, nos erros de validação
+	Fields map[string][]string `json:"fields,omitempty"`
+	// RequestID o mesmo do header X-Request-Id e do log
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Códigos das falhas detectadas no próprio handler
+const (
+	codeInvalidRequest = "INVALID_REQUEST"
+	codeUnauthorized   = "UNAUTHORIZED"
+)
+
+// errorKind status HTTP, código e mensagem pública de uma categoria de erro
+type errorKind struct {
+	target  error
+	status  int
+	code    string
+	message string
+}
+
+// errorKinds na ordem de precedência: um erro que envolve mais de uma
+// categoria fica com a primeira
+var errorKinds = []errorKind{
+	{domain.ErrValidation, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Dados inválidos."},
+	{domain.ErrNotFound, http.StatusNotFound, "NOT_FOUND", "Registro não encontrado."},
+	{domain.ErrAlreadyExists, http.StatusConflict, "ALREADY_EXISTS", "Registro já existe."},
+	{domain.ErrPartnerUserExists, http.StatusConflict, "PARTNER_USER_EXISTS", "Usuário já cadastrado no Clube de Benefícios."},
+	{domain.ErrForbidden, http.StatusForbidden, "FORBIDDEN", "Acesso negado."},
+	{domain.ErrRateLimited, http.StatusTooManyRequests, "RATE_LIMITED", "Muitas requisições no momento. Tente novamente em instantes."},
+	// Credencial nossa recusada pela integração: falha do gateway, não do cliente
+	{domain.ErrUnauthorized, http.StatusBadGateway, "UPSTREAM_UNAUTHORIZED", "Serviço temporariamente indisponível. Tente novamente em instantes."},
+	{domain.ErrUnavailable, http.StatusServiceUnavailable, "UNAVAILABLE", "Serviço temporariamente indisponível. Tente novamente em instantes."},
+	{domain.ErrIntegrationDisabled, http.StatusServiceUnavailable, "INTEGRATION_DISABLED", "Integração desabilitada."},
+}
+
+var internalErrorKind = errorKind{status: http.StatusInternalServerError, code: "INTERNAL", message: "Erro interno. Tente novamente."}
+
+// errorCodes todos os códigos que a API pode responder (enum da especificação)
+func errorCodes() []string {
+	codes := []string{codeInvalidRequest, codeUnauthorized, codeIdempotencyInProgress, codeIdempotencyKeyReused, codeSessionInvalid}
+	for _, kind := range errorKinds {
+		codes = append(codes, kind.code)
+	}
+	return append(codes, internalErrorKind.code)
+}
+
+func classifyError(err error) errorKind {
+	for _, kind := range errorKinds {
+		if errors.Is(err, kind.target) {
+			return kind
+		}
+	}
+	return internalErrorKind
+}
+
+// writeError responde o erro com a mensagem pública da categoria; o detalhe
+// fica só no log
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	writeErrorResponse(w, r, err, false)
+}
+
+// writeAdminError responde o erro com o detalhe completo (API do suporte)
+func writeAdminError(w http.ResponseWriter, r *http.Request, err error) {
+	writeErrorResponse(w, r, err, true)
+}
+
+// writeErrorCode responde uma falha detectada no próprio handler (corpo
+// ilegível, parâmetro inválido, credencial recusada)
+func writeErrorCode(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	writeJSON(w, status, ErrorResponse{Error: ErrorDetail{
+		Code:      code,
+		Message:   message,
+		RequestID: middleware.GetReqID(r.Context()),
+	}})
+}
+
+// invalidRequestBody corpo que não é o JSON esperado
+func invalidRequestBody(w http.ResponseWriter, r *http.Request) {
+	writeErrorCode(w, r, http.StatusBadRequest, codeInvalidRequest, "Corpo da requisição inválido.")
+}
+
+// fieldErrors acumula as mensagens por campo de uma requisição
+type fieldErrors map[string][]string
+
+func (f fieldErrors) add(field, message string) {
+	f[field] = append(f[field], message)
+}
+
+// err nil quando não há problemas; senão um *domain.ValidationError
+func (f fieldErrors) err() error {
+	if len(f) == 0 {
+		return nil
+	}
+	return &domain.ValidationError{Message: "Verifique os dados informados.", Fields: f}
+}
+
+func writeErrorResponse(w http.ResponseWriter, r *http.Request, err error, detailed bool) {
+	kind := classifyError(err)
+	requestID := middleware.GetReqID(r.Context())
+	if kind.status >= 500 {
+		log.Printf("[HTTP] [%s] Erro %d (%s): %v", requestID, kind.status, kind.code, err)
+	}
+
+	detail := ErrorDetail{Code: kind.code, Message: kind.message, RequestID: requestID}
+	var validation *domain.ValidationError
+	if errors.As(err, &validation) {
+		detail.Fields = validation.Fields
+		if validation.Message != "" {
+			detail.Message = validation.Message
+		}
+	}
+	if detailed {
+		detail.Message = err.Error()
+	}
+
+	if wait := domain.RetryAfterOf(err); wait > 0 && (kind.status == http.StatusTooManyRequests || kind.status == http.StatusServiceUnavailable) {
+		w.Header().Set("Retry-After", fmt.Sprint(int(math.Ceil(wait.Seconds()))))
+	}
+	writeJSON(w, kind.status, ErrorResponse{Error: detail})
+}
@@ -4,10 +4,13 @@ import (
 	"encoding/json"
 	"net/http"
 	"regexp"
+	"strings"
+	"sync/atomic"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
+	"github.com/viplounge/platform/internal/auth"
 	"github.com/viplounge/platform/internal/config"
 	"github.com/viplounge/platform/internal/domain"
 	customMiddleware "github.com/viplounge/platform/internal/middleware"
@@ -17,10 +20,69 @@ import (
 type Handler struct {
 	svc *service.ValidationService
 	cfg *config.Config
+
+	// origens CORS permitidas, reconstruídas a cada reload da config
+	origins atomic.Pointer[map[string]bool]
+
+	// autenticação das rotas não públicas
+	authn *auth.Authenticator
+
+	// API do suporte (/admin/v1), montada só quando habilitada
+	admin *service.AdminService
+
+	// notificações da Superlógica, quando habilitadas
+	webhooks *service.WebhookService
+
+	// respostas guardadas por Idempotency-Key nas rotas de ativação,
+	// cifradas com as chaves dos leads quando a cifragem está ligada
+	idempotency       domain.IdempotencyRepository
+	idempotencySealer domain.DataSealer
+}
+
+// Domínios oficiais sempre liberados no CORS
+var defaultAllowedOrigins = []string{
+	"https://viplounge.com.br",
+	"https://viplounge.mobile.adm.br",
+	"https://www.viplounge.com.br",
+	"https://mobile.viplounge.com.br",
+	"http://localhost:8080",
+	"http://localhost:3000",
+}
+
+func NewHandler(svc *service.ValidationService, authn *auth.Authenticator, cfg *config.Config) *Handler {
+	if cfg == nil {
+		cfg = config.Get()
+	}
+	h := &Handler{svc: svc, authn: authn, cfg: cfg}
+	h.configureOrigins(cfg)
+	config.Subscribe(h.configureOrigins)
+	return h
 }
 
-func NewHandler(svc *service.ValidationService, cfg *config.Config) *Handler {
-	return &Handler{svc: svc, cfg: cfg}
+// config retorna a versão da configuração fixada na requisição
+func (h *Handler) config(r *http.Request) *config.Config {
+	if cfg := config.FromContext(r.Context()); cfg != nil {
+		return cfg
+	}
+	return h.cfg
+}
+
+// configureOrigins monta o conjunto de origens CORS a partir da config
+func (h *Handler) configureOrigins(cfg *config.Config) {
+	origins := make(map[string]bool)
+	for _, origin := range defaultAllowedOrigins {
+		origins[origin] = true
+	}
+	if len(cfg.Security.CORSAllowedOrigins) > 0 && cfg.Security.CORSAllowedOrigins[0] != "*" {
+		for _, origin := range cfg.Security.CORSAllowedOrigins {
+			origins[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+		}
+	}
+	h.origins.Store(&origins)
+}
+
+func (h *Handler) allowOrigin(r *http.Request, origin string) bool {
+	return (*h.origins.Load())[strings.ToLower(origin)]
 }
 
 // Routes define as rotas da aplicação
@@ -33,67 +95,83 @@ func (h *Handler) Routes() http.Handler {
 	r.Use(middleware.Heartbeat("/health"))
 
 	// 2. Middlewares de Base e Multi-tenancy
-	r.Use(middleware.Logger)
+	r.Use(customMiddleware.RequestID) // Antes do AccessLog, que registra o ID
+	r.Use(customMiddleware.AccessLog)
 	r.Use(middleware.Recoverer)
+	r.Use(customMiddleware.ConfigSnapshot)   // Uma versão da config por requisição
 	r.Use(customMiddleware.TenantMiddleware) // Identifica o condomínio pelo Host
 	r.Use(customMiddleware.SecurityHeaders)
 	
 	// 3. Configuração de CORS para os domínios oficiais
-	allowedOrigins := []string{
-		"https://viplounge.com.br",
-		"https://viplounge.mobile.adm.br",
-		"https://www.viplounge.com.br",
-		"https://mobile.viplounge.com.br",
-		"http://localhost:8080",
-		"http://localhost:3000",
-	}
-	
-	if len(h.cfg.Security.CORSAllowedOrigins) > 0 && h.cfg.Security.CORSAllowedOrigins[0] != "*" {
-		allowedOrigins = append(allowedOrigins, h.cfg.Security.CORSAllowedOrigins...)
-	}
-	
+	// Origens extras vêm de security.cors_allowed_origins e acompanham o hot reload
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   allowedOrigins,
+		AllowOriginFunc:  h.allowOrigin,
 		AllowedMethods:   []string{"GET", "POST", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "Idempotency-Key", "X-API-Key", "X-Request-Id"},
+		ExposedHeaders:   []string{"Idempotent-Replayed", "Link", "Retry-After", "X-Request-Id"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 
-	// 4. Endpoints de Configuração e API
-	r.Get("/config", h.handleConfig)
-	r.Post("/v1/validate", h.handleValidate)
-	r.Post("/v1/confirm-email", h.handleConfirmEmail)
+	// 4. Autenticação: toda rota que não for registrada como pública exige
+	// API key ou token (deny by default)
+	public := customMiddleware.NewPublicRoutes()
+	r.Use(customMiddleware.Authenticate(h.authn, r, public))
+
+	// pub registra uma rota liberada sem credenciais
+	pub := func(method, pattern string, fn http.HandlerFunc) {
+		r.Method(method, pattern, fn)
+		public.Add(method, pattern)
+	}
+
+	// 5. Endpoints de Configuração e API (públicos, usados pela landing page)
+	pub("GET", "/config", h.handleConfig)
+	pub("POST", "/v1/validate", h.handleValidate)
+	pub("POST", "/v1/confirm-email", h.idempotent(h.handleConfirmEmail))
+	pub("GET", domain.SSOPath+"{handle}", h.handleSSO)
+	pub("POST", sessionRefreshPath, h.handleSessionRefresh)
+	pub("POST", sessionLogoutPath, h.handleLogout)
+	pub("POST", "/v1/consent/withdraw", h.handleConsentWithdraw)
+	pub("GET", "/openapi.json", h.handleOpenAPI)
 
-	// 5. Servir Arquivos Estáticos (Substituindo o Firebase)
+	// Webhook da Superlógica: autenticado pela assinatura do corpo
+	if h.webhooks != nil {
+		pub("POST", "/webhooks/superlogica", h.handleSuperlogicaWebhook)
+	}
+
+	// API do suporte, sempre autenticada
+	if h.admin != nil {
+		r.Mount("/admin/v1", h.adminRoutes())
+	}
+
+	// 6. Servir Arquivos Estáticos (Substituindo o Firebase)
 	// File Server para arquivos estáticos
 	fs := http.FileServer(http.Dir("web"))
 	
 	// Rotas específicas para arquivos conhecidos (otimização + headers corretos)
-	r.Get("/api-config.js", func(w http.ResponseWriter, r *http.Request) {
+	pub("GET", "/api-config.js", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/javascript; charset=utf-8")
 		http.ServeFile(w, r, "web/api-config.js")
 	})
 	
-	r.Get("/backend-config.json", func(w http.ResponseWriter, r *http.Request) {
+	pub("GET", "/backend-config.json", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
 		http.ServeFile(w, r, "web/backend-config.json")
 	})
 	
-	r.Get("/favicon.ico", func(w http.ResponseWriter, r *http.Request) {
+	pub("GET", "/favicon.ico", func(w http.ResponseWriter, r *http.Request) {
 		http.ServeFile(w, r, "web/favicon.ico")
 	})
 	
 	// Rota Raiz: Serve o portal do cliente. Essencial para validação de SSL
-	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+	pub("GET", "/", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		http.ServeFile(w, r, "web/index.html")
 	})
 	
 	// CATCH-ALL: Servir todos os outros arquivos estáticos (imagens, CSS, etc)
 	// Deve ser a ÚLTIMA rota para não conflitar com as rotas da API
-	r.Handle("/*", http.StripPrefix("/", fs))
+	pub("GET", "/*", http.StripPrefix("/", fs).ServeHTTP)
 
 	return r
 }
@@ -101,15 +179,17 @@ func (h *Handler) Routes() http.Handler {
 var cpfRegex = regexp.MustCompile(`^\d{3}\.?\d{3}\.?\d{3}-?\d{2}$`)
 
 func (h *Handler) handleValidate(w http.ResponseWriter, r *http.Request) {
+	cfg := h.config(r)
+
 	var req domain.ValidationRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		invalidRequestBody(w, r)
 		return
 	}
 
+	problems := fieldErrors{}
 	if !cpfRegex.MatchString(req.CPF) {
-		http.Error(w, "Invalid CPF format", http.StatusBadRequest)
-		return
+		problems.add("cpf", "CPF inválido.")
 	}
 	
 	// PRIORIDADE DE BUSCA:
@@ -120,18 +200,22 @@ func (h *Handler) handleValidate(w http.ResponseWriter, r *http.Request) {
 		if tenantID != "" {
 			req.CondoID = tenantID
 		} else {
-			req.CondoID = h.cfg.Behavior.DefaultCondoID
+			req.CondoID = cfg.Behavior.DefaultCondoID
 		}
 	}
 	
-	if h.cfg.Behavior.CondoIDRequired && req.CondoID == "" {
-		http.Error(w, "Condo ID is required", http.StatusBadRequest)
+	if cfg.Behavior.CondoIDRequired && req.CondoID == "" {
+		problems.add("condo_id", "Informe o condomínio.")
+	}
+	checkConsents(problems, cfg, req.Consents)
+	if err := problems.err(); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	resp, err := h.svc.ValidateAndSave(r.Context(), req)
 	if err != nil {
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -142,25 +226,36 @@ func (h *Handler) handleValidate(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) handleConfirmEmail(w http.ResponseWriter, r *http.Request) {
 	var req domain.EmailConfirmationRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		invalidRequestBody(w, r)
 		return
 	}
 
+	problems := fieldErrors{}
 	if !cpfRegex.MatchString(req.CPF) {
-		http.Error(w, "Invalid CPF format", http.StatusBadRequest)
-		return
+		problems.add("cpf", "CPF inválido.")
 	}
-
 	if req.Email == "" {
-		http.Error(w, "Email is required", http.StatusBadRequest)
+		problems.add("email", "Informe o e-mail.")
+	}
+	checkConsents(problems, h.config(r), req.Consents)
+	if err := problems.err(); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
+	// Os clubes ativados são os do condomínio do host
+	if req.CondoID == "" {
+		req.CondoID = customMiddleware.GetTenantID(r.Context())
+	}
+
 	resp, err := h.svc.ConfirmEmailAndActivate(r.Context(), req)
 	if err != nil {
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
+	if resp.Session != "" {
+		h.setSessionCookie(w, r, resp.Session)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
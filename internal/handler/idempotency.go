@@ -0,0 +1,272 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/viplounge/platform/internal/auth"
+	"github.com/viplounge/platform/internal/domain"
+	customMiddleware "github.com/viplounge/platform/internal/middleware"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	// idempotentReplayHeader marca a resposta repetida de uma chave já usada
+	idempotentReplayHeader = "Idempotent-Replayed"
+	maxIdempotencyKey      = 255
+	// maxIdempotentBody limite do corpo lido para calcular o fingerprint
+	maxIdempotentBody = 64 << 10
+)
+
+// Códigos das respostas de chave de idempotência
+const (
+	codeIdempotencyInProgress = "IDEMPOTENCY_IN_PROGRESS"
+	codeIdempotencyKeyReused  = "IDEMPOTENCY_KEY_REUSED"
+)
+
+// EnableIdempotency liga o header Idempotency-Key nas rotas de ativação
+func (h *Handler) EnableIdempotency(store domain.IdempotencyRepository) {
+	h.idempotency = store
+}
+
+// EnableIdempotencySealing cifra as respostas guardadas: elas trazem dados
+// pessoais, o handle SSO e o cookie da sessão
+func (h *Handler) EnableIdempotencySealing(sealer domain.DataSealer) {
+	h.idempotencySealer = sealer
+}
+
+// idempotentResponse o que o replay repete, cifrado em SealedResponse
+type idempotentResponse struct {
+	Body    []byte   `json:"body"`
+	Cookies []string `json:"cookies,omitempty"`
+}
+
+// idempotent guarda a primeira resposta de cada Idempotency-Key e a repete
+// para reenvios dentro da janela (idempotency.window_minutes), sem executar
+// next de novo. Sem o header a rota funciona como antes.
+//
+// A chave vale para a rota, o condomínio do host e o principal (API do
+// suporte). Reenvio com outro corpo é recusado; reenvio enquanto a primeira
+// ainda está em andamento recebe 409. Falhas 5xx liberam a chave, para o
+// cliente poder tentar de novo. O replay repete status, Content-Type, corpo
+// e Set-Cookie; nada mais dos headers da primeira resposta.
+func (h *Handler) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		cfg := h.config(r)
+		if key == "" || h.idempotency == nil || !cfg.Idempotency.Enabled {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKey {
+			writeErrorCode(w, r, http.StatusBadRequest, codeInvalidRequest, "Idempotency-Key muito longa.")
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBody))
+		if err != nil {
+			invalidRequestBody(w, r)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		now := time.Now()
+		record := domain.IdempotencyRecord{
+			ID:          idempotencyID(r, key),
+			Fingerprint: hash(body),
+			Status:      domain.IdempotencyInProgress,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(time.Duration(cfg.Idempotency.WindowMinutes) * time.Minute),
+		}
+
+		existing, err := h.reserveIdempotencyKey(r.Context(), record)
+		if err != nil {
+			// Sem o store a ativação segue; o lock por CPF ainda evita o
+			// cadastro em dobro nesta instância
+			log.Printf("[IDEMPOTENCY] Chave não reservada, seguindo sem idempotência: %v", err)
+			next(w, r)
+			return
+		}
+		if existing != nil {
+			h.replayIdempotent(w, r, existing, record.Fingerprint)
+			return
+		}
+
+		// Gravação independente do cliente: se ele desconectar, a chave não
+		// pode ficar "em andamento" até expirar
+		storeCtx := context.WithoutCancel(r.Context())
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			// panic ou 5xx: libera a chave
+			if err := h.idempotency.DeleteIdempotencyRecord(storeCtx, record.ID); err != nil {
+				log.Printf("[IDEMPOTENCY] Erro liberando chave: %v", err)
+			}
+		}()
+
+		next(recorder, r)
+
+		if recorder.status >= 500 {
+			return
+		}
+		record.Status = domain.IdempotencyCompleted
+		record.ResponseStatus = recorder.status
+		record.ContentType = recorder.Header().Get("Content-Type")
+		if err := h.sealResponse(storeCtx, &record, idempotentResponse{
+			Body:    recorder.body.Bytes(),
+			Cookies: recorder.Header().Values("Set-Cookie"),
+		}); err != nil {
+			log.Printf("[IDEMPOTENCY] Erro cifrando resposta: %v", err)
+			return
+		}
+		if err := h.idempotency.SaveIdempotencyRecord(storeCtx, record); err != nil {
+			log.Printf("[IDEMPOTENCY] Erro gravando resposta: %v", err)
+			return
+		}
+		completed = true
+	}
+}
+
+// reserveIdempotencyKey grava a chave como em andamento. Retorna o registro
+// existente quando a chave já foi usada dentro da janela.
+func (h *Handler) reserveIdempotencyKey(ctx context.Context, record domain.IdempotencyRecord) (*domain.IdempotencyRecord, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		err := h.idempotency.CreateIdempotencyRecord(ctx, record)
+		if err == nil {
+			return nil, nil
+		}
+		if !errors.Is(err, domain.ErrAlreadyExists) {
+			return nil, err
+		}
+
+		existing, err := h.idempotency.GetIdempotencyRecord(ctx, record.ID)
+		if errors.Is(err, domain.ErrNotFound) {
+			// Liberada entre as duas chamadas: tenta reservar de novo
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !existing.Expired(record.CreatedAt) {
+			return existing, nil
+		}
+		// Janela encerrada: a chave volta a valer como nova, se outra
+		// requisição não a reservou primeiro
+		err = h.idempotency.ReplaceExpiredIdempotencyRecord(ctx, record)
+		if errors.Is(err, domain.ErrAlreadyExists) {
+			continue
+		}
+		return nil, err
+	}
+	return nil, errors.New("chave de idempotência liberada e reservada concorrentemente")
+}
+
+// sealResponse guarda a resposta no registro, cifrada quando há sealer
+func (h *Handler) sealResponse(ctx context.Context, record *domain.IdempotencyRecord, response idempotentResponse) error {
+	if h.idempotencySealer == nil {
+		record.ResponseBody = response.Body
+		record.SetCookies = response.Cookies
+		return nil
+	}
+	plaintext, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	record.SealedResponse, err = h.idempotencySealer.SealData(ctx, plaintext, []byte(record.ID))
+	return err
+}
+
+// openResponse lê a resposta guardada no registro
+func (h *Handler) openResponse(ctx context.Context, record *domain.IdempotencyRecord) (idempotentResponse, error) {
+	response := idempotentResponse{Body: record.ResponseBody, Cookies: record.SetCookies}
+	if record.SealedResponse == nil {
+		return response, nil
+	}
+	if h.idempotencySealer == nil {
+		return response, errors.New("resposta cifrada com a cifragem desligada")
+	}
+	plaintext, err := h.idempotencySealer.OpenData(ctx, record.SealedResponse, []byte(record.ID))
+	if err != nil {
+		return response, err
+	}
+	err = json.Unmarshal(plaintext, &response)
+	return response, err
+}
+
+// replayIdempotent responde o reenvio de uma chave já reservada
+func (h *Handler) replayIdempotent(w http.ResponseWriter, r *http.Request, existing *domain.IdempotencyRecord, fingerprint string) {
+	switch {
+	case existing.Fingerprint != fingerprint:
+		writeErrorCode(w, r, http.StatusUnprocessableEntity, codeIdempotencyKeyReused,
+			"Idempotency-Key já usada com outra requisição.")
+	case existing.Status != domain.IdempotencyCompleted:
+		w.Header().Set("Retry-After", "1")
+		writeErrorCode(w, r, http.StatusConflict, codeIdempotencyInProgress,
+			"A requisição original ainda está em andamento. Tente novamente em instantes.")
+	default:
+		response, err := h.openResponse(r.Context(), existing)
+		if err != nil {
+			log.Printf("[IDEMPOTENCY] Resposta guardada ilegível: %v", err)
+			writeError(w, r, err)
+			return
+		}
+		log.Printf("[IDEMPOTENCY] Repetindo resposta de %s (%d)", r.URL.Path, existing.ResponseStatus)
+		if existing.ContentType != "" {
+			w.Header().Set("Content-Type", existing.ContentType)
+		}
+		for _, cookie := range response.Cookies {
+			w.Header().Add("Set-Cookie", cookie)
+		}
+		w.Header().Set(idempotentReplayHeader, "true")
+		w.WriteHeader(existing.ResponseStatus)
+		w.Write(response.Body)
+	}
+}
+
+// idempotencyID identifica a chave no store: rota, condomínio do host e
+// principal, com hash para não gravar a chave do cliente
+func idempotencyID(r *http.Request, key string) string {
+	actor := ""
+	if p := auth.FromContext(r.Context()); p != nil {
+		actor = p.Actor()
+	}
+	return hash([]byte(r.Method + "\n" + r.URL.Path + "\n" + customMiddleware.GetTenantID(r.Context()) + "\n" + actor + "\n" + key))
+}
+
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// responseRecorder repassa a resposta ao cliente e guarda uma cópia
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(data []byte) (int, error) {
+	rec.wroteHeader = true
+	rec.body.Write(data)
+	return rec.ResponseWriter.Write(data)
+}
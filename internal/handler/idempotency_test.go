@@ -0,0 +1,241 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/testing/fakes"
+)
+
+// confirm envia /v1/confirm-email com a Idempotency-Key (vazia = sem header)
+func (e *scenarioEnv) confirm(t *testing.T, key string, req domain.EmailConfirmationRequest) (*http.Response, []byte) {
+	t.Helper()
+	payload, _ := json.Marshal(req)
+	httpReq, _ := http.NewRequest("POST", e.server.URL+"/v1/confirm-email", bytes.NewReader(payload))
+	httpReq.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		httpReq.Header.Set("Idempotency-Key", key)
+	}
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		t.Fatalf("POST /v1/confirm-email: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp, body
+}
+
+func TestIdempotencyKeyReplaysFirstResponse(t *testing.T) {
+	env := newScenarioEnv(t)
+	req := domain.EmailConfirmationRequest{CPF: cpfNewResident, Email: newEmail}
+
+	first, firstBody := env.confirm(t, "clique-1", req)
+	second, secondBody := env.confirm(t, "clique-1", req)
+
+	if first.StatusCode != http.StatusOK || second.StatusCode != http.StatusOK {
+		t.Fatalf("status %d e %d, esperado 200", first.StatusCode, second.StatusCode)
+	}
+	if first.Header.Get("Idempotent-Replayed") != "" {
+		t.Errorf("primeira resposta marcada como repetida")
+	}
+	if second.Header.Get("Idempotent-Replayed") != "true" {
+		t.Errorf("reenvio sem Idempotent-Replayed")
+	}
+	if !bytes.Equal(firstBody, secondBody) {
+		t.Errorf("reenvio com outra resposta:\n%s\n%s", firstBody, secondBody)
+	}
+	// A ativação rodou uma vez só
+	checkCalls(t, env, map[string]int{fakes.EndpointFindUser: 1, fakes.EndpointCreateUser: 1, fakes.EndpointSSO: 1})
+
+	// Outra chave é outra ativação (o morador já está cadastrado)
+	third, _ := env.confirm(t, "clique-2", req)
+	if third.StatusCode != http.StatusOK || third.Header.Get("Idempotent-Replayed") != "" {
+		t.Errorf("chave nova: status %d, replayed %q", third.StatusCode, third.Header.Get("Idempotent-Replayed"))
+	}
+	checkCalls(t, env, map[string]int{fakes.EndpointFindUser: 2, fakes.EndpointCreateUser: 1, fakes.EndpointSSO: 2})
+}
+
+func TestIdempotencyKeyWithOtherBodyIsRejected(t *testing.T) {
+	env := newScenarioEnv(t)
+
+	env.confirm(t, "chave", domain.EmailConfirmationRequest{CPF: cpfNewResident, Email: newEmail})
+	resp, body := env.confirm(t, "chave", domain.EmailConfirmationRequest{CPF: cpfMember, Email: memberEmail})
+
+	var out struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	json.Unmarshal(body, &out)
+	if resp.StatusCode != http.StatusUnprocessableEntity || out.Error.Code != "IDEMPOTENCY_KEY_REUSED" {
+		t.Errorf("status %d código %q, esperado 422 IDEMPOTENCY_KEY_REUSED", resp.StatusCode, out.Error.Code)
+	}
+}
+
+func TestIdempotencyKeyIsReleasedAfterServerError(t *testing.T) {
+	env := newScenarioEnv(t)
+	req := domain.EmailConfirmationRequest{CPF: cpfNewResident, Email: newEmail}
+
+	env.setFaults(t, map[string]fakes.Fault{fakes.EndpointUnits: unavailable()})
+	if resp, _ := env.confirm(t, "retry", req); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("com a Superlógica fora do ar: status %d, esperado 503", resp.StatusCode)
+	}
+
+	env.fakes.Faults.Clear(fakes.EndpointUnits)
+	resp, _ := env.confirm(t, "retry", req)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Idempotent-Replayed") != "" {
+		t.Errorf("retentativa: status %d, replayed %q, esperado nova execução", resp.StatusCode, resp.Header.Get("Idempotent-Replayed"))
+	}
+	checkCalls(t, env, map[string]int{fakes.EndpointFindUser: 1, fakes.EndpointCreateUser: 1, fakes.EndpointSSO: 1})
+}
+
+// Confirmações simultâneas sem chave (duas abas) são serializadas por CPF:
+// a segunda encontra o morador já cadastrado e só gera o acesso
+func TestConcurrentActivationsRegisterOnce(t *testing.T) {
+	env := newScenarioEnv(t)
+	env.setFaults(t, map[string]fakes.Fault{fakes.EndpointCreateUser: {LatencyMs: 200}})
+	req := domain.EmailConfirmationRequest{CPF: cpfNewResident, Email: newEmail}
+
+	var wg sync.WaitGroup
+	statuses := make([]int, 2)
+	for i := range statuses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, _ := env.confirm(t, "", req)
+			statuses[i] = resp.StatusCode
+		}(i)
+	}
+	wg.Wait()
+
+	for i, status := range statuses {
+		if status != http.StatusOK {
+			t.Errorf("confirmação %d: status %d", i, status)
+		}
+	}
+	checkCalls(t, env, map[string]int{fakes.EndpointFindUser: 2, fakes.EndpointCreateUser: 1, fakes.EndpointSSO: 2})
+}
+
+// idempotencySpy guarda os registros como chegam ao store
+type idempotencySpy struct {
+	domain.IdempotencyRepository
+	mu    sync.Mutex
+	saved []domain.IdempotencyRecord
+}
+
+func (s *idempotencySpy) SaveIdempotencyRecord(ctx context.Context, record domain.IdempotencyRecord) error {
+	s.mu.Lock()
+	s.saved = append(s.saved, record)
+	s.mu.Unlock()
+	return s.IdempotencyRepository.SaveIdempotencyRecord(ctx, record)
+}
+
+// TestIdempotentResponseSealedWithCookie a resposta guardada fica cifrada
+// (traz o e-mail, o handle SSO e o cookie da sessão) e o replay devolve
+// também o cookie "lembrar de mim"
+func TestIdempotentResponseSealedWithCookie(t *testing.T) {
+	t.Setenv("REMEMBER_ME_ENABLED", "true")
+	env := newScenarioEnv(t)
+	spy := &idempotencySpy{IdempotencyRepository: env.repo}
+	env.h.EnableIdempotency(spy)
+	req := domain.EmailConfirmationRequest{CPF: cpfMember, Email: memberEmail}
+
+	first, firstBody := env.confirm(t, "clique-1", req)
+	second, secondBody := env.confirm(t, "clique-1", req)
+	if second.Header.Get("Idempotent-Replayed") != "true" || !bytes.Equal(firstBody, secondBody) {
+		t.Fatalf("reenvio não repetiu a resposta:\n%s\n%s", firstBody, secondBody)
+	}
+	cookie := first.Header.Get("Set-Cookie")
+	if !strings.Contains(cookie, "vl_session=") {
+		t.Fatalf("ativação sem cookie da sessão: %q", cookie)
+	}
+	if replayed := second.Header.Get("Set-Cookie"); replayed != cookie {
+		t.Errorf("Set-Cookie no replay = %q, esperado %q", replayed, cookie)
+	}
+
+	if len(spy.saved) != 1 {
+		t.Fatalf("esperado um registro gravado, gravados %d", len(spy.saved))
+	}
+	record := spy.saved[0]
+	if record.SealedResponse == nil || len(record.ResponseBody) != 0 || len(record.SetCookies) != 0 {
+		t.Errorf("resposta gravada em claro: %+v", record)
+	}
+	if bytes.Contains(record.SealedResponse.Ciphertext, []byte(memberEmail)) {
+		t.Errorf("e-mail em claro na resposta cifrada")
+	}
+}
+
+// expiredRace faz as duas primeiras leituras da chave esperarem uma pela
+// outra, para as duas requisições acharem o registro expirado antes de
+// qualquer uma reservá-lo
+type expiredRace struct {
+	domain.IdempotencyRepository
+	mu    sync.Mutex
+	reads int
+	both  chan struct{}
+}
+
+func (s *expiredRace) GetIdempotencyRecord(ctx context.Context, id string) (*domain.IdempotencyRecord, error) {
+	record, err := s.IdempotencyRepository.GetIdempotencyRecord(ctx, id)
+	s.mu.Lock()
+	s.reads++
+	if s.reads == 2 {
+		close(s.both)
+	}
+	waiting := s.reads <= 2
+	s.mu.Unlock()
+	if waiting {
+		select {
+		case <-s.both:
+		case <-time.After(2 * time.Second):
+		}
+	}
+	return record, err
+}
+
+// TestExpiredIdempotencyKeyReservedOnce duas requisições que acham a mesma
+// chave expirada não podem as duas reservá-la: só uma executa a ativação e a
+// outra recebe 409 (em andamento)
+func TestExpiredIdempotencyKeyReservedOnce(t *testing.T) {
+	env := newScenarioEnv(t)
+	spy := &idempotencySpy{IdempotencyRepository: env.repo}
+	env.h.EnableIdempotency(spy)
+	req := domain.EmailConfirmationRequest{CPF: cpfMember, Email: memberEmail}
+
+	env.confirm(t, "clique-1", req)
+	if len(spy.saved) != 1 {
+		t.Fatalf("esperado um registro gravado, gravados %d", len(spy.saved))
+	}
+	expired := spy.saved[0]
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+	if err := env.repo.SaveIdempotencyRecord(context.Background(), expired); err != nil {
+		t.Fatalf("SaveIdempotencyRecord: %v", err)
+	}
+
+	env.h.EnableIdempotency(&expiredRace{IdempotencyRepository: env.repo, both: make(chan struct{})})
+	env.setFaults(t, map[string]fakes.Fault{fakes.EndpointSSO: {LatencyMs: 200}})
+	var wg sync.WaitGroup
+	statuses := make([]int, 2)
+	for i := range statuses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, _ := env.confirm(t, "clique-1", req)
+			statuses[i] = resp.StatusCode
+		}(i)
+	}
+	wg.Wait()
+
+	if statuses[0]+statuses[1] != http.StatusOK+http.StatusConflict {
+		t.Errorf("status %v, esperado um 200 e um 409", statuses)
+	}
+	checkCalls(t, env, map[string]int{fakes.EndpointFindUser: 2, fakes.EndpointSSO: 2})
+}
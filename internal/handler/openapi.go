@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/openapi"
+)
+
+// apiVersion versão da API pública descrita em /openapi.json. Mudança
+// incompatível nos tipos das rotas /v1 exige nova versão (e novo prefixo).
+const apiVersion = "1.0.0"
+
+// scenarios valores possíveis de ValidationResponse.scenario
+var scenarios = []string{
+	domain.ScenarioNewUser,
+	domain.ScenarioExistingUser,
+	domain.ScenarioRevokedUser,
+	domain.ScenarioNotFound,
+	domain.ScenarioError,
+	domain.ScenarioPendingEmailConfirmation,
+	domain.ScenarioClubPicker,
+}
+
+var (
+	specOnce sync.Once
+	specJSON []byte
+)
+
+// OpenAPI especificação das rotas públicas usadas pela landing page. Os
+// schemas são gerados dos próprios tipos de requisição e resposta, então a
+// especificação acompanha qualquer mudança neles.
+func OpenAPI() *openapi.Document {
+	reg := openapi.NewRegistry()
+
+	validate := reg.Component(domain.ValidationRequest{}).Require("cpf")
+	validate.Property("cpf").Pattern = cpfRegex.String()
+	validate.Property("condo_id").Description = "Vazio = condomínio do host ou o padrão da configuração"
+	validate.Property("consents").Description = "Escolhas de consentimento. Aceites do morador encontrado na Superlógica só valem em /v1/confirm-email"
+
+	confirm := reg.Component(domain.EmailConfirmationRequest{}).Require("cpf", "email")
+	confirm.Property("cpf").Pattern = cpfRegex.String()
+	confirm.Property("email").Format = "email"
+	confirm.Property("condo_id").Description = "Vazio = condomínio do host"
+	confirm.Property("club_id").Description = "Ativa apenas este clube do condomínio (vazio = todos)"
+	confirm.Property("consents").Description = "Escolhas de consentimento, gravadas se o e-mail conferir"
+
+	consent := reg.Component(domain.ConsentChoice{}).Require("purpose", "granted")
+	consent.Property("purpose").Description = "Finalidade, uma das chaves de consent.versions em /config"
+	consent.Property("version").Description = "Versão do texto exibido (vazio = a atual de consent.versions)"
+
+	withdraw := reg.Component(domain.ConsentWithdrawalRequest{}).Require("cpf")
+	withdraw.Property("cpf").Pattern = cpfRegex.String()
+	withdraw.Property("purposes").Description = "Finalidades retiradas (vazio = todas)"
+
+	validation := reg.Component(domain.ValidationResponse{})
+	validation.Property("scenario").Enum = scenarios
+	validation.Property("redirect_url").Description = "Acesso ao clube: " + domain.SSOPath + "{handle}, de uso único, relativo ao backend"
+	reg.Component(domain.ClubAccess{}).Property("redirect_url").Description = "Acesso a este clube: " + domain.SSOPath + "{handle}, de uso único"
+
+	errorDetail := reg.Component(ErrorDetail{})
+	errorDetail.Property("code").Enum = errorCodes()
+	errorDetail.Property("fields").Description = "Mensagens por campo, nos erros VALIDATION_ERROR"
+
+	requestID := openapi.Header{
+		Description: "Identificador da requisição (o mesmo do log e de error.request_id)",
+		Schema:      &openapi.Schema{Type: "string"},
+	}
+	retryAfter := openapi.Header{
+		Description: "Segundos até uma nova tentativa, quando a integração informa",
+		Schema:      &openapi.Schema{Type: "integer"},
+	}
+	ok := func(description string, body interface{}) *openapi.Response {
+		return &openapi.Response{
+			Description: description,
+			Headers:     map[string]openapi.Header{"X-Request-Id": requestID},
+			Content:     openapi.JSON(reg.Ref(body)),
+		}
+	}
+	failure := func(description string, retry bool) *openapi.Response {
+		resp := ok(description, ErrorResponse{})
+		if retry {
+			resp.Headers = map[string]openapi.Header{"X-Request-Id": requestID, "Retry-After": retryAfter}
+		}
+		return resp
+	}
+	// Falhas comuns às rotas que consultam as integrações
+	withFailures := func(responses map[string]*openapi.Response) map[string]*openapi.Response {
+		responses["400"] = failure("Corpo da requisição inválido (INVALID_REQUEST)", false)
+		responses["422"] = failure("Campos inválidos (VALIDATION_ERROR), detalhados em error.fields", false)
+		responses["429"] = failure("Integração limitando requisições (RATE_LIMITED)", true)
+		responses["500"] = failure("Erro interno (INTERNAL)", false)
+		responses["502"] = failure("Credencial da integração recusada (UPSTREAM_UNAUTHORIZED)", false)
+		responses["503"] = failure("Integração indisponível ou desabilitada (UNAVAILABLE, INTEGRATION_DISABLED)", true)
+		return responses
+	}
+
+	confirmResponses := withFailures(map[string]*openapi.Response{"200": ok("Resultado da ativação", domain.ValidationResponse{})})
+	confirmResponses["409"] = failure("Reenvio com a mesma Idempotency-Key enquanto a original está em andamento (IDEMPOTENCY_IN_PROGRESS)", true)
+	confirmResponses["422"] = failure("Campos inválidos (VALIDATION_ERROR) ou Idempotency-Key usada com outro corpo (IDEMPOTENCY_KEY_REUSED)", false)
+
+	refreshResponses := withFailures(map[string]*openapi.Response{"200": ok("Novo acesso gerado", domain.ValidationResponse{})})
+	delete(refreshResponses, "400")
+	delete(refreshResponses, "422")
+	refreshResponses["401"] = failure("Sessão ausente, expirada ou revogada (SESSION_INVALID); o cookie é apagado", false)
+
+	paths := map[string]*openapi.PathItem{
+		"/v1/validate": {Post: &openapi.Operation{
+			OperationID: "validate",
+			Summary:     "Valida o CPF na Superlógica e no Clube de Benefícios",
+			Description: "Primeira etapa: identifica o cenário do morador. O acesso só é gerado depois da confirmação do e-mail.",
+			RequestBody: &openapi.RequestBody{Required: true, Content: openapi.JSON(reg.Ref(domain.ValidationRequest{}))},
+			Responses:   withFailures(map[string]*openapi.Response{"200": ok("Cenário identificado", domain.ValidationResponse{})}),
+		}},
+		"/v1/confirm-email": {Post: &openapi.Operation{
+			OperationID: "confirmEmail",
+			Summary:     "Confirma o e-mail do morador e ativa o acesso ao clube",
+			Description: "Segunda etapa: confere o e-mail com o cadastro da Superlógica, cadastra no clube se preciso e gera o acesso. Falha na ativação volta com 200 e a mensagem em message.",
+			Parameters: []openapi.Parameter{{
+				Name:        idempotencyKeyHeader,
+				In:          "header",
+				Description: "Reenvios com a mesma chave (e o mesmo corpo) recebem a primeira resposta, com o header " + idempotentReplayHeader + ", sem ativar de novo",
+				Schema:      &openapi.Schema{Type: "string"},
+			}},
+			RequestBody: &openapi.RequestBody{Required: true, Content: openapi.JSON(reg.Ref(domain.EmailConfirmationRequest{}))},
+			Responses:   confirmResponses,
+		}},
+		domain.SSOPath + "{handle}": {Get: &openapi.Operation{
+			OperationID: "ssoRedirect",
+			Summary:     "Redireciona ao Clube de Benefícios com o acesso gerado na ativação",
+			Description: "Caminho recebido em redirect_url. Cada handle vale uma vez e expira em sso.handle_ttl_seconds; usado ou expirado, redireciona à landing page com ?sso=expired.",
+			Parameters: []openapi.Parameter{{
+				Name:     "handle",
+				In:       "path",
+				Required: true,
+				Schema:   &openapi.Schema{Type: "string"},
+			}},
+			Responses: map[string]*openapi.Response{"303": {
+				Description: "Redirecionamento ao clube (ou à landing page, com ?sso=expired ou ?sso=error)",
+				Headers: map[string]openapi.Header{
+					"Location":     {Description: "Destino do redirecionamento", Schema: &openapi.Schema{Type: "string"}},
+					"X-Request-Id": requestID,
+				},
+			}},
+		}},
+		sessionRefreshPath: {Post: &openapi.Operation{
+			OperationID: "refreshSession",
+			Summary:     "Gera um novo acesso ao clube pela sessão \"lembrar de mim\"",
+			Description: "Usa o cookie httpOnly emitido em /v1/confirm-email (remember_me=true na resposta). Responde como a ativação de um usuário existente, ou com o seletor de clubes.",
+			Responses:   refreshResponses,
+		}},
+		sessionLogoutPath: {Post: &openapi.Operation{
+			OperationID: "logout",
+			Summary:     "Revoga a sessão \"lembrar de mim\" e apaga o cookie",
+			Responses: map[string]*openapi.Response{"204": {
+				Description: "Sessão revogada (ou inexistente)",
+				Headers:     map[string]openapi.Header{"X-Request-Id": requestID},
+			}},
+		}},
+		"/v1/consent/withdraw": {Post: &openapi.Operation{
+			OperationID: "withdrawConsent",
+			Summary:     "Retira os consentimentos do CPF",
+			Description: "Vale para todos os leads do CPF, em qualquer condomínio. A resposta é a mesma com ou sem lead do CPF.",
+			RequestBody: &openapi.RequestBody{Required: true, Content: openapi.JSON(reg.Ref(domain.ConsentWithdrawalRequest{}))},
+			Responses: map[string]*openapi.Response{
+				"200": ok("Consentimentos retirados", domain.ConsentWithdrawalResponse{}),
+				"400": failure("Corpo da requisição inválido (INVALID_REQUEST)", false),
+				"422": failure("Campos inválidos (VALIDATION_ERROR), detalhados em error.fields", false),
+				"500": failure("Erro interno (INTERNAL)", false),
+			},
+		}},
+		"/config": {Get: &openapi.Operation{
+			OperationID: "getConfig",
+			Summary:     "Textos, marca e comportamento da landing page do condomínio",
+			Responses:   map[string]*openapi.Response{"200": ok("Configuração do frontend", ConfigResponse{})},
+		}},
+	}
+
+	return &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:       "VIP Lounge - Validação de moradores",
+			Description: "API pública da landing page. Toda falha responde o corpo ErrorResponse.",
+			Version:     apiVersion,
+		},
+		Paths:      paths,
+		Components: openapi.Components{Schemas: reg.Schemas()},
+	}
+}
+
+// GET /openapi.json
+func (h *Handler) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	specOnce.Do(func() {
+		specJSON, _ = json.MarshalIndent(OpenAPI(), "", "  ")
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.Write(specJSON)
+}
@@ -0,0 +1,267 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/testing/fakes"
+)
+
+// TestOpenAPIDescribesResponses confere respostas reais de cada rota
+// documentada com /openapi.json: status documentado, corpo no schema e
+// X-Request-Id presente
+func TestOpenAPIDescribesResponses(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       interface{}
+		faults     map[string]fakes.Fault
+		wantStatus int
+		wantFields []string
+	}{
+		{name: "config", method: "GET", path: "/config", wantStatus: 200},
+		{name: "validate membro", method: "POST", path: "/v1/validate", body: domain.ValidationRequest{CPF: cpfMember}, wantStatus: 200},
+		{name: "validate desconhecido", method: "POST", path: "/v1/validate", body: domain.ValidationRequest{CPF: cpfUnknown}, wantStatus: 200},
+		{name: "validate corpo ilegível", method: "POST", path: "/v1/validate", body: "{", wantStatus: 400},
+		{name: "validate CPF inválido", method: "POST", path: "/v1/validate", body: domain.ValidationRequest{CPF: "123"}, wantStatus: 422, wantFields: []string{"cpf"}},
+		{
+			name: "validate Superlógica fora do ar", method: "POST", path: "/v1/validate",
+			body:       domain.ValidationRequest{CPF: cpfMember},
+			faults:     map[string]fakes.Fault{fakes.EndpointUnits: unavailable()},
+			wantStatus: 503,
+		},
+		{
+			name: "validate Superlógica limitando", method: "POST", path: "/v1/validate",
+			body:       domain.ValidationRequest{CPF: cpfMember},
+			faults:     map[string]fakes.Fault{fakes.EndpointUnits: {Status: http.StatusTooManyRequests}},
+			wantStatus: 429,
+		},
+		{name: "confirm novo usuário", method: "POST", path: "/v1/confirm-email", body: domain.EmailConfirmationRequest{CPF: cpfNewResident, Email: newEmail}, wantStatus: 200},
+		{name: "confirm membro", method: "POST", path: "/v1/confirm-email", body: domain.EmailConfirmationRequest{CPF: cpfMember, Email: memberEmail}, wantStatus: 200},
+		{name: "validate finalidade desconhecida", method: "POST", path: "/v1/validate", body: domain.ValidationRequest{CPF: cpfUnknown, Consents: []domain.ConsentChoice{{Purpose: "sorteios", Granted: true}}}, wantStatus: 422, wantFields: []string{"consents[0].purpose"}},
+		{name: "retirada de consentimento", method: "POST", path: "/v1/consent/withdraw", body: domain.ConsentWithdrawalRequest{CPF: cpfMember}, wantStatus: 200},
+		{name: "retirada CPF inválido", method: "POST", path: "/v1/consent/withdraw", body: domain.ConsentWithdrawalRequest{CPF: "123"}, wantStatus: 422, wantFields: []string{"cpf"}},
+		{name: "confirm sem e-mail e CPF", method: "POST", path: "/v1/confirm-email", body: domain.EmailConfirmationRequest{CPF: "x"}, wantStatus: 422, wantFields: []string{"cpf", "email"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newScenarioEnv(t)
+			spec := env.spec(t)
+			env.setFaults(t, tt.faults)
+
+			resp, body := env.do(t, tt.method, tt.path, tt.body)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status %d, esperado %d: %s", resp.StatusCode, tt.wantStatus, body)
+			}
+			if resp.Header.Get("X-Request-Id") == "" {
+				t.Errorf("resposta sem X-Request-Id")
+			}
+
+			schema := spec.responseSchema(t, tt.method, tt.path, resp.StatusCode)
+			var doc interface{}
+			if err := json.Unmarshal(body, &doc); err != nil {
+				t.Fatalf("corpo não é JSON: %v: %s", err, body)
+			}
+			for _, problem := range spec.validate("$", schema, doc) {
+				t.Errorf("resposta fora da especificação: %s", problem)
+			}
+
+			if tt.wantFields != nil {
+				var out struct {
+					Error struct {
+						Fields map[string][]string `json:"fields"`
+					} `json:"error"`
+				}
+				json.Unmarshal(body, &out)
+				var got []string
+				for field := range out.Error.Fields {
+					got = append(got, field)
+				}
+				sort.Strings(got)
+				if strings.Join(got, ",") != strings.Join(tt.wantFields, ",") {
+					t.Errorf("campos com erro %v, esperado %v", got, tt.wantFields)
+				}
+			}
+		})
+	}
+}
+
+// do faz a requisição e lê o corpo inteiro; body string vai como está
+func (e *scenarioEnv) do(t *testing.T, method, path string, body interface{}) (*http.Response, []byte) {
+	t.Helper()
+	var payload io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		payload = strings.NewReader(b)
+	default:
+		data, _ := json.Marshal(b)
+		payload = bytes.NewReader(data)
+	}
+	req, _ := http.NewRequest(method, e.server.URL+path, payload)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	return resp, data
+}
+
+// openAPISpec documento servido em /openapi.json, lido como JSON genérico
+// para o teste não depender dos tipos que gera a especificação
+type openAPISpec map[string]interface{}
+
+func (e *scenarioEnv) spec(t *testing.T) openAPISpec {
+	t.Helper()
+	resp, body := e.do(t, "GET", "/openapi.json", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("/openapi.json: status %d", resp.StatusCode)
+	}
+	var spec openAPISpec
+	if err := json.Unmarshal(body, &spec); err != nil {
+		t.Fatalf("/openapi.json inválido: %v", err)
+	}
+	if !strings.HasPrefix(fmt.Sprint(spec["openapi"]), "3.") {
+		t.Fatalf("/openapi.json: versão %v, esperado OpenAPI 3", spec["openapi"])
+	}
+	return spec
+}
+
+func (s openAPISpec) responseSchema(t *testing.T, method, path string, status int) map[string]interface{} {
+	t.Helper()
+	op, _ := lookup(map[string]interface{}(s), "paths", path, strings.ToLower(method)).(map[string]interface{})
+	if op == nil {
+		t.Fatalf("%s %s não documentado", method, path)
+	}
+	resp, _ := lookup(op, "responses", fmt.Sprint(status)).(map[string]interface{})
+	if resp == nil {
+		t.Fatalf("%s %s: status %d não documentado", method, path, status)
+	}
+	schema, _ := lookup(resp, "content", "application/json", "schema").(map[string]interface{})
+	if schema == nil {
+		t.Fatalf("%s %s: status %d sem schema JSON", method, path, status)
+	}
+	return schema
+}
+
+// validate confere doc com o schema (type, nullable, enum, required,
+// properties, items, additionalProperties) e retorna as divergências.
+// Propriedade não declarada também é divergência: o código mandou algo que
+// a especificação não descreve.
+func (s openAPISpec) validate(path string, schema map[string]interface{}, doc interface{}) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		resolved, _ := lookup(map[string]interface{}(s), "components", "schemas", name).(map[string]interface{})
+		if resolved == nil {
+			return []string{fmt.Sprintf("%s: $ref %s sem componente", path, ref)}
+		}
+		schema = resolved
+	}
+
+	if doc == nil {
+		if schema["nullable"] == true || schema["type"] == nil {
+			return nil
+		}
+		return []string{path + ": null sem nullable"}
+	}
+
+	var problems []string
+	report := func(format string, args ...interface{}) {
+		problems = append(problems, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	switch typ, _ := schema["type"].(string); typ {
+	case "string":
+		str, ok := doc.(string)
+		if !ok {
+			report("esperado string, recebido %T", doc)
+			break
+		}
+		if enum, ok := schema["enum"].([]interface{}); ok {
+			found := false
+			for _, option := range enum {
+				found = found || option == str
+			}
+			if !found {
+				report("%q fora do enum %v", str, enum)
+			}
+		}
+		if pattern, ok := schema["pattern"].(string); ok && !regexp.MustCompile(pattern).MatchString(str) {
+			report("%q não segue o pattern %s", str, pattern)
+		}
+	case "boolean":
+		if _, ok := doc.(bool); !ok {
+			report("esperado boolean, recebido %T", doc)
+		}
+	case "integer", "number":
+		n, ok := doc.(float64)
+		if !ok {
+			report("esperado %s, recebido %T", typ, doc)
+		} else if typ == "integer" && n != float64(int64(n)) {
+			report("esperado inteiro, recebido %v", n)
+		}
+	case "array":
+		items, ok := doc.([]interface{})
+		if !ok {
+			report("esperado array, recebido %T", doc)
+			break
+		}
+		itemSchema, _ := schema["items"].(map[string]interface{})
+		for i, item := range items {
+			problems = append(problems, s.validate(fmt.Sprintf("%s[%d]", path, i), itemSchema, item)...)
+		}
+	case "object":
+		obj, ok := doc.(map[string]interface{})
+		if !ok {
+			report("esperado object, recebido %T", doc)
+			break
+		}
+		required, _ := schema["required"].([]interface{})
+		for _, name := range required {
+			if _, present := obj[name.(string)]; !present {
+				report("campo obrigatório %q ausente", name)
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		additional, _ := schema["additionalProperties"].(map[string]interface{})
+		for name, value := range obj {
+			prop, declared := properties[name].(map[string]interface{})
+			if !declared {
+				prop = additional
+			}
+			if prop == nil {
+				report("campo %q não documentado", name)
+				continue
+			}
+			problems = append(problems, s.validate(path+"."+name, prop, value)...)
+		}
+	}
+	return problems
+}
+
+// lookup percorre objetos JSON aninhados pelas chaves
+func lookup(v interface{}, keys ...string) interface{} {
+	for _, key := range keys {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = obj[key]
+	}
+	return v
+}
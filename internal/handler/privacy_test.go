@@ -0,0 +1,236 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/viplounge/platform/internal/config"
+	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/retention"
+	"github.com/viplounge/platform/internal/secrets"
+	"github.com/viplounge/platform/internal/testing/fakes"
+)
+
+// adminDo chama a API do suporte com a API key de platform_admin
+func (e *scenarioEnv) adminDo(t *testing.T, method, path string, body interface{}) (*http.Response, []byte) {
+	t.Helper()
+	var payload io.Reader
+	if body != nil {
+		data, _ := json.Marshal(body)
+		payload = bytes.NewReader(data)
+	}
+	req, _ := http.NewRequest(method, e.server.URL+path, payload)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", adminKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	return resp, data
+}
+
+func (e *scenarioEnv) exportSubject(t *testing.T, cpf string) domain.SubjectData {
+	t.Helper()
+	resp, body := e.adminDo(t, "POST", "/admin/v1/subjects/export", map[string]string{"cpf": cpf, "reason": "pedido do titular"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("export: status %d: %s", resp.StatusCode, body)
+	}
+	var data domain.SubjectData
+	if err := json.Unmarshal(body, &data); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	return data
+}
+
+// TestSubjectExportAndErasure exporta os dados de um morador ativado,
+// elimina com remoção na Rede Parcerias e confere que nada mais aponta
+// para o CPF, com os pedidos assinados na auditoria
+func TestSubjectExportAndErasure(t *testing.T) {
+	env := newScenarioEnv(t)
+	ctx := context.Background()
+	env.post(t, "/v1/confirm-email", domain.EmailConfirmationRequest{CPF: cpfNewResident, Email: newEmail})
+
+	data := env.exportSubject(t, cpfNewResident)
+	if len(data.Leads) != 1 || len(data.Attempts) == 0 || data.PartnerUser == nil {
+		t.Fatalf("export: %d leads, %d tentativas, cadastro no clube %v", len(data.Leads), len(data.Attempts), data.PartnerUser)
+	}
+	if data.Leads[0].Email != newEmail {
+		t.Errorf("export: e-mail %q, esperado %q", data.Leads[0].Email, newEmail)
+	}
+
+	resp, body := env.adminDo(t, "POST", "/admin/v1/subjects/erase", map[string]interface{}{
+		"cpf":             cpfNewResident,
+		"reason":          "pedido do titular",
+		"cascade_partner": true,
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("erase: status %d: %s", resp.StatusCode, body)
+	}
+	var erasure domain.SubjectErasure
+	if err := json.Unmarshal(body, &erasure); err != nil {
+		t.Fatalf("erase: %v", err)
+	}
+	if !erasure.PartnerUserDeleted || erasure.LeadsAnonymized != 1 || erasure.AttemptsDeleted != len(data.Attempts) {
+		t.Errorf("erase: %+v", erasure)
+	}
+	if len(env.fakes.Faults.Requests(fakes.EndpointDeleteUser)) != 1 {
+		t.Errorf("cadastro no clube não foi removido")
+	}
+
+	after := env.exportSubject(t, cpfNewResident)
+	if len(after.Leads) != 0 || len(after.Attempts) != 0 || len(after.Audit) != 0 {
+		t.Errorf("após eliminar: %d leads, %d tentativas, %d eventos", len(after.Leads), len(after.Attempts), len(after.Audit))
+	}
+
+	// O lead continua nos relatórios, sem identificar o morador
+	lead, err := env.repo.GetLead(ctx, domain.LeadID(data.Leads[0].CondoID, erasure.Pseudonym))
+	if err != nil {
+		t.Fatalf("lead anonimizado: %v", err)
+	}
+	if lead.Email != "" || lead.Name != "" || lead.Status != data.Leads[0].Status {
+		t.Errorf("lead anonimizado: %+v", lead)
+	}
+
+	events, err := env.repo.ListAudit(ctx, domain.AuditFilter{CPF: erasure.Pseudonym})
+	if err != nil {
+		t.Fatalf("ListAudit: %v", err)
+	}
+	signed := 0
+	for _, event := range events {
+		if event.Action != domain.AuditActionExportSubject && event.Action != domain.AuditActionEraseSubject {
+			continue
+		}
+		ok, err := env.admin.VerifyAuditSignature(ctx, event)
+		if err != nil || !ok {
+			t.Errorf("auditoria %s: assinatura inválida (%v)", event.Action, err)
+		}
+		signed++
+
+		event.Reason = "adulterado"
+		if ok, _ := env.admin.VerifyAuditSignature(ctx, event); ok {
+			t.Errorf("auditoria %s: assinatura aceita após alteração", event.Action)
+		}
+	}
+	if signed != 3 {
+		t.Errorf("esperados 3 pedidos assinados na auditoria, encontrados %d", signed)
+	}
+}
+
+// TestRetentionPurgesStaleLeads aplica as regras de exemplo da config:
+// visitantes não encontrados apagados, confirmações recusadas
+// pseudonimizadas, o restante mantido
+func TestRetentionPurgesStaleLeads(t *testing.T) {
+	env := newScenarioEnv(t)
+	ctx := context.Background()
+
+	env.post(t, "/v1/validate", domain.ValidationRequest{CPF: cpfUnknown})
+	if lead := env.lead(t, cpfUnknown); lead == nil || lead.Scenario != domain.ScenarioNotFound {
+		t.Fatalf("lead do visitante sem o cenário not_found: %+v", lead)
+	}
+
+	daysAgo := func(days int) time.Time { return time.Now().AddDate(0, 0, -days) }
+	leads := map[string]domain.Lead{
+		"visitante antigo":     {CPF: "11122233396", CondoID: "4", Status: domain.StatusRejected, Scenario: domain.ScenarioNotFound, Origin: "landing_page", UpdatedAt: daysAgo(40)},
+		"confirmação recusada": {CPF: "22233344405", CondoID: "4", Email: "recusado@example.com", Status: domain.StatusRejected, Scenario: domain.ScenarioError, Origin: "email_confirmation", UpdatedAt: daysAgo(100)},
+		"aprovado antigo":      {CPF: "33344455504", CondoID: "4", Status: domain.StatusApproved, Scenario: domain.ScenarioNewUser, Origin: "email_confirmation", UpdatedAt: daysAgo(400)},
+	}
+	for _, lead := range leads {
+		if err := env.repo.Save(ctx, lead); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+
+	policy := config.Retention{MaxPerRun: 100, WebhookDays: 30, Rules: []config.RetentionRule{
+		{Name: "visitantes", Scenario: domain.ScenarioNotFound, Days: 30, Action: domain.RetentionDelete},
+		{Name: "recusados", Origin: "email_confirmation", Status: domain.StatusRejected, Days: 90, Action: domain.RetentionPseudonymize},
+	}}
+	purger := retention.NewPurger(env.repo, secrets.Static("segredo-da-lgpd"))
+
+	dry, err := purger.Purge(ctx, policy, "teste", true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if dry.Rules[0].Deleted != 1 || dry.Rules[1].Pseudonymized != 1 {
+		t.Errorf("dry run: %+v", dry.Rules)
+	}
+	if env.lead(t, "11122233396") == nil {
+		t.Fatalf("dry run apagou o lead")
+	}
+
+	report, err := purger.Purge(ctx, policy, "teste", false)
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if report.Rules[0].Deleted != 1 || report.Rules[1].Pseudonymized != 1 || report.Rules[1].AttemptsDeleted != 1 {
+		t.Errorf("purge: %+v", report.Rules)
+	}
+	if w := report.Webhooks; w == nil || w.Days != 30 || !w.Complete || w.Error != "" {
+		t.Errorf("purge dos webhooks: %+v", w)
+	}
+	if env.lead(t, "11122233396") != nil || env.lead(t, "22233344405") != nil {
+		t.Errorf("leads vencidos continuam com o CPF")
+	}
+	if env.lead(t, cpfUnknown) == nil || env.lead(t, "33344455504") == nil {
+		t.Errorf("leads fora das regras foram expurgados")
+	}
+	anonymized, err := env.repo.GetLead(ctx, domain.LeadID("4", domain.Pseudonym("segredo-da-lgpd", "22233344405")))
+	if err != nil || anonymized.Email != "" || anonymized.Status != domain.StatusRejected {
+		t.Errorf("lead pseudonimizado: %+v (%v)", anonymized, err)
+	}
+
+	// A próxima execução continua de onde parou: nada a reler
+	again, err := purger.Purge(ctx, policy, "teste", false)
+	if err != nil {
+		t.Fatalf("segunda execução: %v", err)
+	}
+	if again.Rules[1].Scanned != 0 {
+		t.Errorf("segunda execução releu %d leads pseudonimizados", again.Rules[1].Scanned)
+	}
+
+	resp, body := env.adminDo(t, "GET", "/admin/v1/retention/reports", nil)
+	var out struct {
+		Reports []domain.RetentionReport `json:"reports"`
+	}
+	if resp.StatusCode != http.StatusOK || json.Unmarshal(body, &out) != nil || len(out.Reports) != 3 {
+		t.Fatalf("relatórios: status %d: %s", resp.StatusCode, body)
+	}
+	if out.Reports[2].DryRun != true || out.Reports[1].Rules[0].ByCondo["4"] != 1 {
+		t.Errorf("relatórios: %+v", out.Reports)
+	}
+}
+
+// TestAdminSearchByCPFInBody a busca por CPF só é aceita no corpo do POST,
+// fora da URL gravada no log de acesso
+func TestAdminSearchByCPFInBody(t *testing.T) {
+	env := newScenarioEnv(t)
+	env.post(t, "/v1/validate", domain.ValidationRequest{CPF: cpfNewResident, CondoID: "4"})
+
+	if resp, body := env.adminDo(t, "GET", "/admin/v1/leads?cpf="+cpfNewResident, nil); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("GET com cpf na query: status %d: %s", resp.StatusCode, body)
+	}
+
+	resp, body := env.adminDo(t, "POST", "/admin/v1/leads/search", map[string]interface{}{"cpf": cpfNewResident, "tenant": "4", "limit": 10})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("busca: status %d: %s", resp.StatusCode, body)
+	}
+	var result struct {
+		Leads []domain.Lead `json:"leads"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil || len(result.Leads) != 1 {
+		t.Errorf("busca: %v %s", err, body)
+	}
+
+	if resp, body := env.adminDo(t, "POST", "/admin/v1/audit/search", map[string]string{"cpf": cpfNewResident}); resp.StatusCode != http.StatusOK {
+		t.Errorf("auditoria: status %d: %s", resp.StatusCode, body)
+	}
+}
@@ -0,0 +1,755 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/viplounge/platform/internal/adapter"
+	"github.com/viplounge/platform/internal/auth"
+	"github.com/viplounge/platform/internal/config"
+	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/handler"
+	"github.com/viplounge/platform/internal/repository"
+	"github.com/viplounge/platform/internal/retention"
+	"github.com/viplounge/platform/internal/secrets"
+	"github.com/viplounge/platform/internal/service"
+	"github.com/viplounge/platform/internal/testing/fakes"
+)
+
+// CPFs das fixtures padrão (condomínio 4)
+const (
+	cpfNewResident = "52998224725" // na Superlógica, sem cadastro no clube
+	cpfMember      = "11144477735" // na Superlógica e no clube
+	cpfFormer      = "12345678909" // só no clube (não é mais morador)
+	cpfUnknown     = "98765432100" // em nenhum dos dois
+
+	memberID    = "00000000-0000-4000-8000-000000000001"
+	formerID    = "00000000-0000-4000-8000-000000000003"
+	memberEmail = "joao.ativo@example.com"
+	newEmail    = "maria.nova@example.com"
+
+	// adminKey API key "admin" (platform_admin) da config padrão
+	adminKey = "chave-do-suporte"
+)
+
+// Endpoints do clube conferidos em cada cenário
+var partnerEndpoints = []string{
+	fakes.EndpointFindUser,
+	fakes.EndpointCreateUser,
+	fakes.EndpointDeleteUser,
+	fakes.EndpointSSO,
+}
+
+// scenarioEnv é o servidor completo (handler, service, adapters reais e
+// repositório em memória) apontado para os fakes
+type scenarioEnv struct {
+	fakes  *fakes.Server
+	repo   *repository.MemoryRepository
+	server *httptest.Server
+	h      *handler.Handler
+	admin  *service.AdminService
+	svc    *service.ValidationService
+	// keyFile arquivo de chaves (KMS local) da cifragem dos leads
+	keyFile string
+}
+
+func newScenarioEnv(t *testing.T) *scenarioEnv {
+	t.Helper()
+
+	fake := fakes.NewServer(fakes.DefaultFixtures())
+	apis := httptest.NewServer(fake)
+	t.Cleanup(apis.Close)
+
+	for k, v := range fake.Env(apis.URL) {
+		t.Setenv(k, v)
+	}
+	// Sem token fixo, para passar pelo OAuth2 do client
+	t.Setenv("REDE_PARCERIAS_BEARER_TOKEN", "")
+	t.Setenv("DEFAULT_CONDO_ID", "4")
+	t.Setenv("ADMIN_API_KEY", adminKey)
+
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("config: %v", err)
+	}
+	provider, err := secrets.FromConfig(cfg)
+	if err != nil {
+		t.Fatalf("secrets: %v", err)
+	}
+	opts := adapter.Options{Secrets: provider}
+	validator, err := adapter.NewBenefValidator(cfg, opts)
+	if err != nil {
+		t.Fatalf("validator: %v", err)
+	}
+	clubs, err := adapter.NewPartnerRegistry(cfg, opts)
+	if err != nil {
+		t.Fatalf("clubs: %v", err)
+	}
+
+	// Leads cifrados em repouso, como em produção
+	repo := repository.NewMemoryRepository()
+	keyFile := filepath.Join(t.TempDir(), "pii-keys.json")
+	writeKeyFile(t, keyFile, "2026-01", "2026-01")
+	protector := newProtector(t, keyFile)
+	repo.EnableEncryption(protector)
+	svc := service.NewValidationService(repo, validator, clubs.Default(), cfg)
+	svc.SetClubs(clubs)
+	svc.SetSessions(repo, secrets.Static("segredo-das-sessoes"))
+	h := handler.NewHandler(svc, auth.NewAuthenticator(cfg, provider), cfg)
+	h.EnableIdempotency(repo)
+	h.EnableIdempotencySealing(protector)
+	admin := service.NewAdminService(repo, repo, clubs.Default())
+	admin.SetClubs(clubs)
+	admin.EnableSSO(svc)
+	admin.EnablePrivacy(repo, secrets.Static("segredo-da-lgpd"))
+	admin.EnableRetention(retention.NewPurger(repo, secrets.Static("segredo-da-lgpd")), repo)
+	admin.EnableConsents(repo)
+	admin.EnableEncryption(repo)
+	h.EnableAdmin(admin)
+
+	server := httptest.NewServer(h.Routes())
+	t.Cleanup(server.Close)
+
+	return &scenarioEnv{fakes: fake, repo: repo, server: server, h: h, admin: admin, svc: svc, keyFile: keyFile}
+}
+
+func (e *scenarioEnv) setFaults(t *testing.T, faults map[string]fakes.Fault) {
+	t.Helper()
+	for endpoint, fault := range faults {
+		if err := e.fakes.Faults.Set(endpoint, fault); err != nil {
+			t.Fatalf("fault %s: %v", endpoint, err)
+		}
+	}
+}
+
+func (e *scenarioEnv) send(t *testing.T, path string, body interface{}) *http.Response {
+	t.Helper()
+	payload, _ := json.Marshal(body)
+	resp, err := http.Post(e.server.URL+path, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		t.Fatalf("POST %s: %v", path, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func (e *scenarioEnv) post(t *testing.T, path string, body interface{}) domain.ValidationResponse {
+	t.Helper()
+	resp := e.send(t, path, body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST %s: status %d, esperado 200", path, resp.StatusCode)
+	}
+	var out domain.ValidationResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("POST %s: resposta inválida: %v", path, err)
+	}
+	return out
+}
+
+// wantError resposta de erro esperada
+type wantError struct {
+	Status int
+	Code   string
+}
+
+// postError faz o POST esperando a resposta de erro want
+func (e *scenarioEnv) postError(t *testing.T, path string, body interface{}, want wantError) {
+	t.Helper()
+	resp := e.send(t, path, body)
+	var out struct {
+		Error struct {
+			Code      string `json:"code"`
+			Message   string `json:"message"`
+			RequestID string `json:"request_id"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("POST %s: status %d, corpo de erro inválido: %v", path, resp.StatusCode, err)
+	}
+	if resp.StatusCode != want.Status || out.Error.Code != want.Code {
+		t.Errorf("POST %s: status %d código %q, esperado %d %q", path, resp.StatusCode, out.Error.Code, want.Status, want.Code)
+	}
+	if out.Error.Message == "" {
+		t.Errorf("POST %s: erro sem mensagem", path)
+	}
+	if id := resp.Header.Get("X-Request-Id"); id == "" || out.Error.RequestID != id {
+		t.Errorf("POST %s: request_id %q, header X-Request-Id %q", path, out.Error.RequestID, id)
+	}
+}
+
+// lead retorna o lead gravado para o CPF (nil se nenhum)
+func (e *scenarioEnv) lead(t *testing.T, cpf string) *domain.Lead {
+	t.Helper()
+	leads, err := e.repo.SearchLeads(context.Background(), domain.LeadFilter{CPF: cpf})
+	if err != nil {
+		t.Fatalf("SearchLeads: %v", err)
+	}
+	switch len(leads) {
+	case 0:
+		return nil
+	case 1:
+		return &leads[0]
+	default:
+		t.Fatalf("esperado um lead para %s, gravados %d", cpf, len(leads))
+		return nil
+	}
+}
+
+// ssoIdentifiers retorna o user_id de cada chamada de SSO, em ordem
+func (e *scenarioEnv) ssoIdentifiers() []string {
+	var ids []string
+	for _, req := range e.fakes.Faults.Requests(fakes.EndpointSSO) {
+		ids = append(ids, req.Query.Get("user_id"))
+	}
+	return ids
+}
+
+// wantLead campos conferidos no lead gravado
+type wantLead struct {
+	Status        string
+	PartnerStatus string
+	PartnerUserID string
+	// PartnerError prefixo esperado do erro do clube
+	PartnerError     string
+	SuperlogicaFound bool
+}
+
+func checkLead(t *testing.T, got *domain.Lead, want *wantLead) {
+	t.Helper()
+	if want == nil {
+		if got != nil {
+			t.Errorf("lead gravado inesperadamente: %+v", *got)
+		}
+		return
+	}
+	if got == nil {
+		t.Fatalf("nenhum lead gravado")
+	}
+	if got.Status != want.Status {
+		t.Errorf("lead.Status = %q, esperado %q", got.Status, want.Status)
+	}
+	if got.RedeParceriasStatus != want.PartnerStatus {
+		t.Errorf("lead.RedeParceriasStatus = %q, esperado %q", got.RedeParceriasStatus, want.PartnerStatus)
+	}
+	if got.RedeParceriasUserID != want.PartnerUserID {
+		t.Errorf("lead.RedeParceriasUserID = %q, esperado %q", got.RedeParceriasUserID, want.PartnerUserID)
+	}
+	if !strings.HasPrefix(got.RedeParceriasError, want.PartnerError) || (want.PartnerError == "" && got.RedeParceriasError != "") {
+		t.Errorf("lead.RedeParceriasError = %q, esperado %q", got.RedeParceriasError, want.PartnerError)
+	}
+	if got.SuperlogicaFound != want.SuperlogicaFound {
+		t.Errorf("lead.SuperlogicaFound = %v, esperado %v", got.SuperlogicaFound, want.SuperlogicaFound)
+	}
+}
+
+// checkCalls compara as chamadas ao clube; endpoints ausentes em want = 0
+func checkCalls(t *testing.T, env *scenarioEnv, want map[string]int) {
+	t.Helper()
+	calls := env.fakes.Faults.Calls()
+	for _, endpoint := range partnerEndpoints {
+		if calls[endpoint] != want[endpoint] {
+			t.Errorf("chamadas a %s = %d, esperado %d", endpoint, calls[endpoint], want[endpoint])
+		}
+	}
+}
+
+func unavailable() fakes.Fault { return fakes.Fault{Status: http.StatusInternalServerError} }
+
+// errUnavailable resposta quando a Superlógica não responde
+var errUnavailable = &wantError{Status: http.StatusServiceUnavailable, Code: "UNAVAILABLE"}
+
+func TestValidateScenarios(t *testing.T) {
+	tests := []struct {
+		name   string
+		cpf    string
+		faults map[string]fakes.Fault
+		// env variáveis de ambiente da config do cenário
+		env map[string]string
+
+		wantScenario string
+		wantValid    bool
+		wantUserID   string
+		// wantError resposta de erro no lugar do cenário
+		wantError *wantError
+		wantLead  *wantLead
+		wantCalls map[string]int
+	}{
+		{
+			name:         "superlógica encontrado, clube não encontrado",
+			cpf:          cpfNewResident,
+			wantScenario: domain.ScenarioPendingEmailConfirmation,
+			wantValid:    true,
+			wantLead:     &wantLead{Status: domain.StatusPending, PartnerStatus: domain.PartnerStatusPending, SuperlogicaFound: true},
+			wantCalls:    map[string]int{fakes.EndpointFindUser: 1},
+		},
+		{
+			name:         "superlógica encontrado, clube encontrado",
+			cpf:          cpfMember,
+			wantScenario: domain.ScenarioPendingEmailConfirmation,
+			wantValid:    true,
+			wantUserID:   memberID,
+			wantLead:     &wantLead{Status: domain.StatusPending, PartnerStatus: domain.PartnerStatusRegistered, PartnerUserID: memberID, SuperlogicaFound: true},
+			wantCalls:    map[string]int{fakes.EndpointFindUser: 1},
+		},
+		{
+			name:         "superlógica encontrado, clube com erro",
+			cpf:          cpfMember,
+			faults:       map[string]fakes.Fault{fakes.EndpointFindUser: unavailable()},
+			wantScenario: domain.ScenarioPendingEmailConfirmation,
+			wantValid:    true,
+			wantLead:     &wantLead{Status: domain.StatusPending, PartnerStatus: domain.PartnerStatusPending, SuperlogicaFound: true},
+			wantCalls:    map[string]int{fakes.EndpointFindUser: 1},
+		},
+		{
+			name:         "superlógica não encontrado, clube encontrado",
+			cpf:          cpfFormer,
+			wantScenario: domain.ScenarioRevokedUser,
+			wantLead:     &wantLead{Status: domain.StatusRejected, PartnerStatus: domain.PartnerStatusRevoked, PartnerUserID: formerID},
+			wantCalls:    map[string]int{fakes.EndpointFindUser: 1, fakes.EndpointDeleteUser: 1},
+		},
+		{
+			name:         "superlógica não encontrado, clube não encontrado",
+			cpf:          cpfUnknown,
+			wantScenario: domain.ScenarioNotFound,
+			wantLead:     &wantLead{Status: domain.StatusRejected},
+			wantCalls:    map[string]int{fakes.EndpointFindUser: 1},
+		},
+		{
+			name:         "superlógica não encontrado, clube com erro",
+			cpf:          cpfFormer,
+			faults:       map[string]fakes.Fault{fakes.EndpointFindUser: unavailable()},
+			wantScenario: domain.ScenarioNotFound,
+			wantLead:     &wantLead{Status: domain.StatusRejected},
+			wantCalls:    map[string]int{fakes.EndpointFindUser: 1},
+		},
+		{
+			// Falha na Superlógica não conta como "não encontrado": o membro
+			// do clube não é revogado
+			name:      "superlógica com erro, clube encontrado",
+			cpf:       cpfMember,
+			faults:    map[string]fakes.Fault{fakes.EndpointUnits: unavailable()},
+			wantError: errUnavailable,
+			wantLead:  &wantLead{Status: domain.StatusError},
+			wantCalls: map[string]int{fakes.EndpointFindUser: 1},
+		},
+		{
+			name:      "superlógica com erro, clube não encontrado",
+			cpf:       cpfNewResident,
+			faults:    map[string]fakes.Fault{fakes.EndpointUnits: unavailable()},
+			wantError: errUnavailable,
+			wantLead:  &wantLead{Status: domain.StatusError},
+			wantCalls: map[string]int{fakes.EndpointFindUser: 1},
+		},
+		{
+			name: "superlógica com erro, clube com erro",
+			cpf:  cpfMember,
+			faults: map[string]fakes.Fault{
+				fakes.EndpointUnits:    unavailable(),
+				fakes.EndpointFindUser: unavailable(),
+			},
+			wantError: errUnavailable,
+			wantLead:  &wantLead{Status: domain.StatusError},
+			wantCalls: map[string]int{fakes.EndpointFindUser: 1},
+		},
+		{
+			// Fonte de moradores desligada também não decide: o membro do
+			// clube continua cadastrado
+			name:      "superlógica desligada, clube encontrado",
+			cpf:       cpfFormer,
+			env:       map[string]string{"SUPERLOGICA_ENABLED": "false"},
+			wantError: &wantError{Status: http.StatusServiceUnavailable, Code: "INTEGRATION_DISABLED"},
+			wantLead:  &wantLead{Status: domain.StatusError},
+			wantCalls: map[string]int{fakes.EndpointFindUser: 1},
+		},
+		{
+			name:      "superlógica limitando requisições",
+			cpf:       cpfMember,
+			faults:    map[string]fakes.Fault{fakes.EndpointUnits: {Status: http.StatusTooManyRequests}},
+			wantError: &wantError{Status: http.StatusTooManyRequests, Code: "RATE_LIMITED"},
+			wantLead:  &wantLead{Status: domain.StatusError},
+			wantCalls: map[string]int{fakes.EndpointFindUser: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			env := newScenarioEnv(t)
+			env.setFaults(t, tt.faults)
+
+			req := domain.ValidationRequest{CPF: tt.cpf, CondoID: "4"}
+			if tt.wantError != nil {
+				env.postError(t, "/v1/validate", req, *tt.wantError)
+			} else {
+				resp := env.post(t, "/v1/validate", req)
+
+				if resp.Scenario != tt.wantScenario {
+					t.Errorf("scenario = %q, esperado %q", resp.Scenario, tt.wantScenario)
+				}
+				if resp.Valid != tt.wantValid {
+					t.Errorf("valid = %v, esperado %v", resp.Valid, tt.wantValid)
+				}
+				if resp.UserID != tt.wantUserID {
+					t.Errorf("user_id = %q, esperado %q", resp.UserID, tt.wantUserID)
+				}
+				// Nenhum acesso é gerado antes da confirmação do e-mail
+				if resp.RedirectURL != "" {
+					t.Errorf("SSO gerado na validação: %+v", resp)
+				}
+			}
+			checkLead(t, env.lead(t, tt.cpf), tt.wantLead)
+			checkCalls(t, env, tt.wantCalls)
+		})
+	}
+}
+
+func TestValidateRevokedUserIsDeletedFromClub(t *testing.T) {
+	env := newScenarioEnv(t)
+	env.post(t, "/v1/validate", domain.ValidationRequest{CPF: cpfFormer, CondoID: "4"})
+
+	if _, ok := env.fakes.RedeParcerias.FindCPF(cpfFormer); ok {
+		t.Errorf("usuário revogado continua no clube")
+	}
+	deletes := env.fakes.Faults.Requests(fakes.EndpointDeleteUser)
+	if len(deletes) != 1 || !strings.HasSuffix(deletes[0].Path, "/users/"+formerID) {
+		t.Errorf("DELETE esperado em /users/%s, recebido %+v", formerID, deletes)
+	}
+}
+
+func TestConfirmEmailScenarios(t *testing.T) {
+	tests := []struct {
+		name   string
+		cpf    string
+		email  string
+		faults map[string]fakes.Fault
+
+		wantScenario string
+		wantValid    bool
+		wantUserID   string
+		wantError    *wantError
+		// wantAccess resposta com redirect_url de uso único
+		wantAccess bool
+		wantLead   *wantLead
+		wantCalls  map[string]int
+		// wantSSO identificadores usados no SSO, em ordem
+		wantSSO []string
+	}{
+		{
+			name:         "novo usuário cadastrado e SSO por e-mail",
+			cpf:          cpfNewResident,
+			email:        newEmail,
+			wantScenario: domain.ScenarioNewUser,
+			wantValid:    true,
+			wantAccess:   true,
+			wantLead:     &wantLead{Status: domain.StatusApproved, PartnerStatus: domain.PartnerStatusRegistered, PartnerUserID: "00000000-0000-4000-8000-000000000004", SuperlogicaFound: true},
+			wantCalls:    map[string]int{fakes.EndpointFindUser: 1, fakes.EndpointCreateUser: 1, fakes.EndpointSSO: 1},
+			wantSSO:      []string{newEmail},
+		},
+		{
+			name:         "novo usuário com SSO por e-mail falhando e por ID funcionando",
+			cpf:          cpfNewResident,
+			email:        newEmail,
+			faults:       map[string]fakes.Fault{fakes.EndpointSSO: {Status: http.StatusInternalServerError, Times: 1}},
+			wantScenario: domain.ScenarioNewUser,
+			wantValid:    true,
+			wantAccess:   true,
+			wantLead:     &wantLead{Status: domain.StatusApproved, PartnerStatus: domain.PartnerStatusRegistered, PartnerUserID: "00000000-0000-4000-8000-000000000004", SuperlogicaFound: true},
+			wantCalls:    map[string]int{fakes.EndpointFindUser: 1, fakes.EndpointCreateUser: 1, fakes.EndpointSSO: 2},
+			wantSSO:      []string{newEmail, "00000000-0000-4000-8000-000000000004"},
+		},
+		{
+			name:         "usuário existente com SSO por e-mail",
+			cpf:          cpfMember,
+			email:        memberEmail,
+			wantScenario: domain.ScenarioExistingUser,
+			wantValid:    true,
+			wantUserID:   memberID,
+			wantAccess:   true,
+			wantLead:     &wantLead{Status: domain.StatusApproved, PartnerStatus: domain.PartnerStatusRegistered, PartnerUserID: memberID, SuperlogicaFound: true},
+			wantCalls:    map[string]int{fakes.EndpointFindUser: 1, fakes.EndpointSSO: 1},
+			wantSSO:      []string{memberEmail},
+		},
+		{
+			name:         "usuário existente com SSO por e-mail falhando e por ID funcionando",
+			cpf:          cpfMember,
+			email:        memberEmail,
+			faults:       map[string]fakes.Fault{fakes.EndpointSSO: {Status: http.StatusServiceUnavailable, Times: 1}},
+			wantScenario: domain.ScenarioExistingUser,
+			wantValid:    true,
+			wantUserID:   memberID,
+			wantAccess:   true,
+			wantLead:     &wantLead{Status: domain.StatusApproved, PartnerStatus: domain.PartnerStatusRegistered, PartnerUserID: memberID, SuperlogicaFound: true},
+			wantCalls:    map[string]int{fakes.EndpointFindUser: 1, fakes.EndpointSSO: 2},
+			wantSSO:      []string{memberEmail, memberID},
+		},
+		{
+			// O lead não é aprovado, mas a resposta continua valid=true
+			name:         "usuário existente com SSO falhando nas duas tentativas",
+			cpf:          cpfMember,
+			email:        memberEmail,
+			faults:       map[string]fakes.Fault{fakes.EndpointSSO: unavailable()},
+			wantScenario: domain.ScenarioExistingUser,
+			wantValid:    true,
+			wantUserID:   memberID,
+			wantLead:     &wantLead{SuperlogicaFound: true},
+			wantCalls:    map[string]int{fakes.EndpointFindUser: 1, fakes.EndpointSSO: 2},
+			wantSSO:      []string{memberEmail, memberID},
+		},
+		{
+			name:         "e-mail confirmado sem diferenciar maiúsculas e espaços",
+			cpf:          cpfMember,
+			email:        "  JOAO.Ativo@Example.COM ",
+			wantScenario: domain.ScenarioExistingUser,
+			wantValid:    true,
+			wantUserID:   memberID,
+			wantAccess:   true,
+			wantLead:     &wantLead{Status: domain.StatusApproved, PartnerStatus: domain.PartnerStatusRegistered, PartnerUserID: memberID, SuperlogicaFound: true},
+			wantCalls:    map[string]int{fakes.EndpointFindUser: 1, fakes.EndpointSSO: 1},
+			wantSSO:      []string{memberEmail},
+		},
+		{
+			name:         "e-mail diferente do cadastrado",
+			cpf:          cpfMember,
+			email:        "outro@example.com",
+			wantScenario: domain.ScenarioError,
+			wantLead:     &wantLead{Status: domain.StatusRejected, SuperlogicaFound: true},
+		},
+		{
+			name:         "CPF fora da Superlógica",
+			cpf:          cpfFormer,
+			email:        "pedro.saiu@example.com",
+			wantScenario: domain.ScenarioError,
+		},
+		{
+			name:      "Superlógica indisponível",
+			cpf:       cpfMember,
+			email:     memberEmail,
+			faults:    map[string]fakes.Fault{fakes.EndpointUnits: unavailable()},
+			wantError: errUnavailable,
+		},
+		{
+			// RegisterAndGetSSO falha e o fallback tenta o SSO pelo e-mail
+			name:         "falha no cadastro do novo usuário",
+			cpf:          cpfNewResident,
+			email:        newEmail,
+			faults:       map[string]fakes.Fault{fakes.EndpointCreateUser: unavailable()},
+			wantScenario: domain.ScenarioNewUser,
+			wantValid:    true,
+			wantLead:     &wantLead{PartnerStatus: domain.PartnerStatusFailed, PartnerError: "HTTP_500", SuperlogicaFound: true},
+			wantCalls:    map[string]int{fakes.EndpointFindUser: 1, fakes.EndpointCreateUser: 1, fakes.EndpointSSO: 1},
+			wantSSO:      []string{newEmail},
+		},
+		{
+			// Dados recusados não mudam numa nova tentativa: sem SSO de fallback
+			name:         "cadastro recusado pelo clube",
+			cpf:          cpfNewResident,
+			email:        newEmail,
+			faults:       map[string]fakes.Fault{fakes.EndpointCreateUser: {Status: http.StatusUnprocessableEntity}},
+			wantScenario: domain.ScenarioNewUser,
+			wantValid:    true,
+			wantLead:     &wantLead{PartnerStatus: domain.PartnerStatusFailed, PartnerError: "VALIDATION_ERROR", SuperlogicaFound: true},
+			wantCalls:    map[string]int{fakes.EndpointFindUser: 1, fakes.EndpointCreateUser: 1},
+		},
+		{
+			// A busca falha, o cadastro responde 422 "já cadastrado" e o
+			// acesso sai pelo e-mail do cadastro existente
+			name:         "422 usuário já cadastrado",
+			cpf:          cpfMember,
+			email:        memberEmail,
+			faults:       map[string]fakes.Fault{fakes.EndpointFindUser: unavailable()},
+			wantScenario: domain.ScenarioNewUser,
+			wantValid:    true,
+			wantAccess:   true,
+			wantLead:     &wantLead{Status: domain.StatusApproved, PartnerStatus: domain.PartnerStatusRegistered, PartnerError: "USER_ALREADY_EXISTS", SuperlogicaFound: true},
+			wantCalls:    map[string]int{fakes.EndpointFindUser: 1, fakes.EndpointCreateUser: 1, fakes.EndpointSSO: 1},
+			wantSSO:      []string{memberEmail},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newScenarioEnv(t)
+			env.setFaults(t, tt.faults)
+
+			req := domain.EmailConfirmationRequest{CPF: tt.cpf, Email: tt.email}
+			if tt.wantError != nil {
+				env.postError(t, "/v1/confirm-email", req, *tt.wantError)
+			} else {
+				resp := env.post(t, "/v1/confirm-email", req)
+
+				if resp.Scenario != tt.wantScenario {
+					t.Errorf("scenario = %q, esperado %q", resp.Scenario, tt.wantScenario)
+				}
+				if resp.Valid != tt.wantValid {
+					t.Errorf("valid = %v, esperado %v", resp.Valid, tt.wantValid)
+				}
+				if tt.wantUserID != "" && resp.UserID != tt.wantUserID {
+					t.Errorf("user_id = %q, esperado %q", resp.UserID, tt.wantUserID)
+				}
+				if gotAccess := strings.HasPrefix(resp.RedirectURL, domain.SSOPath); gotAccess != tt.wantAccess {
+					t.Errorf("acesso gerado = %v, esperado %v (redirect_url=%q)", gotAccess, tt.wantAccess, resp.RedirectURL)
+				}
+			}
+			checkLead(t, env.lead(t, tt.cpf), tt.wantLead)
+			checkCalls(t, env, tt.wantCalls)
+
+			gotSSO := env.ssoIdentifiers()
+			if strings.Join(gotSSO, ",") != strings.Join(tt.wantSSO, ",") {
+				t.Errorf("SSO pedido com %v, esperado %v", gotSSO, tt.wantSSO)
+			}
+		})
+	}
+}
+
+func TestConfirmEmailRegistersNewUserInClub(t *testing.T) {
+	env := newScenarioEnv(t)
+	env.post(t, "/v1/confirm-email", domain.EmailConfirmationRequest{CPF: cpfNewResident, Email: newEmail})
+
+	user, ok := env.fakes.RedeParcerias.FindCPF(cpfNewResident)
+	if !ok {
+		t.Fatalf("novo usuário não foi cadastrado no clube")
+	}
+	if user.Email != newEmail || user.Name != "Maria Nova Moradora" {
+		t.Errorf("cadastro com dados da Superlógica esperado, recebido %+v", user)
+	}
+	if user.Cellphone != "(11) 98765-4321" {
+		t.Errorf("celular = %q, esperado no formato (XX) 9XXXX-XXXX", user.Cellphone)
+	}
+}
+
+// eventRecorder guarda os eventos publicados para os webhooks de saída
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []domain.OutboundEvent
+}
+
+func (r *eventRecorder) Publish(ctx context.Context, event domain.OutboundEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+// TestConfirmEmailEventTenant confirma pelo host de busca global: o lead e o
+// lead.activated ficam no condomínio real do morador, para os webhooks do
+// condomínio receberem a ativação
+func TestConfirmEmailEventTenant(t *testing.T) {
+	env := newScenarioEnv(t)
+	events := &eventRecorder{}
+	env.svc.SetPublisher(events)
+
+	env.post(t, "/v1/confirm-email", domain.EmailConfirmationRequest{CPF: cpfNewResident, Email: newEmail})
+
+	if lead := env.lead(t, cpfNewResident); lead == nil || lead.CondoID != "4" {
+		t.Fatalf("lead fora do condomínio 4: %+v", lead)
+	}
+	activated := 0
+	for _, event := range events.events {
+		if event.TenantID != "4" || event.Data["condo_id"] != "4" {
+			t.Errorf("evento %s com tenant %q (condo_id %v), esperado 4", event.Type, event.TenantID, event.Data["condo_id"])
+		}
+		if event.Type == domain.EventLeadActivated {
+			activated++
+		}
+	}
+	if activated != 1 {
+		t.Errorf("esperado um %s, publicados %+v", domain.EventLeadActivated, events.events)
+	}
+}
+
+func TestSSOHandleRedirectsOnce(t *testing.T) {
+	env := newScenarioEnv(t)
+	resp := env.send(t, "/v1/confirm-email", domain.EmailConfirmationRequest{CPF: cpfMember, Email: memberEmail})
+	body, _ := io.ReadAll(resp.Body)
+
+	// O token do clube não sai na resposta JSON
+	if strings.Contains(string(body), "clube.example") || strings.Contains(string(body), "token") {
+		t.Fatalf("resposta expõe o acesso do clube: %s", body)
+	}
+	var out domain.ValidationResponse
+	if err := json.Unmarshal(body, &out); err != nil {
+		t.Fatalf("resposta inválida: %v", err)
+	}
+	if !strings.HasPrefix(out.RedirectURL, domain.SSOPath) {
+		t.Fatalf("redirect_url = %q, esperado %s{handle}", out.RedirectURL, domain.SSOPath)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	follow := func() string {
+		t.Helper()
+		resp, err := client.Get(env.server.URL + out.RedirectURL)
+		if err != nil {
+			t.Fatalf("GET %s: %v", out.RedirectURL, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusSeeOther {
+			t.Fatalf("GET %s: status %d, esperado 303", out.RedirectURL, resp.StatusCode)
+		}
+		if cc := resp.Header.Get("Cache-Control"); cc != "no-store" {
+			t.Errorf("Cache-Control = %q, esperado no-store", cc)
+		}
+		return resp.Header.Get("Location")
+	}
+
+	if location := follow(); !strings.HasPrefix(location, "https://clube.example/sso?token=") {
+		t.Errorf("Location = %q, esperado o acesso do clube", location)
+	}
+	// Segundo uso do mesmo handle volta para a landing page
+	if location := follow(); location != "/?sso=expired" {
+		t.Errorf("Location = %q no segundo uso, esperado /?sso=expired", location)
+	}
+}
+
+// TestAdminSSOIssuesHandle o link gerado pelo suporte também é um handle
+// de uso único: o token do clube não sai na resposta da API do suporte
+func TestAdminSSOIssuesHandle(t *testing.T) {
+	env := newScenarioEnv(t)
+	env.post(t, "/v1/confirm-email", domain.EmailConfirmationRequest{CPF: cpfMember, Email: memberEmail})
+	lead := env.lead(t, cpfMember)
+	if lead == nil {
+		t.Fatalf("lead de %s não gravado", cpfMember)
+	}
+
+	for _, action := range []string{"sso", "register"} {
+		resp, body := env.adminDo(t, "POST", "/admin/v1/leads/"+domain.LeadID(lead.CondoID, cpfMember)+"/"+action, map[string]string{"reason": "chamado 123"})
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: status %d: %s", action, resp.StatusCode, body)
+		}
+		if strings.Contains(string(body), "clube.example") || strings.Contains(string(body), "token") {
+			t.Errorf("%s expõe o acesso do clube: %s", action, body)
+		}
+		var result domain.AdminActionResult
+		if err := json.Unmarshal(body, &result); err != nil || !strings.HasPrefix(result.RedirectURL, domain.SSOPath) {
+			t.Errorf("%s: redirect_url = %q, esperado %s{handle}", action, result.RedirectURL, domain.SSOPath)
+		}
+	}
+}
+
+func TestExpiredTokenIsRenewed(t *testing.T) {
+	env := newScenarioEnv(t)
+	env.setFaults(t, map[string]fakes.Fault{fakes.EndpointFindUser: {ExpiredToken: true, Times: 1}})
+
+	// A primeira busca recebe 401 e conta como "não cadastrado"
+	first := env.post(t, "/v1/validate", domain.ValidationRequest{CPF: cpfMember, CondoID: "4"})
+	if first.UserID != "" {
+		t.Errorf("busca com token expirado retornou user_id %q", first.UserID)
+	}
+
+	// A seguinte autentica de novo e encontra o usuário
+	second := env.post(t, "/v1/validate", domain.ValidationRequest{CPF: cpfMember, CondoID: "4"})
+	if second.UserID != memberID {
+		t.Errorf("user_id = %q, esperado %q", second.UserID, memberID)
+	}
+	if calls := env.fakes.Faults.Calls()[fakes.EndpointAuth]; calls != 2 {
+		t.Errorf("chamadas a %s = %d, esperado 2", fakes.EndpointAuth, calls)
+	}
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	customMiddleware "github.com/viplounge/platform/internal/middleware"
+	"github.com/viplounge/platform/internal/service"
+)
+
+// codeSessionInvalid cookie "lembrar de mim" ausente, vencido ou revogado
+const codeSessionInvalid = "SESSION_INVALID"
+
+// Rotas do "lembrar de mim"
+const (
+	sessionRefreshPath = "/v1/sso/refresh"
+	sessionLogoutPath  = "/v1/logout"
+)
+
+// setSessionCookie grava o cookie da sessão emitida na ativação. httpOnly:
+// o JavaScript da página nunca lê o valor.
+func (h *Handler) setSessionCookie(w http.ResponseWriter, r *http.Request, value string) {
+	cfg := h.config(r).RememberMe
+	http.SetCookie(w, &http.Cookie{
+		Name:     cfg.CookieName,
+		Value:    value,
+		Path:     "/v1/",
+		MaxAge:   int((time.Duration(cfg.TTLDays) * 24 * time.Hour).Seconds()),
+		HttpOnly: true,
+		Secure:   h.secureCookie(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (h *Handler) clearSessionCookie(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     h.config(r).RememberMe.CookieName,
+		Path:     "/v1/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   h.secureCookie(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// secureCookie em produção sempre; fora dela só quando a requisição chegou
+// por HTTPS (direto ou pelo load balancer)
+func (h *Handler) secureCookie(r *http.Request) bool {
+	return h.config(r).IsProduction() || r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+}
+
+// POST /v1/sso/refresh
+// Novo acesso ao clube para o morador com a sessão "lembrar de mim"
+func (h *Handler) handleSessionRefresh(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(h.config(r).RememberMe.CookieName)
+	if err != nil {
+		writeErrorCode(w, r, http.StatusUnauthorized, codeSessionInvalid, "Sessão expirada. Informe seu CPF para entrar.")
+		return
+	}
+
+	resp, err := h.svc.RefreshSession(r.Context(), cookie.Value, customMiddleware.GetTenantID(r.Context()))
+	if errors.Is(err, service.ErrSessionInvalid) {
+		h.clearSessionCookie(w, r)
+		writeErrorCode(w, r, http.StatusUnauthorized, codeSessionInvalid, "Sessão expirada. Informe seu CPF para entrar.")
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// POST /v1/logout
+// Revoga a sessão "lembrar de mim" e apaga o cookie
+func (h *Handler) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(h.config(r).RememberMe.CookieName); err == nil {
+		h.svc.EndSession(r.Context(), cookie.Value, customMiddleware.GetTenantID(r.Context()))
+	}
+	h.clearSessionCookie(w, r)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,145 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/testing/fakes"
+)
+
+// browser cliente com cookies, como o navegador do morador
+type browser struct {
+	t      *testing.T
+	env    *scenarioEnv
+	client *http.Client
+}
+
+func newBrowser(t *testing.T, env *scenarioEnv) *browser {
+	jar, _ := cookiejar.New(nil)
+	return &browser{t: t, env: env, client: &http.Client{Jar: jar}}
+}
+
+func (b *browser) post(path string, body interface{}) (*http.Response, domain.ValidationResponse) {
+	b.t.Helper()
+	payload, _ := json.Marshal(body)
+	resp, err := b.client.Post(b.env.server.URL+path, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		b.t.Fatalf("POST %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+	var out domain.ValidationResponse
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			b.t.Fatalf("POST %s: resposta inválida: %v", path, err)
+		}
+	}
+	return resp, out
+}
+
+// sessionCookie cookie "lembrar de mim" gravado no navegador
+func (b *browser) sessionCookie() *http.Cookie {
+	u, _ := http.NewRequest("GET", b.env.server.URL+"/v1/", nil)
+	for _, c := range b.client.Jar.Cookies(u.URL) {
+		if c.Name == "vl_session" {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestRememberMeRefreshesAccessWithoutCPF(t *testing.T) {
+	t.Setenv("REMEMBER_ME_ENABLED", "true")
+	env := newScenarioEnv(t)
+	b := newBrowser(t, env)
+
+	resp, activation := b.post("/v1/confirm-email", domain.EmailConfirmationRequest{CPF: cpfMember, Email: memberEmail})
+	if !activation.RememberMe || b.sessionCookie() == nil {
+		t.Fatalf("sessão não emitida na ativação (remember_me=%v)", activation.RememberMe)
+	}
+	if setCookie := resp.Header.Get("Set-Cookie"); !strings.Contains(setCookie, "HttpOnly") {
+		t.Errorf("cookie sem HttpOnly: %s", setCookie)
+	}
+	env.fakes.Faults.ResetCalls()
+
+	// Nova visita: acesso direto pelo ID do morador no clube, sem Superlógica
+	resp, refreshed := b.post("/v1/sso/refresh", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("refresh: status %d, esperado 200", resp.StatusCode)
+	}
+	if refreshed.Scenario != domain.ScenarioExistingUser || !strings.HasPrefix(refreshed.RedirectURL, domain.SSOPath) {
+		t.Errorf("refresh: scenario %q redirect_url %q", refreshed.Scenario, refreshed.RedirectURL)
+	}
+	checkCalls(t, env, map[string]int{fakes.EndpointSSO: 1})
+	if ids := env.ssoIdentifiers(); len(ids) != 1 || ids[0] != memberID {
+		t.Errorf("SSO pedido com %v, esperado [%s]", ids, memberID)
+	}
+	if calls := env.fakes.Faults.Calls()[fakes.EndpointUnits]; calls != 0 {
+		t.Errorf("refresh consultou a Superlógica %d vezes antes do prazo de revalidação", calls)
+	}
+
+	// Logout revoga a sessão no servidor: o cookie antigo não vale mais
+	cookie := b.sessionCookie()
+	if resp, _ := b.post("/v1/logout", nil); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("logout: status %d, esperado 204", resp.StatusCode)
+	}
+	if b.sessionCookie() != nil {
+		t.Errorf("cookie não apagado no logout")
+	}
+	b.client.Jar.SetCookies(resp.Request.URL, []*http.Cookie{{Name: cookie.Name, Value: cookie.Value, Path: "/v1/"}})
+	if resp, _ := b.post("/v1/sso/refresh", nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("refresh após logout: status %d, esperado 401", resp.StatusCode)
+	}
+}
+
+func TestRememberMeRevalidatesAgainstSuperlogica(t *testing.T) {
+	t.Setenv("REMEMBER_ME_ENABLED", "true")
+	env := newScenarioEnv(t)
+	b := newBrowser(t, env)
+	b.post("/v1/confirm-email", domain.EmailConfirmationRequest{CPF: cpfMember, Email: memberEmail})
+	cookie := b.sessionCookie()
+	if cookie == nil {
+		t.Fatalf("sessão não emitida na ativação")
+	}
+
+	// Validação antiga: o store guarda o sha256 do identificador do cookie
+	id, _, _ := strings.Cut(cookie.Value, ".")
+	sum := sha256.Sum256([]byte(id))
+	session, err := env.repo.GetSession(context.Background(), hex.EncodeToString(sum[:]))
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	session.ValidatedAt = time.Now().Add(-48 * time.Hour)
+	env.repo.SaveSession(context.Background(), *session)
+
+	// Morador saiu do condomínio: a sessão é revogada
+	env.fakes.Superlogica.RemoveCPF(cpfMember)
+	if resp, _ := b.post("/v1/sso/refresh", nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("refresh de ex-morador: status %d, esperado 401", resp.StatusCode)
+	}
+	if _, err := env.repo.GetSession(context.Background(), session.ID); err != domain.ErrNotFound {
+		t.Errorf("sessão de ex-morador não revogada: %v", err)
+	}
+	checkCalls(t, env, map[string]int{fakes.EndpointFindUser: 1, fakes.EndpointSSO: 1})
+}
+
+func TestRememberMeDisabledByDefault(t *testing.T) {
+	env := newScenarioEnv(t)
+	b := newBrowser(t, env)
+
+	_, activation := b.post("/v1/confirm-email", domain.EmailConfirmationRequest{CPF: cpfMember, Email: memberEmail})
+	if activation.RememberMe || b.sessionCookie() != nil {
+		t.Errorf("sessão emitida com remember_me desabilitado")
+	}
+	if resp, _ := b.post("/v1/sso/refresh", nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("refresh sem sessão: status %d, esperado 401", resp.StatusCode)
+	}
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/viplounge/platform/internal/domain"
+)
+
+// Valores de ?sso= na landing page quando o acesso não pode ser usado
+const (
+	ssoExpired = "expired"
+	ssoFailed  = "error"
+)
+
+// GET /v1/sso/{handle}
+// Troca o handle da ativação pela URL do clube (uso único). O token do clube
+// só aparece no Location deste redirecionamento.
+func (h *Handler) handleSSO(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+
+	redirect, err := h.svc.ResolveSSO(r.Context(), chi.URLParam(r, "handle"))
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		log.Printf("[SSO] Acesso expirado ou já usado")
+		http.Redirect(w, r, h.ssoFailureURL(r, ssoExpired), http.StatusSeeOther)
+	case err != nil:
+		log.Printf("[SSO] Erro resolvendo acesso: %v", err)
+		http.Redirect(w, r, h.ssoFailureURL(r, ssoFailed), http.StatusSeeOther)
+	default:
+		log.Printf("[SSO] Redirecionando para %s", domain.RedactURL(redirect))
+		http.Redirect(w, r, redirect, http.StatusSeeOther)
+	}
+}
+
+// ssoFailureURL behavior.redirect_url_on_error ou a landing page, com o
+// motivo em ?sso= para ela exibir a mensagem
+func (h *Handler) ssoFailureURL(r *http.Request, reason string) string {
+	target := h.config(r).Behavior.RedirectURLOnError
+	if target == "" {
+		target = "/"
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return "/?sso=" + reason
+	}
+	q := u.Query()
+	q.Set("sso", reason)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
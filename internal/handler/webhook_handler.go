@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/viplounge/platform/internal/service"
+)
+
+// Tamanho máximo aceito para uma notificação
+const maxWebhookBody = 1 << 20
+
+// EnableWebhooks liga o endpoint de notificações da Superlógica
+func (h *Handler) EnableWebhooks(webhooks *service.WebhookService) {
+	h.webhooks = webhooks
+}
+
+// POST /webhooks/superlogica
+// Responde 2xx também para entregas repetidas, para a Superlógica não insistir
+func (h *Handler) handleSuperlogicaWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		invalidRequestBody(w, r)
+		return
+	}
+
+	// A config do serviço, não a da requisição: webhooks.superlogica.* só
+	// muda com restart e o serviço confere a credencial com ela
+	settings := h.webhooks.Settings()
+	credential := r.Header.Get(settings.SignatureHeader)
+	if credential == "" && settings.Auth == "token" {
+		credential = r.URL.Query().Get("token")
+	}
+	var timestamp string
+	if settings.TimestampHeader != "" {
+		timestamp = r.Header.Get(settings.TimestampHeader)
+	}
+	if err := h.webhooks.Authenticate(r.Context(), body, credential, timestamp); err != nil {
+		if !errors.Is(err, service.ErrWebhookUnauthorized) {
+			log.Printf("[WEBHOOK] %v", err)
+		}
+		writeErrorCode(w, r, http.StatusUnauthorized, codeUnauthorized, "Unauthorized")
+		return
+	}
+
+	event, duplicate, err := h.webhooks.Receive(r.Context(), body)
+	if err != nil {
+		if errors.Is(err, service.ErrWebhookPayload) {
+			writeErrorCode(w, r, http.StatusBadRequest, codeInvalidRequest, err.Error())
+			return
+		}
+		log.Printf("[WEBHOOK] Erro recebendo evento: %v", err)
+		writeErrorCode(w, r, http.StatusInternalServerError, internalErrorKind.code, internalErrorKind.message)
+		return
+	}
+
+	status := http.StatusAccepted
+	if duplicate {
+		status = http.StatusOK
+	}
+	writeJSON(w, status, map[string]interface{}{
+		"id":        event.ID,
+		"status":    event.Status,
+		"duplicate": duplicate,
+	})
+}
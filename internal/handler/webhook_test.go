@@ -0,0 +1,42 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/viplounge/platform/internal/adapter"
+	"github.com/viplounge/platform/internal/config"
+	"github.com/viplounge/platform/internal/secrets"
+	"github.com/viplounge/platform/internal/service"
+)
+
+// TestWebhookCredentialAfterReload um reload que muda webhooks.superlogica
+// (restart-only) não separa a credencial que o handler coleta da que o
+// serviço confere
+func TestWebhookCredentialAfterReload(t *testing.T) {
+	t.Setenv("SUPERLOGICA_WEBHOOK_ENABLED", "true")
+	t.Setenv("SUPERLOGICA_WEBHOOK_AUTH", "token")
+	env := newScenarioEnv(t)
+	webhooks := service.NewWebhookService(env.repo, env.repo, adapter.DisabledValidator{}, adapter.DisabledPartner{}, secrets.Static("token-do-webhook"), config.Get(), nil)
+	env.h.EnableWebhooks(webhooks)
+	server := httptest.NewServer(env.h.Routes())
+	t.Cleanup(server.Close)
+
+	// Reload com outro modo de autenticação, ainda não aplicado ao serviço
+	t.Setenv("SUPERLOGICA_WEBHOOK_AUTH", "hmac")
+	if _, err := config.Load(""); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+
+	body := `{"id":"n1","evento":"alteracao_proprietario","data":{"id_condominio_cond":"999"}}`
+	resp, err := http.Post(server.URL+"/webhooks/superlogica?token=token-do-webhook", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("status %d, esperado 202", resp.StatusCode)
+	}
+}
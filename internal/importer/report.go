@@ -0,0 +1,138 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/viplounge/platform/internal/domain"
+)
+
+var reportHeader = []string{"line", "cpf", "condo_id", "scenario", "action", "status", "partner_user_id", "dry_run", "error", "duration_ms", "processed_at"}
+
+// Report grava uma linha de resultado por CPF assim que ele é processado.
+// O próprio relatório serve de checkpoint para retomar uma importação.
+type Report struct {
+	mu   sync.Mutex
+	file *os.File
+	w    *csv.Writer
+}
+
+// OpenReport cria o relatório em path. Com resume, acrescenta ao arquivo
+// existente em vez de sobrescrevê-lo.
+func OpenReport(path string, resume bool) (*Report, error) {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if resume {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	f, err := os.OpenFile(path, flags, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Report{file: f, w: csv.NewWriter(f)}
+	if info, err := f.Stat(); err == nil && info.Size() == 0 {
+		r.w.Write(reportHeader)
+		r.w.Flush()
+	}
+	return r, nil
+}
+
+// Write registra o resultado de uma linha e descarrega em disco, para que
+// uma interrupção não perca o que já foi feito
+func (r *Report) Write(row Row, result *domain.PreRegistration, err error, duration time.Duration) error {
+	record := []string{
+		strconv.Itoa(row.Line),
+		row.CPF,
+		row.CondoID,
+		"", "", "", "",
+		strconv.FormatBool(result != nil && result.DryRun),
+		"",
+		strconv.FormatInt(duration.Milliseconds(), 10),
+		time.Now().Format(time.RFC3339),
+	}
+	if result != nil {
+		record[3] = result.Scenario
+		record[4] = result.Action
+		record[5] = result.Lead.Status
+		record[6] = result.Lead.RedeParceriasUserID
+	}
+	if err != nil {
+		record[4] = domain.ImportActionFailed
+		record[8] = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.w.Write(record)
+	r.w.Flush()
+	if err := r.w.Error(); err != nil {
+		return err
+	}
+	return r.file.Sync()
+}
+
+func (r *Report) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.w.Flush()
+	return r.file.Close()
+}
+
+// LoadCheckpoint lê um relatório anterior e retorna as linhas já concluídas
+// (qualquer ação diferente de failed). Linhas de dry-run não contam.
+func LoadCheckpoint(path string) (map[string]bool, error) {
+	done := make(map[string]bool)
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err == io.EOF {
+		return done, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro lendo checkpoint %s: %w", path, err)
+	}
+	col := make(map[string]int, len(header))
+	for i, name := range header {
+		col[name] = i
+	}
+	for _, name := range []string{"cpf", "condo_id", "action", "dry_run"} {
+		if _, ok := col[name]; !ok {
+			return nil, fmt.Errorf("checkpoint %s sem coluna %q", path, name)
+		}
+	}
+
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("erro lendo checkpoint %s: %w", path, err)
+		}
+		if len(record) < len(header) {
+			continue // linha truncada por uma interrupção
+		}
+		if record[col["action"]] == domain.ImportActionFailed || record[col["dry_run"]] == "true" {
+			continue
+		}
+		row := Row{CPF: record[col["cpf"]], CondoID: record[col["condo_id"]]}
+		done[row.Key()] = true
+	}
+	return done, nil
+}
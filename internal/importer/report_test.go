@@ -0,0 +1,70 @@
+package importer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/viplounge/platform/internal/domain"
+)
+
+// TestReportCheckpoint o relatório retomado acrescenta linhas e só as
+// concluídas de verdade (nem falhas, nem dry-run) entram no checkpoint
+func TestReportCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "relatorio.csv")
+	if done, err := LoadCheckpoint(path); err != nil || len(done) != 0 {
+		t.Fatalf("checkpoint inexistente: %v %v", done, err)
+	}
+
+	report, err := OpenReport(path, false)
+	if err != nil {
+		t.Fatalf("OpenReport: %v", err)
+	}
+	registered := &domain.PreRegistration{Action: domain.ImportActionRegistered, Scenario: domain.ScenarioNewUser}
+	report.Write(Row{Line: 1, CPF: "11144477735", CondoID: "4"}, registered, nil, time.Millisecond)
+	report.Write(Row{Line: 2, CPF: "52998224725", CondoID: "4"}, nil, errors.New("timeout"), time.Millisecond)
+	report.Write(Row{Line: 3, CPF: "22233344405", CondoID: "4"}, &domain.PreRegistration{Action: domain.ImportActionRegistered, DryRun: true}, nil, time.Millisecond)
+	report.Close()
+
+	// Retomada: acrescenta sem repetir o cabeçalho
+	report, err = OpenReport(path, true)
+	if err != nil {
+		t.Fatalf("OpenReport com resume: %v", err)
+	}
+	report.Write(Row{Line: 2, CPF: "52998224725", CondoID: "4"}, &domain.PreRegistration{Action: domain.ImportActionAlreadyRegistered}, nil, time.Millisecond)
+	report.Close()
+
+	// Linha truncada por uma interrupção
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	f.WriteString("4,39053344705,4,new_user\n")
+	f.Close()
+
+	data, _ := os.ReadFile(path)
+	if headers := strings.Count(string(data), "line,cpf,condo_id"); headers != 1 {
+		t.Errorf("%d cabeçalhos no relatório", headers)
+	}
+
+	done, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint: %v", err)
+	}
+	want := map[string]bool{"4|11144477735": true, "4|52998224725": true}
+	if len(done) != len(want) {
+		t.Errorf("checkpoint %v, esperado %v", done, want)
+	}
+	for key := range want {
+		if !done[key] {
+			t.Errorf("%s fora do checkpoint", key)
+		}
+	}
+}
+
+func TestLoadCheckpointWithoutColumns(t *testing.T) {
+	path := writeFile(t, "outro.csv", "cpf,condo_id\n11144477735,4\n")
+	if _, err := LoadCheckpoint(path); err == nil || !strings.Contains(err.Error(), "action") {
+		t.Errorf("erro %v, esperado coluna action ausente", err)
+	}
+}
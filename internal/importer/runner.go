@@ -0,0 +1,120 @@
+package importer
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/viplounge/platform/internal/domain"
+)
+
+// PreRegisterer aplica a árvore de decisão a um CPF (ValidationService.PreRegister)
+type PreRegisterer interface {
+	PreRegister(ctx context.Context, req domain.ValidationRequest, dryRun bool) (*domain.PreRegistration, error)
+}
+
+// Runner processa as linhas em paralelo respeitando um limite de CPFs por
+// segundo, para não estourar as cotas da Superlógica e da Rede Parcerias
+type Runner struct {
+	Service     PreRegisterer
+	Report      *Report
+	Concurrency int
+	RatePerSec  float64
+	DryRun      bool
+}
+
+// Summary contagem de resultados por ação
+type Summary struct {
+	Total   int
+	Skipped int // já concluídas em uma execução anterior (checkpoint)
+	Actions map[string]int
+}
+
+// Run processa rows, pulando as chaves presentes em done. Ao cancelar ctx,
+// nenhuma linha nova é iniciada e as em andamento terminam e vão para o
+// relatório, permitindo retomar depois.
+func (r *Runner) Run(ctx context.Context, rows []Row, done map[string]bool) Summary {
+	summary := Summary{Total: len(rows), Actions: make(map[string]int)}
+
+	pending := make([]Row, 0, len(rows))
+	for _, row := range rows {
+		if !done[row.Key()] {
+			pending = append(pending, row)
+		}
+	}
+	summary.Skipped = len(rows) - len(pending)
+	if summary.Skipped > 0 {
+		log.Printf("[IMPORT] %d linhas já concluídas no checkpoint, %d pendentes", summary.Skipped, len(pending))
+	}
+
+	concurrency := r.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	limiter := rate.NewLimiter(rate.Inf, 1)
+	if r.RatePerSec > 0 {
+		limiter = rate.NewLimiter(rate.Limit(r.RatePerSec), 1)
+	}
+
+	var mu sync.Mutex
+	queue := make(chan Row)
+	var wg sync.WaitGroup
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for row := range queue {
+				// As chamadas usam um contexto próprio: um Ctrl+C não deve
+				// interromper um cadastro no meio
+				start := time.Now()
+				result, err := r.Service.PreRegister(context.Background(), domain.ValidationRequest{CPF: row.CPF, CondoID: row.CondoID}, r.DryRun)
+				if err := r.Report.Write(row, result, err, time.Since(start)); err != nil {
+					log.Printf("[IMPORT] Erro gravando relatório: %v", err)
+				}
+
+				action := domain.ImportActionFailed
+				if err == nil {
+					action = result.Action
+				}
+				mu.Lock()
+				summary.Actions[action]++
+				processed := summary.processed()
+				mu.Unlock()
+				if processed%50 == 0 {
+					log.Printf("[IMPORT] %d/%d processados", processed+summary.Skipped, summary.Total)
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for _, row := range pending {
+		if err := limiter.Wait(ctx); err != nil {
+			break
+		}
+		select {
+		case queue <- row:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		log.Printf("[IMPORT] Interrompido: retome com -resume para processar o restante")
+	}
+	return summary
+}
+
+func (s Summary) processed() int {
+	n := 0
+	for _, count := range s.Actions {
+		n += count
+	}
+	return n
+}
@@ -0,0 +1,82 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/viplounge/platform/internal/domain"
+)
+
+// preRegisterer responde a ação de cada CPF (erro para os ausentes) e
+// guarda os CPFs recebidos
+type preRegisterer struct {
+	mu      sync.Mutex
+	actions map[string]string
+	seen    []string
+	dryRun  bool
+}
+
+func (p *preRegisterer) PreRegister(ctx context.Context, req domain.ValidationRequest, dryRun bool) (*domain.PreRegistration, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.seen = append(p.seen, req.CPF)
+	p.dryRun = p.dryRun || dryRun
+	action, ok := p.actions[req.CPF]
+	if !ok {
+		return nil, errors.New("superlógica indisponível")
+	}
+	return &domain.PreRegistration{Action: action, DryRun: dryRun}, nil
+}
+
+func TestRunner(t *testing.T) {
+	report, err := OpenReport(filepath.Join(t.TempDir(), "relatorio.csv"), false)
+	if err != nil {
+		t.Fatalf("OpenReport: %v", err)
+	}
+	defer report.Close()
+	svc := &preRegisterer{actions: map[string]string{
+		"11144477735": domain.ImportActionRegistered,
+		"52998224725": domain.ImportActionAlreadyRegistered,
+		"22233344405": domain.ImportActionRegistered,
+	}}
+	runner := &Runner{Service: svc, Report: report, Concurrency: 3, DryRun: true}
+
+	rows := []Row{
+		{Line: 1, CPF: "11144477735", CondoID: "4"},
+		{Line: 2, CPF: "52998224725", CondoID: "4"},
+		{Line: 3, CPF: "22233344405", CondoID: "4"},
+		{Line: 4, CPF: "39053344705", CondoID: "4"},
+		{Line: 5, CPF: "11144477735", CondoID: "7"},
+	}
+	summary := runner.Run(context.Background(), rows, map[string]bool{"7|11144477735": true})
+
+	if summary.Total != 5 || summary.Skipped != 1 || len(svc.seen) != 4 || !svc.dryRun {
+		t.Errorf("resumo %+v, %d CPFs processados (dry_run=%v)", summary, len(svc.seen), svc.dryRun)
+	}
+	want := map[string]int{domain.ImportActionRegistered: 2, domain.ImportActionAlreadyRegistered: 1, domain.ImportActionFailed: 1}
+	for action, count := range want {
+		if summary.Actions[action] != count {
+			t.Errorf("%s: %d, esperado %d (%v)", action, summary.Actions[action], count, summary.Actions)
+		}
+	}
+}
+
+// TestRunnerCanceled com o contexto cancelado nenhuma linha é iniciada
+func TestRunnerCanceled(t *testing.T) {
+	report, err := OpenReport(filepath.Join(t.TempDir(), "relatorio.csv"), false)
+	if err != nil {
+		t.Fatalf("OpenReport: %v", err)
+	}
+	defer report.Close()
+	svc := &preRegisterer{actions: map[string]string{}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	summary := (&Runner{Service: svc, Report: report, RatePerSec: 1}).Run(ctx, []Row{{Line: 1, CPF: "11144477735", CondoID: "4"}}, nil)
+	if len(svc.seen) != 0 || summary.processed() != 0 {
+		t.Errorf("linhas processadas depois do cancelamento: %v", svc.seen)
+	}
+}
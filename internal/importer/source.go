@@ -0,0 +1,158 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/viplounge/platform/internal/domain"
+)
+
+// Row é um CPF a importar. Line é a linha no arquivo de origem (1 = primeira)
+// ou a posição na listagem da Superlógica.
+type Row struct {
+	Line    int
+	CPF     string
+	CondoID string
+}
+
+// Key identifica a linha no checkpoint (condomínio + CPF só com dígitos)
+func (r Row) Key() string {
+	return r.CondoID + "|" + onlyDigits(r.CPF)
+}
+
+var nonDigitRegex = regexp.MustCompile(`\D`)
+
+func onlyDigits(s string) string {
+	return nonDigitRegex.ReplaceAllString(s, "")
+}
+
+// Cabeçalhos aceitos para as colunas
+var (
+	cpfHeaders   = map[string]bool{"cpf": true, "cpf_proprietario": true, "documento": true}
+	condoHeaders = map[string]bool{"condo_id": true, "condominio": true, "condomínio": true, "id_condominio": true}
+)
+
+// ReadFile lê CPFs de um .csv (separado por vírgula ou ponto e vírgula) ou
+// .xlsx. Com cabeçalho, usa as colunas "cpf" e "condo_id"; sem cabeçalho, a
+// primeira coluna é o CPF e a segunda, se houver, o condomínio.
+// defaultCondo é usado nas linhas sem condomínio.
+func ReadFile(path, defaultCondo string) ([]Row, error) {
+	var records [][]string
+	var err error
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv", ".txt":
+		records, err = readCSV(path)
+	case ".xlsx":
+		records, err = readXLSX(path)
+	default:
+		return nil, fmt.Errorf("formato não suportado: %s (use .csv ou .xlsx)", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseRecords(records, defaultCondo)
+}
+
+func readCSV(path string) ([][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // BOM do Excel
+
+	// Excel em pt-BR exporta CSV com ";"
+	firstLine, _, _ := bufio.NewReader(bytes.NewReader(data)).ReadLine()
+	r := csv.NewReader(bytes.NewReader(data))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		r.Comma = ';'
+	}
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("erro lendo csv: %w", err)
+	}
+	return records, nil
+}
+
+func parseRecords(records [][]string, defaultCondo string) ([]Row, error) {
+	cpfCol, condoCol, start := 0, 1, 0
+	if len(records) > 0 && onlyDigits(strings.Join(records[0], "")) == "" {
+		// Primeira linha sem dígitos: cabeçalho
+		cpfCol, condoCol = -1, -1
+		for i, name := range records[0] {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if cpfHeaders[name] {
+				cpfCol = i
+			}
+			if condoHeaders[name] {
+				condoCol = i
+			}
+		}
+		if cpfCol < 0 {
+			return nil, fmt.Errorf("cabeçalho sem coluna de CPF (esperado: cpf)")
+		}
+		start = 1
+	}
+
+	var rows []Row
+	for i := start; i < len(records); i++ {
+		record := records[i]
+		if cpfCol >= len(record) || strings.TrimSpace(record[cpfCol]) == "" {
+			continue
+		}
+		row := Row{Line: i + 1, CPF: normalizeCPF(record[cpfCol]), CondoID: defaultCondo}
+		if condoCol >= 0 && condoCol < len(record) && strings.TrimSpace(record[condoCol]) != "" {
+			row.CondoID = strings.TrimSpace(record[condoCol])
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// normalizeCPF devolve só os dígitos, recompondo zeros à esquerda que a
+// planilha perde quando a coluna é numérica
+func normalizeCPF(cpf string) string {
+	digits := onlyDigits(strings.TrimSpace(cpf))
+	if digits != "" && len(digits) < 11 {
+		digits = strings.Repeat("0", 11-len(digits)) + digits
+	}
+	return digits
+}
+
+// FromSuperlogica lista todas as unidades do condomínio como linhas
+func FromSuperlogica(ctx context.Context, lister domain.MemberLister, condoID string) ([]Row, error) {
+	members, err := lister.ListMembers(ctx, condoID)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]Row, 0, len(members))
+	for i, member := range members {
+		rows = append(rows, Row{Line: i + 1, CPF: normalizeCPF(member.CPF), CondoID: member.CondoID})
+	}
+	return rows, nil
+}
+
+// Dedupe remove CPFs repetidos no mesmo condomínio (proprietário de várias
+// unidades), mantendo a primeira ocorrência
+func Dedupe(rows []Row) []Row {
+	seen := make(map[string]bool, len(rows))
+	out := rows[:0]
+	for _, row := range rows {
+		if seen[row.Key()] {
+			continue
+		}
+		seen[row.Key()] = true
+		out = append(out, row)
+	}
+	return out
+}
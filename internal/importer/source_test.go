@@ -0,0 +1,117 @@
+package importer
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return path
+}
+
+// writeXLSX planilha mínima com a coluna A em strings compartilhadas e a B
+// numérica, como o Excel grava CPFs digitados como número
+func writeXLSX(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "moradores.xlsx")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("xlsx: %v", err)
+	}
+	zw := zip.NewWriter(f)
+	files := map[string]string{
+		"xl/sharedStrings.xml": `<sst><si><t>CPF</t></si><si><t>Condomínio</t></si><si><r><t>529.982.</t></r><r><t>247-25</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>
+			<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>
+			<row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2"><v>4</v></c></row>
+			<row r="3"><c r="A3"><v>1144477735</v></c></row>
+			<row r="4"><c r="A4" t="inlineStr"><is><t>222.333.444-05</t></is></c><c r="B4"><v>7</v></c></row>
+		</sheetData></worksheet>`,
+	}
+	for name, content := range files {
+		w, _ := zw.Create(name)
+		w.Write([]byte(content))
+	}
+	zw.Close()
+	f.Close()
+	return path
+}
+
+func TestReadFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    func(t *testing.T) string
+		want    []Row
+		wantErr string
+	}{
+		{
+			name: "csv com cabeçalho e ponto e vírgula",
+			path: func(t *testing.T) string {
+				return writeFile(t, "lista.csv", "\xef\xbb\xbfNome;CPF;Condomínio\nAna;111.444.777-35;4\nBruno;52998224725;\n")
+			},
+			want: []Row{{Line: 2, CPF: "11144477735", CondoID: "4"}, {Line: 3, CPF: "52998224725", CondoID: "-1"}},
+		},
+		{
+			name: "csv sem cabeçalho",
+			path: func(t *testing.T) string { return writeFile(t, "lista.csv", "1144477735,7\n\n52998224725\n") },
+			want: []Row{{Line: 1, CPF: "01144477735", CondoID: "7"}, {Line: 2, CPF: "52998224725", CondoID: "-1"}},
+		},
+		{
+			name:    "cabeçalho sem CPF",
+			path:    func(t *testing.T) string { return writeFile(t, "lista.csv", "nome,email\nAna,ana@example.com\n") },
+			wantErr: "coluna de CPF",
+		},
+		{
+			name:    "formato desconhecido",
+			path:    func(t *testing.T) string { return writeFile(t, "lista.ods", "") },
+			wantErr: "formato não suportado",
+		},
+		{
+			name: "xlsx",
+			path: writeXLSX,
+			want: []Row{
+				{Line: 2, CPF: "52998224725", CondoID: "4"},
+				{Line: 3, CPF: "01144477735", CondoID: "-1"},
+				{Line: 4, CPF: "22233344405", CondoID: "7"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ReadFile(tt.path(t), "-1")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("erro %v, esperado %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadFile: %v", err)
+			}
+			if !reflect.DeepEqual(rows, tt.want) {
+				t.Errorf("linhas %+v, esperadas %+v", rows, tt.want)
+			}
+		})
+	}
+}
+
+func TestDedupe(t *testing.T) {
+	rows := Dedupe([]Row{
+		{Line: 1, CPF: "52998224725", CondoID: "4"},
+		{Line: 2, CPF: "529.982.247-25", CondoID: "4"},
+		{Line: 3, CPF: "52998224725", CondoID: "7"},
+	})
+	if len(rows) != 2 || rows[0].Line != 1 || rows[1].Line != 3 {
+		t.Errorf("linhas %+v", rows)
+	}
+}
@@ -0,0 +1,107 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// Group acompanha jobs em background (sincronizações, entregas, purgas)
+// para que o servidor possa drená-los antes de encerrar.
+type Group struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stopping chan struct{}
+
+	mu     sync.Mutex
+	wg     sync.WaitGroup
+	active map[string]int
+	closed bool
+}
+
+// NewGroup cria um grupo cujos jobs recebem um contexto derivado de parent
+func NewGroup(parent context.Context) *Group {
+	ctx, cancel := context.WithCancel(parent)
+	return &Group{
+		ctx:      ctx,
+		cancel:   cancel,
+		stopping: make(chan struct{}),
+		active:   make(map[string]int),
+	}
+}
+
+// Go executa fn em uma goroutine rastreada. O contexto recebido só é cancelado
+// quando o prazo do Shutdown expira, permitindo que o trabalho em andamento
+// termine; jobs periódicos devem observar Stopping para sair do loop.
+// Jobs submetidos após o Shutdown são ignorados.
+func (g *Group) Go(name string, fn func(ctx context.Context)) bool {
+	g.mu.Lock()
+	if g.closed {
+		g.mu.Unlock()
+		log.Printf("[LIFECYCLE] Job %s ignorado: encerramento em andamento", name)
+		return false
+	}
+	g.active[name]++
+	g.wg.Add(1)
+	g.mu.Unlock()
+
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				log.Printf("[LIFECYCLE] Job %s entrou em pânico: %v", name, rec)
+			}
+			g.mu.Lock()
+			g.active[name]--
+			if g.active[name] == 0 {
+				delete(g.active, name)
+			}
+			g.mu.Unlock()
+			g.wg.Done()
+		}()
+		fn(g.ctx)
+	}()
+	return true
+}
+
+// Context retorna o contexto compartilhado pelos jobs do grupo
+func (g *Group) Context() context.Context {
+	return g.ctx
+}
+
+// Stopping é fechado quando o encerramento começa
+func (g *Group) Stopping() <-chan struct{} {
+	return g.stopping
+}
+
+// Shutdown para de aceitar novos jobs e aguarda os jobs em execução até o
+// prazo de ctx. Se o prazo expirar, o contexto dos jobs é cancelado.
+func (g *Group) Shutdown(ctx context.Context) error {
+	g.mu.Lock()
+	if !g.closed {
+		g.closed = true
+		close(g.stopping)
+	}
+	g.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		g.cancel()
+		return nil
+	case <-ctx.Done():
+		g.cancel()
+		g.mu.Lock()
+		pending := make([]string, 0, len(g.active))
+		for name, n := range g.active {
+			pending = append(pending, fmt.Sprintf("%s(%d)", name, n))
+		}
+		g.mu.Unlock()
+		return fmt.Errorf("jobs não finalizados no prazo %v: %w", pending, ctx.Err())
+	}
+}
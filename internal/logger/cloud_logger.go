@@ -124,7 +124,7 @@ func (cl *CloudLogger) logEntry(ctx context.Context, entry LogEntry) {
 	if cl.logger != nil {
 		cl.logger.Log(logging.Entry{
 			Payload:  string(data),
-			Severity: logging.Severity(entry.Severity),
+			Severity: logging.ParseSeverity(entry.Severity),
 		})
 	} else {
 		// Fallback para stdout
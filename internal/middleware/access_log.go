@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/viplounge/platform/internal/domain"
+)
+
+// maskingFormatter formato do middleware.Logger do chi com os CPFs da URL
+// mascarados: IDs de lead e filtros levam o CPF no caminho
+type maskingFormatter struct {
+	*middleware.DefaultLogFormatter
+}
+
+func (f maskingFormatter) NewLogEntry(r *http.Request) middleware.LogEntry {
+	masked := r.WithContext(r.Context())
+	masked.RequestURI = domain.MaskCPFs(r.RequestURI)
+	return f.DefaultLogFormatter.NewLogEntry(masked)
+}
+
+// AccessLog registra cada requisição como o middleware.Logger do chi, sem
+// CPF em claro no log
+var AccessLog = middleware.RequestLogger(maskingFormatter{
+	&middleware.DefaultLogFormatter{Logger: log.New(os.Stdout, "", log.LstdFlags), NoColor: true},
+})
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+func TestAccessLogMasksCPF(t *testing.T) {
+	var out bytes.Buffer
+	logger := chimiddleware.RequestLogger(maskingFormatter{
+		&chimiddleware.DefaultLogFormatter{Logger: log.New(&out, "", 0), NoColor: true},
+	})
+	ok := logger(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// O handler continua vendo a URL original
+		if !strings.Contains(r.RequestURI, "52998224725") {
+			t.Errorf("URL alterada para o handler: %s", r.RequestURI)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("GET", "/admin/v1/leads/4_52998224725?from=2026-01-15&cpf=529.982.247-25", nil)
+	ok.ServeHTTP(httptest.NewRecorder(), req)
+
+	line := out.String()
+	if strings.Contains(line, "52998224725") || strings.Contains(line, "529.982.247-25") {
+		t.Errorf("CPF em claro no log de acesso: %s", line)
+	}
+	if !strings.Contains(line, "4_529.***.***-25") || !strings.Contains(line, "from=2026-01-15") {
+		t.Errorf("log de acesso: %s", line)
+	}
+}
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/viplounge/platform/internal/auth"
+)
+
+// PublicRoutes lista as rotas liberadas sem autenticação. Toda rota não
+// registrada aqui exige credenciais (deny by default).
+type PublicRoutes struct {
+	routes map[string]bool
+}
+
+func NewPublicRoutes() *PublicRoutes {
+	return &PublicRoutes{routes: make(map[string]bool)}
+}
+
+// Add libera method + pattern (o mesmo pattern usado no chi, ex. "/v1/validate")
+func (p *PublicRoutes) Add(method, pattern string) {
+	p.routes[method+" "+pattern] = true
+}
+
+// allows resolve a rota da requisição no router e verifica se é pública
+func (p *PublicRoutes) allows(mux *chi.Mux, r *http.Request) bool {
+	rctx := chi.NewRouteContext()
+	if !mux.Match(rctx, r.Method, r.URL.Path) {
+		return false
+	}
+	pattern := strings.Join(rctx.RoutePatterns, "")
+	return p.routes[r.Method+" "+pattern]
+}
+
+// Authenticate identifica o principal e bloqueia com 401 qualquer rota que
+// não esteja em public. Em rotas públicas credenciais são opcionais.
+func Authenticate(authn *auth.Authenticator, mux *chi.Mux, public *PublicRoutes) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if public.allows(mux, r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			principal, err := (*auth.Principal)(nil), auth.ErrNoCredentials
+			if authn != nil {
+				principal, err = authn.Authenticate(r)
+			}
+			if err != nil {
+				if !errors.Is(err, auth.ErrNoCredentials) {
+					log.Printf("[AUTH] Credencial rejeitada em %s %s: %v", r.Method, r.URL.Path, err)
+				}
+				unauthorized(w, r)
+				return
+			}
+
+			ctx := auth.WithPrincipal(r.Context(), principal)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequireRole exige que o principal autenticado tenha um dos papéis
+func RequireRole(roles ...auth.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := auth.FromContext(r.Context())
+			if principal == nil {
+				unauthorized(w, r)
+				return
+			}
+			if !principal.HasRole(roles...) {
+				log.Printf("[AUTH] %s sem papel %v para %s %s", principal.Actor(), roles, r.Method, r.URL.Path)
+				writeError(w, r, http.StatusForbidden, "FORBIDDEN", "Acesso negado.")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="viplounge"`)
+	writeError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+}
+
+// errorResponse o mesmo envelope JSON dos handlers (handler.ErrorResponse),
+// que o middleware não pode importar
+type errorResponse struct {
+	Error struct {
+		Code      string `json:"code"`
+		Message   string `json:"message"`
+		RequestID string `json:"request_id,omitempty"`
+	} `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	var body errorResponse
+	body.Error.Code = code
+	body.Error.Message = message
+	body.Error.RequestID = middleware.GetReqID(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/viplounge/platform/internal/auth"
+	"github.com/viplounge/platform/internal/config"
+	"github.com/viplounge/platform/internal/secrets"
+)
+
+// newAuthRouter rotas no formato do servidor: validate pública, consulta
+// de leads para o suporte e revogação só para administradoras
+func newAuthRouter(t *testing.T) http.Handler {
+	t.Helper()
+	t.Setenv("TEST_SUPPORT_API_KEY", "chave-do-atendimento")
+	cfg := &config.Config{}
+	cfg.Auth.APIKeys = []config.APIKey{{ID: "atendimento", KeyRef: "TEST_SUPPORT_API_KEY", Roles: []string{"support"}, Tenants: []string{"4"}}}
+	authn := auth.NewAuthenticator(cfg, secrets.EnvProvider{})
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	r := chi.NewRouter()
+	public := NewPublicRoutes()
+	public.Add("POST", "/v1/validate")
+	public.Add("GET", "/v1/clubs/{clubID}")
+
+	r.Use(RequestID)
+	r.Use(Authenticate(authn, r, public))
+	r.Post("/v1/validate", ok)
+	r.Get("/v1/clubs/{clubID}", ok)
+	r.Route("/v1/admin", func(r chi.Router) {
+		r.Use(RequireRole(auth.RoleSupport, auth.RoleTenantAdmin))
+		r.Get("/leads", ok)
+		r.With(RequireRole(auth.RoleTenantAdmin)).Delete("/leads/{id}", ok)
+	})
+	return r
+}
+
+func TestAuthenticateRoutes(t *testing.T) {
+	router := newAuthRouter(t)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		apiKey     string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "rota pública sem credenciais", method: "POST", path: "/v1/validate", wantStatus: http.StatusOK},
+		{name: "rota pública com parâmetro", method: "GET", path: "/v1/clubs/bronze", wantStatus: http.StatusOK},
+		{name: "rota pública com credencial inválida", method: "POST", path: "/v1/validate", apiKey: "chave-errada", wantStatus: http.StatusOK},
+		{name: "outro método da rota pública", method: "GET", path: "/v1/validate", wantStatus: http.StatusUnauthorized, wantCode: "UNAUTHORIZED"},
+		{name: "rota não registrada", method: "GET", path: "/v1/nao-existe", wantStatus: http.StatusUnauthorized, wantCode: "UNAUTHORIZED"},
+		{name: "rota protegida sem credenciais", method: "GET", path: "/v1/admin/leads", wantStatus: http.StatusUnauthorized, wantCode: "UNAUTHORIZED"},
+		{name: "API key inválida", method: "GET", path: "/v1/admin/leads", apiKey: "chave-errada", wantStatus: http.StatusUnauthorized, wantCode: "UNAUTHORIZED"},
+		{name: "papel aceito", method: "GET", path: "/v1/admin/leads", apiKey: "chave-do-atendimento", wantStatus: http.StatusOK},
+		{name: "papel insuficiente", method: "DELETE", path: "/v1/admin/leads/abc", apiKey: "chave-do-atendimento", wantStatus: http.StatusForbidden, wantCode: "FORBIDDEN"},
+		{name: "rota não registrada autenticada", method: "GET", path: "/v1/nao-existe", apiKey: "chave-do-atendimento", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.apiKey != "" {
+				req.Header.Set("X-API-Key", tt.apiKey)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, esperado %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantCode == "" {
+				return
+			}
+			assertErrorBody(t, rec, tt.wantCode)
+			if unauthorized := rec.Code == http.StatusUnauthorized; unauthorized != (rec.Header().Get("WWW-Authenticate") != "") {
+				t.Errorf("WWW-Authenticate %q no status %d", rec.Header().Get("WWW-Authenticate"), rec.Code)
+			}
+		})
+	}
+}
+
+// TestRequireRoleWithoutPrincipal RequireRole fora do Authenticate nega
+// com 401
+func TestRequireRoleWithoutPrincipal(t *testing.T) {
+	handler := chimiddleware.RequestID(RequireRole(auth.RoleSupport)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler executado sem principal")
+	})))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/admin/leads", nil))
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status %d, esperado 401", rec.Code)
+	}
+	assertErrorBody(t, rec, "UNAUTHORIZED")
+}
+
+// assertErrorBody o corpo é o envelope de erro da API com o request_id
+func assertErrorBody(t *testing.T, rec *httptest.ResponseRecorder, code string) {
+	t.Helper()
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type %q", ct)
+	}
+	var body struct {
+		Error struct {
+			Code      string `json:"code"`
+			Message   string `json:"message"`
+			RequestID string `json:"request_id"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("corpo %q: %v", rec.Body.String(), err)
+	}
+	if body.Error.Code != code || body.Error.Message == "" || body.Error.RequestID == "" {
+		t.Errorf("erro %+v, esperado código %s com request_id", body.Error, code)
+	}
+	if id := rec.Header().Get(chimiddleware.RequestIDHeader); id != "" && id != body.Error.RequestID {
+		t.Errorf("request_id %q diferente do header %q", body.Error.RequestID, id)
+	}
+}
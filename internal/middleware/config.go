@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/viplounge/platform/internal/config"
+)
+
+// ConfigSnapshot fixa a configuração atual no contexto da requisição, para
+// que um hot reload no meio do processamento não misture duas versões
+func ConfigSnapshot(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := config.WithSnapshot(r.Context(), config.Get())
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
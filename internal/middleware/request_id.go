@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// validRequestID formato aceito para o X-Request-Id enviado pelo cliente
+// (proxy, load balancer); fora dele um novo identificador é gerado
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:/-]{1,128}$`)
+
+// RequestID identifica cada requisição (middleware.GetReqID) e devolve o
+// identificador no header X-Request-Id da resposta, o mesmo que aparece no
+// log e no corpo dos erros
+func RequestID(next http.Handler) http.Handler {
+	withHeader := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(middleware.RequestIDHeader, middleware.GetReqID(r.Context()))
+		next.ServeHTTP(w, r)
+	})
+	identify := middleware.RequestID(withHeader)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := r.Header.Get(middleware.RequestIDHeader); id != "" && !validRequestID.MatchString(id) {
+			r.Header.Del(middleware.RequestIDHeader)
+		}
+		identify.ServeHTTP(w, r)
+	})
+}
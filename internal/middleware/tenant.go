@@ -5,6 +5,9 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"sync/atomic"
+
+	"github.com/viplounge/platform/internal/config"
 )
 
 // ContextKey tipo para chaves de contexto
@@ -37,26 +40,41 @@ func TenantMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// tenantHosts guarda o mapa host -> tenant_id montado a partir de config.Tenants
+var tenantHosts atomic.Pointer[map[string]string]
+
+// ConfigureTenants reconstrói o mapeamento de hosts. É registrado como
+// assinante da config para acompanhar o hot reload.
+func ConfigureTenants(cfg *config.Config) {
+	hosts := make(map[string]string)
+	for _, tenant := range cfg.Tenants {
+		for _, host := range tenant.Hosts {
+			hosts[strings.ToLower(host)] = tenant.ID
+		}
+	}
+	tenantHosts.Store(&hosts)
+	log.Printf("[TENANT] %d hosts mapeados para %d tenants", len(hosts), len(cfg.Tenants))
+}
+
 // mapHostToTenantID mapeia o host para o ID do condomínio correspondente
 func mapHostToTenantID(host string) string {
 	// Normalizar host (lowercase)
 	host = strings.ToLower(host)
-	
-	// Mapeamento de domínios específicos (opcional - para performance)
-	// Se você souber antecipadamente o condomínio, pode mapear aqui
-	switch host {
-	case "viplounge.com.br", "www.viplounge.com.br":
-		return "4" // Condomínio VIP Lounge específico (Unidade 48384 - Enrico)
-	case "viplounge.mobile.adm.br":
-		// BUSCA GLOBAL para mobile - permite encontrar qualquer morador
-		log.Printf("[TENANT] Host mobile -> Usando busca global (tenant_id=-1)", host)
-		return "-1"
-	default:
-		// BUSCA GLOBAL: Para hosts desconhecidos ou localhost, usar -1
-		// Isso permite que a API Superlógica procure em todos os condomínios
-		log.Printf("[TENANT] Host: %s -> Usando busca global (tenant_id=-1)", host)
-		return "-1"
+
+	hosts := tenantHosts.Load()
+	if hosts == nil {
+		ConfigureTenants(config.Get())
+		hosts = tenantHosts.Load()
+	}
+
+	if tenantID, ok := (*hosts)[host]; ok {
+		return tenantID
 	}
+
+	// BUSCA GLOBAL: Para hosts desconhecidos ou localhost, usar -1
+	// Isso permite que a API Superlógica procure em todos os condomínios
+	log.Printf("[TENANT] Host: %s -> Usando busca global (tenant_id=-1)", host)
+	return "-1"
 }
 
 // GetTenantID extrai o tenant_id do contexto
@@ -0,0 +1,334 @@
+package outbound
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/viplounge/platform/internal/config"
+	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/lifecycle"
+	"github.com/viplounge/platform/internal/secrets"
+)
+
+// Cabeçalhos enviados em cada entrega. A assinatura segue o formato
+// "t=<unix>,v1=<hex>", com o HMAC-SHA256 de "<t>.<corpo>"; o timestamp permite
+// ao destino recusar reenvios antigos. Destinos devem deduplicar pelo
+// X-Viplounge-Event-Id, pois uma entrega pode chegar mais de uma vez.
+const (
+	HeaderEvent     = "X-Viplounge-Event"
+	HeaderEventID   = "X-Viplounge-Event-Id"
+	HeaderDelivery  = "X-Viplounge-Delivery"
+	HeaderSignature = "X-Viplounge-Signature"
+)
+
+// Máximo de corpo de resposta guardado no log de entregas
+const maxResponseLog = 512
+
+// ErrInFlight a entrega já está sendo enviada nesta instância
+var ErrInFlight = errors.New("entrega em andamento")
+
+// Dispatcher entrega os eventos aos endpoints de webhooks.outbound. Cada
+// entrega é gravada antes do envio; as que falham ficam pendentes com a
+// próxima tentativa agendada e são reenviadas por Run.
+type Dispatcher struct {
+	repo    domain.DeliveryRepository
+	secrets secrets.Provider
+	client  *http.Client
+	jobs    *lifecycle.Group
+
+	mu       sync.Mutex
+	inflight map[string]bool
+}
+
+func NewDispatcher(repo domain.DeliveryRepository, provider secrets.Provider, jobs *lifecycle.Group) *Dispatcher {
+	return &Dispatcher{
+		repo:     repo,
+		secrets:  provider,
+		client:   &http.Client{},
+		jobs:     jobs,
+		inflight: make(map[string]bool),
+	}
+}
+
+// Publish cria uma entrega por endpoint que assina o evento no tenant e faz
+// a primeira tentativa em background
+func (d *Dispatcher) Publish(ctx context.Context, event domain.OutboundEvent) {
+	if event.ID == "" {
+		event.ID = newID()
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	cfg := configFrom(ctx)
+	endpoints := subscribers(cfg.Webhooks.Outbound, event)
+	if len(endpoints) == 0 {
+		return
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("[OUTBOUND] Erro serializando evento %s: %v", event.Type, err)
+		return
+	}
+
+	d.jobs.Go("outbound-webhook", func(ctx context.Context) {
+		// A primeira tentativa usa a mesma versão da config que escolheu os endpoints
+		ctx = config.WithSnapshot(ctx, cfg)
+		for _, endpoint := range endpoints {
+			delivery := domain.WebhookDelivery{
+				ID:            event.ID + "_" + endpoint.ID,
+				EventID:       event.ID,
+				EventType:     event.Type,
+				TenantID:      event.TenantID,
+				EndpointID:    endpoint.ID,
+				URL:           endpoint.URL,
+				Payload:       string(payload),
+				CPFs:          eventCPFs(event),
+				Status:        domain.DeliveryStatusPending,
+				NextAttemptAt: time.Now(),
+				CreatedAt:     time.Now(),
+			}
+			if err := d.repo.SaveDelivery(ctx, delivery); err != nil {
+				log.Printf("[OUTBOUND] Erro gravando entrega %s: %v", delivery.ID, err)
+				continue
+			}
+			d.attempt(ctx, &delivery)
+		}
+	})
+}
+
+// Run reenvia as entregas pendentes vencidas a cada interval, até stop
+func (d *Dispatcher) Run(ctx context.Context, stop <-chan struct{}, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case <-ticker.C:
+			d.retryDue(ctx, stop)
+		}
+	}
+}
+
+func (d *Dispatcher) retryDue(ctx context.Context, stop <-chan struct{}) {
+	due, err := d.repo.ListDeliveries(ctx, domain.DeliveryFilter{
+		Status:    domain.DeliveryStatusPending,
+		DueBefore: time.Now(),
+		Limit:     100,
+	})
+	if err != nil {
+		log.Printf("[OUTBOUND] Erro buscando entregas pendentes: %v", err)
+		return
+	}
+	for i := range due {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		d.attempt(ctx, &due[i])
+	}
+}
+
+// Redeliver reenvia uma entrega na hora, inclusive as que esgotaram as
+// tentativas, e retorna o resultado
+func (d *Dispatcher) Redeliver(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	delivery, err := d.repo.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.attempt(ctx, delivery); errors.Is(err, ErrInFlight) {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// attempt faz uma tentativa e grava o resultado: entregue, pendente com a
+// próxima tentativa agendada ou falha definitiva
+func (d *Dispatcher) attempt(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	if !d.acquire(delivery.ID) {
+		return ErrInFlight
+	}
+	defer d.release(delivery.ID)
+
+	settings := configFrom(ctx).Webhooks.Outbound
+	delivery.Attempts++
+	code, err := d.send(ctx, settings, delivery)
+	delivery.LastStatusCode = code
+
+	switch {
+	case err == nil:
+		delivery.Status = domain.DeliveryStatusDelivered
+		delivery.DeliveredAt = time.Now()
+		delivery.NextAttemptAt = time.Time{}
+		delivery.LastError = ""
+		log.Printf("[OUTBOUND] %s entregue a %s (tentativa %d)", delivery.EventType, delivery.EndpointID, delivery.Attempts)
+	case delivery.Attempts >= settings.MaxAttempts:
+		delivery.Status = domain.DeliveryStatusFailed
+		delivery.NextAttemptAt = time.Time{}
+		delivery.LastError = err.Error()
+		log.Printf("[OUTBOUND] %s para %s falhou definitivamente após %d tentativas: %v", delivery.EventType, delivery.EndpointID, delivery.Attempts, err)
+	default:
+		delivery.Status = domain.DeliveryStatusPending
+		delivery.NextAttemptAt = time.Now().Add(backoff(settings, delivery.Attempts))
+		delivery.LastError = err.Error()
+		log.Printf("[OUTBOUND] %s para %s falhou (tentativa %d), nova tentativa às %s: %v",
+			delivery.EventType, delivery.EndpointID, delivery.Attempts, delivery.NextAttemptAt.Format(time.RFC3339), err)
+	}
+
+	if saveErr := d.repo.SaveDelivery(ctx, *delivery); saveErr != nil {
+		log.Printf("[OUTBOUND] Erro gravando entrega %s: %v", delivery.ID, saveErr)
+	}
+	return err
+}
+
+// send assina e envia o payload. Qualquer resposta fora de 2xx é falha.
+func (d *Dispatcher) send(ctx context.Context, settings config.OutboundWebhooks, delivery *domain.WebhookDelivery) (int, error) {
+	endpoint, ok := findEndpoint(settings, delivery.EndpointID)
+	if !ok {
+		return 0, fmt.Errorf("endpoint %q não existe mais na config", delivery.EndpointID)
+	}
+	secret, err := secrets.NewSecret(d.secrets, endpoint.SecretRef).Value(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("segredo do endpoint %s (%s) indisponível: %w", endpoint.ID, endpoint.SecretRef, err)
+	}
+
+	timeout := time.Duration(settings.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Usa a URL atual do endpoint, para que correções na config valham nos reenvios
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint.URL, bytes.NewReader([]byte(delivery.Payload)))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "viplounge-webhooks/1")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderEventID, delivery.EventID)
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderSignature, Sign(secret, time.Now(), []byte(delivery.Payload)))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseLog))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign gera o cabeçalho de assinatura "t=<unix>,v1=<hex>"
+func Sign(secret string, at time.Time, payload []byte) string {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "."))
+	mac.Write(payload)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// backoff dobra a espera a cada tentativa, até o máximo configurado
+func backoff(settings config.OutboundWebhooks, attempts int) time.Duration {
+	wait := time.Duration(settings.InitialBackoffSeconds) * time.Second
+	max := time.Duration(settings.MaxBackoffSeconds) * time.Second
+	for i := 1; i < attempts && wait < max; i++ {
+		wait *= 2
+	}
+	if wait > max {
+		wait = max
+	}
+	return wait
+}
+
+// eventCPFs CPF do titular do evento, que indexa a entrega nos pedidos do
+// titular e é trocado pelo hash ao gravar com a cifragem ligada
+func eventCPFs(event domain.OutboundEvent) []string {
+	if cpf, ok := event.Data["cpf"].(string); ok && cpf != "" {
+		return []string{cpf}
+	}
+	return nil
+}
+
+// configFrom versão da configuração fixada no contexto (a da requisição
+// que publicou o evento) ou, nos reenvios em background, a atual
+func configFrom(ctx context.Context) *config.Config {
+	if cfg := config.FromContext(ctx); cfg != nil {
+		return cfg
+	}
+	return config.Get()
+}
+
+// subscribers retorna os endpoints que assinam o tipo do evento no tenant
+func subscribers(settings config.OutboundWebhooks, event domain.OutboundEvent) []config.OutboundEndpoint {
+	var out []config.OutboundEndpoint
+	for _, endpoint := range settings.Endpoints {
+		if contains(endpoint.Events, event.Type) && (contains(endpoint.Tenants, "*") || contains(endpoint.Tenants, event.TenantID)) {
+			out = append(out, endpoint)
+		}
+	}
+	return out
+}
+
+func findEndpoint(settings config.OutboundWebhooks, id string) (config.OutboundEndpoint, bool) {
+	for _, endpoint := range settings.Endpoints {
+		if endpoint.ID == id {
+			return endpoint, true
+		}
+	}
+	return config.OutboundEndpoint{}, false
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Dispatcher) acquire(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.inflight[id] {
+		return false
+	}
+	d.inflight[id] = true
+	return true
+}
+
+func (d *Dispatcher) release(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.inflight, id)
+}
+
+func newID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,213 @@
+package outbound
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/viplounge/platform/internal/config"
+	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/lifecycle"
+	"github.com/viplounge/platform/internal/repository"
+	"github.com/viplounge/platform/internal/secrets"
+)
+
+// receiver destino dos webhooks nos testes; responde status
+type receiver struct {
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newReceiver(t *testing.T, status int) (*receiver, *httptest.Server) {
+	t.Helper()
+	rec := &receiver{status: status}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rec.mu.Lock()
+		rec.requests = append(rec.requests, r)
+		rec.bodies = append(rec.bodies, body)
+		rec.mu.Unlock()
+		w.WriteHeader(rec.status)
+	}))
+	t.Cleanup(server.Close)
+	return rec, server
+}
+
+func (rec *receiver) count() int {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return len(rec.requests)
+}
+
+// snapshot config com um endpoint que assina lead.activated em todos os
+// condomínios
+func snapshot(url string) *config.Config {
+	cfg := &config.Config{}
+	cfg.Webhooks.Outbound = config.OutboundWebhooks{
+		Enabled:               true,
+		MaxAttempts:           3,
+		InitialBackoffSeconds: 30,
+		MaxBackoffSeconds:     300,
+		TimeoutSeconds:        5,
+		Endpoints: []config.OutboundEndpoint{{
+			ID:        "crm",
+			URL:       url,
+			SecretRef: "CRM_WEBHOOK_SECRET",
+			Events:    []string{domain.EventLeadActivated},
+			Tenants:   []string{"*"},
+		}},
+	}
+	return cfg
+}
+
+func newTestDispatcher(t *testing.T) (*Dispatcher, *repository.MemoryRepository, *lifecycle.Group) {
+	t.Helper()
+	t.Setenv("CRM_WEBHOOK_SECRET", "segredo-do-crm")
+	repo := repository.NewMemoryRepository()
+	jobs := lifecycle.NewGroup(context.Background())
+	return NewDispatcher(repo, secrets.EnvProvider{}, jobs), repo, jobs
+}
+
+// TestPublishUsesRequestConfig os endpoints vêm da config fixada na
+// requisição que publicou o evento, não da global
+func TestPublishUsesRequestConfig(t *testing.T) {
+	rec, server := newReceiver(t, http.StatusOK)
+	d, repo, jobs := newTestDispatcher(t)
+
+	// Sem a config da requisição vale a global, sem endpoints
+	d.Publish(context.Background(), domain.OutboundEvent{Type: domain.EventLeadActivated, TenantID: "4"})
+
+	ctx := config.WithSnapshot(context.Background(), snapshot(server.URL))
+	d.Publish(ctx, domain.OutboundEvent{Type: domain.EventLeadActivated, TenantID: "4", Data: map[string]interface{}{"cpf": "52998224725"}})
+	if err := jobs.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	if rec.count() != 1 {
+		t.Fatalf("esperada uma entrega, recebidas %d", rec.count())
+	}
+	deliveries, err := repo.ListDeliveries(context.Background(), domain.DeliveryFilter{})
+	if err != nil || len(deliveries) != 1 || deliveries[0].Status != domain.DeliveryStatusDelivered {
+		t.Fatalf("entregas gravadas: %+v (%v)", deliveries, err)
+	}
+	if deliveries[0].TenantID != "4" || len(deliveries[0].CPFs) != 1 {
+		t.Errorf("entrega sem tenant ou CPF do titular: %+v", deliveries[0])
+	}
+}
+
+func TestSign(t *testing.T) {
+	at := time.Unix(1760000000, 0)
+	payload := []byte(`{"id":"evt1"}`)
+
+	got := Sign("segredo", at, payload)
+	mac := hmac.New(sha256.New, []byte("segredo"))
+	mac.Write([]byte("1760000000." + string(payload)))
+	if want := "t=1760000000,v1=" + hex.EncodeToString(mac.Sum(nil)); got != want {
+		t.Fatalf("Sign = %s, esperado %s", got, want)
+	}
+
+	tests := []struct {
+		name    string
+		secret  string
+		at      time.Time
+		payload []byte
+	}{
+		{"outro segredo", "outro", at, payload},
+		{"outro instante", "segredo", at.Add(time.Second), payload},
+		{"outro corpo", "segredo", at, []byte(`{"id":"evt2"}`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if Sign(tt.secret, tt.at, tt.payload) == got {
+				t.Errorf("assinatura igual à original")
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	settings := config.OutboundWebhooks{InitialBackoffSeconds: 30, MaxBackoffSeconds: 300}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{4, 4 * time.Minute},
+		{5, 5 * time.Minute},
+		{20, 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := backoff(settings, tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %s, esperado %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// TestAttemptSchedulesRetry uma falha agenda a próxima tentativa com o
+// backoff e a última tentativa marca a entrega como falha, com o corpo
+// assinado no formato t=..,v1=..
+func TestAttemptSchedulesRetry(t *testing.T) {
+	rec, server := newReceiver(t, http.StatusServiceUnavailable)
+	d, repo, _ := newTestDispatcher(t)
+	ctx := config.WithSnapshot(context.Background(), snapshot(server.URL))
+
+	delivery := domain.WebhookDelivery{ID: "evt1_crm", EventID: "evt1", EventType: domain.EventLeadActivated, EndpointID: "crm", Payload: `{"id":"evt1"}`}
+	tests := []struct {
+		wantStatus string
+		wantWait   time.Duration
+	}{
+		{domain.DeliveryStatusPending, 30 * time.Second},
+		{domain.DeliveryStatusPending, time.Minute},
+		{domain.DeliveryStatusFailed, 0},
+	}
+	for i, tt := range tests {
+		start := time.Now()
+		if err := d.attempt(ctx, &delivery); err == nil {
+			t.Fatalf("tentativa %d: esperada falha", i+1)
+		}
+		if delivery.Status != tt.wantStatus || delivery.LastStatusCode != http.StatusServiceUnavailable {
+			t.Errorf("tentativa %d: status %s, HTTP %d", i+1, delivery.Status, delivery.LastStatusCode)
+		}
+		if tt.wantWait == 0 {
+			if !delivery.NextAttemptAt.IsZero() {
+				t.Errorf("tentativa %d: nova tentativa agendada após falha definitiva", i+1)
+			}
+			continue
+		}
+		if wait := delivery.NextAttemptAt.Sub(start); wait < tt.wantWait || wait > tt.wantWait+time.Second {
+			t.Errorf("tentativa %d: próxima em %s, esperado %s", i+1, wait, tt.wantWait)
+		}
+	}
+
+	stored, err := repo.GetDelivery(context.Background(), delivery.ID)
+	if err != nil || stored.Status != domain.DeliveryStatusFailed || stored.Attempts != 3 {
+		t.Errorf("entrega gravada: %+v (%v)", stored, err)
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	req := rec.requests[0]
+	signature := req.Header.Get(HeaderSignature)
+	ts := strings.TrimPrefix(strings.Split(signature, ",")[0], "t=")
+	unix, _ := strconv.ParseInt(ts, 10, 64)
+	if signature != Sign("segredo-do-crm", time.Unix(unix, 0), rec.bodies[0]) {
+		t.Errorf("assinatura %q não confere com o corpo enviado", signature)
+	}
+	if req.Header.Get(HeaderEventID) != "evt1" || req.Header.Get(HeaderDelivery) != "evt1_crm" {
+		t.Errorf("headers: %v", req.Header)
+	}
+}
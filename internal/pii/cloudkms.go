@@ -0,0 +1,88 @@
+package pii
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	cloudkms "google.golang.org/api/cloudkms/v1"
+)
+
+// primaryTTL intervalo entre consultas da versão primária da chave no
+// Cloud KMS: a rotação feita no console vale em poucos minutos
+const primaryTTL = 5 * time.Minute
+
+// CloudKMS KEK no Google Cloud KMS. O ID de cada chave de dados cifrada é
+// a versão da chave (.../cryptoKeyVersions/N) que cifrou; a rotação é a do
+// próprio KMS (nova versão primária), e as versões antigas continuam
+// decifrando enquanto estiverem habilitadas.
+type CloudKMS struct {
+	key string
+	svc *cloudkms.Service
+
+	mu        sync.Mutex
+	primary   string
+	fetchedAt time.Time
+}
+
+// NewCloudKMS usa as credenciais padrão do ambiente (ADC); key é o nome
+// completo da chave (projects/.../cryptoKeys/...)
+func NewCloudKMS(ctx context.Context, key string) (*CloudKMS, error) {
+	svc, err := cloudkms.NewService(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro criando client do Cloud KMS: %w", err)
+	}
+	return &CloudKMS{key: key, svc: svc}, nil
+}
+
+func (k *CloudKMS) Wrap(ctx context.Context, dataKey []byte) (string, []byte, error) {
+	resp, err := k.svc.Projects.Locations.KeyRings.CryptoKeys.Encrypt(k.key, &cloudkms.EncryptRequest{
+		Plaintext: base64.StdEncoding.EncodeToString(dataKey),
+	}).Context(ctx).Do()
+	if err != nil {
+		return "", nil, fmt.Errorf("erro cifrando chave de dados com %s: %w", k.key, err)
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(resp.Ciphertext)
+	if err != nil {
+		return "", nil, fmt.Errorf("resposta do Cloud KMS inválida: %w", err)
+	}
+	return resp.Name, wrapped, nil
+}
+
+func (k *CloudKMS) Unwrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	// O texto cifrado identifica a versão; a chamada é feita na chave
+	if !strings.HasPrefix(keyID, k.key+"/") {
+		return nil, fmt.Errorf("KEK %q não pertence a %s", keyID, k.key)
+	}
+	resp, err := k.svc.Projects.Locations.KeyRings.CryptoKeys.Decrypt(k.key, &cloudkms.DecryptRequest{
+		Ciphertext: base64.StdEncoding.EncodeToString(wrapped),
+	}).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("erro decifrando chave de dados (%s): %w", keyID, err)
+	}
+	return base64.StdEncoding.DecodeString(resp.Plaintext)
+}
+
+func (k *CloudKMS) Primary(ctx context.Context) (string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.primary != "" && time.Since(k.fetchedAt) < primaryTTL {
+		return k.primary, nil
+	}
+
+	key, err := k.svc.Projects.Locations.KeyRings.CryptoKeys.Get(k.key).Context(ctx).Do()
+	if err != nil {
+		if k.primary != "" {
+			return k.primary, nil
+		}
+		return "", fmt.Errorf("erro consultando %s: %w", k.key, err)
+	}
+	if key.Primary == nil {
+		return "", fmt.Errorf("%s sem versão primária", k.key)
+	}
+	k.primary, k.fetchedAt = key.Primary.Name, time.Now()
+	return k.primary, nil
+}
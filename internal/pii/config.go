@@ -0,0 +1,43 @@
+package pii
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/viplounge/platform/internal/config"
+	"github.com/viplounge/platform/internal/secrets"
+)
+
+// FromConfig monta a cifragem descrita em config.Privacy.Encryption; nil
+// quando desabilitada. A chave do hash é conferida aqui: sem ela nenhum lead
+// seria gravado nem encontrado.
+func FromConfig(ctx context.Context, cfg *config.Config, provider secrets.Provider) (*Protector, error) {
+	encryption := cfg.Privacy.Encryption
+	if !encryption.Enabled {
+		return nil, nil
+	}
+
+	var kms KeyService
+	var err error
+	switch encryption.KMS {
+	case "local":
+		kms, err = NewLocalKeyFile(encryption.KeyFile)
+	case "gcp":
+		kms, err = NewCloudKMS(ctx, encryption.KMSKey)
+	default:
+		err = fmt.Errorf("KMS desconhecido %q", encryption.KMS)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cifragem dos leads: %w", err)
+	}
+
+	hashKey := secrets.NewSecret(provider, encryption.HashKeyRef)
+	if _, err := hashKey.Value(ctx); err != nil {
+		return nil, fmt.Errorf("cifragem dos leads: chave do hash do CPF (%s) indisponível: %w", hashKey.Ref(), err)
+	}
+	if _, err := kms.Primary(ctx); err != nil {
+		return nil, fmt.Errorf("cifragem dos leads: %w", err)
+	}
+	return NewProtector(kms, hashKey, time.Duration(encryption.DataKeyTTLMinutes)*time.Minute), nil
+}
@@ -0,0 +1,116 @@
+// Package pii cifra os dados pessoais dos leads em repouso: cifragem em
+// envelope com a KEK num KMS e hash com chave do CPF para buscas e IDs.
+package pii
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// KeyService é o KMS: cifra e decifra chaves de dados com a KEK. Cada
+// chave cifrada leva o ID da KEK usada, para continuar legível depois da
+// rotação.
+type KeyService interface {
+	// Wrap cifra a chave de dados com a KEK primária
+	Wrap(ctx context.Context, dataKey []byte) (keyID string, wrapped []byte, err error)
+	// Unwrap decifra com a KEK keyID
+	Unwrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+	// Primary ID da KEK usada nas novas cifragens
+	Primary(ctx context.Context) (string, error)
+}
+
+// LocalKeyFile KMS de desenvolvimento: as KEKs ficam num arquivo JSON
+//
+//	{"primary": "2026-10", "keys": {"2026-01": "<base64>", "2026-10": "<base64>"}}
+//
+// Para rotacionar, acrescente uma chave e aponte primary para ela; as
+// anteriores continuam no arquivo até a recifragem dos leads.
+type LocalKeyFile struct {
+	primary string
+	keys    map[string][]byte
+}
+
+// NewLocalKeyFile lê o arquivo de chaves (cada uma com 32 bytes)
+func NewLocalKeyFile(path string) (*LocalKeyFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("lendo arquivo de chaves %s: %w", path, err)
+	}
+	var file struct {
+		Primary string            `json:"primary"`
+		Keys    map[string]string `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("arquivo de chaves %s inválido: %w", path, err)
+	}
+
+	k := &LocalKeyFile{primary: file.Primary, keys: map[string][]byte{}}
+	for id, encoded := range file.Keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("arquivo de chaves %s: chave %q deve ter 32 bytes em base64", path, id)
+		}
+		k.keys[id] = key
+	}
+	if _, ok := k.keys[k.primary]; !ok {
+		return nil, fmt.Errorf("arquivo de chaves %s: primary %q não está em keys", path, k.primary)
+	}
+	return k, nil
+}
+
+func (k *LocalKeyFile) Wrap(ctx context.Context, dataKey []byte) (string, []byte, error) {
+	wrapped, err := seal(k.keys[k.primary], dataKey, []byte(k.primary))
+	return k.primary, wrapped, err
+}
+
+func (k *LocalKeyFile) Unwrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	key, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("KEK %q não está no arquivo de chaves", keyID)
+	}
+	return open(key, wrapped, []byte(keyID))
+}
+
+func (k *LocalKeyFile) Primary(ctx context.Context) (string, error) {
+	return k.primary, nil
+}
+
+// seal AES-256-GCM com nonce aleatório no início do resultado
+func seal(key, plaintext, aad []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(key, sealed, aad []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("texto cifrado truncado")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, aad)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,215 @@
+package pii
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/secrets"
+)
+
+// maxCachedKeys chaves de dados decifradas mantidas em memória; passado o
+// limite o cache recomeça (cada chave vale DataKeyTTL, então são poucas)
+const maxCachedKeys = 1024
+
+// Protector cifra os dados pessoais dos leads (domain.LeadSealer). Cada
+// instância gera uma chave de dados, cifrada uma vez no KMS e reutilizada
+// até vencer o ttl ou a KEK primária mudar; as chaves lidas dos documentos
+// são decifradas no KMS uma vez e ficam em cache.
+type Protector struct {
+	kms     KeyService
+	hashKey secrets.Secret
+	ttl     time.Duration
+
+	mu        sync.Mutex
+	current   *dataKey
+	unwrapped map[string][]byte
+}
+
+type dataKey struct {
+	keyID     string
+	wrapped   []byte
+	plain     []byte
+	createdAt time.Time
+}
+
+// sealedFields dados pessoais cifrados no documento
+type sealedFields struct {
+	CPF   string `json:"cpf"`
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+	Phone string `json:"phone,omitempty"`
+}
+
+// NewProtector usa kms para as chaves de dados e hashKey para o hash do CPF
+func NewProtector(kms KeyService, hashKey secrets.Secret, ttl time.Duration) *Protector {
+	return &Protector{kms: kms, hashKey: hashKey, ttl: ttl, unwrapped: map[string][]byte{}}
+}
+
+// HashCPF HMAC-SHA256 dos dígitos do CPF com a chave de hash, em hex
+func (p *Protector) HashCPF(ctx context.Context, cpf string) (string, error) {
+	key, err := p.hashKey.Value(ctx)
+	if err != nil {
+		return "", fmt.Errorf("chave do hash do CPF (%s) indisponível: %w", p.hashKey.Ref(), err)
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(onlyDigits(cpf)))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+func (p *Protector) Seal(ctx context.Context, lead domain.Lead) (domain.Lead, error) {
+	lead.PII = nil
+	if !sealable(lead) {
+		lead.CPFHash = ""
+		return lead, nil
+	}
+	hash, err := p.HashCPF(ctx, lead.CPF)
+	if err != nil {
+		return lead, err
+	}
+	plaintext, err := json.Marshal(sealedFields{CPF: lead.CPF, Name: lead.Name, Email: lead.Email, Phone: lead.Phone})
+	if err != nil {
+		return lead, err
+	}
+	// O ID do documento autentica o texto cifrado: não decifra em outro lead
+	sealed, err := p.SealData(ctx, plaintext, []byte(domain.LeadID(lead.CondoID, hash)))
+	if err != nil {
+		return lead, fmt.Errorf("erro cifrando dados do lead: %w", err)
+	}
+
+	lead.CPF, lead.Name, lead.Email, lead.Phone = "", "", "", ""
+	lead.CPFHash = hash
+	lead.PII = sealed
+	return lead, nil
+}
+
+func (p *Protector) Open(ctx context.Context, lead *domain.Lead) error {
+	if lead.PII == nil {
+		return nil
+	}
+	plaintext, err := p.OpenData(ctx, lead.PII, []byte(domain.LeadID(lead.CondoID, lead.CPFHash)))
+	if err != nil {
+		return fmt.Errorf("erro decifrando lead %s: %w", domain.LeadID(lead.CondoID, lead.CPFHash), err)
+	}
+	var fields sealedFields
+	if err := json.Unmarshal(plaintext, &fields); err != nil {
+		return fmt.Errorf("dados cifrados do lead inválidos: %w", err)
+	}
+	lead.CPF, lead.Name, lead.Email, lead.Phone = fields.CPF, fields.Name, fields.Email, fields.Phone
+	lead.PII = nil
+	return nil
+}
+
+// SealData cifra dados avulsos com a chave de dados atual; aad autentica o
+// documento que guarda o resultado
+func (p *Protector) SealData(ctx context.Context, plaintext, aad []byte) (*domain.SealedPII, error) {
+	key, err := p.dataKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := seal(key.plain, plaintext, aad)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.SealedPII{KeyID: key.keyID, DataKey: key.wrapped, Ciphertext: ciphertext}, nil
+}
+
+func (p *Protector) OpenData(ctx context.Context, sealed *domain.SealedPII, aad []byte) ([]byte, error) {
+	key, err := p.unwrap(ctx, sealed.KeyID, sealed.DataKey)
+	if err != nil {
+		return nil, err
+	}
+	return open(key, sealed.Ciphertext, aad)
+}
+
+func (p *Protector) Current(ctx context.Context, lead domain.Lead) (bool, error) {
+	if lead.PII == nil {
+		return !sealable(lead), nil
+	}
+	primary, err := p.kms.Primary(ctx)
+	if err != nil {
+		return false, err
+	}
+	return lead.PII.KeyID == primary, nil
+}
+
+// dataKey chave de dados atual, gerada de novo ao vencer ou quando a KEK
+// primária muda
+func (p *Protector) dataKey(ctx context.Context) (*dataKey, error) {
+	primary, err := p.kms.Primary(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("KEK primária indisponível: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if k := p.current; k != nil && k.keyID == primary && time.Since(k.createdAt) < p.ttl {
+		return k, nil
+	}
+
+	plain := make([]byte, 32)
+	if _, err := rand.Read(plain); err != nil {
+		return nil, err
+	}
+	keyID, wrapped, err := p.kms.Wrap(ctx, plain)
+	if err != nil {
+		return nil, err
+	}
+	p.current = &dataKey{keyID: keyID, wrapped: wrapped, plain: plain, createdAt: time.Now()}
+	p.cache(keyID, wrapped, plain)
+	return p.current, nil
+}
+
+func (p *Protector) unwrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	p.mu.Lock()
+	key, ok := p.unwrapped[cacheKey(keyID, wrapped)]
+	p.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	key, err := p.kms.Unwrap(ctx, keyID, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.cache(keyID, wrapped, key)
+	p.mu.Unlock()
+	return key, nil
+}
+
+// cache guarda a chave decifrada; chamado com mu travado
+func (p *Protector) cache(keyID string, wrapped, plain []byte) {
+	if len(p.unwrapped) >= maxCachedKeys {
+		p.unwrapped = map[string][]byte{}
+	}
+	p.unwrapped[cacheKey(keyID, wrapped)] = plain
+}
+
+func cacheKey(keyID string, wrapped []byte) string {
+	return keyID + "\x00" + string(wrapped)
+}
+
+// sealable leads com CPF em claro; os anonimizados levam o pseudônimo, que
+// não identifica o morador
+func sealable(lead domain.Lead) bool {
+	return lead.CPF != "" && !domain.IsPseudonym(lead.CPF)
+}
+
+func onlyDigits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,206 @@
+package pii
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/secrets"
+)
+
+// countingKMS LocalKeyFile em memória que conta as chamadas ao KMS
+type countingKMS struct {
+	*LocalKeyFile
+	wraps, unwraps int
+}
+
+func newKMS(primary string, ids ...string) *countingKMS {
+	keys := map[string][]byte{}
+	for _, id := range ids {
+		key := sha256.Sum256([]byte("kek-" + id))
+		keys[id] = key[:]
+	}
+	return &countingKMS{LocalKeyFile: &LocalKeyFile{primary: primary, keys: keys}}
+}
+
+func (k *countingKMS) Wrap(ctx context.Context, dataKey []byte) (string, []byte, error) {
+	k.wraps++
+	return k.LocalKeyFile.Wrap(ctx, dataKey)
+}
+
+func (k *countingKMS) Unwrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	k.unwraps++
+	return k.LocalKeyFile.Unwrap(ctx, keyID, wrapped)
+}
+
+func newTestProtector(kms KeyService) *Protector {
+	return NewProtector(kms, secrets.Static("chave-do-hash-do-cpf"), time.Hour)
+}
+
+func TestSealOpen(t *testing.T) {
+	ctx := context.Background()
+	p := newTestProtector(newKMS("2026-01", "2026-01"))
+	lead := domain.Lead{CPF: "529.982.247-25", CondoID: "4", Name: "Maria", Email: "maria@example.com", Phone: "11999990000"}
+
+	sealed, err := p.Seal(ctx, lead)
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if sealed.CPF != "" || sealed.Name != "" || sealed.Email != "" || sealed.Phone != "" || sealed.PII == nil {
+		t.Fatalf("lead cifrado com dados em claro: %+v", sealed)
+	}
+	if hash, _ := p.HashCPF(ctx, "52998224725"); sealed.CPFHash != hash {
+		t.Errorf("cpf_hash %s, esperado o hash dos dígitos %s", sealed.CPFHash, hash)
+	}
+	if bytes.Contains(sealed.PII.Ciphertext, []byte("maria@example.com")) {
+		t.Errorf("e-mail em claro no texto cifrado")
+	}
+
+	opened := sealed
+	if err := p.Open(ctx, &opened); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if opened.CPF != lead.CPF || opened.Name != lead.Name || opened.Email != lead.Email || opened.Phone != lead.Phone || opened.PII != nil {
+		t.Errorf("lead decifrado: %+v", opened)
+	}
+
+	// O texto cifrado é autenticado com o ID do documento
+	moved := sealed
+	moved.CondoID = "7"
+	if err := p.Open(ctx, &moved); err == nil {
+		t.Errorf("dados de um lead decifrados em outro condomínio")
+	}
+}
+
+func TestSealSkipsUnidentifiedLeads(t *testing.T) {
+	p := newTestProtector(newKMS("2026-01", "2026-01"))
+	tests := []struct {
+		name string
+		cpf  string
+	}{
+		{"sem CPF", ""},
+		{"pseudônimo", domain.Pseudonym("segredo-da-lgpd", "52998224725")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lead := domain.Lead{CPF: tt.cpf, CondoID: "4", CPFHash: "antigo"}
+			sealed, err := p.Seal(context.Background(), lead)
+			if err != nil {
+				t.Fatalf("Seal: %v", err)
+			}
+			if sealed.PII != nil || sealed.CPFHash != "" || sealed.CPF != tt.cpf {
+				t.Errorf("lead cifrado: %+v", sealed)
+			}
+			if current, _ := p.Current(context.Background(), sealed); !current {
+				t.Errorf("lead sem dados pessoais marcado para recifragem")
+			}
+		})
+	}
+}
+
+func TestHashCPF(t *testing.T) {
+	ctx := context.Background()
+	p := newTestProtector(newKMS("2026-01", "2026-01"))
+	masked, _ := p.HashCPF(ctx, "529.982.247-25")
+	digits, _ := p.HashCPF(ctx, "52998224725")
+	if masked != digits || len(masked) != 64 {
+		t.Errorf("hash com máscara %s, sem máscara %s", masked, digits)
+	}
+
+	other := NewProtector(newKMS("2026-01", "2026-01"), secrets.Static("outra-chave"), time.Hour)
+	if hash, _ := other.HashCPF(ctx, "52998224725"); hash == digits {
+		t.Errorf("hash não depende da chave")
+	}
+	if _, err := NewProtector(nil, secrets.Static(""), time.Hour).HashCPF(ctx, "52998224725"); err == nil {
+		t.Errorf("hash sem chave deveria falhar")
+	}
+}
+
+// TestDataKeyRotation a chave de dados é reutilizada até a KEK primária
+// mudar, e as chaves antigas continuam legíveis (com cache do unwrap)
+func TestDataKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	kms := newKMS("2026-01", "2026-01", "2026-10")
+	p := newTestProtector(kms)
+	lead := domain.Lead{CPF: "52998224725", CondoID: "4", Email: "maria@example.com"}
+
+	first, _ := p.Seal(ctx, lead)
+	second, _ := p.Seal(ctx, lead)
+	if kms.wraps != 1 || !bytes.Equal(first.PII.DataKey, second.PII.DataKey) {
+		t.Errorf("%d chaves de dados geradas para a mesma KEK", kms.wraps)
+	}
+	if current, _ := p.Current(ctx, first); !current {
+		t.Errorf("lead com a KEK primária marcado para recifragem")
+	}
+
+	kms.primary = "2026-10"
+	rotated, _ := p.Seal(ctx, lead)
+	if kms.wraps != 2 || rotated.PII.KeyID != "2026-10" {
+		t.Errorf("rotação: %d wraps, KEK %s", kms.wraps, rotated.PII.KeyID)
+	}
+	if current, _ := p.Current(ctx, first); current {
+		t.Errorf("lead com a KEK antiga não marcado para recifragem")
+	}
+
+	// Outra instância decifra a chave antiga no KMS uma vez só
+	reader := newTestProtector(kms)
+	for i := 0; i < 2; i++ {
+		opened := first
+		if err := reader.Open(ctx, &opened); err != nil || opened.Email != lead.Email {
+			t.Fatalf("Open com a KEK antiga: %v", err)
+		}
+	}
+	if kms.unwraps != 1 {
+		t.Errorf("%d unwraps no KMS, esperado 1", kms.unwraps)
+	}
+
+	// Sem a KEK antiga no KMS o lead não abre
+	delete(kms.keys, "2026-01")
+	opened := first
+	if err := newTestProtector(kms).Open(ctx, &opened); err == nil {
+		t.Errorf("lead decifrado sem a KEK")
+	}
+}
+
+func TestNewLocalKeyFile(t *testing.T) {
+	valid := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	tests := []struct {
+		name    string
+		content interface{}
+		wantErr string
+	}{
+		{name: "válido", content: map[string]interface{}{"primary": "a", "keys": map[string]string{"a": valid}}},
+		{name: "primary ausente", content: map[string]interface{}{"primary": "b", "keys": map[string]string{"a": valid}}, wantErr: "primary"},
+		{name: "chave curta", content: map[string]interface{}{"primary": "a", "keys": map[string]string{"a": "YWJj"}}, wantErr: "32 bytes"},
+		{name: "JSON inválido", content: "{", wantErr: "inválido"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "keys.json")
+			data, _ := json.Marshal(tt.content)
+			if s, ok := tt.content.(string); ok {
+				data = []byte(s)
+			}
+			os.WriteFile(path, data, 0o600)
+
+			_, err := NewLocalKeyFile(path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("NewLocalKeyFile: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("erro %v, esperado %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,17 +2,65 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"sort"
+	"time"
 
 	"cloud.google.com/go/firestore"
 	"github.com/viplounge/platform/internal/domain"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Store reúne tudo que o servidor precisa da camada de persistência
+type Store interface {
+	domain.LeadStore
+	domain.AuditRepository
+	domain.DirectoryRepository
+	domain.WebhookEventRepository
+	domain.DeliveryRepository
+	domain.IdempotencyRepository
+	domain.SSOGrantRepository
+	domain.SessionRepository
+	domain.SubjectRepository
+	domain.RetentionRepository
+	domain.ConsentRepository
+	domain.LeadKeyRotator
+	// EnableEncryption cifra os dados pessoais dos leads gravados
+	EnableEncryption(sealer domain.LeadSealer)
+	// SetLegacyLookup com a cifragem ligada, busca também os leads ainda
+	// gravados com o CPF em claro
+	SetLegacyLookup(enabled bool)
+	Close() error
+}
+
+const (
+	attemptsCollection         = "attempts"
+	auditCollection            = "audit_log"
+	residentsCollection        = "residents"
+	residentChangesCollection  = "resident_changes"
+	directorySyncCollection    = "directory_sync"
+	webhookEventsCollection    = "webhook_events"
+	deliveriesCollection       = "webhook_deliveries"
+	idempotencyCollection      = "idempotency_keys"
+	ssoGrantsCollection        = "sso_grants"
+	sessionsCollection         = "sessions"
+	retentionReportsCollection = "retention_reports"
+	// limite de escritas por batch do Firestore
+	maxBatchWrites     = 500
+	defaultSearchLimit = 50
+	maxSearchLimit     = 500
 )
 
 type FirestoreRepository struct {
-	client *firestore.Client
+	client         *firestore.Client
 	collectionName string
+	sealing        leadSealing
+	legacyLookup   bool
 }
 
 func NewFirestoreRepository(ctx context.Context, projectID string) (*FirestoreRepository, error) {
@@ -27,33 +75,1312 @@ func NewFirestoreRepository(ctx context.Context, projectID string) (*FirestoreRe
 	}
 
 	return &FirestoreRepository{
-		client: client,
+		client:         client,
 		collectionName: "leads",
+		legacyLookup:   true,
 	}, nil
 }
 
+// EnableEncryption passa a gravar os leads com os dados pessoais cifrados,
+// no documento com o hash do CPF
+func (r *FirestoreRepository) EnableEncryption(sealer domain.LeadSealer) {
+	r.sealing = newLeadSealing(sealer)
+}
+
+// SetLegacyLookup liga ou desliga a busca em claro pelo CPF (cpf in
+// variantes), que com a cifragem ligada só acha leads não migrados
+func (r *FirestoreRepository) SetLegacyLookup(enabled bool) {
+	r.legacyLookup = enabled
+}
+
 func (r *FirestoreRepository) Save(ctx context.Context, lead domain.Lead) error {
 	// Se client é nil, retorna silenciosamente (dev local sem credenciais)
 	if r == nil || r.client == nil {
 		log.Printf("INFO: Firestore client não disponível, pulando save")
 		return nil
 	}
-	
-	// Usa o CPF e CondoID como chave composta ou ID do documento para evitar duplicatas fáceis
-	docID := fmt.Sprintf("%s_%s", lead.CondoID, lead.CPF)
-	
-	// Set (upsert) para criar ou atualizar
-	_, err := r.client.Collection(r.collectionName).Doc(docID).Set(ctx, lead)
-	if err != nil {
+
+	if _, err := r.saveLead(ctx, lead, true); err != nil {
 		log.Printf("Erro ao salvar no Firestore: %v", err)
 		return err
 	}
-	
 	return nil
 }
 
-func (r *FirestoreRepository) Close() error {
-	return r.client.Close()
+// saveLead grava o lead e, com record, a tentativa. O ID do documento é
+// condo_cpf, ou condo_<hash do CPF> com a cifragem ligada, para evitar
+// duplicatas fáceis; o lead ainda em claro no ID antigo é movido para o
+// documento cifrado junto com as tentativas. Sem record (recifragem) um
+// documento mais recente no destino é mantido. Retorna as tentativas movidas.
+func (r *FirestoreRepository) saveLead(ctx context.Context, lead domain.Lead, record bool) (int, error) {
+	sealed, docID, err := r.sealing.seal(ctx, lead)
+	if err != nil {
+		return 0, err
+	}
+	doc := r.client.Collection(r.collectionName).Doc(docID)
+	var legacy *firestore.DocumentRef
+	if legacyID := domain.LeadID(lead.CondoID, lead.CPF); legacyID != docID {
+		legacy = r.client.Collection(r.collectionName).Doc(legacyID)
+	}
+
+	// Set (upsert) para criar ou atualizar + histórico de tentativas na
+	// subcoleção. A transação lê os consentimentos já gravados, que o lead
+	// regravado pelos fluxos sem escolha de consentimento não traz.
+	moved := 0
+	err = r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		merged := sealed
+		moved = 0
+		stored, err := getLeadTx(tx, doc)
+		if err != nil {
+			return err
+		}
+		var legacyLead *domain.Lead
+		var legacyAttempts []*firestore.DocumentSnapshot
+		if legacy != nil {
+			if legacyLead, err = getLeadTx(tx, legacy); err != nil {
+				return err
+			}
+			if legacyLead != nil {
+				if legacyAttempts, err = tx.Documents(legacy.Collection(attemptsCollection)).GetAll(); err != nil {
+					return err
+				}
+				if stored == nil {
+					stored = legacyLead
+				}
+			}
+		}
+
+		for _, snap := range legacyAttempts {
+			var attempt domain.LeadAttempt
+			if err := snap.DataTo(&attempt); err != nil {
+				return fmt.Errorf("erro decodificando tentativa %s: %w", snap.Ref.ID, err)
+			}
+			if attempt.Lead, err = r.sealing.reseal(ctx, attempt.Lead); err != nil {
+				return err
+			}
+			attempt.LeadID = docID
+			if err := tx.Set(doc.Collection(attemptsCollection).Doc(snap.Ref.ID), attempt); err != nil {
+				return err
+			}
+			if err := tx.Delete(snap.Ref); err != nil {
+				return err
+			}
+			moved++
+		}
+		if legacyLead != nil {
+			if err := tx.Delete(legacy); err != nil {
+				return err
+			}
+		}
+
+		if stored != nil && !record && stored.UpdatedAt.After(sealed.UpdatedAt) {
+			return nil
+		}
+		if stored != nil {
+			domain.MergeConsents(&merged, stored.Consents)
+		}
+		if err := tx.Set(doc, merged); err != nil {
+			return err
+		}
+		if !record {
+			return nil
+		}
+		return tx.Set(doc.Collection(attemptsCollection).NewDoc(), domain.LeadAttempt{
+			LeadID:     docID,
+			Lead:       merged,
+			RecordedAt: time.Now(),
+		})
+	})
+	return moved, err
+}
+
+// GetLead busca um lead pelo ID do documento. O ID condo_cpf usado pelo
+// suporte também encontra o lead cifrado (condo_<hash do CPF>).
+func (r *FirestoreRepository) GetLead(ctx context.Context, id string) (*domain.Lead, error) {
+	hashed, err := r.sealing.hashedID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if hashed != "" {
+		lead, err := r.getLead(ctx, hashed)
+		if !errors.Is(err, domain.ErrNotFound) {
+			return lead, err
+		}
+	}
+	return r.getLead(ctx, id)
+}
+
+func (r *FirestoreRepository) getLead(ctx context.Context, id string) (*domain.Lead, error) {
+	snap, err := r.client.Collection(r.collectionName).Doc(id).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("erro buscando lead %s: %w", id, err)
+	}
+
+	var lead domain.Lead
+	if err := snap.DataTo(&lead); err != nil {
+		return nil, fmt.Errorf("erro decodificando lead %s: %w", id, err)
+	}
+	if err := r.sealing.open(ctx, &lead); err != nil {
+		return nil, err
+	}
+	return &lead, nil
+}
+
+// SearchLeads busca leads por CPF, tenant, status e período (updated_at).
+// Com a cifragem ligada o CPF é buscado pelo hash (cpf_hash, que precisa
+// dos mesmos índices compostos do cpf) e, com legacy_lookup, em claro, nos
+// leads ainda não migrados.
+func (r *FirestoreRepository) SearchLeads(ctx context.Context, filter domain.LeadFilter) ([]domain.Lead, error) {
+	q := r.client.Collection(r.collectionName).Query
+	if filter.TenantID != "" {
+		q = q.Where("condo_id", "==", filter.TenantID)
+	}
+	if filter.Status != "" {
+		q = q.Where("status", "==", filter.Status)
+	}
+	if !filter.From.IsZero() {
+		q = q.Where("updated_at", ">=", filter.From)
+	}
+	if !filter.To.IsZero() {
+		q = q.Where("updated_at", "<=", filter.To)
+	}
+
+	queries := []firestore.Query{q}
+	if filter.CPF != "" {
+		queries = nil
+		if r.sealing.plaintextLookup(r.legacyLookup) {
+			// O CPF é gravado como digitado na landing page (com ou sem máscara)
+			queries = append(queries, q.Where("cpf", "in", cpfVariants(filter.CPF)))
+		}
+		hash, err := r.sealing.cpfHash(ctx, filter.CPF)
+		if err != nil {
+			return nil, err
+		}
+		if hash != "" {
+			queries = append(queries, q.Where("cpf_hash", "==", hash))
+		}
+	}
+
+	limit := searchLimit(filter.Limit)
+	var leads []domain.Lead
+	for _, query := range queries {
+		iter := query.OrderBy("updated_at", firestore.Desc).Limit(limit).Documents(ctx)
+		for {
+			snap, err := iter.Next()
+			if errors.Is(err, iterator.Done) {
+				break
+			}
+			if err != nil {
+				iter.Stop()
+				return nil, fmt.Errorf("erro buscando leads: %w", err)
+			}
+			var lead domain.Lead
+			if err := snap.DataTo(&lead); err != nil {
+				iter.Stop()
+				return nil, fmt.Errorf("erro decodificando lead %s: %w", snap.Ref.ID, err)
+			}
+			leads = append(leads, lead)
+		}
+		iter.Stop()
+	}
+	if len(queries) > 1 {
+		sort.Slice(leads, func(i, j int) bool { return leads[i].UpdatedAt.After(leads[j].UpdatedAt) })
+		if len(leads) > limit {
+			leads = leads[:limit]
+		}
+	}
+	return leads, r.sealing.openLeads(ctx, leads)
+}
+
+// ConsentingLeads leads com consents.<purpose>.granted, mais recentes
+// primeiro. Exige índice composto (consents.<purpose>.granted, updated_at
+// desc), com condo_id antes do updated_at para o filtro por tenant.
+func (r *FirestoreRepository) ConsentingLeads(ctx context.Context, purpose, tenantID string) ([]domain.Lead, error) {
+	q := r.client.Collection(r.collectionName).Where("consents."+purpose+".granted", "==", true)
+	if tenantID != "" {
+		q = q.Where("condo_id", "==", tenantID)
+	}
+	q = q.OrderBy("updated_at", firestore.Desc)
+
+	var leads []domain.Lead
+	iter := q.Documents(ctx)
+	defer iter.Stop()
+	for {
+		snap, err := iter.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("erro buscando leads com consentimento %s: %w", purpose, err)
+		}
+		var lead domain.Lead
+		if err := snap.DataTo(&lead); err != nil {
+			return nil, fmt.Errorf("erro decodificando lead %s: %w", snap.Ref.ID, err)
+		}
+		leads = append(leads, lead)
+	}
+	return leads, r.sealing.openLeads(ctx, leads)
+}
+
+// ListAttempts retorna o histórico de tentativas de um lead, mais recente
+// primeiro (o ID condo_cpf também encontra o lead cifrado)
+func (r *FirestoreRepository) ListAttempts(ctx context.Context, leadID string) ([]domain.LeadAttempt, error) {
+	hashed, err := r.sealing.hashedID(ctx, leadID)
+	if err != nil {
+		return nil, err
+	}
+	if hashed != "" {
+		attempts, err := r.listAttempts(ctx, hashed)
+		if err != nil || len(attempts) > 0 {
+			return attempts, err
+		}
+	}
+	return r.listAttempts(ctx, leadID)
+}
+
+func (r *FirestoreRepository) listAttempts(ctx context.Context, leadID string) ([]domain.LeadAttempt, error) {
+	iter := r.client.Collection(r.collectionName).Doc(leadID).Collection(attemptsCollection).
+		OrderBy("recorded_at", firestore.Desc).Documents(ctx)
+	defer iter.Stop()
+
+	var attempts []domain.LeadAttempt
+	for {
+		snap, err := iter.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("erro listando tentativas de %s: %w", leadID, err)
+		}
+		var attempt domain.LeadAttempt
+		if err := snap.DataTo(&attempt); err != nil {
+			return nil, fmt.Errorf("erro decodificando tentativa %s: %w", snap.Ref.ID, err)
+		}
+		attempt.ID = snap.Ref.ID
+		attempts = append(attempts, attempt)
+	}
+	return attempts, r.sealing.openAttempts(ctx, attempts)
+}
+
+// SaveAudit grava um evento no log de auditoria
+func (r *FirestoreRepository) SaveAudit(ctx context.Context, event domain.AuditEvent) error {
+	doc := r.client.Collection(auditCollection).NewDoc()
+	if event.ID != "" {
+		doc = r.client.Collection(auditCollection).Doc(event.ID)
+	}
+	if _, err := doc.Set(ctx, event); err != nil {
+		return fmt.Errorf("erro gravando auditoria: %w", err)
+	}
+	return nil
+}
+
+// ListAudit consulta o log de auditoria, mais recente primeiro
+func (r *FirestoreRepository) ListAudit(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEvent, error) {
+	q := r.client.Collection(auditCollection).Query
+	if filter.Actor != "" {
+		q = q.Where("actor", "==", filter.Actor)
+	}
+	if filter.TenantID != "" {
+		q = q.Where("tenant_id", "==", filter.TenantID)
+	}
+	if filter.LeadID != "" {
+		q = q.Where("lead_id", "==", filter.LeadID)
+	}
+	if filter.CPF != "" {
+		q = q.Where("cpf", "in", cpfVariants(filter.CPF))
+	}
+	if !filter.From.IsZero() {
+		q = q.Where("created_at", ">=", filter.From)
+	}
+	if !filter.To.IsZero() {
+		q = q.Where("created_at", "<=", filter.To)
+	}
+	q = q.OrderBy("created_at", firestore.Desc).Limit(searchLimit(filter.Limit))
+
+	var events []domain.AuditEvent
+	iter := q.Documents(ctx)
+	defer iter.Stop()
+	for {
+		snap, err := iter.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("erro consultando auditoria: %w", err)
+		}
+		var event domain.AuditEvent
+		if err := snap.DataTo(&event); err != nil {
+			return nil, fmt.Errorf("erro decodificando auditoria %s: %w", snap.Ref.ID, err)
+		}
+		event.ID = snap.Ref.ID
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// FindResidents busca o CPF no diretório de moradores, em todos os condomínios
+func (r *FirestoreRepository) FindResidents(ctx context.Context, cpf string) ([]domain.Resident, error) {
+	q := r.client.Collection(residentsCollection).Where("cpf", "==", onlyDigits(cpf))
+	return r.queryResidents(ctx, q)
+}
+
+// ListResidents retorna todos os registros do diretório de um condomínio
+func (r *FirestoreRepository) ListResidents(ctx context.Context, condoID string) ([]domain.Resident, error) {
+	q := r.client.Collection(residentsCollection).Where("condo_id", "==", condoID)
+	return r.queryResidents(ctx, q)
+}
+
+func (r *FirestoreRepository) queryResidents(ctx context.Context, q firestore.Query) ([]domain.Resident, error) {
+	iter := q.Documents(ctx)
+	defer iter.Stop()
+
+	var residents []domain.Resident
+	for {
+		snap, err := iter.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("erro consultando diretório de moradores: %w", err)
+		}
+		var resident domain.Resident
+		if err := snap.DataTo(&resident); err != nil {
+			return nil, fmt.Errorf("erro decodificando morador %s: %w", snap.Ref.ID, err)
+		}
+		resident.ID = snap.Ref.ID
+		residents = append(residents, resident)
+	}
+	return residents, nil
+}
+
+// ApplySync grava moradores e mudanças em batches (limite de 500 escritas
+// cada) e por último o estado, que só avança se tudo foi gravado
+func (r *FirestoreRepository) ApplySync(ctx context.Context, state domain.DirectorySync, residents []domain.Resident, changes []domain.ResidentChange) error {
+	batch, writes := r.client.Batch(), 0
+	flush := func() error {
+		if writes == 0 {
+			return nil
+		}
+		_, err := batch.Commit(ctx)
+		batch, writes = r.client.Batch(), 0
+		return err
+	}
+
+	for _, resident := range residents {
+		batch.Set(r.client.Collection(residentsCollection).Doc(domain.LeadID(resident.CondoID, resident.CPF)), resident)
+		if writes++; writes == maxBatchWrites {
+			if err := flush(); err != nil {
+				return fmt.Errorf("erro gravando moradores de %s: %w", state.CondoID, err)
+			}
+		}
+	}
+	for _, change := range changes {
+		batch.Set(r.client.Collection(residentChangesCollection).NewDoc(), change)
+		if writes++; writes == maxBatchWrites {
+			if err := flush(); err != nil {
+				return fmt.Errorf("erro gravando mudanças de %s: %w", state.CondoID, err)
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return fmt.Errorf("erro gravando diretório de %s: %w", state.CondoID, err)
+	}
+	return r.SaveSyncState(ctx, state)
 }
 
+// SaveSyncState grava o estado da sincronização do condomínio
+func (r *FirestoreRepository) SaveSyncState(ctx context.Context, state domain.DirectorySync) error {
+	if _, err := r.client.Collection(directorySyncCollection).Doc(state.CondoID).Set(ctx, state); err != nil {
+		return fmt.Errorf("erro gravando estado da sincronização de %s: %w", state.CondoID, err)
+	}
+	return nil
+}
+
+// GetSyncState retorna o estado da última sincronização do condomínio
+func (r *FirestoreRepository) GetSyncState(ctx context.Context, condoID string) (*domain.DirectorySync, error) {
+	snap, err := r.client.Collection(directorySyncCollection).Doc(condoID).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("erro buscando sincronização de %s: %w", condoID, err)
+	}
+	var state domain.DirectorySync
+	if err := snap.DataTo(&state); err != nil {
+		return nil, fmt.Errorf("erro decodificando sincronização de %s: %w", condoID, err)
+	}
+	return &state, nil
+}
+
+// ListChanges consulta o feed de mudanças do diretório, mais antigas primeiro
+func (r *FirestoreRepository) ListChanges(ctx context.Context, filter domain.ResidentChangeFilter) ([]domain.ResidentChange, error) {
+	q := r.client.Collection(residentChangesCollection).Query
+	if filter.CondoID != "" {
+		q = q.Where("condo_id", "==", filter.CondoID)
+	}
+	if filter.Type != "" {
+		q = q.Where("type", "==", filter.Type)
+	}
+	if !filter.Since.IsZero() {
+		q = q.Where("detected_at", ">", filter.Since)
+	}
+	q = q.OrderBy("detected_at", firestore.Asc).Limit(searchLimit(filter.Limit))
+
+	var changes []domain.ResidentChange
+	iter := q.Documents(ctx)
+	defer iter.Stop()
+	for {
+		snap, err := iter.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("erro consultando mudanças do diretório: %w", err)
+		}
+		var change domain.ResidentChange
+		if err := snap.DataTo(&change); err != nil {
+			return nil, fmt.Errorf("erro decodificando mudança %s: %w", snap.Ref.ID, err)
+		}
+		change.ID = snap.Ref.ID
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
 
+// CreateWebhookEvent grava o evento usando a chave de idempotência como ID
+// do documento; uma entrega repetida falha com ErrAlreadyExists
+func (r *FirestoreRepository) CreateWebhookEvent(ctx context.Context, event domain.WebhookEvent) error {
+	event, err := r.sealing.sealWebhookEvent(ctx, event)
+	if err != nil {
+		return err
+	}
+	if _, err := r.client.Collection(webhookEventsCollection).Doc(event.ID).Create(ctx, event); err != nil {
+		if status.Code(err) == codes.AlreadyExists {
+			return domain.ErrAlreadyExists
+		}
+		return fmt.Errorf("erro gravando evento %s: %w", event.ID, err)
+	}
+	return nil
+}
+
+// UpdateWebhookEvent grava o resultado do processamento de um evento
+func (r *FirestoreRepository) UpdateWebhookEvent(ctx context.Context, event domain.WebhookEvent) error {
+	event, err := r.sealing.sealWebhookEvent(ctx, event)
+	if err != nil {
+		return err
+	}
+	if _, err := r.client.Collection(webhookEventsCollection).Doc(event.ID).Set(ctx, event); err != nil {
+		return fmt.Errorf("erro atualizando evento %s: %w", event.ID, err)
+	}
+	return nil
+}
+
+// GetWebhookEvent busca um evento pela chave de idempotência
+func (r *FirestoreRepository) GetWebhookEvent(ctx context.Context, id string) (*domain.WebhookEvent, error) {
+	snap, err := r.client.Collection(webhookEventsCollection).Doc(id).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("erro buscando evento %s: %w", id, err)
+	}
+	var event domain.WebhookEvent
+	if err := snap.DataTo(&event); err != nil {
+		return nil, fmt.Errorf("erro decodificando evento %s: %w", id, err)
+	}
+	event.ID = snap.Ref.ID
+	events := []domain.WebhookEvent{event}
+	return &events[0], r.sealing.openWebhookEvents(ctx, events)
+}
+
+// ListWebhookEvents consulta os eventos recebidos, mais antigos primeiro
+func (r *FirestoreRepository) ListWebhookEvents(ctx context.Context, filter domain.WebhookEventFilter) ([]domain.WebhookEvent, error) {
+	q := r.client.Collection(webhookEventsCollection).Query
+	if filter.Source != "" {
+		q = q.Where("source", "==", filter.Source)
+	}
+	if filter.Status != "" {
+		q = q.Where("status", "==", filter.Status)
+	}
+	if !filter.From.IsZero() {
+		q = q.Where("received_at", ">=", filter.From)
+	}
+	if !filter.To.IsZero() {
+		q = q.Where("received_at", "<=", filter.To)
+	}
+	q = q.OrderBy("received_at", firestore.Asc).Limit(searchLimit(filter.Limit))
+
+	var events []domain.WebhookEvent
+	iter := q.Documents(ctx)
+	defer iter.Stop()
+	for {
+		snap, err := iter.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("erro consultando eventos: %w", err)
+		}
+		var event domain.WebhookEvent
+		if err := snap.DataTo(&event); err != nil {
+			return nil, fmt.Errorf("erro decodificando evento %s: %w", snap.Ref.ID, err)
+		}
+		event.ID = snap.Ref.ID
+		events = append(events, event)
+	}
+	return events, r.sealing.openWebhookEvents(ctx, events)
+}
+
+// SaveDelivery cria ou atualiza o registro de uma entrega
+func (r *FirestoreRepository) SaveDelivery(ctx context.Context, delivery domain.WebhookDelivery) error {
+	delivery, err := r.sealing.sealDelivery(ctx, delivery)
+	if err != nil {
+		return err
+	}
+	if _, err := r.client.Collection(deliveriesCollection).Doc(delivery.ID).Set(ctx, delivery); err != nil {
+		return fmt.Errorf("erro gravando entrega %s: %w", delivery.ID, err)
+	}
+	return nil
+}
+
+// GetDelivery busca uma entrega pelo ID
+func (r *FirestoreRepository) GetDelivery(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	snap, err := r.client.Collection(deliveriesCollection).Doc(id).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("erro buscando entrega %s: %w", id, err)
+	}
+	var delivery domain.WebhookDelivery
+	if err := snap.DataTo(&delivery); err != nil {
+		return nil, fmt.Errorf("erro decodificando entrega %s: %w", id, err)
+	}
+	delivery.ID = snap.Ref.ID
+	deliveries := []domain.WebhookDelivery{delivery}
+	return &deliveries[0], r.sealing.openDeliveries(ctx, deliveries)
+}
+
+// ListDeliveries consulta o log de entregas
+func (r *FirestoreRepository) ListDeliveries(ctx context.Context, filter domain.DeliveryFilter) ([]domain.WebhookDelivery, error) {
+	q := r.client.Collection(deliveriesCollection).Query
+	if filter.TenantID != "" {
+		q = q.Where("tenant_id", "==", filter.TenantID)
+	}
+	if filter.EventType != "" {
+		q = q.Where("event_type", "==", filter.EventType)
+	}
+	if filter.EndpointID != "" {
+		q = q.Where("endpoint_id", "==", filter.EndpointID)
+	}
+	if filter.Status != "" {
+		q = q.Where("status", "==", filter.Status)
+	}
+	if !filter.DueBefore.IsZero() {
+		q = q.Where("next_attempt_at", "<=", filter.DueBefore).OrderBy("next_attempt_at", firestore.Asc)
+	} else {
+		if !filter.From.IsZero() {
+			q = q.Where("created_at", ">=", filter.From)
+		}
+		if !filter.To.IsZero() {
+			q = q.Where("created_at", "<=", filter.To)
+		}
+		q = q.OrderBy("created_at", firestore.Desc)
+	}
+	q = q.Limit(searchLimit(filter.Limit))
+
+	var deliveries []domain.WebhookDelivery
+	iter := q.Documents(ctx)
+	defer iter.Stop()
+	for {
+		snap, err := iter.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("erro consultando entregas: %w", err)
+		}
+		var delivery domain.WebhookDelivery
+		if err := snap.DataTo(&delivery); err != nil {
+			return nil, fmt.Errorf("erro decodificando entrega %s: %w", snap.Ref.ID, err)
+		}
+		delivery.ID = snap.Ref.ID
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, r.sealing.openDeliveries(ctx, deliveries)
+}
+
+// CreateIdempotencyRecord reserva a chave; uma repetição falha com
+// ErrAlreadyExists. Documentos expirados são removidos pela política de TTL
+// do campo expires_at.
+func (r *FirestoreRepository) CreateIdempotencyRecord(ctx context.Context, record domain.IdempotencyRecord) error {
+	if _, err := r.client.Collection(idempotencyCollection).Doc(record.ID).Create(ctx, record); err != nil {
+		if status.Code(err) == codes.AlreadyExists {
+			return domain.ErrAlreadyExists
+		}
+		return fmt.Errorf("erro reservando chave de idempotência: %w", err)
+	}
+	return nil
+}
+
+func (r *FirestoreRepository) SaveIdempotencyRecord(ctx context.Context, record domain.IdempotencyRecord) error {
+	if _, err := r.client.Collection(idempotencyCollection).Doc(record.ID).Set(ctx, record); err != nil {
+		return fmt.Errorf("erro gravando chave de idempotência: %w", err)
+	}
+	return nil
+}
+
+// ReplaceExpiredIdempotencyRecord confere a expiração e grava na mesma
+// transação: de duas requisições que acharam a chave expirada, só uma reserva
+func (r *FirestoreRepository) ReplaceExpiredIdempotencyRecord(ctx context.Context, record domain.IdempotencyRecord) error {
+	ref := r.client.Collection(idempotencyCollection).Doc(record.ID)
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snap, err := tx.Get(ref)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		if err == nil {
+			var existing domain.IdempotencyRecord
+			if err := snap.DataTo(&existing); err != nil {
+				return err
+			}
+			if !existing.Expired(record.CreatedAt) {
+				return domain.ErrAlreadyExists
+			}
+		}
+		return tx.Set(ref, record)
+	})
+	if errors.Is(err, domain.ErrAlreadyExists) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("erro reservando chave de idempotência expirada: %w", err)
+	}
+	return nil
+}
+
+func (r *FirestoreRepository) GetIdempotencyRecord(ctx context.Context, id string) (*domain.IdempotencyRecord, error) {
+	snap, err := r.client.Collection(idempotencyCollection).Doc(id).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("erro buscando chave de idempotência: %w", err)
+	}
+	var record domain.IdempotencyRecord
+	if err := snap.DataTo(&record); err != nil {
+		return nil, fmt.Errorf("erro decodificando chave de idempotência: %w", err)
+	}
+	record.ID = snap.Ref.ID
+	return &record, nil
+}
+
+func (r *FirestoreRepository) DeleteIdempotencyRecord(ctx context.Context, id string) error {
+	if _, err := r.client.Collection(idempotencyCollection).Doc(id).Delete(ctx); err != nil {
+		return fmt.Errorf("erro removendo chave de idempotência: %w", err)
+	}
+	return nil
+}
+
+// CreateSSOGrant guarda o acesso até o redirecionamento. Acessos não usados
+// são removidos pela política de TTL do campo expires_at.
+func (r *FirestoreRepository) CreateSSOGrant(ctx context.Context, grant domain.SSOGrant) error {
+	if _, err := r.client.Collection(ssoGrantsCollection).Doc(grant.ID).Create(ctx, grant); err != nil {
+		return fmt.Errorf("erro gravando acesso SSO: %w", err)
+	}
+	return nil
+}
+
+// ConsumeSSOGrant lê e remove o acesso na mesma transação, para dois
+// redirecionamentos simultâneos não usarem o mesmo handle
+func (r *FirestoreRepository) ConsumeSSOGrant(ctx context.Context, id string) (*domain.SSOGrant, error) {
+	ref := r.client.Collection(ssoGrantsCollection).Doc(id)
+	var grant domain.SSOGrant
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snap, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		if err := snap.DataTo(&grant); err != nil {
+			return err
+		}
+		return tx.Delete(ref)
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("erro consumindo acesso SSO: %w", err)
+	}
+	grant.ID = id
+	return &grant, nil
+}
+
+// CreateSession grava a sessão "lembrar de mim". Sessões vencidas são
+// removidas pela política de TTL do campo expires_at.
+func (r *FirestoreRepository) CreateSession(ctx context.Context, session domain.Session) error {
+	if _, err := r.client.Collection(sessionsCollection).Doc(session.ID).Create(ctx, session); err != nil {
+		return fmt.Errorf("erro gravando sessão: %w", err)
+	}
+	return nil
+}
+
+func (r *FirestoreRepository) GetSession(ctx context.Context, id string) (*domain.Session, error) {
+	snap, err := r.client.Collection(sessionsCollection).Doc(id).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("erro buscando sessão: %w", err)
+	}
+	var session domain.Session
+	if err := snap.DataTo(&session); err != nil {
+		return nil, fmt.Errorf("erro decodificando sessão: %w", err)
+	}
+	session.ID = snap.Ref.ID
+	return &session, nil
+}
+
+func (r *FirestoreRepository) SaveSession(ctx context.Context, session domain.Session) error {
+	if _, err := r.client.Collection(sessionsCollection).Doc(session.ID).Set(ctx, session); err != nil {
+		return fmt.Errorf("erro gravando sessão: %w", err)
+	}
+	return nil
+}
+
+func (r *FirestoreRepository) DeleteSession(ctx context.Context, id string) error {
+	if _, err := r.client.Collection(sessionsCollection).Doc(id).Delete(ctx); err != nil {
+		return fmt.Errorf("erro removendo sessão: %w", err)
+	}
+	return nil
+}
+
+// ExportSubject reúne os documentos do CPF em todas as coleções
+func (r *FirestoreRepository) ExportSubject(ctx context.Context, cpf string) (*domain.SubjectData, error) {
+	digits := onlyDigits(cpf)
+	data := &domain.SubjectData{CPF: digits, ExportedAt: time.Now()}
+
+	leads, err := r.subjectLeads(ctx, cpf)
+	if err != nil {
+		return nil, err
+	}
+	var leadIDs []string
+	for _, snap := range leads {
+		var lead domain.Lead
+		if err := snap.DataTo(&lead); err != nil {
+			return nil, fmt.Errorf("erro decodificando lead %s: %w", snap.Ref.ID, err)
+		}
+		if err := r.sealing.open(ctx, &lead); err != nil {
+			return nil, err
+		}
+		data.Leads = append(data.Leads, lead)
+		leadIDs = append(leadIDs, subjectLeadIDs(snap.Ref.ID, lead)...)
+
+		attempts, err := r.ListAttempts(ctx, snap.Ref.ID)
+		if err != nil {
+			return nil, err
+		}
+		data.Attempts = append(data.Attempts, attempts...)
+	}
+
+	audit, err := r.subjectAudit(ctx, cpf, leadIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, snap := range audit {
+		var event domain.AuditEvent
+		if err := snap.DataTo(&event); err != nil {
+			return nil, fmt.Errorf("erro decodificando auditoria %s: %w", snap.Ref.ID, err)
+		}
+		event.ID = snap.Ref.ID
+		data.Audit = append(data.Audit, event)
+	}
+
+	if data.Residents, err = r.FindResidents(ctx, digits); err != nil {
+		return nil, err
+	}
+	changes, err := r.client.Collection(residentChangesCollection).Where("cpf", "==", digits).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("erro buscando mudanças do diretório: %w", err)
+	}
+	for _, snap := range changes {
+		var change domain.ResidentChange
+		if err := snap.DataTo(&change); err != nil {
+			return nil, fmt.Errorf("erro decodificando mudança %s: %w", snap.Ref.ID, err)
+		}
+		change.ID = snap.Ref.ID
+		data.DirectoryChanges = append(data.DirectoryChanges, change)
+	}
+	sessions, err := r.client.Collection(sessionsCollection).Where("cpf", "==", digits).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("erro buscando sessões: %w", err)
+	}
+	for _, snap := range sessions {
+		var session domain.Session
+		if err := snap.DataTo(&session); err != nil {
+			return nil, fmt.Errorf("erro decodificando sessão: %w", err)
+		}
+		session.ID = snap.Ref.ID
+		data.Sessions = append(data.Sessions, session)
+	}
+
+	keys, err := r.sealing.subjectKeys(ctx, cpf)
+	if err != nil {
+		return nil, err
+	}
+	events, err := r.client.Collection(webhookEventsCollection).Where("cpfs", "array-contains-any", keys).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("erro buscando eventos de webhook: %w", err)
+	}
+	for _, snap := range events {
+		var event domain.WebhookEvent
+		if err := snap.DataTo(&event); err != nil {
+			return nil, fmt.Errorf("erro decodificando evento %s: %w", snap.Ref.ID, err)
+		}
+		event.ID = snap.Ref.ID
+		data.WebhookEvents = append(data.WebhookEvents, event)
+	}
+	deliveries, err := r.client.Collection(deliveriesCollection).Where("cpfs", "array-contains-any", keys).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("erro buscando entregas de webhook: %w", err)
+	}
+	for _, snap := range deliveries {
+		var delivery domain.WebhookDelivery
+		if err := snap.DataTo(&delivery); err != nil {
+			return nil, fmt.Errorf("erro decodificando entrega %s: %w", snap.Ref.ID, err)
+		}
+		delivery.ID = snap.Ref.ID
+		data.WebhookDeliveries = append(data.WebhookDeliveries, delivery)
+	}
+	if err := r.sealing.openWebhookEvents(ctx, data.WebhookEvents); err != nil {
+		return nil, err
+	}
+	return data, r.sealing.openDeliveries(ctx, data.WebhookDeliveries)
+}
+
+// EraseSubject apaga e anonimiza os documentos do CPF em batches. Uma falha
+// no meio deixa parte dos dados para trás; repetir o pedido completa a
+// eliminação (o pseudônimo é sempre o mesmo para o CPF).
+func (r *FirestoreRepository) EraseSubject(ctx context.Context, cpf, pseudonym string) (*domain.SubjectErasure, error) {
+	digits := onlyDigits(cpf)
+	erasure := &domain.SubjectErasure{Pseudonym: pseudonym}
+
+	batch := r.newBatchWriter()
+	write := func(fn func(b *firestore.WriteBatch)) error { return batch.add(ctx, fn) }
+
+	leads, err := r.subjectLeads(ctx, cpf)
+	if err != nil {
+		return nil, err
+	}
+	var leadIDs []string
+	for _, snap := range leads {
+		var lead domain.Lead
+		if err := snap.DataTo(&lead); err != nil {
+			return nil, fmt.Errorf("erro decodificando lead %s: %w", snap.Ref.ID, err)
+		}
+		if err := r.sealing.open(ctx, &lead); err != nil {
+			return nil, err
+		}
+		leadIDs = append(leadIDs, subjectLeadIDs(snap.Ref.ID, lead)...)
+
+		attempts, err := snap.Ref.Collection(attemptsCollection).Documents(ctx).GetAll()
+		if err != nil {
+			return nil, fmt.Errorf("erro listando tentativas de %s: %w", snap.Ref.ID, err)
+		}
+		for _, attempt := range attempts {
+			if err := write(func(b *firestore.WriteBatch) { b.Delete(attempt.Ref) }); err != nil {
+				return nil, fmt.Errorf("erro apagando tentativas de %s: %w", snap.Ref.ID, err)
+			}
+			erasure.AttemptsDeleted++
+		}
+
+		anonymized := domain.AnonymizeLead(lead, pseudonym)
+		doc := r.client.Collection(r.collectionName).Doc(domain.LeadID(anonymized.CondoID, pseudonym))
+		if err := write(func(b *firestore.WriteBatch) { b.Set(doc, anonymized) }); err != nil {
+			return nil, fmt.Errorf("erro anonimizando lead %s: %w", snap.Ref.ID, err)
+		}
+		if err := write(func(b *firestore.WriteBatch) { b.Delete(snap.Ref) }); err != nil {
+			return nil, fmt.Errorf("erro apagando lead %s: %w", snap.Ref.ID, err)
+		}
+		erasure.LeadsAnonymized++
+	}
+
+	audit, err := r.subjectAudit(ctx, cpf, leadIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, snap := range audit {
+		var event domain.AuditEvent
+		if err := snap.DataTo(&event); err != nil {
+			return nil, fmt.Errorf("erro decodificando auditoria %s: %w", snap.Ref.ID, err)
+		}
+		event = domain.AnonymizeAuditEvent(event, pseudonym)
+		ref := snap.Ref
+		if err := write(func(b *firestore.WriteBatch) { b.Set(ref, event) }); err != nil {
+			return nil, fmt.Errorf("erro anonimizando auditoria: %w", err)
+		}
+		erasure.AuditAnonymized++
+	}
+
+	keys, err := r.sealing.subjectKeys(ctx, cpf)
+	if err != nil {
+		return nil, err
+	}
+	for _, target := range []struct {
+		collection string
+		query      firestore.Query
+		count      *int
+	}{
+		{residentsCollection, r.client.Collection(residentsCollection).Where("cpf", "==", digits), &erasure.ResidentsDeleted},
+		{residentChangesCollection, r.client.Collection(residentChangesCollection).Where("cpf", "==", digits), &erasure.ChangesDeleted},
+		{sessionsCollection, r.client.Collection(sessionsCollection).Where("cpf", "==", digits), &erasure.SessionsDeleted},
+		{webhookEventsCollection, r.client.Collection(webhookEventsCollection).Where("cpfs", "array-contains-any", keys), &erasure.WebhookEventsDeleted},
+		{deliveriesCollection, r.client.Collection(deliveriesCollection).Where("cpfs", "array-contains-any", keys), &erasure.DeliveriesDeleted},
+	} {
+		docs, err := target.query.Documents(ctx).GetAll()
+		if err != nil {
+			return nil, fmt.Errorf("erro buscando %s: %w", target.collection, err)
+		}
+		for _, snap := range docs {
+			ref := snap.Ref
+			if err := write(func(b *firestore.WriteBatch) { b.Delete(ref) }); err != nil {
+				return nil, fmt.Errorf("erro apagando %s: %w", target.collection, err)
+			}
+			*target.count++
+		}
+	}
+
+	if err := batch.flush(ctx); err != nil {
+		return nil, fmt.Errorf("erro eliminando dados do titular: %w", err)
+	}
+	return erasure, nil
+}
+
+// StaleLeads consulta os leads de uma regra de retenção. Cada combinação de
+// status, cenário e origem usada nas regras precisa de índice composto com
+// updated_at.
+func (r *FirestoreRepository) StaleLeads(ctx context.Context, criteria domain.RetentionCriteria, after, before time.Time, limit int) ([]domain.Lead, error) {
+	q := r.client.Collection(r.collectionName).Where("updated_at", "<", before)
+	if !after.IsZero() {
+		q = q.Where("updated_at", ">", after)
+	}
+	if criteria.Status != "" {
+		q = q.Where("status", "==", criteria.Status)
+	}
+	if criteria.Scenario != "" {
+		q = q.Where("scenario", "==", criteria.Scenario)
+	}
+	if criteria.Origin != "" {
+		q = q.Where("origin", "==", criteria.Origin)
+	}
+	snaps, err := q.OrderBy("updated_at", firestore.Asc).Limit(limit).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("erro buscando leads para retenção: %w", err)
+	}
+	leads := make([]domain.Lead, 0, len(snaps))
+	for _, snap := range snaps {
+		var lead domain.Lead
+		if err := snap.DataTo(&lead); err != nil {
+			return nil, fmt.Errorf("erro decodificando lead %s: %w", snap.Ref.ID, err)
+		}
+		leads = append(leads, lead)
+	}
+	return leads, r.sealing.openLeads(ctx, leads)
+}
+
+// PurgeLead apaga o lead e as tentativas (e grava o anonimizado) em batches
+func (r *FirestoreRepository) PurgeLead(ctx context.Context, lead domain.Lead, pseudonym string) (int, error) {
+	doc := r.client.Collection(r.collectionName).Doc(storedID(lead))
+	attempts, err := doc.Collection(attemptsCollection).Documents(ctx).GetAll()
+	if err != nil {
+		return 0, fmt.Errorf("erro listando tentativas de %s: %w", doc.ID, err)
+	}
+
+	batch := r.newBatchWriter()
+	for _, attempt := range attempts {
+		if err := batch.add(ctx, func(b *firestore.WriteBatch) { b.Delete(attempt.Ref) }); err != nil {
+			return 0, fmt.Errorf("erro apagando tentativas de %s: %w", doc.ID, err)
+		}
+	}
+	if pseudonym != "" {
+		anonymized := r.client.Collection(r.collectionName).Doc(domain.LeadID(lead.CondoID, pseudonym))
+		if err := batch.add(ctx, func(b *firestore.WriteBatch) { b.Set(anonymized, domain.AnonymizeLead(lead, pseudonym)) }); err != nil {
+			return 0, fmt.Errorf("erro anonimizando lead %s: %w", doc.ID, err)
+		}
+	}
+	if err := batch.add(ctx, func(b *firestore.WriteBatch) { b.Delete(doc) }); err != nil {
+		return 0, fmt.Errorf("erro apagando lead %s: %w", doc.ID, err)
+	}
+	if err := batch.flush(ctx); err != nil {
+		return 0, fmt.Errorf("erro expurgando lead %s: %w", doc.ID, err)
+	}
+	return len(attempts), nil
+}
+
+// PurgeWebhooks apaga as notificações e entregas mais antigas que before,
+// as mais antigas primeiro
+func (r *FirestoreRepository) PurgeWebhooks(ctx context.Context, before time.Time, limit int, dryRun bool) (int, int, error) {
+	batch := r.newBatchWriter()
+	counts := [2]int{}
+	for i, target := range []struct {
+		collection string
+		field      string
+	}{
+		{webhookEventsCollection, "received_at"},
+		{deliveriesCollection, "created_at"},
+	} {
+		remaining := limit - counts[0] - counts[1]
+		if remaining <= 0 {
+			break
+		}
+		docs, err := r.client.Collection(target.collection).Where(target.field, "<", before).
+			OrderBy(target.field, firestore.Asc).Limit(remaining).Documents(ctx).GetAll()
+		if err != nil {
+			return counts[0], counts[1], fmt.Errorf("erro buscando %s para retenção: %w", target.collection, err)
+		}
+		for _, snap := range docs {
+			ref := snap.Ref
+			if !dryRun {
+				if err := batch.add(ctx, func(b *firestore.WriteBatch) { b.Delete(ref) }); err != nil {
+					return counts[0], counts[1], fmt.Errorf("erro apagando %s: %w", target.collection, err)
+				}
+			}
+			counts[i]++
+		}
+	}
+	if err := batch.flush(ctx); err != nil {
+		return 0, 0, fmt.Errorf("erro expurgando webhooks: %w", err)
+	}
+	return counts[0], counts[1], nil
+}
+
+func (r *FirestoreRepository) SaveRetentionReport(ctx context.Context, report domain.RetentionReport) error {
+	if _, _, err := r.client.Collection(retentionReportsCollection).Add(ctx, report); err != nil {
+		return fmt.Errorf("erro gravando relatório de retenção: %w", err)
+	}
+	return nil
+}
+
+func (r *FirestoreRepository) ListRetentionReports(ctx context.Context, limit int) ([]domain.RetentionReport, error) {
+	snaps, err := r.client.Collection(retentionReportsCollection).
+		OrderBy("started_at", firestore.Desc).Limit(searchLimit(limit)).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("erro listando relatórios de retenção: %w", err)
+	}
+	reports := make([]domain.RetentionReport, 0, len(snaps))
+	for _, snap := range snaps {
+		var report domain.RetentionReport
+		if err := snap.DataTo(&report); err != nil {
+			return nil, fmt.Errorf("erro decodificando relatório %s: %w", snap.Ref.ID, err)
+		}
+		report.ID = snap.Ref.ID
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// batchWriter agrupa escritas em batches de até maxBatchWrites. Cada batch
+// é atômico; uma sequência longa não é.
+type batchWriter struct {
+	client *firestore.Client
+	batch  *firestore.WriteBatch
+	writes int
+}
+
+func (r *FirestoreRepository) newBatchWriter() *batchWriter {
+	return &batchWriter{client: r.client, batch: r.client.Batch()}
+}
+
+func (w *batchWriter) add(ctx context.Context, fn func(b *firestore.WriteBatch)) error {
+	fn(w.batch)
+	if w.writes++; w.writes < maxBatchWrites {
+		return nil
+	}
+	return w.flush(ctx)
+}
+
+func (w *batchWriter) flush(ctx context.Context) error {
+	if w.writes == 0 {
+		return nil
+	}
+	_, err := w.batch.Commit(ctx)
+	w.batch, w.writes = w.client.Batch(), 0
+	return err
+}
+
+// subjectLeads busca os leads do CPF (gravado com ou sem máscara, ou pelo
+// hash quando cifrado)
+func (r *FirestoreRepository) subjectLeads(ctx context.Context, cpf string) ([]*firestore.DocumentSnapshot, error) {
+	var snaps []*firestore.DocumentSnapshot
+	if r.sealing.plaintextLookup(r.legacyLookup) {
+		var err error
+		snaps, err = r.client.Collection(r.collectionName).Where("cpf", "in", cpfVariants(cpf)).Documents(ctx).GetAll()
+		if err != nil {
+			return nil, fmt.Errorf("erro buscando leads do titular: %w", err)
+		}
+	}
+	hash, err := r.sealing.cpfHash(ctx, cpf)
+	if err != nil || hash == "" {
+		return snaps, err
+	}
+	sealed, err := r.client.Collection(r.collectionName).Where("cpf_hash", "==", hash).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("erro buscando leads do titular: %w", err)
+	}
+	return append(snaps, sealed...), nil
+}
+
+// subjectLeadIDs IDs do lead na auditoria: o do documento e o condo_cpf
+// usado pela API do suporte
+func subjectLeadIDs(docID string, lead domain.Lead) []string {
+	if id := domain.LeadID(lead.CondoID, lead.CPF); id != docID {
+		return []string{docID, id}
+	}
+	return []string{docID}
+}
+
+// getLeadTx lê o lead na transação; nil quando não existe
+func getLeadTx(tx *firestore.Transaction, doc *firestore.DocumentRef) (*domain.Lead, error) {
+	snap, err := tx.Get(doc)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var lead domain.Lead
+	if err := snap.DataTo(&lead); err != nil {
+		return nil, fmt.Errorf("erro decodificando lead %s: %w", doc.ID, err)
+	}
+	return &lead, nil
+}
+
+// subjectAudit busca os eventos com o CPF ou com um dos leads dele (ações
+// que falharam antes de carregar o lead não têm o CPF)
+func (r *FirestoreRepository) subjectAudit(ctx context.Context, cpf string, leadIDs []string) ([]*firestore.DocumentSnapshot, error) {
+	snaps, err := r.client.Collection(auditCollection).Where("cpf", "in", cpfVariants(cpf)).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("erro buscando auditoria do titular: %w", err)
+	}
+	seen := map[string]bool{}
+	for _, snap := range snaps {
+		seen[snap.Ref.ID] = true
+	}
+	// "in" aceita até 30 valores por consulta
+	for start := 0; start < len(leadIDs); start += 30 {
+		end := start + 30
+		if end > len(leadIDs) {
+			end = len(leadIDs)
+		}
+		more, err := r.client.Collection(auditCollection).Where("lead_id", "in", leadIDs[start:end]).Documents(ctx).GetAll()
+		if err != nil {
+			return nil, fmt.Errorf("erro buscando auditoria dos leads do titular: %w", err)
+		}
+		for _, snap := range more {
+			if !seen[snap.Ref.ID] {
+				seen[snap.Ref.ID] = true
+				snaps = append(snaps, snap)
+			}
+		}
+	}
+	return snaps, nil
+}
+
+// RotateLeadKeys recifra com a KEK primária os leads e as tentativas com
+// KEKs antigas e migra os leads ainda em claro. Percorre a coleção inteira
+// e depois as tentativas (collection group); com o limite atingido a
+// próxima execução continua o trabalho.
+func (r *FirestoreRepository) RotateLeadKeys(ctx context.Context, limit int) (*domain.KeyRotation, error) {
+	if !r.sealing.enabled() {
+		return nil, errEncryptionDisabled
+	}
+	rotation := &domain.KeyRotation{}
+
+	leads := r.client.Collection(r.collectionName).Documents(ctx)
+	defer leads.Stop()
+	for {
+		snap, err := leads.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return rotation, fmt.Errorf("erro percorrendo leads: %w", err)
+		}
+		var lead domain.Lead
+		if err := snap.DataTo(&lead); err != nil {
+			return rotation, fmt.Errorf("erro decodificando lead %s: %w", snap.Ref.ID, err)
+		}
+		rotation.Scanned++
+		current, err := r.sealing.sealer.Current(ctx, lead)
+		if err != nil {
+			return rotation, err
+		}
+		if current {
+			continue
+		}
+		if rotationWrites(rotation) >= limit {
+			return rotation, nil
+		}
+		legacy := lead.PII == nil
+		if err := r.sealing.open(ctx, &lead); err != nil {
+			return rotation, err
+		}
+		moved, err := r.saveLead(ctx, lead, false)
+		if err != nil {
+			return rotation, fmt.Errorf("erro recifrando lead %s: %w", snap.Ref.ID, err)
+		}
+		rotation.AttemptsResealed += moved
+		if legacy {
+			rotation.Migrated++
+		} else {
+			rotation.Resealed++
+		}
+	}
+
+	batch := r.newBatchWriter()
+	attempts := r.client.CollectionGroup(attemptsCollection).Documents(ctx)
+	defer attempts.Stop()
+	for {
+		snap, err := attempts.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return rotation, fmt.Errorf("erro percorrendo tentativas: %w", err)
+		}
+		var attempt domain.LeadAttempt
+		if err := snap.DataTo(&attempt); err != nil {
+			return rotation, fmt.Errorf("erro decodificando tentativa %s: %w", snap.Ref.ID, err)
+		}
+		current, err := r.sealing.sealer.Current(ctx, attempt.Lead)
+		if err != nil {
+			return rotation, err
+		}
+		if current {
+			continue
+		}
+		if rotationWrites(rotation) >= limit {
+			return rotation, batch.flush(ctx)
+		}
+		if attempt.Lead, err = r.sealing.reseal(ctx, attempt.Lead); err != nil {
+			return rotation, err
+		}
+		ref := snap.Ref
+		if err := batch.add(ctx, func(b *firestore.WriteBatch) { b.Set(ref, attempt) }); err != nil {
+			return rotation, fmt.Errorf("erro recifrando tentativas: %w", err)
+		}
+		rotation.AttemptsResealed++
+	}
+	if err := batch.flush(ctx); err != nil {
+		return rotation, fmt.Errorf("erro recifrando tentativas: %w", err)
+	}
+	rotation.Complete = true
+	return rotation, nil
+}
+
+func (r *FirestoreRepository) Close() error {
+	return r.client.Close()
+}
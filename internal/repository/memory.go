@@ -0,0 +1,943 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/viplounge/platform/internal/domain"
+)
+
+// MemoryRepository guarda tudo em memória. Usado em dev local sem
+// credenciais do Firestore e nos testes; os dados somem ao reiniciar.
+type MemoryRepository struct {
+	mu       sync.RWMutex
+	leads    map[string]domain.Lead
+	attempts map[string][]domain.LeadAttempt
+	audit    []domain.AuditEvent
+	seq      int
+
+	residents map[string]domain.Resident
+	changes   []domain.ResidentChange
+	syncs     map[string]domain.DirectorySync
+
+	webhookEvents map[string]domain.WebhookEvent
+	deliveries    map[string]domain.WebhookDelivery
+
+	idempotency map[string]domain.IdempotencyRecord
+	ssoGrants   map[string]domain.SSOGrant
+	sessions    map[string]domain.Session
+
+	retentionReports []domain.RetentionReport
+
+	sealing      leadSealing
+	legacyLookup bool
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		leads:    make(map[string]domain.Lead),
+		attempts: make(map[string][]domain.LeadAttempt),
+
+		residents: make(map[string]domain.Resident),
+		syncs:     make(map[string]domain.DirectorySync),
+
+		webhookEvents: make(map[string]domain.WebhookEvent),
+		deliveries:    make(map[string]domain.WebhookDelivery),
+
+		idempotency: make(map[string]domain.IdempotencyRecord),
+		ssoGrants:   make(map[string]domain.SSOGrant),
+		sessions:    make(map[string]domain.Session),
+
+		legacyLookup: true,
+	}
+}
+
+func (r *MemoryRepository) nextID() string {
+	r.seq++
+	return fmt.Sprintf("%08d", r.seq)
+}
+
+// EnableEncryption passa a gravar os leads com os dados pessoais cifrados
+func (r *MemoryRepository) EnableEncryption(sealer domain.LeadSealer) {
+	r.sealing = newLeadSealing(sealer)
+}
+
+// SetLegacyLookup liga ou desliga a busca pelo CPF em claro com a cifragem
+// ligada
+func (r *MemoryRepository) SetLegacyLookup(enabled bool) {
+	r.legacyLookup = enabled
+}
+
+func (r *MemoryRepository) Save(ctx context.Context, lead domain.Lead) error {
+	sealed, id, err := r.sealing.seal(ctx, lead)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, err = r.store(ctx, lead, sealed, id, true)
+	return err
+}
+
+// store grava o lead já cifrado em id. O lead ainda em claro no ID antigo é
+// movido para id com as tentativas; sem record (recifragem) não há tentativa
+// nova e um documento mais recente em id é mantido. Chamado com mu travado.
+func (r *MemoryRepository) store(ctx context.Context, lead, sealed domain.Lead, id string, record bool) (int, error) {
+	stored, exists := r.leads[id]
+	moved := 0
+	if legacyID := domain.LeadID(lead.CondoID, lead.CPF); legacyID != id {
+		if legacy, ok := r.leads[legacyID]; ok {
+			attempts := make([]domain.LeadAttempt, 0, len(r.attempts[legacyID])+len(r.attempts[id]))
+			for _, attempt := range r.attempts[legacyID] {
+				resealed, err := r.sealing.reseal(ctx, attempt.Lead)
+				if err != nil {
+					return 0, err
+				}
+				attempt.LeadID, attempt.Lead = id, resealed
+				attempts = append(attempts, attempt)
+			}
+			moved = len(attempts)
+			r.attempts[id] = append(attempts, r.attempts[id]...)
+			delete(r.attempts, legacyID)
+			delete(r.leads, legacyID)
+			if !exists {
+				stored, exists = legacy, true
+			}
+		}
+	}
+	if exists && !record && stored.UpdatedAt.After(sealed.UpdatedAt) {
+		return moved, nil
+	}
+	if exists {
+		domain.MergeConsents(&sealed, stored.Consents)
+	}
+	r.leads[id] = sealed
+	if record {
+		r.attempts[id] = append(r.attempts[id], domain.LeadAttempt{
+			ID:         r.nextID(),
+			LeadID:     id,
+			Lead:       sealed,
+			RecordedAt: time.Now(),
+		})
+	}
+	return moved, nil
+}
+
+func (r *MemoryRepository) GetLead(ctx context.Context, id string) (*domain.Lead, error) {
+	hashed, err := r.sealing.hashedID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	lead, ok := r.leads[hashed]
+	if !ok {
+		lead, ok = r.leads[id]
+	}
+	r.mu.RUnlock()
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	if err := r.sealing.open(ctx, &lead); err != nil {
+		return nil, err
+	}
+	return &lead, nil
+}
+
+func (r *MemoryRepository) SearchLeads(ctx context.Context, filter domain.LeadFilter) ([]domain.Lead, error) {
+	hash, err := r.sealing.cpfHash(ctx, filter.CPF)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	digits, plaintext := onlyDigits(filter.CPF), r.sealing.plaintextLookup(r.legacyLookup)
+	var leads []domain.Lead
+	for _, lead := range r.leads {
+		if filter.CPF != "" && !matchesCPF(lead, digits, hash, plaintext) {
+			continue
+		}
+		if filter.TenantID != "" && lead.CondoID != filter.TenantID {
+			continue
+		}
+		if filter.Status != "" && lead.Status != filter.Status {
+			continue
+		}
+		if !inRange(lead.UpdatedAt, filter.From, filter.To) {
+			continue
+		}
+		leads = append(leads, lead)
+	}
+
+	sort.Slice(leads, func(i, j int) bool { return leads[i].UpdatedAt.After(leads[j].UpdatedAt) })
+	if limit := searchLimit(filter.Limit); len(leads) > limit {
+		leads = leads[:limit]
+	}
+	return leads, r.sealing.openLeads(ctx, leads)
+}
+
+func (r *MemoryRepository) ListAttempts(ctx context.Context, leadID string) ([]domain.LeadAttempt, error) {
+	hashed, err := r.sealing.hashedID(ctx, leadID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.attempts[hashed]; ok {
+		leadID = hashed
+	}
+	attempts := make([]domain.LeadAttempt, 0, len(r.attempts[leadID]))
+	for i := len(r.attempts[leadID]) - 1; i >= 0; i-- {
+		attempts = append(attempts, r.attempts[leadID][i])
+	}
+	return attempts, r.sealing.openAttempts(ctx, attempts)
+}
+
+func (r *MemoryRepository) SaveAudit(ctx context.Context, event domain.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if event.ID == "" {
+		event.ID = r.nextID()
+	}
+	r.audit = append(r.audit, event)
+	return nil
+}
+
+func (r *MemoryRepository) ListAudit(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var events []domain.AuditEvent
+	for i := len(r.audit) - 1; i >= 0; i-- {
+		event := r.audit[i]
+		if filter.Actor != "" && event.Actor != filter.Actor {
+			continue
+		}
+		if filter.TenantID != "" && event.TenantID != filter.TenantID {
+			continue
+		}
+		if filter.LeadID != "" && event.LeadID != filter.LeadID {
+			continue
+		}
+		if filter.CPF != "" && onlyDigits(event.CPF) != onlyDigits(filter.CPF) {
+			continue
+		}
+		if !inRange(event.CreatedAt, filter.From, filter.To) {
+			continue
+		}
+		events = append(events, event)
+		if len(events) == searchLimit(filter.Limit) {
+			break
+		}
+	}
+	return events, nil
+}
+
+func (r *MemoryRepository) FindResidents(ctx context.Context, cpf string) ([]domain.Resident, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var residents []domain.Resident
+	for _, resident := range r.residents {
+		if resident.CPF == onlyDigits(cpf) {
+			residents = append(residents, resident)
+		}
+	}
+	return residents, nil
+}
+
+func (r *MemoryRepository) ListResidents(ctx context.Context, condoID string) ([]domain.Resident, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var residents []domain.Resident
+	for _, resident := range r.residents {
+		if resident.CondoID == condoID {
+			residents = append(residents, resident)
+		}
+	}
+	return residents, nil
+}
+
+func (r *MemoryRepository) ApplySync(ctx context.Context, state domain.DirectorySync, residents []domain.Resident, changes []domain.ResidentChange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, resident := range residents {
+		resident.ID = domain.LeadID(resident.CondoID, resident.CPF)
+		r.residents[resident.ID] = resident
+	}
+	for _, change := range changes {
+		change.ID = r.nextID()
+		r.changes = append(r.changes, change)
+	}
+	r.syncs[state.CondoID] = state
+	return nil
+}
+
+func (r *MemoryRepository) SaveSyncState(ctx context.Context, state domain.DirectorySync) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.syncs[state.CondoID] = state
+	return nil
+}
+
+func (r *MemoryRepository) GetSyncState(ctx context.Context, condoID string) (*domain.DirectorySync, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	state, ok := r.syncs[condoID]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &state, nil
+}
+
+func (r *MemoryRepository) ListChanges(ctx context.Context, filter domain.ResidentChangeFilter) ([]domain.ResidentChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var changes []domain.ResidentChange
+	for _, change := range r.changes {
+		if filter.CondoID != "" && change.CondoID != filter.CondoID {
+			continue
+		}
+		if filter.Type != "" && change.Type != filter.Type {
+			continue
+		}
+		if !filter.Since.IsZero() && !change.DetectedAt.After(filter.Since) {
+			continue
+		}
+		changes = append(changes, change)
+		if len(changes) == searchLimit(filter.Limit) {
+			break
+		}
+	}
+	return changes, nil
+}
+
+func (r *MemoryRepository) CreateWebhookEvent(ctx context.Context, event domain.WebhookEvent) error {
+	event, err := r.sealing.sealWebhookEvent(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.webhookEvents[event.ID]; ok {
+		return domain.ErrAlreadyExists
+	}
+	r.webhookEvents[event.ID] = event
+	return nil
+}
+
+func (r *MemoryRepository) UpdateWebhookEvent(ctx context.Context, event domain.WebhookEvent) error {
+	event, err := r.sealing.sealWebhookEvent(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.webhookEvents[event.ID] = event
+	return nil
+}
+
+func (r *MemoryRepository) GetWebhookEvent(ctx context.Context, id string) (*domain.WebhookEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	event, ok := r.webhookEvents[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	events := []domain.WebhookEvent{event}
+	return &events[0], r.sealing.openWebhookEvents(ctx, events)
+}
+
+func (r *MemoryRepository) ListWebhookEvents(ctx context.Context, filter domain.WebhookEventFilter) ([]domain.WebhookEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var events []domain.WebhookEvent
+	for _, event := range r.webhookEvents {
+		if filter.Source != "" && event.Source != filter.Source {
+			continue
+		}
+		if filter.Status != "" && event.Status != filter.Status {
+			continue
+		}
+		if !inRange(event.ReceivedAt, filter.From, filter.To) {
+			continue
+		}
+		events = append(events, event)
+	}
+
+	sort.Slice(events, func(i, j int) bool { return events[i].ReceivedAt.Before(events[j].ReceivedAt) })
+	if limit := searchLimit(filter.Limit); len(events) > limit {
+		events = events[:limit]
+	}
+	return events, r.sealing.openWebhookEvents(ctx, events)
+}
+
+func (r *MemoryRepository) SaveDelivery(ctx context.Context, delivery domain.WebhookDelivery) error {
+	delivery, err := r.sealing.sealDelivery(ctx, delivery)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.deliveries[delivery.ID] = delivery
+	return nil
+}
+
+func (r *MemoryRepository) GetDelivery(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	delivery, ok := r.deliveries[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	deliveries := []domain.WebhookDelivery{delivery}
+	return &deliveries[0], r.sealing.openDeliveries(ctx, deliveries)
+}
+
+func (r *MemoryRepository) ListDeliveries(ctx context.Context, filter domain.DeliveryFilter) ([]domain.WebhookDelivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var deliveries []domain.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if filter.TenantID != "" && delivery.TenantID != filter.TenantID {
+			continue
+		}
+		if filter.EventType != "" && delivery.EventType != filter.EventType {
+			continue
+		}
+		if filter.EndpointID != "" && delivery.EndpointID != filter.EndpointID {
+			continue
+		}
+		if filter.Status != "" && delivery.Status != filter.Status {
+			continue
+		}
+		if !filter.DueBefore.IsZero() && delivery.NextAttemptAt.After(filter.DueBefore) {
+			continue
+		}
+		if !inRange(delivery.CreatedAt, filter.From, filter.To) {
+			continue
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if filter.DueBefore.IsZero() {
+		sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt) })
+	} else {
+		sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].NextAttemptAt.Before(deliveries[j].NextAttemptAt) })
+	}
+	if limit := searchLimit(filter.Limit); len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, r.sealing.openDeliveries(ctx, deliveries)
+}
+
+func (r *MemoryRepository) CreateIdempotencyRecord(ctx context.Context, record domain.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.idempotency[record.ID]; ok {
+		return domain.ErrAlreadyExists
+	}
+	r.idempotency[record.ID] = record
+	return nil
+}
+
+func (r *MemoryRepository) ReplaceExpiredIdempotencyRecord(ctx context.Context, record domain.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.idempotency[record.ID]; ok && !existing.Expired(record.CreatedAt) {
+		return domain.ErrAlreadyExists
+	}
+	r.idempotency[record.ID] = record
+	return nil
+}
+
+func (r *MemoryRepository) SaveIdempotencyRecord(ctx context.Context, record domain.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.idempotency[record.ID] = record
+	return nil
+}
+
+func (r *MemoryRepository) GetIdempotencyRecord(ctx context.Context, id string) (*domain.IdempotencyRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	record, ok := r.idempotency[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &record, nil
+}
+
+func (r *MemoryRepository) DeleteIdempotencyRecord(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.idempotency, id)
+	return nil
+}
+
+func (r *MemoryRepository) CreateSSOGrant(ctx context.Context, grant domain.SSOGrant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Sem TTL em memória: aproveita para descartar os expirados
+	now := time.Now()
+	for id, g := range r.ssoGrants {
+		if now.After(g.ExpiresAt) {
+			delete(r.ssoGrants, id)
+		}
+	}
+	r.ssoGrants[grant.ID] = grant
+	return nil
+}
+
+func (r *MemoryRepository) ConsumeSSOGrant(ctx context.Context, id string) (*domain.SSOGrant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	grant, ok := r.ssoGrants[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	delete(r.ssoGrants, id)
+	return &grant, nil
+}
+
+func (r *MemoryRepository) CreateSession(ctx context.Context, session domain.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.sessions[session.ID]; ok {
+		return domain.ErrAlreadyExists
+	}
+	r.sessions[session.ID] = session
+	return nil
+}
+
+func (r *MemoryRepository) GetSession(ctx context.Context, id string) (*domain.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	session.Clubs = append([]domain.SessionClub(nil), session.Clubs...)
+	return &session, nil
+}
+
+func (r *MemoryRepository) SaveSession(ctx context.Context, session domain.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sessions[session.ID] = session
+	return nil
+}
+
+func (r *MemoryRepository) DeleteSession(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.sessions, id)
+	return nil
+}
+
+func (r *MemoryRepository) ExportSubject(ctx context.Context, cpf string) (*domain.SubjectData, error) {
+	hash, err := r.sealing.cpfHash(ctx, cpf)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := r.sealing.subjectKeys(ctx, cpf)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	digits, plaintext := onlyDigits(cpf), r.sealing.plaintextLookup(r.legacyLookup)
+	data := &domain.SubjectData{CPF: digits, ExportedAt: time.Now()}
+	leadIDs := map[string]bool{}
+	for id, lead := range r.leads {
+		if !matchesCPF(lead, digits, hash, plaintext) {
+			continue
+		}
+		if err := r.sealing.open(ctx, &lead); err != nil {
+			return nil, err
+		}
+		attempts := append([]domain.LeadAttempt(nil), r.attempts[id]...)
+		if err := r.sealing.openAttempts(ctx, attempts); err != nil {
+			return nil, err
+		}
+		// A auditoria do suporte registra o lead pelo ID condo_cpf
+		leadIDs[id] = true
+		leadIDs[domain.LeadID(lead.CondoID, lead.CPF)] = true
+		data.Leads = append(data.Leads, lead)
+		data.Attempts = append(data.Attempts, attempts...)
+	}
+	for _, event := range r.audit {
+		if onlyDigits(event.CPF) == digits || leadIDs[event.LeadID] {
+			data.Audit = append(data.Audit, event)
+		}
+	}
+	for _, resident := range r.residents {
+		if resident.CPF == digits {
+			data.Residents = append(data.Residents, resident)
+		}
+	}
+	for _, change := range r.changes {
+		if change.CPF == digits {
+			data.DirectoryChanges = append(data.DirectoryChanges, change)
+		}
+	}
+	for _, session := range r.sessions {
+		if session.CPF == digits {
+			data.Sessions = append(data.Sessions, session)
+		}
+	}
+	for _, event := range r.webhookEvents {
+		if containsAny(event.CPFs, keys) {
+			data.WebhookEvents = append(data.WebhookEvents, event)
+		}
+	}
+	for _, delivery := range r.deliveries {
+		if containsAny(delivery.CPFs, keys) {
+			data.WebhookDeliveries = append(data.WebhookDeliveries, delivery)
+		}
+	}
+	if err := r.sealing.openWebhookEvents(ctx, data.WebhookEvents); err != nil {
+		return nil, err
+	}
+	return data, r.sealing.openDeliveries(ctx, data.WebhookDeliveries)
+}
+
+func (r *MemoryRepository) EraseSubject(ctx context.Context, cpf, pseudonym string) (*domain.SubjectErasure, error) {
+	hash, err := r.sealing.cpfHash(ctx, cpf)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := r.sealing.subjectKeys(ctx, cpf)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	digits, plaintext := onlyDigits(cpf), r.sealing.plaintextLookup(r.legacyLookup)
+	erasure := &domain.SubjectErasure{Pseudonym: pseudonym}
+	leadIDs := map[string]bool{}
+	for id, lead := range r.leads {
+		if !matchesCPF(lead, digits, hash, plaintext) {
+			continue
+		}
+		if err := r.sealing.open(ctx, &lead); err != nil {
+			return nil, err
+		}
+		leadIDs[id] = true
+		leadIDs[domain.LeadID(lead.CondoID, lead.CPF)] = true
+		erasure.AttemptsDeleted += len(r.attempts[id])
+		delete(r.attempts, id)
+		delete(r.leads, id)
+		lead = domain.AnonymizeLead(lead, pseudonym)
+		r.leads[domain.LeadID(lead.CondoID, pseudonym)] = lead
+		erasure.LeadsAnonymized++
+	}
+	for i, event := range r.audit {
+		if onlyDigits(event.CPF) == digits || leadIDs[event.LeadID] {
+			r.audit[i] = domain.AnonymizeAuditEvent(event, pseudonym)
+			erasure.AuditAnonymized++
+		}
+	}
+	for id, resident := range r.residents {
+		if resident.CPF == digits {
+			delete(r.residents, id)
+			erasure.ResidentsDeleted++
+		}
+	}
+	changes := r.changes[:0]
+	for _, change := range r.changes {
+		if change.CPF == digits {
+			erasure.ChangesDeleted++
+			continue
+		}
+		changes = append(changes, change)
+	}
+	r.changes = changes
+	for id, session := range r.sessions {
+		if session.CPF == digits {
+			delete(r.sessions, id)
+			erasure.SessionsDeleted++
+		}
+	}
+	for id, event := range r.webhookEvents {
+		if containsAny(event.CPFs, keys) {
+			delete(r.webhookEvents, id)
+			erasure.WebhookEventsDeleted++
+		}
+	}
+	for id, delivery := range r.deliveries {
+		if containsAny(delivery.CPFs, keys) {
+			delete(r.deliveries, id)
+			erasure.DeliveriesDeleted++
+		}
+	}
+	return erasure, nil
+}
+
+func (r *MemoryRepository) StaleLeads(ctx context.Context, criteria domain.RetentionCriteria, after, before time.Time, limit int) ([]domain.Lead, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var leads []domain.Lead
+	for _, lead := range r.leads {
+		if criteria.Matches(lead) && lead.UpdatedAt.After(after) && lead.UpdatedAt.Before(before) {
+			leads = append(leads, lead)
+		}
+	}
+	sort.Slice(leads, func(i, j int) bool { return leads[i].UpdatedAt.Before(leads[j].UpdatedAt) })
+	if len(leads) > limit {
+		leads = leads[:limit]
+	}
+	return leads, r.sealing.openLeads(ctx, leads)
+}
+
+func (r *MemoryRepository) PurgeLead(ctx context.Context, lead domain.Lead, pseudonym string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := storedID(lead)
+	attempts := len(r.attempts[id])
+	delete(r.attempts, id)
+	delete(r.leads, id)
+	if pseudonym != "" {
+		r.leads[domain.LeadID(lead.CondoID, pseudonym)] = domain.AnonymizeLead(lead, pseudonym)
+	}
+	return attempts, nil
+}
+
+func (r *MemoryRepository) PurgeWebhooks(ctx context.Context, before time.Time, limit int, dryRun bool) (int, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events, deliveries := 0, 0
+	for id, event := range r.webhookEvents {
+		if events < limit && event.ReceivedAt.Before(before) {
+			if !dryRun {
+				delete(r.webhookEvents, id)
+			}
+			events++
+		}
+	}
+	for id, delivery := range r.deliveries {
+		if events+deliveries < limit && delivery.CreatedAt.Before(before) {
+			if !dryRun {
+				delete(r.deliveries, id)
+			}
+			deliveries++
+		}
+	}
+	return events, deliveries, nil
+}
+
+func (r *MemoryRepository) SaveRetentionReport(ctx context.Context, report domain.RetentionReport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report.ID = r.nextID()
+	r.retentionReports = append(r.retentionReports, report)
+	return nil
+}
+
+func (r *MemoryRepository) ListRetentionReports(ctx context.Context, limit int) ([]domain.RetentionReport, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var reports []domain.RetentionReport
+	for i := len(r.retentionReports) - 1; i >= 0 && len(reports) < searchLimit(limit); i-- {
+		reports = append(reports, r.retentionReports[i])
+	}
+	return reports, nil
+}
+
+func (r *MemoryRepository) ConsentingLeads(ctx context.Context, purpose, tenantID string) ([]domain.Lead, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var leads []domain.Lead
+	for _, lead := range r.leads {
+		if !lead.Consented(purpose) || (tenantID != "" && lead.CondoID != tenantID) {
+			continue
+		}
+		leads = append(leads, lead)
+	}
+	sort.Slice(leads, func(i, j int) bool { return leads[i].UpdatedAt.After(leads[j].UpdatedAt) })
+	return leads, r.sealing.openLeads(ctx, leads)
+}
+
+// RotateLeadKeys recifra com a KEK primária os leads e as tentativas que
+// estão com KEKs antigas e migra os leads ainda em claro
+func (r *MemoryRepository) RotateLeadKeys(ctx context.Context, limit int) (*domain.KeyRotation, error) {
+	if !r.sealing.enabled() {
+		return nil, errEncryptionDisabled
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rotation := &domain.KeyRotation{}
+	for _, id := range sortedKeys(r.leads) {
+		lead, ok := r.leads[id]
+		if !ok {
+			continue // movido junto com um lead migrado antes
+		}
+		rotation.Scanned++
+		current, err := r.sealing.sealer.Current(ctx, lead)
+		if err != nil {
+			return rotation, err
+		}
+		if current {
+			continue
+		}
+		if rotationWrites(rotation) >= limit {
+			return rotation, nil
+		}
+		if err := r.sealing.open(ctx, &lead); err != nil {
+			return rotation, err
+		}
+		sealed, target, err := r.sealing.seal(ctx, lead)
+		if err != nil {
+			return rotation, err
+		}
+		moved, err := r.store(ctx, lead, sealed, target, false)
+		if err != nil {
+			return rotation, err
+		}
+		rotation.AttemptsResealed += moved
+		if target != id {
+			rotation.Migrated++
+		} else {
+			rotation.Resealed++
+		}
+	}
+
+	for _, id := range sortedKeys(r.attempts) {
+		for i, attempt := range r.attempts[id] {
+			current, err := r.sealing.sealer.Current(ctx, attempt.Lead)
+			if err != nil {
+				return rotation, err
+			}
+			if current {
+				continue
+			}
+			if rotationWrites(rotation) >= limit {
+				return rotation, nil
+			}
+			if r.attempts[id][i].Lead, err = r.sealing.reseal(ctx, attempt.Lead); err != nil {
+				return rotation, err
+			}
+			rotation.AttemptsResealed++
+		}
+	}
+	rotation.Complete = true
+	return rotation, nil
+}
+
+func (r *MemoryRepository) Close() error {
+	return nil
+}
+
+// matchesCPF lead do CPF (só dígitos): pelo hash quando cifrado, pelo CPF
+// quando ainda em claro e plaintext (ver leadSealing.plaintextLookup)
+func matchesCPF(lead domain.Lead, digits, hash string, plaintext bool) bool {
+	if lead.CPFHash != "" {
+		return lead.CPFHash == hash
+	}
+	return plaintext && onlyDigits(lead.CPF) == digits
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var nonDigitRegex = regexp.MustCompile(`\D`)
+
+func onlyDigits(s string) string {
+	return nonDigitRegex.ReplaceAllString(s, "")
+}
+
+// cpfVariants retorna o CPF só com dígitos e com máscara, pois o lead guarda
+// o CPF no formato em que foi digitado
+func cpfVariants(cpf string) []string {
+	digits := onlyDigits(cpf)
+	if len(digits) != 11 {
+		return []string{cpf}
+	}
+	masked := fmt.Sprintf("%s.%s.%s-%s", digits[:3], digits[3:6], digits[6:9], digits[9:])
+	return []string{digits, masked}
+}
+
+func searchLimit(limit int) int {
+	switch {
+	case limit <= 0:
+		return defaultSearchLimit
+	case limit > maxSearchLimit:
+		return maxSearchLimit
+	}
+	return limit
+}
+
+func inRange(t, from, to time.Time) bool {
+	if !from.IsZero() && t.Before(from) {
+		return false
+	}
+	if !to.IsZero() && t.After(to) {
+		return false
+	}
+	return true
+}
+
+// containsAny indica se list tem algum dos valores
+func containsAny(list, values []string) bool {
+	for _, item := range list {
+		for _, v := range values {
+			if item == v {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/viplounge/platform/internal/domain"
+)
+
+// errEncryptionDisabled recifragem pedida a um repositório sem cifragem
+var errEncryptionDisabled = errors.New("cifragem dos leads desabilitada")
+
+// hashedCPFPrefix marca os CPFs já trocados pelo hash nos webhooks gravados,
+// para regravar o documento sem calcular o hash do hash
+const hashedCPFPrefix = "h:"
+
+// leadSealing cifragem dos leads comum aos repositórios. Sem sealer os
+// leads são gravados em claro, no ID condo_cpf; com sealer, no ID
+// condo_<hash do CPF>, com os dados pessoais cifrados. Leads gravados antes
+// de ligar a cifragem continuam legíveis no ID antigo até serem regravados
+// ou migrados pela recifragem.
+//
+// Os payloads dos webhooks (recebidos e enviados) seguem a mesma cifragem,
+// autenticados com o ID do documento, e os CPFs que os indexam viram hash.
+type leadSealing struct {
+	sealer domain.LeadSealer
+	data   domain.DataSealer
+}
+
+func newLeadSealing(sealer domain.LeadSealer) leadSealing {
+	data, _ := sealer.(domain.DataSealer)
+	return leadSealing{sealer: sealer, data: data}
+}
+
+func (s leadSealing) enabled() bool {
+	return s.sealer != nil
+}
+
+// plaintextLookup se a busca pelo CPF olha o campo em claro: sempre sem
+// cifragem e, com ela, só enquanto houver leads a migrar (legacy)
+func (s leadSealing) plaintextLookup(legacy bool) bool {
+	return !s.enabled() || legacy
+}
+
+// seal retorna o lead como é gravado e o ID do documento
+func (s leadSealing) seal(ctx context.Context, lead domain.Lead) (domain.Lead, string, error) {
+	if !s.enabled() {
+		return lead, domain.LeadID(lead.CondoID, lead.CPF), nil
+	}
+	sealed, err := s.sealer.Seal(ctx, lead)
+	if err != nil {
+		return lead, "", err
+	}
+	return sealed, storedID(sealed), nil
+}
+
+// open decifra os dados pessoais do lead lido
+func (s leadSealing) open(ctx context.Context, lead *domain.Lead) error {
+	if !s.enabled() {
+		return nil
+	}
+	return s.sealer.Open(ctx, lead)
+}
+
+func (s leadSealing) openLeads(ctx context.Context, leads []domain.Lead) error {
+	for i := range leads {
+		if err := s.open(ctx, &leads[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s leadSealing) openAttempts(ctx context.Context, attempts []domain.LeadAttempt) error {
+	for i := range attempts {
+		if err := s.open(ctx, &attempts[i].Lead); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reseal cifra de novo a fotografia do lead com a chave atual
+func (s leadSealing) reseal(ctx context.Context, lead domain.Lead) (domain.Lead, error) {
+	if err := s.sealer.Open(ctx, &lead); err != nil {
+		return lead, err
+	}
+	return s.sealer.Seal(ctx, lead)
+}
+
+// cpfHash hash do CPF nas buscas ("" sem cifragem)
+func (s leadSealing) cpfHash(ctx context.Context, cpf string) (string, error) {
+	if !s.enabled() {
+		return "", nil
+	}
+	return s.sealer.HashCPF(ctx, cpf)
+}
+
+// subjectKeys chaves que localizam o CPF nos webhooks gravados, com ou sem
+// a cifragem ligada na época
+func (s leadSealing) subjectKeys(ctx context.Context, cpf string) ([]string, error) {
+	keys := []string{onlyDigits(cpf)}
+	if s.enabled() {
+		hash, err := s.sealer.HashCPF(ctx, cpf)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, hashedCPFPrefix+hash)
+	}
+	return keys, nil
+}
+
+// cpfKeys CPFs como gravados nos webhooks: o hash com a cifragem ligada,
+// senão só os dígitos
+func (s leadSealing) cpfKeys(ctx context.Context, cpfs []string) ([]string, error) {
+	var keys []string
+	for _, cpf := range cpfs {
+		if strings.HasPrefix(cpf, hashedCPFPrefix) {
+			keys = append(keys, cpf)
+			continue
+		}
+		if onlyDigits(cpf) == "" {
+			continue
+		}
+		if !s.enabled() {
+			keys = append(keys, onlyDigits(cpf))
+			continue
+		}
+		hash, err := s.sealer.HashCPF(ctx, cpf)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, hashedCPFPrefix+hash)
+	}
+	return keys, nil
+}
+
+// sealPayload cifra o payload de um webhook gravado no documento id
+func (s leadSealing) sealPayload(ctx context.Context, id string, payload *string, sealed **domain.SealedPII, cpfs *[]string) error {
+	keys, err := s.cpfKeys(ctx, *cpfs)
+	if err != nil {
+		return err
+	}
+	*cpfs = keys
+	if s.data == nil || *payload == "" {
+		return nil
+	}
+	*sealed, err = s.data.SealData(ctx, []byte(*payload), []byte(id))
+	if err != nil {
+		return fmt.Errorf("erro cifrando payload de %s: %w", id, err)
+	}
+	*payload = ""
+	return nil
+}
+
+// openPayload decifra o payload de um webhook lido
+func (s leadSealing) openPayload(ctx context.Context, id string, payload *string, sealed **domain.SealedPII) error {
+	if *sealed == nil {
+		return nil
+	}
+	if s.data == nil {
+		return fmt.Errorf("payload de %s cifrado: %w", id, errEncryptionDisabled)
+	}
+	plaintext, err := s.data.OpenData(ctx, *sealed, []byte(id))
+	if err != nil {
+		return fmt.Errorf("erro decifrando payload de %s: %w", id, err)
+	}
+	*payload = string(plaintext)
+	*sealed = nil
+	return nil
+}
+
+func (s leadSealing) sealDelivery(ctx context.Context, delivery domain.WebhookDelivery) (domain.WebhookDelivery, error) {
+	err := s.sealPayload(ctx, delivery.ID, &delivery.Payload, &delivery.SealedPayload, &delivery.CPFs)
+	return delivery, err
+}
+
+func (s leadSealing) openDeliveries(ctx context.Context, deliveries []domain.WebhookDelivery) error {
+	for i := range deliveries {
+		d := &deliveries[i]
+		if err := s.openPayload(ctx, d.ID, &d.Payload, &d.SealedPayload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s leadSealing) sealWebhookEvent(ctx context.Context, event domain.WebhookEvent) (domain.WebhookEvent, error) {
+	err := s.sealPayload(ctx, event.ID, &event.Payload, &event.SealedPayload, &event.CPFs)
+	return event, err
+}
+
+func (s leadSealing) openWebhookEvents(ctx context.Context, events []domain.WebhookEvent) error {
+	for i := range events {
+		e := &events[i]
+		if err := s.openPayload(ctx, e.ID, &e.Payload, &e.SealedPayload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hashedID ID do documento cifrado correspondente a um ID condo_cpf, usado
+// pela API do suporte; "" quando o ID não traz um CPF em claro
+func (s leadSealing) hashedID(ctx context.Context, id string) (string, error) {
+	i := strings.LastIndex(id, "_")
+	if !s.enabled() || i < 0 || !isCPF(id[i+1:]) {
+		return "", nil
+	}
+	hash, err := s.sealer.HashCPF(ctx, id[i+1:])
+	if err != nil {
+		return "", err
+	}
+	return domain.LeadID(id[:i], hash), nil
+}
+
+// storedID ID do documento de um lead lido: o do hash do CPF quando
+// cifrado, senão o do CPF (em claro ou pseudônimo)
+func storedID(lead domain.Lead) string {
+	if lead.CPFHash != "" {
+		return domain.LeadID(lead.CondoID, lead.CPFHash)
+	}
+	return domain.LeadID(lead.CondoID, lead.CPF)
+}
+
+// isCPF CPF com ou sem máscara
+func isCPF(s string) bool {
+	return len(onlyDigits(s)) == 11 && strings.Trim(s, "0123456789.-") == ""
+}
+
+// rotationWrites documentos regravados pela recifragem, para o limite
+func rotationWrites(rotation *domain.KeyRotation) int {
+	return rotation.Resealed + rotation.Migrated + rotation.AttemptsResealed
+}
@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/pii"
+	"github.com/viplounge/platform/internal/secrets"
+)
+
+const (
+	cpfSubject = "529.982.247-25"
+	cpfOther   = "111.444.777-35"
+)
+
+// newSealedRepository repositório em memória com a cifragem dos leads ligada
+// (KMS local com uma KEK)
+func newSealedRepository(t *testing.T) (*MemoryRepository, *pii.Protector) {
+	t.Helper()
+	key := sha256.Sum256([]byte("kek-teste"))
+	data, _ := json.Marshal(map[string]interface{}{
+		"primary": "2026-01",
+		"keys":    map[string]string{"2026-01": base64.StdEncoding.EncodeToString(key[:])},
+	})
+	path := filepath.Join(t.TempDir(), "pii-keys.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("arquivo de chaves: %v", err)
+	}
+	kms, err := pii.NewLocalKeyFile(path)
+	if err != nil {
+		t.Fatalf("KMS local: %v", err)
+	}
+	protector := pii.NewProtector(kms, secrets.Static("chave-do-hash-do-cpf"), time.Hour)
+
+	repo := NewMemoryRepository()
+	repo.EnableEncryption(protector)
+	return repo, protector
+}
+
+func deliveryFor(id, cpf string, createdAt time.Time) domain.WebhookDelivery {
+	payload, _ := json.Marshal(domain.OutboundEvent{ID: id, Type: domain.EventLeadActivated, Data: map[string]interface{}{"cpf": cpf, "email": "morador@example.com"}})
+	return domain.WebhookDelivery{ID: id + "_crm", EventID: id, EndpointID: "crm", Payload: string(payload), CPFs: []string{cpf}, CreatedAt: createdAt}
+}
+
+func webhookEventFor(id, cpf, previous string, receivedAt time.Time) domain.WebhookEvent {
+	payload := `{"id":"` + id + `","data":{"cpf_proprietario":"` + cpf + `","cpf_proprietario_anterior":"` + previous + `"}}`
+	return domain.WebhookEvent{ID: "superlogica_" + id, Source: "superlogica", Payload: payload, CPFs: []string{cpf, previous}, ReceivedAt: receivedAt}
+}
+
+func TestWebhookPayloadsSealedAtRest(t *testing.T) {
+	repo, _ := newSealedRepository(t)
+	ctx := context.Background()
+
+	delivery := deliveryFor("evt1", cpfSubject, time.Now())
+	if err := repo.SaveDelivery(ctx, delivery); err != nil {
+		t.Fatalf("SaveDelivery: %v", err)
+	}
+	event := webhookEventFor("n1", "52998224725", "", time.Now())
+	if err := repo.CreateWebhookEvent(ctx, event); err != nil {
+		t.Fatalf("CreateWebhookEvent: %v", err)
+	}
+
+	raw := repo.deliveries[delivery.ID]
+	rawEvent := repo.webhookEvents[event.ID]
+	for name, stored := range map[string]struct {
+		payload string
+		sealed  *domain.SealedPII
+		cpfs    []string
+	}{
+		"entrega": {raw.Payload, raw.SealedPayload, raw.CPFs},
+		"evento":  {rawEvent.Payload, rawEvent.SealedPayload, rawEvent.CPFs},
+	} {
+		if stored.payload != "" || stored.sealed == nil {
+			t.Errorf("%s: payload gravado em claro", name)
+		}
+		// O CPF anterior vazio não indexa nada
+		if len(stored.cpfs) != 1 || !strings.HasPrefix(stored.cpfs[0], hashedCPFPrefix) {
+			t.Errorf("%s: CPFs gravados %v, esperado só o hash", name, stored.cpfs)
+		}
+	}
+
+	got, err := repo.GetDelivery(ctx, delivery.ID)
+	if err != nil || got.Payload != delivery.Payload || got.SealedPayload != nil {
+		t.Errorf("GetDelivery: %+v (%v)", got, err)
+	}
+	events, err := repo.ListWebhookEvents(ctx, domain.WebhookEventFilter{})
+	if err != nil || len(events) != 1 || events[0].Payload != event.Payload {
+		t.Errorf("ListWebhookEvents: %+v (%v)", events, err)
+	}
+
+	// Regravar o que foi lido não calcula o hash do hash
+	got.Status = domain.DeliveryStatusDelivered
+	if err := repo.SaveDelivery(ctx, *got); err != nil {
+		t.Fatalf("SaveDelivery: %v", err)
+	}
+	if cpfs := repo.deliveries[delivery.ID].CPFs; len(cpfs) != 1 || cpfs[0] != raw.CPFs[0] {
+		t.Errorf("CPFs regravados %v, esperado %v", cpfs, raw.CPFs)
+	}
+
+	// Outro documento com o mesmo payload cifrado não decifra
+	moved := repo.deliveries[delivery.ID]
+	moved.ID = "outro_crm"
+	repo.deliveries[moved.ID] = moved
+	if _, err := repo.GetDelivery(ctx, moved.ID); err == nil {
+		t.Errorf("payload decifrado em outro documento")
+	}
+}
+
+func TestEraseSubjectDeletesWebhooks(t *testing.T) {
+	repo, protector := newSealedRepository(t)
+	ctx := context.Background()
+
+	// Gravados antes de ligar a cifragem: indexados pelos dígitos
+	repo.EnableEncryption(nil)
+	if err := repo.SaveDelivery(ctx, deliveryFor("antigo", cpfSubject, time.Now())); err != nil {
+		t.Fatalf("SaveDelivery: %v", err)
+	}
+	repo.EnableEncryption(protector)
+
+	for _, delivery := range []domain.WebhookDelivery{
+		deliveryFor("evt1", cpfSubject, time.Now()),
+		deliveryFor("evt2", cpfOther, time.Now()),
+	} {
+		if err := repo.SaveDelivery(ctx, delivery); err != nil {
+			t.Fatalf("SaveDelivery: %v", err)
+		}
+	}
+	for _, event := range []domain.WebhookEvent{
+		webhookEventFor("n1", cpfOther, cpfSubject, time.Now()),
+		webhookEventFor("n2", cpfOther, "", time.Now()),
+	} {
+		if err := repo.CreateWebhookEvent(ctx, event); err != nil {
+			t.Fatalf("CreateWebhookEvent: %v", err)
+		}
+	}
+
+	data, err := repo.ExportSubject(ctx, cpfSubject)
+	if err != nil {
+		t.Fatalf("ExportSubject: %v", err)
+	}
+	if len(data.WebhookDeliveries) != 2 || len(data.WebhookEvents) != 1 {
+		t.Fatalf("export: %d entregas, %d notificações", len(data.WebhookDeliveries), len(data.WebhookEvents))
+	}
+	for _, delivery := range data.WebhookDeliveries {
+		if !strings.Contains(delivery.Payload, "morador@example.com") {
+			t.Errorf("export: payload da entrega %s não decifrado", delivery.ID)
+		}
+	}
+
+	erasure, err := repo.EraseSubject(ctx, cpfSubject, domain.Pseudonym("segredo", "52998224725"))
+	if err != nil {
+		t.Fatalf("EraseSubject: %v", err)
+	}
+	if erasure.DeliveriesDeleted != 2 || erasure.WebhookEventsDeleted != 1 {
+		t.Errorf("erase: %+v", erasure)
+	}
+	if _, ok := repo.deliveries["evt2_crm"]; !ok {
+		t.Errorf("entrega de outro titular apagada")
+	}
+	if _, ok := repo.webhookEvents["superlogica_n2"]; !ok {
+		t.Errorf("notificação de outro titular apagada")
+	}
+}
+
+func TestPurgeWebhooks(t *testing.T) {
+	repo, _ := newSealedRepository(t)
+	ctx := context.Background()
+	old := time.Now().AddDate(0, 0, -100)
+
+	for _, delivery := range []domain.WebhookDelivery{
+		deliveryFor("velho1", cpfSubject, old),
+		deliveryFor("velho2", cpfSubject, old),
+		deliveryFor("novo", cpfSubject, time.Now()),
+	} {
+		if err := repo.SaveDelivery(ctx, delivery); err != nil {
+			t.Fatalf("SaveDelivery: %v", err)
+		}
+	}
+	if err := repo.CreateWebhookEvent(ctx, webhookEventFor("velho", cpfOther, "", old)); err != nil {
+		t.Fatalf("CreateWebhookEvent: %v", err)
+	}
+	cutoff := time.Now().AddDate(0, 0, -90)
+
+	events, deliveries, err := repo.PurgeWebhooks(ctx, cutoff, 10, true)
+	if err != nil || events != 1 || deliveries != 2 || len(repo.deliveries) != 3 {
+		t.Fatalf("dry run: %d notificações, %d entregas, %d gravadas (%v)", events, deliveries, len(repo.deliveries), err)
+	}
+	// O limite vale para as duas coleções juntas
+	if events, deliveries, _ := repo.PurgeWebhooks(ctx, cutoff, 2, false); events+deliveries != 2 {
+		t.Errorf("limite 2: %d notificações, %d entregas", events, deliveries)
+	}
+	if _, _, err := repo.PurgeWebhooks(ctx, cutoff, 10, false); err != nil {
+		t.Fatalf("PurgeWebhooks: %v", err)
+	}
+	if len(repo.webhookEvents) != 0 || len(repo.deliveries) != 1 {
+		t.Errorf("após expurgo: %d notificações, %d entregas", len(repo.webhookEvents), len(repo.deliveries))
+	}
+	if _, ok := repo.deliveries["novo_crm"]; !ok {
+		t.Errorf("entrega dentro do prazo apagada")
+	}
+}
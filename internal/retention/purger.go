@@ -0,0 +1,250 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/viplounge/platform/internal/config"
+	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/secrets"
+)
+
+// TriggerSchedule execução periódica (o Trigger das execuções pela API é o
+// operador)
+const TriggerSchedule = "schedule"
+
+// pageSize leads lidos por consulta
+const pageSize = 200
+
+// Purger expurga os leads que passaram do prazo das regras de
+// privacy.retention e os webhooks mais antigos que webhook_days. As regras são relidas a cada execução (hot reload).
+// Cada regra continua de onde a última execução parou (Through do último
+// relatório), para não reler os leads que ela já pseudonimizou.
+type Purger struct {
+	repo domain.RetentionRepository
+	// secret gera o pseudônimo do CPF (privacy.secret_ref)
+	secret secrets.Secret
+	// mu uma execução por vez (job e API do suporte)
+	mu sync.Mutex
+}
+
+func NewPurger(repo domain.RetentionRepository, secret secrets.Secret) *Purger {
+	return &Purger{repo: repo, secret: secret}
+}
+
+// Run expurga a cada interval até stop ser fechado. A primeira execução
+// espera o intervalo, para um restart não disparar um expurgo.
+func (p *Purger) Run(ctx context.Context, stop <-chan struct{}, interval time.Duration) {
+	log.Printf("[RETENÇÃO] Expurgo de leads a cada %v", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case <-ticker.C:
+			if _, err := p.Purge(ctx, config.Get().Privacy.Retention, TriggerSchedule, false); err != nil {
+				log.Printf("[RETENÇÃO] Erro no expurgo: %v", err)
+			}
+		}
+	}
+}
+
+// Purge aplica as regras e grava o relatório. Com dryRun nada é alterado:
+// o relatório só conta o que seria expurgado.
+func (p *Purger) Purge(ctx context.Context, policy config.Retention, trigger string, dryRun bool) (*domain.RetentionReport, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	report := &domain.RetentionReport{StartedAt: now, Trigger: trigger, DryRun: dryRun}
+	watermarks, err := p.watermarks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	secret, secretErr := p.secret.Value(ctx)
+
+	budget := policy.MaxPerRun
+	for i, rule := range policy.Rules {
+		result := domain.RetentionRuleResult{
+			Rule:   ruleName(rule, i),
+			Key:    ruleKey(policy.Rules, i),
+			Action: rule.Action,
+			Days:   rule.Days,
+			Cutoff: now.AddDate(0, 0, -rule.Days),
+		}
+		after := watermarks[result.Key]
+		switch {
+		case rule.Action == domain.RetentionPseudonymize && secretErr != nil:
+			result.Error = fmt.Sprintf("segredo da LGPD (%s) indisponível: %v", p.secret.Ref(), secretErr)
+		case rule.Action != domain.RetentionDelete && rule.Action != domain.RetentionPseudonymize:
+			result.Error = fmt.Sprintf("ação desconhecida %q", rule.Action)
+		default:
+			after = p.apply(ctx, policy.Rules, i, &result, after, &budget, secret, dryRun)
+		}
+		result.Through = after
+		report.Rules = append(report.Rules, result)
+	}
+	if policy.WebhookDays > 0 {
+		report.Webhooks = p.purgeWebhooks(ctx, policy.WebhookDays, now, budget, dryRun)
+	}
+	report.FinishedAt = time.Now()
+
+	for _, result := range report.Rules {
+		log.Printf("[RETENÇÃO] %s: %d lidos, %d apagados, %d pseudonimizados (dry_run=%t)%s",
+			result.Rule, result.Scanned, result.Deleted, result.Pseudonymized, dryRun, errSuffix(result.Error))
+	}
+	if w := report.Webhooks; w != nil {
+		log.Printf("[RETENÇÃO] webhooks: %d notificações e %d entregas apagadas (dry_run=%t)%s",
+			w.EventsDeleted, w.DeliveriesDeleted, dryRun, errSuffix(w.Error))
+	}
+	if err := p.repo.SaveRetentionReport(ctx, *report); err != nil {
+		return report, fmt.Errorf("gravando relatório de retenção: %w", err)
+	}
+	return report, nil
+}
+
+// apply percorre os leads da regra do mais antigo ao corte e retorna o
+// updated_at do último processado. Leads atendidos por uma regra anterior
+// seguem aquela regra e são só pulados aqui.
+func (p *Purger) apply(ctx context.Context, rules []config.RetentionRule, index int, result *domain.RetentionRuleResult, after time.Time, budget *int, secret string, dryRun bool) time.Time {
+	rule := rules[index]
+	for *budget > 0 {
+		limit := pageSize
+		if *budget < limit {
+			limit = *budget
+		}
+		leads, err := p.repo.StaleLeads(ctx, criteria(rule), after, result.Cutoff, limit)
+		if err != nil {
+			result.Error = err.Error()
+			return after
+		}
+		for _, lead := range leads {
+			*budget--
+			result.Scanned++
+			if governing(rules, lead) != index ||
+				(rule.Action == domain.RetentionPseudonymize && domain.IsPseudonym(lead.CPF)) {
+				after = lead.UpdatedAt
+				continue
+			}
+
+			if !dryRun {
+				pseudonym := ""
+				if rule.Action == domain.RetentionPseudonymize {
+					pseudonym = domain.Pseudonym(secret, onlyDigits(lead.CPF))
+				}
+				attempts, err := p.repo.PurgeLead(ctx, lead, pseudonym)
+				if err != nil {
+					// O lead fica para a próxima execução
+					result.Error = err.Error()
+					return after
+				}
+				result.AttemptsDeleted += attempts
+			}
+			if rule.Action == domain.RetentionPseudonymize {
+				result.Pseudonymized++
+			} else {
+				result.Deleted++
+			}
+			if result.ByCondo == nil {
+				result.ByCondo = map[string]int{}
+			}
+			result.ByCondo[lead.CondoID]++
+			after = lead.UpdatedAt
+		}
+		if len(leads) < limit {
+			result.Complete = true
+			return after
+		}
+	}
+	return after
+}
+
+// purgeWebhooks apaga as notificações recebidas e as entregas de webhook
+// anteriores ao prazo, com o que sobrou do limite da execução
+func (p *Purger) purgeWebhooks(ctx context.Context, days int, now time.Time, budget int, dryRun bool) *domain.WebhookRetentionResult {
+	result := &domain.WebhookRetentionResult{Days: days, Cutoff: now.AddDate(0, 0, -days)}
+	if budget <= 0 {
+		return result
+	}
+	events, deliveries, err := p.repo.PurgeWebhooks(ctx, result.Cutoff, budget, dryRun)
+	result.EventsDeleted, result.DeliveriesDeleted = events, deliveries
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Complete = events+deliveries < budget
+	return result
+}
+
+// watermarks Through de cada regra na última execução real
+func (p *Purger) watermarks(ctx context.Context) (map[string]time.Time, error) {
+	reports, err := p.repo.ListRetentionReports(ctx, 20)
+	if err != nil {
+		return nil, fmt.Errorf("lendo relatórios de retenção: %w", err)
+	}
+	marks := map[string]time.Time{}
+	for _, report := range reports {
+		if report.DryRun {
+			continue
+		}
+		for _, result := range report.Rules {
+			marks[result.Key] = result.Through
+		}
+		break
+	}
+	return marks, nil
+}
+
+// governing índice da primeira regra que atende o lead
+func governing(rules []config.RetentionRule, lead domain.Lead) int {
+	for i, rule := range rules {
+		if criteria(rule).Matches(lead) {
+			return i
+		}
+	}
+	return -1
+}
+
+func criteria(rule config.RetentionRule) domain.RetentionCriteria {
+	return domain.RetentionCriteria{Status: rule.Status, Scenario: rule.Scenario, Origin: rule.Origin}
+}
+
+// ruleKey identifica a regra entre execuções pelos critérios e ação dela e
+// das anteriores (que decidem quais leads ela pula): mudar qualquer um
+// recomeça a regra do início; mudar só prazos ou nomes, não
+func ruleKey(rules []config.RetentionRule, index int) string {
+	var key strings.Builder
+	for _, rule := range rules[:index+1] {
+		fmt.Fprintf(&key, "%s|%s|%s|%s;", rule.Action, rule.Status, rule.Scenario, rule.Origin)
+	}
+	return key.String()
+}
+
+func ruleName(rule config.RetentionRule, index int) string {
+	if rule.Name != "" {
+		return rule.Name
+	}
+	return fmt.Sprintf("rules[%d]", index)
+}
+
+func errSuffix(err string) string {
+	if err == "" {
+		return ""
+	}
+	return " - erro: " + err
+}
+
+var nonDigitRegex = regexp.MustCompile(`\D`)
+
+func onlyDigits(s string) string {
+	return nonDigitRegex.ReplaceAllString(s, "")
+}
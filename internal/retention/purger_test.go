@@ -0,0 +1,157 @@
+package retention
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/viplounge/platform/internal/config"
+	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/repository"
+	"github.com/viplounge/platform/internal/secrets"
+)
+
+// policy rejeitados apagados em 30 dias; o resto pseudonimizado em 365
+func policy(maxPerRun int) config.Retention {
+	return config.Retention{
+		Enabled:   true,
+		MaxPerRun: maxPerRun,
+		Rules: []config.RetentionRule{
+			{Name: "rejeitados", Status: domain.StatusRejected, Days: 30, Action: domain.RetentionDelete},
+			{Name: "todos", Days: 365, Action: domain.RetentionPseudonymize},
+		},
+	}
+}
+
+func seed(t *testing.T, repo *repository.MemoryRepository, leads ...domain.Lead) {
+	t.Helper()
+	for _, lead := range leads {
+		if err := repo.Save(context.Background(), lead); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+}
+
+func daysAgo(days int) time.Time {
+	return time.Now().AddDate(0, 0, -days)
+}
+
+func TestPurge(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	seed(t, repo,
+		domain.Lead{CPF: "11144477735", CondoID: "4", Status: domain.StatusRejected, UpdatedAt: daysAgo(60)},
+		domain.Lead{CPF: "52998224725", CondoID: "4", Status: domain.StatusApproved, UpdatedAt: daysAgo(400)},
+		domain.Lead{CPF: "22233344405", CondoID: "7", Status: domain.StatusRejected, UpdatedAt: daysAgo(10)},
+		domain.Lead{CPF: "39053344705", CondoID: "7", Status: domain.StatusApproved, UpdatedAt: daysAgo(100)},
+	)
+	purger := NewPurger(repo, secrets.Static("segredo-da-lgpd"))
+
+	dry, err := purger.Purge(ctx, policy(100), "api_key:admin", true)
+	if err != nil {
+		t.Fatalf("Purge dry run: %v", err)
+	}
+	if dry.Rules[0].Deleted != 1 || dry.Rules[1].Pseudonymized != 1 {
+		t.Errorf("dry run: %+v", dry.Rules)
+	}
+	if leads, _ := repo.SearchLeads(ctx, domain.LeadFilter{}); len(leads) != 4 {
+		t.Fatalf("dry run alterou os leads: %d", len(leads))
+	}
+
+	report, err := purger.Purge(ctx, policy(100), TriggerSchedule, false)
+	if err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	deleted, pseudonymized := report.Rules[0], report.Rules[1]
+	if deleted.Deleted != 1 || !deleted.Complete || deleted.ByCondo["4"] != 1 {
+		t.Errorf("regra de exclusão: %+v", deleted)
+	}
+	if pseudonymized.Pseudonymized != 1 || !pseudonymized.Complete {
+		t.Errorf("regra de pseudonimização: %+v", pseudonymized)
+	}
+
+	if leads, _ := repo.SearchLeads(ctx, domain.LeadFilter{CPF: "11144477735"}); len(leads) != 0 {
+		t.Errorf("lead rejeitado não apagado: %+v", leads)
+	}
+	if leads, _ := repo.SearchLeads(ctx, domain.LeadFilter{CPF: "52998224725"}); len(leads) != 0 {
+		t.Errorf("lead antigo ainda com o CPF: %+v", leads)
+	}
+	leads, _ := repo.SearchLeads(ctx, domain.LeadFilter{})
+	if len(leads) != 3 {
+		t.Fatalf("%d leads depois do expurgo, esperados 3", len(leads))
+	}
+	pseudonyms := 0
+	for _, lead := range leads {
+		if domain.IsPseudonym(lead.CPF) {
+			pseudonyms++
+		}
+	}
+	if pseudonyms != 1 {
+		t.Errorf("%d leads pseudonimizados, esperado 1", pseudonyms)
+	}
+
+	// A próxima execução continua do Through e não relê os já tratados
+	again, err := purger.Purge(ctx, policy(100), TriggerSchedule, false)
+	if err != nil {
+		t.Fatalf("segunda execução: %v", err)
+	}
+	for _, result := range again.Rules {
+		if result.Scanned != 0 || result.Deleted+result.Pseudonymized != 0 {
+			t.Errorf("segunda execução releu leads: %+v", result)
+		}
+	}
+}
+
+// TestPurgeBudget max_per_run limita a execução; a seguinte termina o resto
+func TestPurgeBudget(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	seed(t, repo,
+		domain.Lead{CPF: "11144477735", CondoID: "4", Status: domain.StatusRejected, UpdatedAt: daysAgo(90)},
+		domain.Lead{CPF: "22233344405", CondoID: "4", Status: domain.StatusRejected, UpdatedAt: daysAgo(60)},
+	)
+	purger := NewPurger(repo, secrets.Static("segredo-da-lgpd"))
+
+	first, err := purger.Purge(ctx, policy(1), TriggerSchedule, false)
+	if err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if first.Rules[0].Deleted != 1 || first.Rules[0].Complete {
+		t.Errorf("primeira execução: %+v", first.Rules[0])
+	}
+	second, err := purger.Purge(ctx, policy(1), TriggerSchedule, false)
+	if err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if second.Rules[0].Deleted != 1 {
+		t.Errorf("segunda execução: %+v", second.Rules[0])
+	}
+	if leads, _ := repo.SearchLeads(ctx, domain.LeadFilter{}); len(leads) != 0 {
+		t.Errorf("leads restantes: %+v", leads)
+	}
+}
+
+// TestPurgeWithoutSecret sem o segredo da LGPD a pseudonimização falha com
+// erro no relatório, sem impedir a exclusão
+func TestPurgeWithoutSecret(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	seed(t, repo,
+		domain.Lead{CPF: "11144477735", CondoID: "4", Status: domain.StatusRejected, UpdatedAt: daysAgo(60)},
+		domain.Lead{CPF: "52998224725", CondoID: "4", Status: domain.StatusApproved, UpdatedAt: daysAgo(400)},
+	)
+
+	report, err := NewPurger(repo, secrets.Static("")).Purge(ctx, policy(100), TriggerSchedule, false)
+	if err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if report.Rules[0].Deleted != 1 || report.Rules[0].Error != "" {
+		t.Errorf("regra de exclusão: %+v", report.Rules[0])
+	}
+	if report.Rules[1].Error == "" || report.Rules[1].Pseudonymized != 0 {
+		t.Errorf("pseudonimização sem segredo: %+v", report.Rules[1])
+	}
+	if leads, _ := repo.SearchLeads(ctx, domain.LeadFilter{CPF: "52998224725"}); len(leads) != 1 {
+		t.Errorf("lead alterado sem o segredo: %+v", leads)
+	}
+}
@@ -0,0 +1,46 @@
+package secrets
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/viplounge/platform/internal/config"
+)
+
+// FromConfig monta o provedor de credenciais descrito em config.Secrets.
+// O client do Secret Manager só é criado no primeiro uso de uma referência gsm.
+func FromConfig(cfg *config.Config) (Provider, error) {
+	resolver, err := NewResolver(cfg.Secrets.Provider, map[string]Provider{
+		"env":  EnvProvider{},
+		"file": FileProvider{Dir: cfg.Secrets.FileDir},
+		"gsm":  &lazySecretManager{project: cfg.Secrets.GCPProject},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := time.Duration(cfg.Secrets.CacheTTLSeconds) * time.Second
+	if ttl <= 0 {
+		return resolver, nil
+	}
+	return NewCache(resolver, ttl), nil
+}
+
+type lazySecretManager struct {
+	project string
+
+	once     sync.Once
+	provider *SecretManagerProvider
+	err      error
+}
+
+func (l *lazySecretManager) Get(ctx context.Context, name string) (string, error) {
+	l.once.Do(func() {
+		l.provider, l.err = NewSecretManagerProvider(context.Background(), l.project)
+	})
+	if l.err != nil {
+		return "", l.err
+	}
+	return l.provider.Get(ctx, name)
+}
@@ -0,0 +1,60 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"google.golang.org/api/googleapi"
+	secretmanager "google.golang.org/api/secretmanager/v1"
+)
+
+// SecretManagerProvider lê credenciais do Google Secret Manager.
+// Nomes curtos ("superlogica-app-token") usam a versão latest do projeto
+// configurado; nomes completos (projects/.../versions/...) são usados como estão.
+type SecretManagerProvider struct {
+	project string
+	svc     *secretmanager.Service
+}
+
+// NewSecretManagerProvider usa as credenciais padrão do ambiente (ADC)
+func NewSecretManagerProvider(ctx context.Context, project string) (*SecretManagerProvider, error) {
+	svc, err := secretmanager.NewService(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro criando client do Secret Manager: %w", err)
+	}
+	return &SecretManagerProvider{project: project, svc: svc}, nil
+}
+
+func (p *SecretManagerProvider) Get(ctx context.Context, name string) (string, error) {
+	resource := name
+	if !strings.HasPrefix(name, "projects/") {
+		if p.project == "" {
+			return "", fmt.Errorf("gsm %s: projeto GCP não configurado para nome curto", name)
+		}
+		resource = fmt.Sprintf("projects/%s/secrets/%s/versions/latest", p.project, name)
+	} else if !strings.Contains(name, "/versions/") {
+		resource = name + "/versions/latest"
+	}
+
+	resp, err := p.svc.Projects.Secrets.Versions.Access(resource).Context(ctx).Do()
+	if err != nil {
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+			return "", fmt.Errorf("gsm %s: %w", resource, ErrNotFound)
+		}
+		return "", fmt.Errorf("erro acessando segredo %s: %w", resource, err)
+	}
+	if resp.Payload == nil {
+		return "", fmt.Errorf("gsm %s sem payload: %w", resource, ErrNotFound)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(resp.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("erro decodificando segredo %s: %w", resource, err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,88 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound indica que a credencial não existe no provedor
+var ErrNotFound = errors.New("segredo não encontrado")
+
+// Provider resolve o nome de uma credencial para o seu valor.
+// Implementações nunca devem logar o valor retornado.
+type Provider interface {
+	Get(ctx context.Context, name string) (string, error)
+}
+
+// EnvProvider lê credenciais de variáveis de ambiente
+type EnvProvider struct{}
+
+func (EnvProvider) Get(ctx context.Context, name string) (string, error) {
+	if val, ok := os.LookupEnv(name); ok && val != "" {
+		return val, nil
+	}
+	return "", fmt.Errorf("env %s: %w", name, ErrNotFound)
+}
+
+// FileProvider lê credenciais de arquivos montados (Secret Manager no Cloud Run,
+// secrets do Kubernetes). O arquivo é relido a cada Get, então uma rotação
+// aparece sem reiniciar o processo.
+type FileProvider struct {
+	Dir string
+}
+
+func (p FileProvider) Get(ctx context.Context, name string) (string, error) {
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(p.Dir, name)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("arquivo %s: %w", path, ErrNotFound)
+		}
+		return "", fmt.Errorf("erro lendo segredo %s: %w", path, err)
+	}
+	val := strings.TrimRight(string(data), "\r\n")
+	if val == "" {
+		return "", fmt.Errorf("arquivo %s vazio: %w", path, ErrNotFound)
+	}
+	return val, nil
+}
+
+// Resolver direciona cada referência ao provedor indicado pelo prefixo:
+//
+//	env:NOME          variável de ambiente
+//	file:NOME         arquivo em Dir (ou caminho absoluto)
+//	gsm:NOME          Google Secret Manager (nome curto ou projects/.../versions/...)
+//	NOME              provedor padrão
+type Resolver struct {
+	providers map[string]Provider
+	fallback  string
+}
+
+// NewResolver cria um Resolver com o provedor padrão para referências sem prefixo
+func NewResolver(defaultScheme string, providers map[string]Provider) (*Resolver, error) {
+	if _, ok := providers[defaultScheme]; !ok {
+		return nil, fmt.Errorf("provedor de segredos padrão %q não configurado", defaultScheme)
+	}
+	return &Resolver{providers: providers, fallback: defaultScheme}, nil
+}
+
+func (r *Resolver) Get(ctx context.Context, ref string) (string, error) {
+	scheme, name := r.fallback, ref
+	if i := strings.Index(ref, ":"); i > 0 {
+		if _, ok := r.providers[ref[:i]]; ok {
+			scheme, name = ref[:i], ref[i+1:]
+		}
+	}
+	provider, ok := r.providers[scheme]
+	if !ok {
+		return "", fmt.Errorf("provedor de segredos %q não configurado", scheme)
+	}
+	return provider.Get(ctx, name)
+}
@@ -0,0 +1,131 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// Cache guarda valores resolvidos por um TTL curto. Depois do TTL o valor é
+// buscado de novo, o que permite rotacionar credenciais sem reiniciar.
+// Se a nova busca falhar por instabilidade do provedor, o último valor bom
+// continua em uso por até maxStale; um segredo apagado ou desativado
+// (ErrNotFound) é revogação e deixa de valer na hora. Toda busca, com
+// sucesso ou não, só se repete depois do TTL.
+type Cache struct {
+	provider Provider
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// maxStale por quanto tempo, além do TTL, o último valor bom cobre falhas
+// transitórias do provedor
+const maxStale = time.Hour
+
+type cacheEntry struct {
+	value string
+	// err falha da última busca, quando não há valor bom a usar
+	err error
+	// fetchedAt última busca com sucesso; checkedAt última tentativa
+	fetchedAt time.Time
+	checkedAt time.Time
+}
+
+// NewCache envolve um Provider com cache de ttl
+func NewCache(provider Provider, ttl time.Duration) *Cache {
+	return &Cache{
+		provider: provider,
+		ttl:      ttl,
+		entries:  make(map[string]cacheEntry),
+	}
+}
+
+func (c *Cache) Get(ctx context.Context, ref string) (string, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[ref]
+	c.mu.Unlock()
+
+	if ok && time.Since(entry.checkedAt) < c.ttl {
+		return entry.value, entry.err
+	}
+
+	val, err := c.provider.Get(ctx, ref)
+	now := time.Now()
+	switch {
+	case err == nil:
+		entry = cacheEntry{value: val, fetchedAt: now}
+	case errors.Is(err, ErrNotFound):
+		if ok && entry.err == nil {
+			log.Printf("[SECRETS] %s não existe mais no provedor, valor anterior descartado", ref)
+		}
+		entry = cacheEntry{err: err}
+	case ok && entry.err == nil && now.Sub(entry.fetchedAt) < c.ttl+maxStale:
+		log.Printf("[SECRETS] Falha ao renovar %s, mantendo valor anterior: %v", ref, err)
+	default:
+		if ok && entry.err == nil {
+			log.Printf("[SECRETS] Falha ao renovar %s e valor anterior vencido há mais de %s: %v", ref, maxStale, err)
+		}
+		entry = cacheEntry{err: err}
+	}
+	entry.checkedAt = now
+
+	c.mu.Lock()
+	c.entries[ref] = entry
+	c.mu.Unlock()
+	return entry.value, entry.err
+}
+
+// Secret é uma referência a uma credencial, resolvida a cada uso.
+// Formatar um Secret com %v/%s nunca exibe o valor.
+type Secret struct {
+	ref      string
+	provider Provider
+}
+
+// NewSecret cria a referência; ref vazio representa credencial não configurada
+func NewSecret(provider Provider, ref string) Secret {
+	return Secret{ref: ref, provider: provider}
+}
+
+// Static cria um Secret com valor fixo (testes e ferramentas locais)
+func Static(value string) Secret {
+	return Secret{ref: "static", provider: staticProvider(value)}
+}
+
+// Configured indica se há uma referência definida
+func (s Secret) Configured() bool {
+	return s.ref != "" && s.provider != nil
+}
+
+// Ref retorna a referência (nunca o valor), útil em mensagens de erro
+func (s Secret) Ref() string {
+	return s.ref
+}
+
+// Value resolve o valor atual da credencial
+func (s Secret) Value(ctx context.Context) (string, error) {
+	if !s.Configured() {
+		return "", ErrNotFound
+	}
+	return s.provider.Get(ctx, s.ref)
+}
+
+func (s Secret) String() string {
+	if !s.Configured() {
+		return "<não configurado>"
+	}
+	return s.ref + "=********"
+}
+
+type staticProvider string
+
+func (p staticProvider) Get(ctx context.Context, name string) (string, error) {
+	if p == "" {
+		return "", ErrNotFound
+	}
+	return string(p), nil
+}
@@ -0,0 +1,243 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/api/option"
+	secretmanager "google.golang.org/api/secretmanager/v1"
+
+	"github.com/viplounge/platform/internal/config"
+)
+
+// fakeSecretManager responde a API de acesso do Secret Manager com os
+// segredos por recurso (projects/.../versions/...)
+func fakeSecretManager(t *testing.T, values map[string]string) *SecretManagerProvider {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resource := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1/"), ":access")
+		value, ok := values[resource]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]interface{}{"code": 404, "message": "not found"}})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"name":    resource,
+			"payload": map[string]string{"data": base64.StdEncoding.EncodeToString([]byte(value))},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	svc, err := secretmanager.NewService(context.Background(), option.WithEndpoint(server.URL+"/"), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("client do Secret Manager: %v", err)
+	}
+	return &SecretManagerProvider{project: "viplounge", svc: svc}
+}
+
+func TestResolver(t *testing.T) {
+	t.Setenv("SUPERLOGICA_APP_TOKEN", "token-da-env")
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "rede-parcerias"), []byte("segredo-do-arquivo\n"), 0o600)
+	os.WriteFile(filepath.Join(dir, "vazio"), []byte("\n"), 0o600)
+	absolute := filepath.Join(t.TempDir(), "montado")
+	os.WriteFile(absolute, []byte("segredo-absoluto"), 0o600)
+
+	gsm := fakeSecretManager(t, map[string]string{
+		"projects/viplounge/secrets/pii-hash-key/versions/latest": "segredo-do-gsm",
+		"projects/outro/secrets/jwt/versions/3":                   "versão-fixa",
+		"projects/outro/secrets/jwt/versions/latest":              "última-versão",
+	})
+	resolver, err := NewResolver("env", map[string]Provider{
+		"env":  EnvProvider{},
+		"file": FileProvider{Dir: dir},
+		"gsm":  gsm,
+	})
+	if err != nil {
+		t.Fatalf("NewResolver: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		ref     string
+		want    string
+		wantErr error
+	}{
+		{name: "sem prefixo usa o padrão", ref: "SUPERLOGICA_APP_TOKEN", want: "token-da-env"},
+		{name: "env", ref: "env:SUPERLOGICA_APP_TOKEN", want: "token-da-env"},
+		{name: "env ausente", ref: "env:NAO_DEFINIDA", wantErr: ErrNotFound},
+		{name: "arquivo sem a quebra de linha", ref: "file:rede-parcerias", want: "segredo-do-arquivo"},
+		{name: "arquivo por caminho absoluto", ref: "file:" + absolute, want: "segredo-absoluto"},
+		{name: "arquivo vazio", ref: "file:vazio", wantErr: ErrNotFound},
+		{name: "arquivo ausente", ref: "file:nao-existe", wantErr: ErrNotFound},
+		{name: "gsm nome curto", ref: "gsm:pii-hash-key", want: "segredo-do-gsm"},
+		{name: "gsm versão fixa", ref: "gsm:projects/outro/secrets/jwt/versions/3", want: "versão-fixa"},
+		{name: "gsm sem versão usa latest", ref: "gsm:projects/outro/secrets/jwt", want: "última-versão"},
+		{name: "gsm ausente", ref: "gsm:nao-existe", wantErr: ErrNotFound},
+		// Dois-pontos sem provedor conhecido fazem parte do nome
+		{name: "prefixo desconhecido", ref: "vault:token", wantErr: ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolver.Get(context.Background(), tt.ref)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("erro %v, esperado %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Get(%s) = %q (%v), esperado %q", tt.ref, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolverUnknownDefault(t *testing.T) {
+	if _, err := NewResolver("vault", map[string]Provider{"env": EnvProvider{}}); err == nil {
+		t.Errorf("provedor padrão inexistente aceito")
+	}
+}
+
+func TestSecretManagerShortNameWithoutProject(t *testing.T) {
+	gsm := fakeSecretManager(t, nil)
+	gsm.project = ""
+	if _, err := gsm.Get(context.Background(), "pii-hash-key"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("erro %v, esperado projeto não configurado", err)
+	}
+}
+
+// countingProvider devolve "v<n>" na n-ésima busca, ou err
+type countingProvider struct {
+	calls atomic.Int32
+	err   error
+}
+
+func (p *countingProvider) Get(ctx context.Context, name string) (string, error) {
+	n := p.calls.Add(1)
+	if p.err != nil {
+		return "", p.err
+	}
+	return fmt.Sprintf("v%d", n), nil
+}
+
+// age envelhece a entrada do cache: última busca boa e última tentativa
+func age(cache *Cache, ref string, fetched, checked time.Duration) {
+	entry := cache.entries[ref]
+	entry.fetchedAt = time.Now().Add(-fetched)
+	entry.checkedAt = time.Now().Add(-checked)
+	cache.entries[ref] = entry
+}
+
+func TestCache(t *testing.T) {
+	provider := &countingProvider{}
+	cache := NewCache(provider, time.Hour)
+	ctx := context.Background()
+
+	if v, _ := cache.Get(ctx, "A"); v != "v1" {
+		t.Fatalf("primeira busca: %s", v)
+	}
+	if v, _ := cache.Get(ctx, "A"); v != "v1" || provider.calls.Load() != 1 {
+		t.Errorf("dentro do TTL: %s, %d buscas", v, provider.calls.Load())
+	}
+
+	// Vencido o TTL, uma falha transitória mantém o último valor bom e a
+	// tentativa conta para o TTL: o provedor não é chamado a cada uso
+	age(cache, "A", 90*time.Minute, 90*time.Minute)
+	provider.err = errors.New("gsm indisponível")
+	if v, err := cache.Get(ctx, "A"); v != "v1" || err != nil {
+		t.Errorf("falha na renovação: %q (%v)", v, err)
+	}
+	if v, _ := cache.Get(ctx, "A"); v != "v1" || provider.calls.Load() != 2 {
+		t.Errorf("depois da falha: %s, %d buscas", v, provider.calls.Load())
+	}
+	if _, err := cache.Get(ctx, "B"); err == nil {
+		t.Errorf("falha sem valor anterior deveria retornar erro")
+	}
+
+	// Passado o limite, o valor antigo deixa de valer
+	age(cache, "A", time.Hour+maxStale+time.Minute, 2*time.Hour)
+	if v, err := cache.Get(ctx, "A"); err == nil {
+		t.Errorf("valor vencido além do limite: %q", v)
+	}
+
+	// Sem falha, o valor vencido é substituído (rotação)
+	provider.err = nil
+	age(cache, "A", 2*time.Hour, 2*time.Hour)
+	if v, _ := cache.Get(ctx, "A"); v != "v5" {
+		t.Errorf("rotação: %s", v)
+	}
+
+	// Segredo apagado ou desativado é revogação: o valor anterior some na
+	// primeira busca depois do TTL
+	age(cache, "A", 2*time.Hour, 2*time.Hour)
+	provider.err = fmt.Errorf("gsm A: %w", ErrNotFound)
+	if v, err := cache.Get(ctx, "A"); !errors.Is(err, ErrNotFound) || v != "" {
+		t.Errorf("segredo removido: %q (%v)", v, err)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	t.Setenv("CRM_WEBHOOK_SECRET", "segredo-do-crm")
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "CRM_WEBHOOK_SECRET"), []byte("segredo-montado"), 0o600)
+
+	tests := []struct {
+		name     string
+		provider string
+		ttl      int
+		want     string
+	}{
+		{"env", "env", 0, "segredo-do-crm"},
+		{"arquivo", "file", 0, "segredo-montado"},
+		{"env com cache", "env", 60, "segredo-do-crm"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Secrets.Provider = tt.provider
+			cfg.Secrets.FileDir = dir
+			cfg.Secrets.CacheTTLSeconds = tt.ttl
+
+			provider, err := FromConfig(cfg)
+			if err != nil {
+				t.Fatalf("FromConfig: %v", err)
+			}
+			if _, cached := provider.(*Cache); cached != (tt.ttl > 0) {
+				t.Errorf("cache=%v com ttl %d", cached, tt.ttl)
+			}
+			if got, err := NewSecret(provider, "CRM_WEBHOOK_SECRET").Value(context.Background()); err != nil || got != tt.want {
+				t.Errorf("Value = %q (%v), esperado %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecretNeverFormatsValue(t *testing.T) {
+	t.Setenv("CRM_WEBHOOK_SECRET", "segredo-do-crm")
+	secret := NewSecret(EnvProvider{}, "CRM_WEBHOOK_SECRET")
+
+	for _, formatted := range []string{fmt.Sprint(secret), fmt.Sprintf("%v", secret), fmt.Sprintf("%+v", secret), fmt.Sprintf("%s", secret)} {
+		if strings.Contains(formatted, "segredo-do-crm") {
+			t.Errorf("valor exposto: %s", formatted)
+		}
+	}
+	if unset := NewSecret(EnvProvider{}, ""); unset.Configured() || unset.String() != "<não configurado>" {
+		t.Errorf("referência vazia: %v", unset)
+	}
+	if _, err := NewSecret(EnvProvider{}, "").Value(context.Background()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("erro %v, esperado ErrNotFound", err)
+	}
+}
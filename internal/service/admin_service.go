@@ -0,0 +1,465 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/viplounge/platform/internal/auth"
+	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/secrets"
+)
+
+// AdminService atende o suporte: consulta de leads e ações manuais sobre a
+// Rede Parcerias. Toda chamada, inclusive as consultas, vai para o log de
+// auditoria com o operador que a fez. Operadores restritos a alguns
+// condomínios (administradoras) só enxergam leads desses tenants.
+type AdminService struct {
+	store   domain.LeadStore
+	audit   domain.AuditRepository
+	partner domain.PartnerService
+	// clubs resolve o clube principal do condomínio do lead (opcional; sem
+	// ele todo lead usa partner)
+	clubs domain.ClubRegistry
+	// diretório de moradores, quando a sincronização está habilitada
+	directory domain.DirectoryRepository
+	// log e reenvio dos webhooks de saída, quando habilitados
+	deliveries  domain.DeliveryRepository
+	redeliverer Redeliverer
+	// pedidos do titular dos dados (LGPD), quando habilitados
+	subjects      domain.SubjectRepository
+	privacySecret secrets.Secret
+	// expurgo dos leads antigos e seus relatórios
+	retention        RetentionRunner
+	retentionReports domain.RetentionRepository
+	// leads por consentimento, para a exportação de marketing
+	consents domain.ConsentRepository
+	// recifragem dos leads, com a cifragem em repouso ligada
+	rotator domain.LeadKeyRotator
+	// handles de uso único no lugar do acesso do clube
+	sso SSOIssuer
+	// eventos das revogações e restaurações para os webhooks de saída
+	events domain.EventPublisher
+}
+
+// SSOIssuer troca o acesso do clube por um handle de uso único
+// (ValidationService), o mesmo entregue no fluxo público
+type SSOIssuer interface {
+	IssueSSO(ctx context.Context, lead *domain.Lead, club domain.Club, sso *domain.SSOToken) (string, error)
+}
+
+// Redeliverer reenvia uma entrega de webhook (outbound.Dispatcher)
+type Redeliverer interface {
+	Redeliver(ctx context.Context, id string) (*domain.WebhookDelivery, error)
+}
+
+func NewAdminService(store domain.LeadStore, audit domain.AuditRepository, partner domain.PartnerService) *AdminService {
+	return &AdminService{
+		store:   store,
+		audit:   audit,
+		partner: partner,
+	}
+}
+
+// SetClubs liga a resolução dos clubes por condomínio (tenants[].clubs)
+func (s *AdminService) SetClubs(clubs domain.ClubRegistry) {
+	s.clubs = clubs
+}
+
+// club clube principal do condomínio do lead, onde as ações do suporte
+// são aplicadas
+func (s *AdminService) club(ctx context.Context, lead *domain.Lead) domain.Club {
+	return resolveClubs(ctx, s.clubs, s.partner, lead.CondoID)[0]
+}
+
+// SetPublisher liga a emissão de eventos para os webhooks de saída
+func (s *AdminService) SetPublisher(events domain.EventPublisher) {
+	s.events = events
+}
+
+// EnableSSO liga a geração dos links de acesso pelo suporte
+func (s *AdminService) EnableSSO(issuer SSOIssuer) {
+	s.sso = issuer
+}
+
+// EnableDirectory expõe o feed de mudanças do diretório de moradores
+func (s *AdminService) EnableDirectory(directory domain.DirectoryRepository) {
+	s.directory = directory
+}
+
+// DirectoryEnabled indica se o diretório de moradores está disponível
+func (s *AdminService) DirectoryEnabled() bool {
+	return s.directory != nil
+}
+
+// EnableDeliveries expõe o log de entregas dos webhooks de saída
+func (s *AdminService) EnableDeliveries(deliveries domain.DeliveryRepository, redeliverer Redeliverer) {
+	s.deliveries = deliveries
+	s.redeliverer = redeliverer
+}
+
+// DeliveriesEnabled indica se os webhooks de saída estão habilitados
+func (s *AdminService) DeliveriesEnabled() bool {
+	return s.deliveries != nil
+}
+
+// SearchLeads busca leads por CPF, tenant, status e período
+func (s *AdminService) SearchLeads(ctx context.Context, p *auth.Principal, filter domain.LeadFilter) ([]domain.Lead, error) {
+	var err error
+	var leads []domain.Lead
+	filter.TenantID, err = scopeTenant(p, filter.TenantID)
+	if err == nil {
+		leads, err = s.store.SearchLeads(ctx, filter)
+	}
+	s.record(ctx, domain.AuditEvent{
+		Actor:    p.Actor(),
+		Action:   domain.AuditActionSearchLeads,
+		TenantID: filter.TenantID,
+		CPF:      filter.CPF,
+		Details: map[string]interface{}{
+			"status":  filter.Status,
+			"results": len(leads),
+		},
+	}, err)
+	return leads, err
+}
+
+// LeadDetails retorna o lead, o histórico de tentativas, a situação atual na
+// Rede Parcerias e as ações do suporte já feitas sobre ele
+func (s *AdminService) LeadDetails(ctx context.Context, p *auth.Principal, leadID string) (*domain.LeadDetails, error) {
+	event := domain.AuditEvent{Actor: p.Actor(), Action: domain.AuditActionViewLead, LeadID: leadID}
+	lead, err := s.getLead(ctx, p, leadID)
+	if err != nil {
+		s.record(ctx, event, err)
+		return nil, err
+	}
+	event.TenantID = lead.CondoID
+	event.CPF = lead.CPF
+
+	details := &domain.LeadDetails{Lead: lead}
+	if details.Attempts, err = s.store.ListAttempts(ctx, leadID); err != nil {
+		s.record(ctx, event, err)
+		return nil, err
+	}
+	if details.Audit, err = s.audit.ListAudit(ctx, domain.AuditFilter{LeadID: leadID}); err != nil {
+		s.record(ctx, event, err)
+		return nil, err
+	}
+
+	// Falha na Rede Parcerias não impede ver o que temos localmente
+	details.PartnerUser, err = s.club(ctx, lead).Partner.FindUserByCPF(ctx, lead.CPF)
+	if err != nil {
+		log.Printf("[ADMIN] Falha consultando Rede Parcerias para %s: %v", maskCPF(lead.CPF), err)
+		details.PartnerError = err.Error()
+	}
+
+	s.record(ctx, event, nil)
+	return details, nil
+}
+
+// Reregister refaz o cadastro na Rede Parcerias com os dados do lead
+func (s *AdminService) Reregister(ctx context.Context, p *auth.Principal, leadID, reason string) (*domain.AdminActionResult, error) {
+	return s.act(ctx, p, leadID, reason, domain.AuditActionReregister, func(lead *domain.Lead) (*domain.AdminActionResult, error) {
+		club := s.club(ctx, lead)
+		lead.RedeParceriasAttempts++
+		start := time.Now()
+		sso, err := club.Partner.RegisterAndGetSSO(ctx, lead)
+		lead.RedeParceriasResponseMs = time.Since(start).Milliseconds()
+		if err != nil {
+			lead.Status = domain.StatusError
+			lead.RedeParceriasStatus = domain.PartnerStatusFailed
+			lead.RedeParceriasError = err.Error()
+			return nil, fmt.Errorf("recadastro na Rede Parcerias falhou: %w", err)
+		}
+
+		lead.Status = domain.StatusApproved
+		lead.RedeParceriasStatus = domain.PartnerStatusRegistered
+		lead.RedeParceriasError = ""
+		redirect, err := s.issueSSO(ctx, lead, club, sso)
+		if err != nil {
+			// O cadastro vale; o link sai depois por GenerateSSO
+			log.Printf("[WARN] Recadastro de %s sem link de acesso: %v", maskCPF(lead.CPF), err)
+			return &domain.AdminActionResult{Message: "Usuário recadastrado na Rede Parcerias, mas o link de acesso não foi gerado"}, nil
+		}
+		return &domain.AdminActionResult{
+			RedirectURL: redirect,
+			Message:     "Usuário recadastrado na Rede Parcerias",
+		}, nil
+	})
+}
+
+// GenerateSSO gera um novo link de acesso para um usuário já cadastrado
+func (s *AdminService) GenerateSSO(ctx context.Context, p *auth.Principal, leadID, reason string) (*domain.AdminActionResult, error) {
+	return s.act(ctx, p, leadID, reason, domain.AuditActionGenerateSSO, func(lead *domain.Lead) (*domain.AdminActionResult, error) {
+		club := s.club(ctx, lead)
+		user, err := partnerUser(ctx, club, lead)
+		if err != nil {
+			return nil, err
+		}
+
+		identifier := user.Email
+		if identifier == "" {
+			identifier = user.ID
+		}
+		sso, err := club.Partner.GetSSOToken(ctx, identifier)
+		if err != nil {
+			return nil, fmt.Errorf("falha ao gerar SSO: %w", err)
+		}
+		lead.RedeParceriasUserID = user.ID
+		redirect, err := s.issueSSO(ctx, lead, club, sso)
+		if err != nil {
+			return nil, fmt.Errorf("falha ao gerar SSO: %w", err)
+		}
+
+		return &domain.AdminActionResult{
+			RedirectURL: redirect,
+			Message:     "Link de acesso gerado",
+		}, nil
+	})
+}
+
+// issueSSO retorna o handle de uso único no lugar do token do clube, que
+// não pode sair na resposta nem ficar gravado pela idempotência
+func (s *AdminService) issueSSO(ctx context.Context, lead *domain.Lead, club domain.Club, sso *domain.SSOToken) (string, error) {
+	if s.sso == nil {
+		return "", errors.New("geração de acesso SSO não configurada")
+	}
+	if sso == nil || sso.Redirect == "" {
+		return "", errors.New("acesso sem redirect")
+	}
+	return s.sso.IssueSSO(ctx, lead, club, sso)
+}
+
+// Revoke remove o usuário da Rede Parcerias independentemente da Superlógica
+func (s *AdminService) Revoke(ctx context.Context, p *auth.Principal, leadID, reason string) (*domain.AdminActionResult, error) {
+	var club domain.Club
+	result, err := s.act(ctx, p, leadID, reason, domain.AuditActionRevoke, func(lead *domain.Lead) (*domain.AdminActionResult, error) {
+		club = s.club(ctx, lead)
+		user, err := partnerUser(ctx, club, lead)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return nil, err
+		}
+		if user != nil {
+			if err := club.Partner.DeleteUser(ctx, user.ID); err != nil {
+				return nil, fmt.Errorf("falha ao revogar na Rede Parcerias: %w", err)
+			}
+		}
+
+		lead.Status = domain.StatusRejected
+		lead.RedeParceriasStatus = domain.PartnerStatusRevoked
+		return &domain.AdminActionResult{Message: "Acesso revogado"}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	publishLead(ctx, s.events, domain.EventLeadRevoked, result.Lead, map[string]interface{}{"club_id": club.ID})
+	return result, nil
+}
+
+// Restore cadastra de volta um usuário revogado
+func (s *AdminService) Restore(ctx context.Context, p *auth.Principal, leadID, reason string) (*domain.AdminActionResult, error) {
+	var club domain.Club
+	result, err := s.act(ctx, p, leadID, reason, domain.AuditActionRestore, func(lead *domain.Lead) (*domain.AdminActionResult, error) {
+		club = s.club(ctx, lead)
+		lead.RedeParceriasAttempts++
+		if err := club.Partner.RegisterUser(ctx, lead); err != nil && !errors.Is(err, domain.ErrPartnerUserExists) {
+			lead.RedeParceriasStatus = domain.PartnerStatusFailed
+			lead.RedeParceriasError = err.Error()
+			return nil, fmt.Errorf("falha ao restaurar na Rede Parcerias: %w", err)
+		}
+
+		lead.Status = domain.StatusApproved
+		lead.RedeParceriasStatus = domain.PartnerStatusRegistered
+		lead.RedeParceriasError = ""
+		return &domain.AdminActionResult{Message: "Acesso restaurado"}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	publishLead(ctx, s.events, domain.EventLeadActivated, result.Lead, map[string]interface{}{"club_id": club.ID})
+	return result, nil
+}
+
+// ListAudit consulta o log de auditoria
+func (s *AdminService) ListAudit(ctx context.Context, p *auth.Principal, filter domain.AuditFilter) ([]domain.AuditEvent, error) {
+	var err error
+	var events []domain.AuditEvent
+	filter.TenantID, err = scopeTenant(p, filter.TenantID)
+	if err == nil {
+		events, err = s.audit.ListAudit(ctx, filter)
+	}
+	s.record(ctx, domain.AuditEvent{
+		Actor:    p.Actor(),
+		Action:   domain.AuditActionListAudit,
+		TenantID: filter.TenantID,
+		LeadID:   filter.LeadID,
+		CPF:      filter.CPF,
+		Details: map[string]interface{}{
+			"actor_filter": filter.Actor,
+			"results":      len(events),
+		},
+	}, err)
+	return events, err
+}
+
+// ListDirectoryChanges consulta o feed de mudanças do diretório de moradores
+// (entradas, saídas e alterações detectadas pela sincronização). As saídas
+// são as candidatas à revogação na Rede Parcerias.
+func (s *AdminService) ListDirectoryChanges(ctx context.Context, p *auth.Principal, filter domain.ResidentChangeFilter) ([]domain.ResidentChange, error) {
+	var err error
+	var changes []domain.ResidentChange
+	filter.CondoID, err = scopeTenant(p, filter.CondoID)
+	if err == nil {
+		changes, err = s.directory.ListChanges(ctx, filter)
+	}
+	s.record(ctx, domain.AuditEvent{
+		Actor:    p.Actor(),
+		Action:   domain.AuditActionDirectoryChanges,
+		TenantID: filter.CondoID,
+		Details: map[string]interface{}{
+			"type":    filter.Type,
+			"results": len(changes),
+		},
+	}, err)
+	return changes, err
+}
+
+// ListDeliveries consulta o log de entregas dos webhooks de saída
+func (s *AdminService) ListDeliveries(ctx context.Context, p *auth.Principal, filter domain.DeliveryFilter) ([]domain.WebhookDelivery, error) {
+	var err error
+	filter.TenantID, err = scopeTenant(p, filter.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	return s.deliveries.ListDeliveries(ctx, filter)
+}
+
+// GetDelivery retorna uma entrega de um tenant visível ao principal
+func (s *AdminService) GetDelivery(ctx context.Context, p *auth.Principal, id string) (*domain.WebhookDelivery, error) {
+	delivery, err := s.deliveries.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !p.CanAccessTenant(delivery.TenantID) {
+		return nil, fmt.Errorf("entrega %s fora dos tenants de %s: %w", id, p.Actor(), domain.ErrForbidden)
+	}
+	return delivery, nil
+}
+
+// Redeliver reenvia uma entrega na hora (inclusive as que esgotaram as
+// tentativas) e registra a auditoria
+func (s *AdminService) Redeliver(ctx context.Context, p *auth.Principal, id, reason string) (*domain.WebhookDelivery, error) {
+	event := domain.AuditEvent{Actor: p.Actor(), Action: domain.AuditActionRedeliver, Reason: reason}
+	delivery, err := s.GetDelivery(ctx, p, id)
+	if err == nil {
+		event.TenantID = delivery.TenantID
+		log.Printf("[ADMIN] %s reenviando entrega %s", p.Actor(), id)
+		delivery, err = s.redeliverer.Redeliver(ctx, id)
+	}
+	if delivery != nil {
+		event.Details = map[string]interface{}{
+			"delivery_id": id,
+			"event_type":  delivery.EventType,
+			"endpoint_id": delivery.EndpointID,
+			"status":      delivery.Status,
+			"status_code": delivery.LastStatusCode,
+		}
+	}
+	s.record(ctx, event, err)
+	return delivery, err
+}
+
+// act carrega o lead, aplica a ação, grava o lead (inclusive em caso de falha,
+// para o histórico de tentativas) e registra a auditoria
+func (s *AdminService) act(ctx context.Context, p *auth.Principal, leadID, reason, action string, fn func(lead *domain.Lead) (*domain.AdminActionResult, error)) (*domain.AdminActionResult, error) {
+	event := domain.AuditEvent{Actor: p.Actor(), Action: action, LeadID: leadID, Reason: reason}
+
+	lead, err := s.getLead(ctx, p, leadID)
+	if err != nil {
+		s.record(ctx, event, err)
+		return nil, err
+	}
+	event.TenantID = lead.CondoID
+	event.CPF = lead.CPF
+	previous := lead.RedeParceriasStatus
+
+	log.Printf("[ADMIN] %s executando %s em %s", p.Actor(), action, maskCPF(lead.CPF))
+	result, actionErr := fn(lead)
+
+	lead.Origin = "admin"
+	lead.UpdatedAt = time.Now()
+	if err := s.store.Save(ctx, *lead); err != nil {
+		log.Printf("[WARN] Erro ao salvar lead após %s: %v", action, err)
+		if actionErr == nil {
+			actionErr = err
+		}
+	}
+
+	event.Details = map[string]interface{}{
+		"partner_status_before": previous,
+		"partner_status_after":  lead.RedeParceriasStatus,
+	}
+	s.record(ctx, event, actionErr)
+	if actionErr != nil {
+		return nil, actionErr
+	}
+
+	result.Lead = lead
+	return result, nil
+}
+
+// getLead carrega o lead verificando se o principal enxerga o condomínio
+func (s *AdminService) getLead(ctx context.Context, p *auth.Principal, leadID string) (*domain.Lead, error) {
+	lead, err := s.store.GetLead(ctx, leadID)
+	if err != nil {
+		return nil, err
+	}
+	if !p.CanAccessTenant(lead.CondoID) {
+		return nil, fmt.Errorf("lead %s fora dos tenants de %s: %w", leadID, p.Actor(), domain.ErrForbidden)
+	}
+	return lead, nil
+}
+
+// scopeTenant aplica a restrição de tenants do principal a um filtro.
+// Sem tenant informado, um principal com um único condomínio usa esse.
+func scopeTenant(p *auth.Principal, tenantID string) (string, error) {
+	if p.SeesAllTenants() {
+		return tenantID, nil
+	}
+	if tenantID == "" {
+		if len(p.Tenants) == 1 {
+			return p.Tenants[0], nil
+		}
+		return "", fmt.Errorf("informe o tenant (acesso restrito a %v): %w", p.Tenants, domain.ErrForbidden)
+	}
+	if !p.CanAccessTenant(tenantID) {
+		return "", fmt.Errorf("tenant %s fora do acesso de %s: %w", tenantID, p.Actor(), domain.ErrForbidden)
+	}
+	return tenantID, nil
+}
+
+// partnerUser busca o usuário do lead no clube
+func partnerUser(ctx context.Context, club domain.Club, lead *domain.Lead) (*domain.PartnerUser, error) {
+	user, err := club.Partner.FindUserByCPF(ctx, lead.CPF)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao consultar Rede Parcerias: %w", err)
+	}
+	if user == nil {
+		return nil, fmt.Errorf("usuário não cadastrado na Rede Parcerias: %w", domain.ErrNotFound)
+	}
+	return user, nil
+}
+
+// record grava o evento de auditoria; uma falha aqui não desfaz a ação
+func (s *AdminService) record(ctx context.Context, event domain.AuditEvent, err error) {
+	event.CreatedAt = time.Now()
+	event.Success = err == nil
+	if err != nil {
+		event.Error = err.Error()
+	}
+	if saveErr := s.audit.SaveAudit(ctx, event); saveErr != nil {
+		log.Printf("[ERRO] Falha gravando auditoria %s de %s: %v", event.Action, event.Actor, saveErr)
+	}
+}
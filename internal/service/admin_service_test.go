@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/viplounge/platform/internal/domain"
+)
+
+// TestAdminRevokeRestorePublishes revogação e restauração pelo suporte
+// avisam os webhooks de saída como o fluxo público
+func TestAdminRevokeRestorePublishes(t *testing.T) {
+	ctx := context.Background()
+	gold := newClubStub("11144477735")
+	admin, _ := newPrivacyService(t, condoClubs{"4": {{ID: "ouro", Partner: gold}}})
+	events := &eventRecorder{}
+	admin.SetPublisher(events)
+
+	if _, err := admin.Revoke(ctx, platformAdmin, domain.LeadID("4", "11144477735"), "saiu do condomínio"); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if _, err := admin.Restore(ctx, platformAdmin, domain.LeadID("4", "11144477735"), "revogado por engano"); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+
+	want := []string{domain.EventLeadRevoked, domain.EventLeadActivated}
+	if len(*events) != len(want) {
+		t.Fatalf("%d eventos, esperados %v", len(*events), want)
+	}
+	for i, event := range *events {
+		if event.Type != want[i] || event.TenantID != "4" || event.Data["club_id"] != "ouro" || event.Data["cpf"] != "11144477735" {
+			t.Errorf("evento %d: %s tenant %q %v, esperado %s no clube ouro", i, event.Type, event.TenantID, event.Data, want[i])
+		}
+	}
+}
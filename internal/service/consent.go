@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/viplounge/platform/internal/auth"
+	"github.com/viplounge/platform/internal/config"
+	"github.com/viplounge/platform/internal/domain"
+)
+
+// recordConsents grava no lead as escolhas de consentimento, com a versão
+// atual da configuração quando a landing page não informa a exibida.
+// Sem verified (CPF do morador sem o e-mail confirmado) só recusas valem:
+// qualquer um digita o CPF, mas o aceite expõe nome, e-mail e telefone.
+func (s *ValidationService) recordConsents(ctx context.Context, lead *domain.Lead, choices []domain.ConsentChoice, verified bool) {
+	if len(choices) == 0 {
+		return
+	}
+	versions := s.config(ctx).Privacy.Consent.Versions
+	consents := map[string]domain.Consent{}
+	for _, choice := range choices {
+		if choice.Granted && !verified {
+			log.Printf("[CONSENTIMENTO] Aceite de %s para %s aguarda a confirmação do e-mail", choice.Purpose, maskCPF(lead.CPF))
+			continue
+		}
+		if choice.Version == "" {
+			choice.Version = versions[choice.Purpose]
+		}
+		consents[choice.Purpose] = domain.Consent{}.Record(choice, lead.Origin, lead.UpdatedAt)
+	}
+	if len(consents) > 0 {
+		lead.Consents = consents
+	}
+}
+
+// WithdrawConsent retira os consentimentos do CPF (purposes vazio = todos)
+// em todos os leads dele, de qualquer condomínio e formato do CPF, e avisa
+// os webhooks de saída. updated_at não muda: a retirada não renova o prazo
+// de retenção do lead. Retorna quantos leads mudaram.
+func (s *ValidationService) WithdrawConsent(ctx context.Context, req domain.ConsentWithdrawalRequest) (int, error) {
+	if s.leads == nil {
+		return 0, fmt.Errorf("repositório sem consulta de leads")
+	}
+	leads, err := s.leads.SearchLeads(ctx, domain.LeadFilter{CPF: req.CPF})
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	changed := 0
+	for _, lead := range leads {
+		var withdrawn []string
+		consents := make(map[string]domain.Consent, len(lead.Consents))
+		for purpose, consent := range lead.Consents {
+			if consent.Granted && (len(req.Purposes) == 0 || contains(req.Purposes, purpose)) {
+				consent = consent.Record(domain.ConsentChoice{Purpose: purpose}, domain.ConsentOriginWithdrawal, now)
+				withdrawn = append(withdrawn, purpose)
+			}
+			consents[purpose] = consent
+		}
+		if len(withdrawn) == 0 {
+			continue
+		}
+		sort.Strings(withdrawn)
+
+		lead.Consents = consents
+		if err := s.repo.Save(ctx, lead); err != nil {
+			return changed, fmt.Errorf("gravando retirada de consentimento: %w", err)
+		}
+		changed++
+		s.emit(ctx, domain.EventConsentWithdrawn, &lead, map[string]interface{}{"purposes": withdrawn})
+	}
+	log.Printf("[CONSENTIMENTO] Retirada para %s: %d lead(s) alterado(s)", maskCPF(req.CPF), changed)
+	return changed, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// EnableConsents expõe a exportação dos leads que aceitaram contato
+func (s *AdminService) EnableConsents(consents domain.ConsentRepository) {
+	s.consents = consents
+}
+
+// ConsentsEnabled indica se a exportação de marketing está disponível
+func (s *AdminService) ConsentsEnabled() bool {
+	return s.consents != nil
+}
+
+// ExportMarketingContacts contatos dos leads com o consentimento da
+// finalidade em vigor, um por condomínio + CPF (o lead mais recente).
+// Leads pseudonimizados ficam de fora, mesmo com o aceite gravado.
+func (s *AdminService) ExportMarketingContacts(ctx context.Context, p *auth.Principal, purpose, tenantID string) ([]domain.MarketingContact, error) {
+	event := domain.AuditEvent{
+		Actor:   p.Actor(),
+		Action:  domain.AuditActionExportMarketing,
+		Details: map[string]interface{}{"purpose": purpose},
+	}
+	var err error
+	event.TenantID, err = scopeTenant(p, tenantID)
+	if err == nil {
+		err = knownPurpose(ctx, purpose)
+	}
+	if err != nil {
+		s.record(ctx, event, err)
+		return nil, err
+	}
+
+	leads, err := s.consents.ConsentingLeads(ctx, purpose, event.TenantID)
+	if err != nil {
+		s.record(ctx, event, err)
+		return nil, err
+	}
+	contacts := []domain.MarketingContact{}
+	seen := map[string]bool{}
+	for _, lead := range leads {
+		key := domain.LeadID(lead.CondoID, onlyDigits(lead.CPF))
+		if domain.IsPseudonym(lead.CPF) || seen[key] {
+			continue
+		}
+		seen[key] = true
+		consent := lead.Consents[purpose]
+		contacts = append(contacts, domain.MarketingContact{
+			CPF:       lead.CPF,
+			CondoID:   lead.CondoID,
+			Name:      lead.Name,
+			Email:     lead.Email,
+			Phone:     lead.Phone,
+			Version:   consent.Version,
+			GrantedAt: consent.GrantedAt,
+		})
+	}
+
+	event.Details["results"] = len(contacts)
+	s.record(ctx, event, nil)
+	return contacts, nil
+}
+
+// knownPurpose confere a finalidade com privacy.consent.versions
+func knownPurpose(ctx context.Context, purpose string) error {
+	cfg := config.FromContext(ctx)
+	if cfg == nil {
+		cfg = config.Get()
+	}
+	if _, ok := cfg.Privacy.Consent.Versions[purpose]; !ok {
+		return &domain.ValidationError{
+			Message: "Finalidade de consentimento desconhecida.",
+			Fields:  map[string][]string{"purpose": {fmt.Sprintf("%q não está em privacy.consent.versions", purpose)}},
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"github.com/viplounge/platform/internal/auth"
+	"github.com/viplounge/platform/internal/domain"
+)
+
+// defaultRotationLimit documentos regravados por execução da recifragem
+const defaultRotationLimit = 1000
+
+// EnableEncryption expõe a recifragem dos leads (cifragem em repouso ligada)
+func (s *AdminService) EnableEncryption(rotator domain.LeadKeyRotator) {
+	s.rotator = rotator
+}
+
+// EncryptionEnabled indica se a recifragem está disponível na API
+func (s *AdminService) EncryptionEnabled() bool {
+	return s.rotator != nil
+}
+
+// RotateLeadKeys recifra com a KEK primária até limit documentos (leads e
+// tentativas) e migra os leads gravados antes da cifragem. Com Complete
+// false ainda há trabalho: basta executar de novo.
+func (s *AdminService) RotateLeadKeys(ctx context.Context, p *auth.Principal, reason string, limit int) (*domain.KeyRotation, error) {
+	if limit <= 0 {
+		limit = defaultRotationLimit
+	}
+	log.Printf("[CIFRAGEM] %s recifrando leads (limite %d)", p.Actor(), limit)
+	rotation, err := s.rotator.RotateLeadKeys(ctx, limit)
+
+	event := domain.AuditEvent{
+		Actor:   p.Actor(),
+		Action:  domain.AuditActionRotateKeys,
+		Reason:  reason,
+		Details: map[string]interface{}{"limit": limit},
+	}
+	if rotation != nil {
+		event.Details["scanned"] = rotation.Scanned
+		event.Details["resealed"] = rotation.Resealed
+		event.Details["migrated"] = rotation.Migrated
+		event.Details["attempts_resealed"] = rotation.AttemptsResealed
+		event.Details["complete"] = rotation.Complete
+	}
+	s.record(ctx, event, err)
+	return rotation, err
+}
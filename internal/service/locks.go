@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"sync"
+)
+
+// keyedLocks exclusão mútua por chave (CPF) dentro da instância. Entre
+// instâncias a proteção contra reenvios é a chave de idempotência.
+type keyedLocks struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	ch   chan struct{}
+	refs int
+}
+
+func newKeyedLocks() *keyedLocks {
+	return &keyedLocks{locks: make(map[string]*keyedLock)}
+}
+
+// Lock espera a vez da chave; o erro é o do ctx se ele acabar antes. unlock
+// deve ser chamado exatamente uma vez.
+func (k *keyedLocks) Lock(ctx context.Context, key string) (unlock func(), err error) {
+	k.mu.Lock()
+	l, ok := k.locks[key]
+	if !ok {
+		l = &keyedLock{ch: make(chan struct{}, 1)}
+		k.locks[key] = l
+	}
+	l.refs++
+	k.mu.Unlock()
+
+	select {
+	case l.ch <- struct{}{}:
+		return func() {
+			<-l.ch
+			k.release(key, l)
+		}, nil
+	case <-ctx.Done():
+		k.release(key, l)
+		return nil, ctx.Err()
+	}
+}
+
+// release descarta a entrada quando ninguém mais usa a chave
+func (k *keyedLocks) release(key string, l *keyedLock) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	l.refs--
+	if l.refs == 0 {
+		delete(k.locks, key)
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/viplounge/platform/internal/domain"
+)
+
+// PreRegister aplica a mesma árvore de decisão do ValidateAndSave a um CPF
+// importado em lote, sem a confirmação de e-mail: moradores elegíveis são
+// cadastrados direto na Rede Parcerias (sem gerar SSO), no clube principal do
+// condomínio quando ele tem mais de um.
+//
+// Diferente da landing page, uma falha em qualquer API retorna erro em vez de
+// seguir como "não encontrado", para não revogar ninguém por indisponibilidade.
+// Com dryRun nada é gravado nem enviado à Rede Parcerias.
+func (s *ValidationService) PreRegister(ctx context.Context, req domain.ValidationRequest, dryRun bool) (*domain.PreRegistration, error) {
+	lead := domain.Lead{
+		CPF:       req.CPF,
+		CondoID:   req.CondoID,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Origin:    "bulk_import",
+	}
+	result := &domain.PreRegistration{DryRun: dryRun}
+
+	found := s.lookup(ctx, &lead)
+	if found.superlogicaErr != nil {
+		return s.failPreRegistration(result, lead, fmt.Errorf("superlógica: %w", found.superlogicaErr))
+	}
+	if found.partnerErr != nil {
+		return s.failPreRegistration(result, lead, fmt.Errorf("rede parcerias: %w", found.partnerErr))
+	}
+	existsInPartner := found.partnerUser != nil
+
+	switch {
+	// CENÁRIO 1: Na Superlógica + NÃO na Rede Parcerias → CADASTRAR
+	case found.inSuperlogica && !existsInPartner:
+		result.Scenario = domain.ScenarioNewUser
+		result.Action = domain.ImportActionRegistered
+		if !dryRun {
+			start := time.Now()
+			err := found.club.Partner.RegisterUser(ctx, &lead)
+			lead.RedeParceriasResponseMs = time.Since(start).Milliseconds()
+			if errors.Is(err, domain.ErrPartnerUserExists) {
+				result.Action = domain.ImportActionAlreadyRegistered
+				err = nil
+			}
+			if err != nil {
+				lead.Status = domain.StatusError
+				lead.RedeParceriasStatus = domain.PartnerStatusFailed
+				lead.RedeParceriasError = err.Error()
+				s.savePreRegistration(ctx, lead, dryRun)
+				s.emit(ctx, domain.EventPartnerRegistrationFailed, &lead, map[string]interface{}{"error": err.Error()})
+				return s.failPreRegistration(result, lead, fmt.Errorf("cadastro na rede parcerias: %w", err))
+			}
+		}
+		lead.Status = domain.StatusApproved
+		lead.RedeParceriasStatus = domain.PartnerStatusRegistered
+
+	// CENÁRIO 2: Na Superlógica + JÁ na Rede Parcerias → NADA A FAZER
+	case found.inSuperlogica && existsInPartner:
+		result.Scenario = domain.ScenarioExistingUser
+		result.Action = domain.ImportActionAlreadyRegistered
+		lead.Status = domain.StatusApproved
+		lead.RedeParceriasStatus = domain.PartnerStatusRegistered
+		lead.RedeParceriasUserID = found.partnerUser.ID
+
+	// CENÁRIO 3: NÃO na Superlógica + NA Rede Parcerias → REVOGAR
+	case !found.inSuperlogica && existsInPartner:
+		result.Scenario = domain.ScenarioRevokedUser
+		result.Action = domain.ImportActionRevoked
+		lead.RedeParceriasUserID = found.partnerUser.ID
+		if !dryRun {
+			if err := found.club.Partner.DeleteUser(ctx, found.partnerUser.ID); err != nil {
+				return s.failPreRegistration(result, lead, fmt.Errorf("revogação na rede parcerias: %w", err))
+			}
+			s.revokeOtherClubs(ctx, &lead, found.club.ID)
+		}
+		lead.Status = domain.StatusRejected
+		lead.RedeParceriasStatus = domain.PartnerStatusRevoked
+
+	// CENÁRIO 4: NÃO existe em nenhum sistema
+	default:
+		result.Scenario = domain.ScenarioNotFound
+		result.Action = domain.ImportActionSkipped
+		lead.Status = domain.StatusRejected
+	}
+
+	s.savePreRegistration(ctx, lead, dryRun)
+	if !dryRun {
+		switch result.Action {
+		case domain.ImportActionRegistered:
+			s.emit(ctx, domain.EventLeadActivated, &lead, map[string]interface{}{"scenario": result.Scenario})
+		case domain.ImportActionRevoked:
+			s.emit(ctx, domain.EventLeadRevoked, &lead, nil)
+		}
+	}
+	result.Lead = lead
+	return result, nil
+}
+
+func (s *ValidationService) failPreRegistration(result *domain.PreRegistration, lead domain.Lead, err error) (*domain.PreRegistration, error) {
+	log.Printf("[IMPORT] CPF %s: %v", maskCPF(lead.CPF), err)
+	if result.Scenario == "" {
+		result.Scenario = domain.ScenarioError
+	}
+	result.Action = domain.ImportActionFailed
+	lead.Status = domain.StatusError
+	result.Lead = lead
+	return result, err
+}
+
+func (s *ValidationService) savePreRegistration(ctx context.Context, lead domain.Lead, dryRun bool) {
+	if dryRun || s.repo == nil {
+		return
+	}
+	if err := s.repo.Save(ctx, lead); err != nil {
+		log.Printf("[WARN] Erro ao salvar lead importado: %v", err)
+	}
+}
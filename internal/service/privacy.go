@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/viplounge/platform/internal/auth"
+	"github.com/viplounge/platform/internal/config"
+	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/secrets"
+)
+
+// retainedAfterErasure dados que não são indexados por CPF: saem pelo prazo
+// de retenção de cada coleção, não pela eliminação
+var retainedAfterErasure = []string{
+	"idempotency_keys: respostas repetidas durante a janela de idempotência",
+	"sso_grants: acessos de uso único ainda não usados",
+}
+
+// EnablePrivacy liga os pedidos do titular dos dados (LGPD): subjects
+// localiza e elimina os dados do CPF; secret assina a auditoria dos pedidos
+// e gera o pseudônimo que substitui o CPF
+func (s *AdminService) EnablePrivacy(subjects domain.SubjectRepository, secret secrets.Secret) {
+	s.subjects = subjects
+	s.privacySecret = secret
+}
+
+// PrivacyEnabled indica se os pedidos do titular estão disponíveis
+func (s *AdminService) PrivacyEnabled() bool {
+	return s.subjects != nil
+}
+
+// RetentionRunner executa o expurgo dos leads antigos (retention.Purger)
+type RetentionRunner interface {
+	Purge(ctx context.Context, policy config.Retention, trigger string, dryRun bool) (*domain.RetentionReport, error)
+}
+
+// EnableRetention expõe a execução do expurgo e os relatórios
+func (s *AdminService) EnableRetention(runner RetentionRunner, reports domain.RetentionRepository) {
+	s.retention = runner
+	s.retentionReports = reports
+}
+
+// RetentionEnabled indica se o expurgo está disponível na API
+func (s *AdminService) RetentionEnabled() bool {
+	return s.retention != nil
+}
+
+// RunRetention executa o expurgo na hora com as regras da configuração
+// (dryRun só conta o que seria expurgado)
+func (s *AdminService) RunRetention(ctx context.Context, p *auth.Principal, reason string, dryRun bool) (*domain.RetentionReport, error) {
+	cfg := config.FromContext(ctx)
+	if cfg == nil {
+		cfg = config.Get()
+	}
+	log.Printf("[RETENÇÃO] %s executando expurgo (dry_run=%t)", p.Actor(), dryRun)
+	report, err := s.retention.Purge(ctx, cfg.Privacy.Retention, p.Actor(), dryRun)
+
+	event := domain.AuditEvent{
+		Actor:   p.Actor(),
+		Action:  domain.AuditActionRetentionRun,
+		Reason:  reason,
+		Details: map[string]interface{}{"dry_run": dryRun},
+	}
+	if report != nil {
+		deleted, pseudonymized := 0, 0
+		for _, result := range report.Rules {
+			deleted += result.Deleted
+			pseudonymized += result.Pseudonymized
+		}
+		event.Details["deleted"] = deleted
+		event.Details["pseudonymized"] = pseudonymized
+	}
+	s.record(ctx, event, err)
+	return report, err
+}
+
+// ListRetentionReports relatórios do expurgo, mais recentes primeiro
+func (s *AdminService) ListRetentionReports(ctx context.Context, p *auth.Principal, limit int) ([]domain.RetentionReport, error) {
+	return s.retentionReports.ListRetentionReports(ctx, limit)
+}
+
+// ExportSubject reúne tudo o que a plataforma guarda sobre o CPF, em todos
+// os condomínios, mais o cadastro atual em cada Clube de Benefícios
+func (s *AdminService) ExportSubject(ctx context.Context, p *auth.Principal, cpf, reason string) (*domain.SubjectData, error) {
+	event := domain.AuditEvent{Actor: p.Actor(), Action: domain.AuditActionExportSubject, Reason: reason}
+	digits, pseudonym, err := s.subject(ctx, p, cpf)
+	if err != nil {
+		s.recordSigned(ctx, event, err)
+		return nil, err
+	}
+	event.CPF = pseudonym
+
+	log.Printf("[LGPD] %s exportando dados de %s", p.Actor(), maskCPF(digits))
+	data, err := s.subjects.ExportSubject(ctx, digits)
+	if err != nil {
+		s.recordSigned(ctx, event, err)
+		return nil, err
+	}
+
+	// Falha em um clube não impede exportar o que temos localmente
+	users := 0
+	for _, club := range allClubs(s.clubs, s.partner) {
+		entry := domain.SubjectClub{ClubID: club.ID}
+		entry.User, err = club.Partner.FindUserByCPF(ctx, digits)
+		if err != nil {
+			log.Printf("[LGPD] Falha consultando clube %s para %s: %v", club.ID, maskCPF(digits), err)
+			entry.Error = err.Error()
+		}
+		if entry.User != nil {
+			users++
+		}
+		if club.ID == domain.DefaultClubID {
+			data.PartnerUser, data.PartnerError = entry.User, entry.Error
+		}
+		data.Clubs = append(data.Clubs, entry)
+	}
+
+	event.Details = map[string]interface{}{
+		"leads":             len(data.Leads),
+		"attempts":          len(data.Attempts),
+		"audit":             len(data.Audit),
+		"residents":         len(data.Residents),
+		"directory_changes": len(data.DirectoryChanges),
+		"sessions":          len(data.Sessions),
+		"partner_user":      data.PartnerUser != nil,
+		"partner_users":     users,
+	}
+	s.recordSigned(ctx, event, nil)
+	return data, nil
+}
+
+// EraseSubject elimina os dados do CPF: tentativas, diretório, sessões e
+// webhooks são apagados; leads e auditoria ficam com o pseudônimo no lugar
+// do CPF. Com cascadePartner o cadastro em cada Clube de Benefícios é
+// removido antes, enquanto o CPF ainda localiza o usuário; se a remoção
+// falha em algum clube nada é apagado aqui.
+func (s *AdminService) EraseSubject(ctx context.Context, p *auth.Principal, cpf, reason string, cascadePartner bool) (*domain.SubjectErasure, error) {
+	event := domain.AuditEvent{Actor: p.Actor(), Action: domain.AuditActionEraseSubject, Reason: reason}
+	digits, pseudonym, err := s.subject(ctx, p, cpf)
+	if err != nil {
+		s.recordSigned(ctx, event, err)
+		return nil, err
+	}
+	event.CPF = pseudonym
+	event.Details = map[string]interface{}{"cascade_partner": cascadePartner}
+
+	log.Printf("[LGPD] %s eliminando dados de %s (cascade_partner=%t)", p.Actor(), maskCPF(digits), cascadePartner)
+	var clubs []domain.SubjectClub
+	partnerDeleted := false
+	if cascadePartner {
+		var errs []error
+		clubs, errs = s.eraseFromClubs(ctx, digits)
+		for _, club := range clubs {
+			partnerDeleted = partnerDeleted || club.Deleted
+		}
+		event.Details["clubs"] = subjectClubResults(clubs)
+		if len(errs) > 0 {
+			err = fmt.Errorf("falha ao remover cadastro nos clubes: %w", errors.Join(errs...))
+			event.Details["partner_user_deleted"] = partnerDeleted
+			s.recordSigned(ctx, event, err)
+			return nil, err
+		}
+	}
+
+	erasure, err := s.subjects.EraseSubject(ctx, digits, pseudonym)
+	if err != nil {
+		event.Details["partner_user_deleted"] = partnerDeleted
+		s.recordSigned(ctx, event, err)
+		return nil, err
+	}
+	erasure.PartnerUserDeleted = partnerDeleted
+	erasure.Clubs = clubs
+	erasure.Retained = retainedAfterErasure
+
+	event.Details["partner_user_deleted"] = partnerDeleted
+	event.Details["leads_anonymized"] = erasure.LeadsAnonymized
+	event.Details["attempts_deleted"] = erasure.AttemptsDeleted
+	event.Details["audit_anonymized"] = erasure.AuditAnonymized
+	event.Details["residents_deleted"] = erasure.ResidentsDeleted
+	event.Details["changes_deleted"] = erasure.ChangesDeleted
+	event.Details["sessions_deleted"] = erasure.SessionsDeleted
+	event.Details["webhook_events_deleted"] = erasure.WebhookEventsDeleted
+	event.Details["deliveries_deleted"] = erasure.DeliveriesDeleted
+	s.recordSigned(ctx, event, nil)
+	return erasure, nil
+}
+
+// eraseFromClubs remove o cadastro do CPF em todos os clubes configurados,
+// como a revogação faz nos clubes do condomínio; uma falha não interrompe
+// os demais clubes
+func (s *AdminService) eraseFromClubs(ctx context.Context, cpf string) ([]domain.SubjectClub, []error) {
+	var results []domain.SubjectClub
+	var errs []error
+	for _, club := range allClubs(s.clubs, s.partner) {
+		entry := domain.SubjectClub{ClubID: club.ID}
+		user, err := club.Partner.FindUserByCPF(ctx, cpf)
+		if err == nil && user != nil {
+			entry.User = user
+			err = club.Partner.DeleteUser(ctx, user.ID)
+			entry.Deleted = err == nil
+		}
+		if err != nil {
+			log.Printf("[LGPD] Falha removendo %s do clube %s: %v", maskCPF(cpf), club.ID, err)
+			entry.Error = err.Error()
+			errs = append(errs, fmt.Errorf("clube %s: %w", club.ID, err))
+		}
+		results = append(results, entry)
+	}
+	return results, errs
+}
+
+// subjectClubResults resultado de cada clube para a auditoria, sem os dados
+// do cadastro
+func subjectClubResults(clubs []domain.SubjectClub) map[string]interface{} {
+	results := map[string]interface{}{}
+	for _, club := range clubs {
+		switch {
+		case club.Error != "":
+			results[club.ClubID] = "error"
+		case club.Deleted:
+			results[club.ClubID] = "deleted"
+		default:
+			results[club.ClubID] = "not_found"
+		}
+	}
+	return results
+}
+
+// VerifyAuditSignature confere a assinatura de um evento de pedido do
+// titular lido do log de auditoria
+func (s *AdminService) VerifyAuditSignature(ctx context.Context, event domain.AuditEvent) (bool, error) {
+	if event.Signature == "" {
+		return false, nil
+	}
+	expected, err := s.auditSignature(ctx, event)
+	if err != nil {
+		return false, err
+	}
+	return hmac.Equal([]byte(event.Signature), []byte(expected)), nil
+}
+
+// pseudonymOf pseudônimo do CPF nos registros anonimizados
+func (s *AdminService) pseudonymOf(ctx context.Context, cpf string) (string, error) {
+	secret, err := s.privacySecret.Value(ctx)
+	if err != nil {
+		return "", fmt.Errorf("segredo da LGPD (%s) indisponível: %w", s.privacySecret.Ref(), err)
+	}
+	return domain.Pseudonym(secret, onlyDigits(cpf)), nil
+}
+
+// subject valida o pedido: só quem enxerga todos os condomínios atende o
+// titular, pois os dados dele podem estar em vários
+func (s *AdminService) subject(ctx context.Context, p *auth.Principal, cpf string) (digits, pseudonym string, err error) {
+	if !p.SeesAllTenants() {
+		return "", "", fmt.Errorf("pedidos do titular exigem acesso a todos os condomínios: %w", domain.ErrForbidden)
+	}
+	digits = onlyDigits(cpf)
+	if len(digits) != 11 {
+		return "", "", &domain.ValidationError{
+			Message: "CPF inválido.",
+			Fields:  map[string][]string{"cpf": {"deve ter 11 dígitos"}},
+		}
+	}
+	pseudonym, err = s.pseudonymOf(ctx, digits)
+	return digits, pseudonym, err
+}
+
+// recordSigned grava o evento do pedido do titular assinado. Sem o segredo
+// o evento é gravado sem assinatura, para o pedido não sumir da auditoria.
+func (s *AdminService) recordSigned(ctx context.Context, event domain.AuditEvent, err error) {
+	// O Firestore guarda microssegundos: a assinatura cobre o horário gravado
+	event.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	event.Success = err == nil
+	if err != nil {
+		event.Error = err.Error()
+	}
+	signature, signErr := s.auditSignature(ctx, event)
+	if signErr != nil {
+		log.Printf("[ERRO] Auditoria %s de %s gravada sem assinatura: %v", event.Action, event.Actor, signErr)
+	}
+	event.Signature = signature
+	if saveErr := s.audit.SaveAudit(ctx, event); saveErr != nil {
+		log.Printf("[ERRO] Falha gravando auditoria %s de %s: %v", event.Action, event.Actor, saveErr)
+	}
+}
+
+// auditSignature HMAC-SHA256 do evento em JSON, sem o ID (atribuído pelo
+// store) e sem a própria assinatura
+func (s *AdminService) auditSignature(ctx context.Context, event domain.AuditEvent) (string, error) {
+	secret, err := s.privacySecret.Value(ctx)
+	if err != nil {
+		return "", fmt.Errorf("segredo da LGPD (%s) indisponível: %w", s.privacySecret.Ref(), err)
+	}
+	event.ID = ""
+	event.Signature = ""
+	event.CreatedAt = event.CreatedAt.UTC()
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
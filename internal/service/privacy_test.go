@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/viplounge/platform/internal/auth"
+	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/repository"
+	"github.com/viplounge/platform/internal/secrets"
+)
+
+// brokenClub clube que encontra o usuário mas não consegue removê-lo
+type brokenClub struct {
+	*clubStub
+}
+
+func (c brokenClub) DeleteUser(ctx context.Context, userID string) error {
+	return errors.New("clube indisponível")
+}
+
+var platformAdmin = &auth.Principal{Subject: "admin", Method: "api_key", Roles: []auth.Role{auth.RolePlatformAdmin}}
+
+func newPrivacyService(t *testing.T, clubs condoClubs) (*AdminService, *repository.MemoryRepository) {
+	t.Helper()
+	repo := repository.NewMemoryRepository()
+	if err := repo.Save(context.Background(), domain.Lead{CPF: "11144477735", CondoID: "4", Name: "João"}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	admin := NewAdminService(repo, repo, clubs["4"][0].Partner)
+	admin.SetClubs(clubs)
+	admin.EnablePrivacy(repo, secrets.Static("segredo-da-lgpd"))
+	return admin, repo
+}
+
+// TestSubjectCoversAllClubs exportação e eliminação consultam e removem o
+// CPF em todos os clubes configurados, não só no default
+func TestSubjectCoversAllClubs(t *testing.T) {
+	ctx := context.Background()
+	standard, gold := newClubStub("11144477735"), newClubStub("11144477735")
+	admin, _ := newPrivacyService(t, condoClubs{"4": {{ID: domain.DefaultClubID, Partner: standard}, {ID: "ouro", Partner: gold}}})
+
+	data, err := admin.ExportSubject(ctx, platformAdmin, "111.444.777-35", "pedido do titular")
+	if err != nil {
+		t.Fatalf("ExportSubject: %v", err)
+	}
+	if len(data.Clubs) != 2 || data.Clubs[0].User == nil || data.Clubs[1].User == nil || data.PartnerUser == nil {
+		t.Errorf("export sem o cadastro de cada clube: %+v", data.Clubs)
+	}
+
+	erasure, err := admin.EraseSubject(ctx, platformAdmin, "11144477735", "pedido do titular", true)
+	if err != nil {
+		t.Fatalf("EraseSubject: %v", err)
+	}
+	if !erasure.PartnerUserDeleted || len(erasure.Clubs) != 2 || !erasure.Clubs[0].Deleted || !erasure.Clubs[1].Deleted {
+		t.Errorf("eliminação: %+v", erasure.Clubs)
+	}
+	for name, club := range map[string]*clubStub{"padrão": standard, "ouro": gold} {
+		if len(club.deleted) != 1 {
+			t.Errorf("clube %s: remoções %v", name, club.deleted)
+		}
+	}
+}
+
+// TestEraseSubjectStopsOnClubFailure uma falha em qualquer clube impede a
+// eliminação local, para o CPF continuar localizando o cadastro que restou
+func TestEraseSubjectStopsOnClubFailure(t *testing.T) {
+	ctx := context.Background()
+	standard := newClubStub("11144477735")
+	gold := brokenClub{newClubStub("11144477735")}
+	admin, repo := newPrivacyService(t, condoClubs{"4": {{ID: domain.DefaultClubID, Partner: standard}, {ID: "ouro", Partner: gold}}})
+
+	if _, err := admin.EraseSubject(ctx, platformAdmin, "11144477735", "pedido do titular", true); err == nil {
+		t.Fatalf("eliminação concluída com falha no clube ouro")
+	}
+	if len(standard.deleted) != 1 {
+		t.Errorf("clube padrão não removido: %v", standard.deleted)
+	}
+	if leads, _ := repo.SearchLeads(ctx, domain.LeadFilter{CPF: "11144477735"}); len(leads) != 1 {
+		t.Errorf("lead eliminado apesar da falha: %+v", leads)
+	}
+
+	events, _ := repo.ListAudit(ctx, domain.AuditFilter{})
+	if len(events) != 1 || events[0].Success || events[0].Details["clubs"] == nil {
+		t.Errorf("auditoria sem o resultado por clube: %+v", events)
+	}
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/secrets"
+)
+
+// ErrSessionInvalid cookie ausente, adulterado, de outro condomínio, vencido
+// ou revogado; o morador volta ao fluxo de CPF e e-mail
+var ErrSessionInvalid = errors.New("sessão inválida ou expirada")
+
+// SetSessions liga o "lembrar de mim" (remember_me): secret assina o cookie
+func (s *ValidationService) SetSessions(sessions domain.SessionRepository, secret secrets.Secret) {
+	s.sessions = sessions
+	s.sessionSecret = secret
+}
+
+// startSession cria a sessão do morador recém-ativado e retorna o valor do
+// cookie: identificador aleatório e HMAC do identificador com o condomínio
+func (s *ValidationService) startSession(ctx context.Context, condoID string, lead *domain.Lead, clubs []domain.SessionClub) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("gerando sessão: %w", err)
+	}
+	id := base64.RawURLEncoding.EncodeToString(buf)
+	mac, err := s.sessionMAC(ctx, id, condoID)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	session := domain.Session{
+		ID:          handleHash(id),
+		CPF:         onlyDigits(lead.CPF),
+		CondoID:     condoID,
+		LeadCondoID: lead.CondoID,
+		Clubs:       clubs,
+		CreatedAt:   now,
+		ValidatedAt: now,
+		ExpiresAt:   now.AddDate(0, 0, s.config(ctx).RememberMe.TTLDays),
+	}
+	if err := s.sessions.CreateSession(ctx, session); err != nil {
+		return "", err
+	}
+	return id + "." + mac, nil
+}
+
+// rememberActivation emite a sessão quando o condomínio usa o "lembrar de
+// mim" e a ativação gerou acesso a pelo menos um clube
+func (s *ValidationService) rememberActivation(ctx context.Context, condoID string, lead *domain.Lead, club domain.Club, response *domain.ValidationResponse) {
+	if s.sessions == nil || !s.config(ctx).RememberMeEnabled(condoID) {
+		return
+	}
+
+	var clubs []domain.SessionClub
+	if response.Scenario == domain.ScenarioClubPicker {
+		for _, access := range response.Clubs {
+			if access.RedirectURL != "" && access.UserID != "" {
+				clubs = append(clubs, domain.SessionClub{ClubID: access.ID, PartnerUserID: access.UserID})
+			}
+		}
+	} else if response.RedirectURL != "" && lead.RedeParceriasUserID != "" {
+		clubs = append(clubs, domain.SessionClub{ClubID: club.ID, PartnerUserID: lead.RedeParceriasUserID})
+	}
+	if len(clubs) == 0 {
+		return
+	}
+
+	token, err := s.startSession(ctx, condoID, lead, clubs)
+	if err != nil {
+		// Sem sessão o morador só volta a digitar CPF e e-mail
+		log.Printf("[SESSÃO] Erro criando sessão: %v", err)
+		return
+	}
+	response.Session = token
+	response.RememberMe = true
+}
+
+// RefreshSession gera um novo acesso aos clubes da sessão, sem CPF e e-mail.
+// condoID é o condomínio do host: a sessão só vale no condomínio em que foi
+// criada. A cada remember_me.revalidate_hours o CPF é conferido de novo na
+// Superlógica; quem saiu do condomínio tem a sessão revogada.
+func (s *ValidationService) RefreshSession(ctx context.Context, token, condoID string) (*domain.ValidationResponse, error) {
+	cfg := s.config(ctx)
+	if s.sessions == nil || !cfg.RememberMeEnabled(condoID) {
+		return nil, ErrSessionInvalid
+	}
+	session, err := s.session(ctx, token, condoID)
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Since(session.ValidatedAt) > time.Duration(cfg.RememberMe.RevalidateHours)*time.Hour {
+		log.Printf("[SESSÃO] Revalidando CPF %s na Superlógica...", maskCPF(session.CPF))
+		found, _, err := s.validator.ValidateMember(ctx, session.CondoID, session.CPF)
+		if err != nil {
+			// Sem resposta da Superlógica a sessão continua para a próxima tentativa
+			return nil, fmt.Errorf("revalidando sessão: %w", err)
+		}
+		if !found {
+			log.Printf("[SESSÃO] CPF %s não está mais na Superlógica - sessão revogada", maskCPF(session.CPF))
+			s.revokeSession(ctx, session.ID)
+			return nil, ErrSessionInvalid
+		}
+		session.ValidatedAt = time.Now()
+		if err := s.sessions.SaveSession(ctx, *session); err != nil {
+			log.Printf("[WARN] Erro ao gravar revalidação da sessão: %v", err)
+		}
+	}
+
+	// Os clubes são os do condomínio do lead, como na ativação (sessões
+	// anteriores a lead_condo_id usam o do host)
+	leadCondo := session.LeadCondoID
+	if leadCondo == "" {
+		leadCondo = session.CondoID
+	}
+	lead := &domain.Lead{CPF: session.CPF, CondoID: leadCondo}
+	available := s.clubsFor(ctx, leadCondo)
+	var accesses []domain.ClubAccess
+	var lastErr error
+	for _, entry := range session.Clubs {
+		club, ok := findClub(available, entry.ClubID)
+		if !ok {
+			continue
+		}
+		access := domain.ClubAccess{ID: club.ID, Name: club.Name, UserID: entry.PartnerUserID}
+		redirect, err := s.refreshClub(ctx, lead, club, entry.PartnerUserID)
+		if err != nil {
+			log.Printf("[SESSÃO] Falha ao gerar acesso ao clube %s: %v", club.ID, err)
+			access.Error = activationFailureMessage(err, "Houve um erro ao gerar seu acesso. Tente novamente.")
+			lastErr = err
+		}
+		access.RedirectURL = redirect
+		accesses = append(accesses, access)
+	}
+
+	switch {
+	case len(accesses) == 0:
+		// Nenhum clube da sessão atende mais o condomínio
+		s.revokeSession(ctx, session.ID)
+		return nil, ErrSessionInvalid
+	case len(accesses) == 1 && lastErr != nil:
+		if errors.Is(lastErr, domain.ErrNotFound) {
+			// Removido do clube: a sessão não serve mais
+			s.revokeSession(ctx, session.ID)
+			return nil, ErrSessionInvalid
+		}
+		return nil, lastErr
+	case len(accesses) == 1:
+		return &domain.ValidationResponse{
+			Valid:       true,
+			Scenario:    domain.ScenarioExistingUser,
+			UserID:      accesses[0].UserID,
+			RedirectURL: accesses[0].RedirectURL,
+			Message:     "Bem-vindo de volta! Redirecionando...",
+		}, nil
+	default:
+		return &domain.ValidationResponse{
+			Valid:    true,
+			Scenario: domain.ScenarioClubPicker,
+			Clubs:    accesses,
+			Message:  "Bem-vindo de volta! Escolha qual Clube de Benefícios deseja acessar.",
+		}, nil
+	}
+}
+
+// refreshClub gera o acesso direto pelo ID do morador no clube
+func (s *ValidationService) refreshClub(ctx context.Context, lead *domain.Lead, club domain.Club, partnerUserID string) (string, error) {
+	sso, err := club.Partner.GetSSOToken(ctx, partnerUserID)
+	if err != nil {
+		return "", err
+	}
+	if sso == nil || sso.Redirect == "" {
+		return "", domain.UnavailableError(club.ID, "sso", errors.New("acesso sem redirect"))
+	}
+	return s.IssueSSO(ctx, lead, club, sso)
+}
+
+// EndSession revoga a sessão do cookie (logout). Cookie inválido não é erro.
+func (s *ValidationService) EndSession(ctx context.Context, token, condoID string) {
+	if s.sessions == nil {
+		return
+	}
+	session, err := s.session(ctx, token, condoID)
+	if err != nil {
+		return
+	}
+	log.Printf("[SESSÃO] Logout do CPF %s", maskCPF(session.CPF))
+	s.revokeSession(ctx, session.ID)
+}
+
+// session confere a assinatura do cookie e busca a sessão
+func (s *ValidationService) session(ctx context.Context, token, condoID string) (*domain.Session, error) {
+	id, mac, ok := strings.Cut(token, ".")
+	if !ok || id == "" {
+		return nil, ErrSessionInvalid
+	}
+	expected, err := s.sessionMAC(ctx, id, condoID)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal([]byte(mac), []byte(expected)) {
+		return nil, ErrSessionInvalid
+	}
+
+	session, err := s.sessions.GetSession(ctx, handleHash(id))
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, ErrSessionInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if session.CondoID != condoID || time.Now().After(session.ExpiresAt) {
+		return nil, ErrSessionInvalid
+	}
+	return session, nil
+}
+
+func (s *ValidationService) revokeSession(ctx context.Context, id string) {
+	if err := s.sessions.DeleteSession(ctx, id); err != nil {
+		log.Printf("[WARN] Erro ao revogar sessão: %v", err)
+	}
+}
+
+// sessionMAC assina o identificador junto com o condomínio, para o cookie
+// de um condomínio não valer em outro
+func (s *ValidationService) sessionMAC(ctx context.Context, id, condoID string) (string, error) {
+	secret, err := s.sessionSecret.Value(ctx)
+	if err != nil {
+		return "", fmt.Errorf("segredo da sessão (%s) indisponível: %w", s.sessionSecret.Ref(), err)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(id + "\n" + condoID))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
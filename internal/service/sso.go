@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/viplounge/platform/internal/domain"
+)
+
+// SetSSOGrants troca o store dos handles de acesso (por padrão o próprio
+// repositório de leads, quando implementa domain.SSOGrantRepository)
+func (s *ValidationService) SetSSOGrants(grants domain.SSOGrantRepository) {
+	s.grants = grants
+}
+
+// IssueSSO guarda o redirecionamento do clube e retorna o caminho de uso
+// único que o frontend (ou o suporte) recebe no lugar dele
+func (s *ValidationService) IssueSSO(ctx context.Context, lead *domain.Lead, club domain.Club, sso *domain.SSOToken) (string, error) {
+	if s.grants == nil {
+		return "", fmt.Errorf("store de acessos SSO não configurado")
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("gerando handle SSO: %w", err)
+	}
+	handle := base64.RawURLEncoding.EncodeToString(buf)
+
+	now := time.Now()
+	grant := domain.SSOGrant{
+		ID:        handleHash(handle),
+		Redirect:  sso.Redirect,
+		ClubID:    club.ID,
+		CondoID:   lead.CondoID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Duration(s.config(ctx).SSO.HandleTTLSeconds) * time.Second),
+	}
+	if err := s.grants.CreateSSOGrant(ctx, grant); err != nil {
+		return "", err
+	}
+	return domain.SSOPath + handle, nil
+}
+
+// ResolveSSO troca o handle pela URL do clube. Cada handle vale uma vez;
+// usado, expirado ou desconhecido retorna domain.ErrNotFound.
+func (s *ValidationService) ResolveSSO(ctx context.Context, handle string) (string, error) {
+	if s.grants == nil || handle == "" {
+		return "", domain.ErrNotFound
+	}
+	grant, err := s.grants.ConsumeSSOGrant(ctx, handleHash(handle))
+	if err != nil {
+		return "", err
+	}
+	if time.Now().After(grant.ExpiresAt) {
+		return "", domain.ErrNotFound
+	}
+	return grant.Redirect, nil
+}
+
+// handleHash o store guarda só o hash dos handles e das sessões: quem lê o
+// banco não consegue montar o link de acesso nem o cookie
+func handleHash(handle string) string {
+	sum := sha256.Sum256([]byte(handle))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,12 +2,14 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
 	"github.com/viplounge/platform/internal/config"
 	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/secrets"
 )
 
 type ValidationService struct {
@@ -15,18 +17,119 @@ type ValidationService struct {
 	validator domain.BenefValidator
 	partner   domain.PartnerService
 	cfg       *config.Config
+	// events publica o ciclo de vida do lead nos webhooks de saída (opcional)
+	events domain.EventPublisher
+	// clubs resolve os Clubes de Benefícios de cada condomínio (opcional;
+	// sem ele todo condomínio usa apenas partner)
+	clubs domain.ClubRegistry
+	// activations serializa as ativações do mesmo CPF, para duas
+	// confirmações simultâneas não cadastrarem o morador duas vezes
+	activations *keyedLocks
+	// grants guarda a URL do clube até o morador ser redirecionado por
+	// /v1/sso/{handle}
+	grants domain.SSOGrantRepository
+	// sessions sessões "lembrar de mim" (opcional), com o cookie assinado
+	// por sessionSecret
+	sessions      domain.SessionRepository
+	sessionSecret secrets.Secret
+	// leads localiza os leads do CPF na retirada de consentimento
+	leads domain.LeadStore
 }
 
 func NewValidationService(repo domain.LeadRepository, validator domain.BenefValidator, partner domain.PartnerService, cfg *config.Config) *ValidationService {
 	if cfg == nil {
 		cfg = config.Get()
 	}
-	return &ValidationService{
-		repo:      repo,
-		validator: validator,
-		partner:   partner,
-		cfg:       cfg,
+	s := &ValidationService{
+		repo:        repo,
+		validator:   validator,
+		partner:     partner,
+		cfg:         cfg,
+		activations: newKeyedLocks(),
 	}
+	if grants, ok := repo.(domain.SSOGrantRepository); ok {
+		s.grants = grants
+	}
+	if leads, ok := repo.(domain.LeadStore); ok {
+		s.leads = leads
+	}
+	return s
+}
+
+// SetPublisher liga a emissão de eventos para os webhooks de saída
+func (s *ValidationService) SetPublisher(events domain.EventPublisher) {
+	s.events = events
+}
+
+// SetClubs liga a resolução dos clubes por condomínio (tenants[].clubs)
+func (s *ValidationService) SetClubs(clubs domain.ClubRegistry) {
+	s.clubs = clubs
+}
+
+// clubsFor retorna os clubes do condomínio, o principal primeiro
+func (s *ValidationService) clubsFor(ctx context.Context, condoID string) []domain.Club {
+	return resolveClubs(ctx, s.clubs, s.partner, condoID)
+}
+
+// resolveClubs clubes do condomínio segundo o registry ou, sem ele, apenas
+// partner como o clube padrão
+func resolveClubs(ctx context.Context, registry domain.ClubRegistry, partner domain.PartnerService, condoID string) []domain.Club {
+	if registry != nil {
+		if clubs := registry.ClubsFor(ctx, condoID); len(clubs) > 0 {
+			return clubs
+		}
+	}
+	return []domain.Club{{ID: domain.DefaultClubID, Name: "Clube de Benefícios", Partner: partner}}
+}
+
+// allClubs todos os clubes do registry ou, sem ele, apenas partner como o
+// clube padrão (pedidos por CPF, sem condomínio)
+func allClubs(registry domain.ClubRegistry, partner domain.PartnerService) []domain.Club {
+	if registry != nil {
+		if clubs := registry.Clubs(); len(clubs) > 0 {
+			return clubs
+		}
+	}
+	return []domain.Club{{ID: domain.DefaultClubID, Name: "Clube de Benefícios", Partner: partner}}
+}
+
+// emit publica um evento do lead; extra complementa os dados padrão
+func (s *ValidationService) emit(ctx context.Context, eventType string, lead *domain.Lead, extra map[string]interface{}) {
+	publishLead(ctx, s.events, eventType, lead, extra)
+}
+
+// publishLead publica o evento com os dados padrão do lead, os mesmos em
+// todos os fluxos que alteram o acesso (público, suporte e webhooks)
+func publishLead(ctx context.Context, events domain.EventPublisher, eventType string, lead *domain.Lead, extra map[string]interface{}) {
+	if events == nil {
+		return
+	}
+	data := map[string]interface{}{
+		"cpf":             lead.CPF,
+		"condo_id":        lead.CondoID,
+		"name":            lead.Name,
+		"email":           lead.Email,
+		"status":          lead.Status,
+		"partner_status":  lead.RedeParceriasStatus,
+		"partner_user_id": lead.RedeParceriasUserID,
+		"origin":          lead.Origin,
+	}
+	for k, v := range extra {
+		data[k] = v
+	}
+	events.Publish(ctx, domain.OutboundEvent{
+		Type:     eventType,
+		TenantID: lead.CondoID,
+		Data:     data,
+	})
+}
+
+// config retorna a versão da configuração fixada na requisição (hot reload)
+func (s *ValidationService) config(ctx context.Context) *config.Config {
+	if cfg := config.FromContext(ctx); cfg != nil {
+		return cfg
+	}
+	return s.cfg
 }
 
 // ValidateAndSave implementa a ÁRVORE DE DECISÃO completa
@@ -42,36 +145,24 @@ func (s *ValidationService) ValidateAndSave(ctx context.Context, req domain.Vali
 		Origin:    "landing_page",
 	}
 
-	// ===== PASSO 1: Verificar na Superlógica =====
-	log.Printf("[VALIDAÇÃO] Verificando CPF %s na Superlógica...", maskCPF(req.CPF))
-	
-	existsInSuperlogica, superlogicaData, superlogicaErr := s.validator.ValidateMember(ctx, req.CondoID, req.CPF)
-	
-	if superlogicaErr != nil {
-		log.Printf("[ERRO] Falha na Superlógica: %v", superlogicaErr)
-		// Não bloquear fluxo, continuar verificação na Rede Parcerias
-	}
-
-	if existsInSuperlogica && superlogicaData != nil {
-		lead.Name = superlogicaData.Name
-		lead.Email = superlogicaData.Email
-		lead.Phone = superlogicaData.Phone
-		lead.SuperlogicaFound = true
-		lead.SuperlogicaResponseMs = superlogicaData.SuperlogicaResponseMs
-	}
-
-	// ===== PASSO 2: Verificar na Rede Parcerias =====
-	log.Printf("[VALIDAÇÃO] Verificando CPF %s na Rede Parcerias...", maskCPF(req.CPF))
-	
-	var existsInPartner bool
-	var partnerUser *domain.PartnerUser
-	
-	partnerUser, partnerErr := s.partner.FindUserByCPF(ctx, req.CPF)
-	if partnerErr != nil {
-		log.Printf("[WARN] Erro ao verificar Rede Parcerias: %v", partnerErr)
-		// Continuar fluxo assumindo que não existe
+	found := s.lookup(ctx, &lead)
+	// Sem cadastro na Superlógica o lead não tem dados do morador: o aceite
+	// vale já aqui; do morador, só depois de confirmar o e-mail
+	s.recordConsents(ctx, &lead, req.Consents, !found.inSuperlogica)
+	if err := found.superlogicaErr; err != nil {
+		// Sem a Superlógica (instável ou desligada) não há decisão: tratar
+		// como "não encontrado" revogaria membros do clube
+		lead.Status = domain.StatusError
+		lead.Scenario = domain.ScenarioError
+		if s.repo != nil {
+			if saveErr := s.repo.Save(ctx, lead); saveErr != nil {
+				log.Printf("[WARN] Erro ao salvar lead: %v", saveErr)
+			}
+		}
+		return nil, fmt.Errorf("consulta à Superlógica: %w", err)
 	}
-	existsInPartner = partnerUser != nil
+	existsInSuperlogica, partnerUser := found.inSuperlogica, found.partnerUser
+	existsInPartner := partnerUser != nil
 
 	// ===== PASSO 3: ÁRVORE DE DECISÃO =====
 	log.Printf("[DECISÃO] Superlógica=%v, RedeParcerias=%v", existsInSuperlogica, existsInPartner)
@@ -90,7 +181,7 @@ func (s *ValidationService) ValidateAndSave(ctx context.Context, req domain.Vali
 	// CENÁRIO 3: NÃO na Superlógica + NA Rede Parcerias → REVOGAR ACESSO
 	case !existsInSuperlogica && existsInPartner:
 		log.Printf("[CENÁRIO] REVOGAR ACESSO - Usuário não está mais na Superlógica")
-		response = s.handleRevokedUser(ctx, &lead, partnerUser)
+		response = s.handleRevokedUser(ctx, &lead, found.club, partnerUser)
 
 	// CENÁRIO 4: NÃO existe em nenhum sistema → MARKETING
 	default:
@@ -99,6 +190,7 @@ func (s *ValidationService) ValidateAndSave(ctx context.Context, req domain.Vali
 	}
 
 	// ===== PASSO 4: Salvar lead para analytics =====
+	lead.Scenario = response.Scenario
 	if s.repo != nil {
 		if err := s.repo.Save(ctx, lead); err != nil {
 			log.Printf("[WARN] Erro ao salvar lead: %v", err)
@@ -108,6 +200,70 @@ func (s *ValidationService) ValidateAndSave(ctx context.Context, req domain.Vali
 	return response, nil
 }
 
+// lookupResult resultado dos passos 1 e 2 da árvore de decisão
+type lookupResult struct {
+	inSuperlogica bool
+	// club onde o CPF está cadastrado (ou o principal, se em nenhum)
+	club           domain.Club
+	partnerUser    *domain.PartnerUser
+	superlogicaErr error
+	partnerErr     error
+}
+
+// lookup executa os passos 1 e 2 da árvore de decisão: preenche o lead com
+// os dados da Superlógica e busca o usuário na Rede Parcerias.
+// Na landing page uma falha na Superlógica interrompe o fluxo e uma falha no
+// clube conta como "não cadastrado" (a confirmação de e-mail consulta o clube
+// de novo); a importação em lote usa os dois erros para não decidir às cegas.
+func (s *ValidationService) lookup(ctx context.Context, lead *domain.Lead) lookupResult {
+	// ===== PASSO 1: Verificar na Superlógica =====
+	log.Printf("[VALIDAÇÃO] Verificando CPF %s na Superlógica...", maskCPF(lead.CPF))
+	
+	existsInSuperlogica, superlogicaData, superlogicaErr := s.validator.ValidateMember(ctx, lead.CondoID, lead.CPF)
+	
+	if superlogicaErr != nil {
+		log.Printf("[ERRO] Falha na Superlógica: %v", superlogicaErr)
+		// Não bloquear fluxo, continuar verificação na Rede Parcerias
+	}
+
+	if existsInSuperlogica && superlogicaData != nil {
+		lead.Name = superlogicaData.Name
+		lead.Email = superlogicaData.Email
+		lead.Phone = superlogicaData.Phone
+		lead.SuperlogicaFound = true
+		lead.SuperlogicaResponseMs = superlogicaData.SuperlogicaResponseMs
+	}
+
+	// ===== PASSO 2: Verificar na Rede Parcerias =====
+	log.Printf("[VALIDAÇÃO] Verificando CPF %s na Rede Parcerias...", maskCPF(lead.CPF))
+
+	// Com vários clubes no condomínio vale o primeiro em que o CPF está cadastrado
+	clubs := s.clubsFor(ctx, lead.CondoID)
+	result := lookupResult{
+		inSuperlogica:  existsInSuperlogica,
+		club:           clubs[0],
+		superlogicaErr: superlogicaErr,
+	}
+	for _, club := range clubs {
+		partnerUser, partnerErr := club.Partner.FindUserByCPF(ctx, lead.CPF)
+		if partnerErr != nil {
+			log.Printf("[WARN] Erro ao verificar clube %s: %v", club.ID, partnerErr)
+			// Continuar fluxo assumindo que não existe
+			if result.partnerErr == nil {
+				result.partnerErr = partnerErr
+			}
+			continue
+		}
+		if partnerUser != nil {
+			result.club = club
+			result.partnerUser = partnerUser
+			break
+		}
+	}
+
+	return result
+}
+
 // handleNewUser - CPF na Superlógica, NÃO na Rede Parcerias
 // Ação: Retornar pending_email_confirmation para validação em duas etapas
 func (s *ValidationService) handleNewUser(ctx context.Context, lead *domain.Lead) *domain.ValidationResponse {
@@ -126,6 +282,7 @@ func (s *ValidationService) handleNewUser(ctx context.Context, lead *domain.Lead
 
 	lead.Status = domain.StatusPending // Status pendente até confirmar e-mail
 	lead.RedeParceriasStatus = domain.PartnerStatusPending
+	s.emit(ctx, domain.EventLeadValidated, lead, map[string]interface{}{"scenario": domain.ScenarioNewUser})
 
 	return response
 }
@@ -148,13 +305,14 @@ func (s *ValidationService) handleExistingUser(ctx context.Context, lead *domain
 	lead.Status = domain.StatusPending // Status pendente até confirmar e-mail
 	lead.RedeParceriasStatus = domain.PartnerStatusRegistered
 	lead.RedeParceriasUserID = partnerUser.ID
+	s.emit(ctx, domain.EventLeadValidated, lead, map[string]interface{}{"scenario": domain.ScenarioExistingUser})
 
 	return response
 }
 
 // handleRevokedUser - CPF NÃO na Superlógica, mas NA Rede Parcerias
 // Ação: Revogar acesso e mostrar marketing
-func (s *ValidationService) handleRevokedUser(ctx context.Context, lead *domain.Lead, partnerUser *domain.PartnerUser) *domain.ValidationResponse {
+func (s *ValidationService) handleRevokedUser(ctx context.Context, lead *domain.Lead, club domain.Club, partnerUser *domain.PartnerUser) *domain.ValidationResponse {
 	response := &domain.ValidationResponse{
 		Valid:          false,
 		Scenario:       domain.ScenarioRevokedUser,
@@ -163,18 +321,47 @@ func (s *ValidationService) handleRevokedUser(ctx context.Context, lead *domain.
 	}
 
 	// Desativar na Rede Parcerias
-	if err := s.partner.DeleteUser(ctx, partnerUser.ID); err != nil {
-		log.Printf("[WARN] Falha ao revogar acesso: %v", err)
+	revokeErr := club.Partner.DeleteUser(ctx, partnerUser.ID)
+	if revokeErr != nil {
+		log.Printf("[WARN] Falha ao revogar acesso: %v", revokeErr)
 	} else {
 		log.Printf("[INFO] Acesso revogado com sucesso para user %s", partnerUser.ID)
 	}
+	s.revokeOtherClubs(ctx, lead, club.ID)
 
 	lead.Status = domain.StatusRejected
 	lead.RedeParceriasStatus = domain.PartnerStatusRevoked
+	lead.RedeParceriasUserID = partnerUser.ID
+	if revokeErr == nil {
+		s.emit(ctx, domain.EventLeadRevoked, lead, nil)
+	}
 
 	return response
 }
 
+// revokeOtherClubs revoga o acesso nos demais clubes do condomínio. Falhas
+// só são registradas no log, como na revogação principal.
+func (s *ValidationService) revokeOtherClubs(ctx context.Context, lead *domain.Lead, revokedClub string) {
+	for _, club := range s.clubsFor(ctx, lead.CondoID) {
+		if club.ID == revokedClub {
+			continue
+		}
+		user, err := club.Partner.FindUserByCPF(ctx, lead.CPF)
+		if err != nil {
+			log.Printf("[WARN] Erro ao verificar clube %s na revogação: %v", club.ID, err)
+			continue
+		}
+		if user == nil {
+			continue
+		}
+		if err := club.Partner.DeleteUser(ctx, user.ID); err != nil {
+			log.Printf("[WARN] Falha ao revogar acesso no clube %s: %v", club.ID, err)
+		} else {
+			log.Printf("[INFO] Acesso revogado no clube %s para user %s", club.ID, user.ID)
+		}
+	}
+}
+
 // handleNotFound - CPF não existe em nenhum sistema
 // Ação: Mostrar marketing para atrair novo cliente
 func (s *ValidationService) handleNotFound(ctx context.Context, lead *domain.Lead) *domain.ValidationResponse {
@@ -198,17 +385,26 @@ func (s *ValidationService) ConfirmEmailAndActivate(ctx context.Context, req dom
 	// Preparar lead para tracking
 	lead := domain.Lead{
 		CPF:       req.CPF,
+		CondoID:   req.CondoID,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 		Origin:    "email_confirmation",
 	}
+	condoID := req.CondoID
+	if condoID == "" {
+		condoID = s.config(ctx).Behavior.DefaultCondoID
+	}
 
 	// ===== PASSO 1: Verificar na Superlógica novamente =====
 	log.Printf("[CONFIRMAÇÃO] Verificando CPF %s na Superlógica...", maskCPF(req.CPF))
 	
-	existsInSuperlogica, superlogicaData, superlogicaErr := s.validator.ValidateMember(ctx, s.cfg.Behavior.DefaultCondoID, req.CPF)
-	
-	if superlogicaErr != nil || !existsInSuperlogica || superlogicaData == nil {
+	existsInSuperlogica, superlogicaData, superlogicaErr := s.validator.ValidateMember(ctx, condoID, req.CPF)
+	if superlogicaErr != nil {
+		log.Printf("[ERRO] Falha na Superlógica na confirmação: %v", superlogicaErr)
+		return nil, fmt.Errorf("consulta à Superlógica: %w", superlogicaErr)
+	}
+
+	if !existsInSuperlogica || superlogicaData == nil {
 		log.Printf("[ERRO] CPF não encontrado na confirmação: %v", superlogicaErr)
 		response.Valid = false
 		response.Scenario = domain.ScenarioError
@@ -220,6 +416,11 @@ func (s *ValidationService) ConfirmEmailAndActivate(ctx context.Context, req dom
 	lead.Email = superlogicaData.Email
 	lead.Phone = superlogicaData.Phone
 	lead.SuperlogicaFound = true
+	// Na busca global o lead (e os eventos dele) fica no condomínio real do
+	// morador, para os webhooks e o suporte do condomínio o encontrarem
+	if (lead.CondoID == "" || lead.CondoID == "-1") && superlogicaData.CondoID != "" {
+		lead.CondoID = superlogicaData.CondoID
+	}
 
 	// ===== PASSO 2: Validar e-mail digitado =====
 	log.Printf("[VALIDAÇÃO] Comparando e-mail fornecido com cadastrado...")
@@ -234,6 +435,7 @@ func (s *ValidationService) ConfirmEmailAndActivate(ctx context.Context, req dom
 		response.Scenario = domain.ScenarioError
 		response.Message = "E-mail incorreto. Por favor, verifique o e-mail cadastrado."
 		lead.Status = domain.StatusRejected
+		lead.Scenario = response.Scenario
 		
 		// Salvar tentativa falha para auditoria
 		if s.repo != nil {
@@ -244,26 +446,42 @@ func (s *ValidationService) ConfirmEmailAndActivate(ctx context.Context, req dom
 	}
 
 	log.Printf("[SUCESSO] E-mail confirmado! Prosseguindo com ativação...")
-
-	// ===== PASSO 3: Verificar se já existe na Rede Parcerias =====
-	partnerUser, partnerErr := s.partner.FindUserByCPF(ctx, req.CPF)
-	if partnerErr != nil {
-		log.Printf("[WARN] Erro ao verificar Rede Parcerias: %v", partnerErr)
+	s.recordConsents(ctx, &lead, req.Consents, true)
+
+	// ===== PASSO 3: Clubes do condomínio =====
+	// Os do condomínio do lead (o real, na busca global), os mesmos que o
+	// suporte e os webhooks usam depois para revogar ou recadastrar
+	clubs := s.clubsFor(ctx, lead.CondoID)
+	if req.ClubID != "" {
+		club, ok := findClub(clubs, req.ClubID)
+		if !ok {
+			log.Printf("[ERRO] Clube %q não atende o condomínio %s", req.ClubID, lead.CondoID)
+			response.Valid = false
+			response.Scenario = domain.ScenarioError
+			response.Message = "Clube de Benefícios inválido. Por favor, inicie o processo novamente."
+			return response, nil
+		}
+		clubs = []domain.Club{club}
 	}
-	existsInPartner := partnerUser != nil
 
 	// ===== PASSO 4: Ativar usuário =====
-	if existsInPartner {
-		// Usuário já existe - apenas gerar SSO
-		log.Printf("[ATIVAÇÃO] Usuário já existe - gerando SSO")
-		response = s.activateExistingUser(ctx, &lead, partnerUser)
+	// Uma ativação por vez para o mesmo CPF: a segunda já encontra o
+	// morador cadastrado pela primeira e só gera o acesso
+	unlock, err := s.activations.Lock(ctx, onlyDigits(req.CPF))
+	if err != nil {
+		return nil, fmt.Errorf("aguardando ativação em andamento do CPF %s: %w", maskCPF(req.CPF), err)
+	}
+	defer unlock()
+
+	if len(clubs) > 1 {
+		response = s.activateClubs(ctx, &lead, clubs)
 	} else {
-		// Novo usuário - cadastrar e gerar SSO
-		log.Printf("[ATIVAÇÃO] Novo usuário - cadastrando e gerando SSO")
-		response = s.activateNewUser(ctx, &lead)
+		response = s.activate(ctx, &lead, clubs[0])
 	}
+	s.rememberActivation(ctx, req.CondoID, &lead, clubs[0], response)
 
 	// ===== PASSO 5: Salvar lead para analytics =====
+	lead.Scenario = response.Scenario
 	if s.repo != nil {
 		if err := s.repo.Save(ctx, lead); err != nil {
 			log.Printf("[WARN] Erro ao salvar lead: %v", err)
@@ -273,8 +491,82 @@ func (s *ValidationService) ConfirmEmailAndActivate(ctx context.Context, req dom
 	return response, nil
 }
 
+// activate verifica se o usuário já existe no clube e o ativa
+func (s *ValidationService) activate(ctx context.Context, lead *domain.Lead, club domain.Club) *domain.ValidationResponse {
+	partnerUser, partnerErr := club.Partner.FindUserByCPF(ctx, lead.CPF)
+	if partnerErr != nil {
+		log.Printf("[WARN] Erro ao verificar clube %s: %v", club.ID, partnerErr)
+	}
+
+	if partnerUser != nil {
+		// Usuário já existe - apenas gerar SSO
+		log.Printf("[ATIVAÇÃO] Usuário já existe no clube %s - gerando SSO", club.ID)
+		return s.activateExistingUser(ctx, lead, club, partnerUser)
+	}
+	// Novo usuário - cadastrar e gerar SSO
+	log.Printf("[ATIVAÇÃO] Novo usuário no clube %s - cadastrando e gerando SSO", club.ID)
+	return s.activateNewUser(ctx, lead, club)
+}
+
+// activateClubs ativa o morador em cada clube do condomínio e responde com o
+// seletor de clubes. O lead fica com o resultado do primeiro clube ativado e
+// guarda o status de cada um em Metadata["clubs"].
+func (s *ValidationService) activateClubs(ctx context.Context, lead *domain.Lead, clubs []domain.Club) *domain.ValidationResponse {
+	response := &domain.ValidationResponse{
+		Scenario: domain.ScenarioClubPicker,
+		Name:     lead.Name,
+		Email:    lead.Email,
+		Message:  "Você tem direito a mais de um Clube de Benefícios. Escolha qual deseja acessar.",
+	}
+
+	statuses := map[string]interface{}{}
+	var activated *domain.Lead
+	for _, club := range clubs {
+		clubLead := *lead
+		result := s.activate(ctx, &clubLead, club)
+
+		access := domain.ClubAccess{
+			ID:          club.ID,
+			Name:        club.Name,
+			UserID:      result.UserID,
+			RedirectURL: result.RedirectURL,
+		}
+		if result.RedirectURL == "" {
+			access.Error = result.Message
+		}
+		response.Clubs = append(response.Clubs, access)
+		statuses[club.ID] = clubLead.RedeParceriasStatus
+
+		if activated == nil && clubLead.Status == domain.StatusApproved {
+			activated = &clubLead
+		}
+	}
+
+	if activated != nil {
+		*lead = *activated
+		response.Valid = true
+		response.UserID = lead.RedeParceriasUserID
+	} else {
+		response.Message = "Você tem direito ao benefício mas houve um erro. Tente novamente."
+	}
+	if lead.Metadata == nil {
+		lead.Metadata = map[string]interface{}{}
+	}
+	lead.Metadata["clubs"] = statuses
+	return response
+}
+
+func findClub(clubs []domain.Club, id string) (domain.Club, bool) {
+	for _, club := range clubs {
+		if club.ID == id {
+			return club, true
+		}
+	}
+	return domain.Club{}, false
+}
+
 // activateNewUser cadastra novo usuário e gera SSO
-func (s *ValidationService) activateNewUser(ctx context.Context, lead *domain.Lead) *domain.ValidationResponse {
+func (s *ValidationService) activateNewUser(ctx context.Context, lead *domain.Lead, club domain.Club) *domain.ValidationResponse {
 	response := &domain.ValidationResponse{
 		Valid:    true,
 		Scenario: domain.ScenarioNewUser,
@@ -284,42 +576,52 @@ func (s *ValidationService) activateNewUser(ctx context.Context, lead *domain.Le
 	}
 
 	// Cadastrar e gerar SSO
-	sso, err := s.partner.RegisterAndGetSSO(ctx, lead)
+	sso, err := club.Partner.RegisterAndGetSSO(ctx, lead)
 	if err != nil {
 		log.Printf("[ERRO] Falha no RegisterAndGetSSO: %v", err)
 		
-		// Fallback: tentar só o SSO se tiver email
-		if lead.Email != "" {
-			log.Printf("[RETRY] Tentando gerar SSO usando email: %s", lead.Email)
-			sso, err = s.partner.GetSSOToken(ctx, lead.Email)
+		// Fallback: tentar só o SSO se tiver email. Dados recusados pelo
+		// clube não mudam numa nova tentativa.
+		if lead.Email != "" && !errors.Is(err, domain.ErrValidation) {
+			log.Printf("[RETRY] Tentando gerar SSO usando email: %s", maskEmail(lead.Email))
+			sso, err = club.Partner.GetSSOToken(ctx, lead.Email)
 			if err != nil {
 				log.Printf("[ERRO] Fallback SSO também falhou: %v", err)
-				response.Message = "Você tem direito ao benefício mas houve um erro. Tente novamente."
+				response.Message = activationFailureMessage(err, "Você tem direito ao benefício mas houve um erro. Tente novamente.")
+				s.emit(ctx, domain.EventPartnerRegistrationFailed, lead, map[string]interface{}{"error": err.Error(), "club_id": club.ID})
 				return response
 			}
 		} else {
-			response.Message = "Você tem direito ao benefício mas houve um erro. Tente novamente."
+			response.Message = activationFailureMessage(err, "Você tem direito ao benefício mas houve um erro. Tente novamente.")
+			s.emit(ctx, domain.EventPartnerRegistrationFailed, lead, map[string]interface{}{"error": err.Error(), "club_id": club.ID})
 			return response
 		}
 	}
 
 	// Sucesso
 	if sso != nil && sso.Redirect != "" {
-		response.SSOToken = sso.Token
-		response.RedirectURL = sso.Redirect
+		redirect, err := s.IssueSSO(ctx, lead, club, sso)
+		if err != nil {
+			// Cadastrado no clube: a próxima confirmação só gera o acesso
+			log.Printf("[ERRO] Falha ao guardar acesso SSO: %v", err)
+			response.Message = "Sua conta foi ativada, mas houve um erro ao gerar seu acesso. Tente novamente."
+		} else {
+			response.RedirectURL = redirect
+			response.Message = "Conta ativada com sucesso! Redirecionando para o Clube de Benefícios..."
+			log.Printf("[SUCESSO] SSO gerado! Redirect: %s", domain.RedactURL(sso.Redirect))
+		}
 		response.UserID = lead.RedeParceriasUserID
-		response.Message = "Conta ativada com sucesso! Redirecionando para o Clube de Benefícios..."
-		log.Printf("[SUCESSO] SSO gerado! Redirect: %s", sso.Redirect)
 	}
 
 	lead.Status = domain.StatusApproved
 	lead.RedeParceriasStatus = domain.PartnerStatusRegistered
+	s.emit(ctx, domain.EventLeadActivated, lead, map[string]interface{}{"scenario": domain.ScenarioNewUser, "club_id": club.ID})
 
 	return response
 }
 
 // activateExistingUser gera SSO para usuário existente
-func (s *ValidationService) activateExistingUser(ctx context.Context, lead *domain.Lead, partnerUser *domain.PartnerUser) *domain.ValidationResponse {
+func (s *ValidationService) activateExistingUser(ctx context.Context, lead *domain.Lead, club domain.Club, partnerUser *domain.PartnerUser) *domain.ValidationResponse {
 	response := &domain.ValidationResponse{
 		Valid:    true,
 		Scenario: domain.ScenarioExistingUser,
@@ -335,39 +637,58 @@ func (s *ValidationService) activateExistingUser(ctx context.Context, lead *doma
 		ssoIdentifier = partnerUser.ID
 	}
 
-	sso, err := s.partner.GetSSOToken(ctx, ssoIdentifier)
+	sso, err := club.Partner.GetSSOToken(ctx, ssoIdentifier)
 	if err != nil {
 		log.Printf("[WARN] Falha ao gerar SSO com %s: %v", ssoIdentifier, err)
 		
 		// Fallback
 		if partnerUser.Email != "" && ssoIdentifier == partnerUser.ID {
-			sso, err = s.partner.GetSSOToken(ctx, partnerUser.Email)
+			sso, err = club.Partner.GetSSOToken(ctx, partnerUser.Email)
 		} else if partnerUser.ID != "" {
-			sso, err = s.partner.GetSSOToken(ctx, partnerUser.ID)
+			sso, err = club.Partner.GetSSOToken(ctx, partnerUser.ID)
 		}
 		
 		if err != nil {
 			log.Printf("[ERRO] SSO fallback também falhou: %v", err)
-			response.Message = "Houve um erro ao gerar seu acesso. Tente novamente."
+			response.Message = activationFailureMessage(err, "Houve um erro ao gerar seu acesso. Tente novamente.")
 			return response
 		}
 	}
 
 	// Sucesso
 	if sso != nil && sso.Redirect != "" {
-		response.SSOToken = sso.Token
-		response.RedirectURL = sso.Redirect
+		redirect, err := s.IssueSSO(ctx, lead, club, sso)
+		if err != nil {
+			log.Printf("[ERRO] Falha ao guardar acesso SSO: %v", err)
+			response.Message = "Houve um erro ao gerar seu acesso. Tente novamente."
+			return response
+		}
+		response.RedirectURL = redirect
 		response.Message = fmt.Sprintf("Bem-vindo de volta, %s! Redirecionando...", firstName(lead.Name))
-		log.Printf("[SUCESSO] SSO gerado para usuário existente! Redirect: %s", sso.Redirect)
+		log.Printf("[SUCESSO] SSO gerado para usuário existente! Redirect: %s", domain.RedactURL(sso.Redirect))
 	}
 
 	lead.Status = domain.StatusApproved
 	lead.RedeParceriasStatus = domain.PartnerStatusRegistered
 	lead.RedeParceriasUserID = partnerUser.ID
+	s.emit(ctx, domain.EventLeadActivated, lead, map[string]interface{}{"scenario": domain.ScenarioExistingUser, "club_id": club.ID})
 
 	return response
 }
 
+// activationFailureMessage mensagem ao morador conforme a falha do clube;
+// fallback para falhas sem orientação específica
+func activationFailureMessage(err error, fallback string) string {
+	switch {
+	case errors.Is(err, domain.ErrValidation):
+		return "O Clube de Benefícios recusou os dados do seu cadastro. Procure a administração do condomínio para atualizá-los."
+	case errors.Is(err, domain.ErrRateLimited):
+		return "O Clube de Benefícios está com muitos acessos no momento. Tente novamente em alguns minutos."
+	default:
+		return fallback
+	}
+}
+
 // normalizeEmail normaliza um e-mail para comparação
 func normalizeEmail(email string) string {
 	// Converter para lowercase e remover espaços
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/viplounge/platform/internal/config"
+	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/repository"
+)
+
+// TestConfirmEmailUsesResidentCondoClubs na busca global (-1) o morador é
+// ativado nos clubes do condomínio real, os mesmos que o suporte e os
+// webhooks usam para revogar
+func TestConfirmEmailUsesResidentCondoClubs(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	validator := residents{"52998224725": {CondoID: "7", Name: "Maria", Email: "maria@example.com"}}
+	global, gold := newClubStub(), newClubStub()
+	svc := NewValidationService(repo, validator, global, &config.Config{})
+	svc.SetClubs(condoClubs{
+		"-1": {{ID: domain.DefaultClubID, Partner: global}},
+		"7":  {{ID: "ouro", Partner: gold}},
+	})
+
+	resp, err := svc.ConfirmEmailAndActivate(ctx, domain.EmailConfirmationRequest{CPF: "52998224725", Email: "maria@example.com", CondoID: "-1"})
+	if err != nil || !resp.Valid {
+		t.Fatalf("ConfirmEmailAndActivate: %v (%+v)", err, resp)
+	}
+	if len(gold.registered) != 1 || len(global.registered) != 0 {
+		t.Errorf("cadastros: ouro %v, clube do tenant -1 %v", gold.registered, global.registered)
+	}
+	if leads, _ := repo.SearchLeads(ctx, domain.LeadFilter{CPF: "52998224725"}); len(leads) != 1 || leads[0].CondoID != "7" {
+		t.Errorf("lead gravado: %+v", leads)
+	}
+}
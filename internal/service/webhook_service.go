@@ -0,0 +1,480 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/viplounge/platform/internal/config"
+	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/lifecycle"
+	"github.com/viplounge/platform/internal/secrets"
+)
+
+var (
+	// ErrWebhookUnauthorized assinatura ou token do webhook inválido
+	ErrWebhookUnauthorized = errors.New("credencial do webhook inválida")
+	// ErrWebhookPayload corpo do webhook ilegível
+	ErrWebhookPayload = errors.New("payload do webhook inválido")
+)
+
+const webhookSourceSuperlogica = "superlogica"
+
+// superlogicaNotification notificação de alteração de unidade. Na troca de
+// proprietário cpf_proprietario_anterior traz quem saiu.
+type superlogicaNotification struct {
+	ID    string `json:"id"`
+	Event string `json:"evento"`
+	Data  struct {
+		CondoID     string `json:"id_condominio_cond"`
+		UnitID      string `json:"id_unidade_uni"`
+		CPF         string `json:"cpf_proprietario"`
+		PreviousCPF string `json:"cpf_proprietario_anterior"`
+	} `json:"data"`
+}
+
+// WebhookService recebe as notificações da Superlógica e aplica a árvore de
+// decisão aos moradores afetados: quem entrou é cadastrado e quem saiu é
+// revogado nos clubes do condomínio. A notificação só indica quem conferir; a
+// decisão sempre usa a consulta ao vivo na Superlógica.
+type WebhookService struct {
+	events    domain.WebhookEventRepository
+	repo      domain.LeadRepository
+	validator domain.BenefValidator
+	partner   domain.PartnerService
+	secret    secrets.Secret
+	cfg       *config.Config
+	// jobs processa os eventos em background; nil processa na hora (replay)
+	jobs *lifecycle.Group
+	// clubs resolve os clubes do condomínio (opcional; sem ele todo
+	// condomínio usa apenas partner)
+	clubs domain.ClubRegistry
+	// eventos das entradas e saídas para os webhooks de saída
+	publisher domain.EventPublisher
+}
+
+func NewWebhookService(events domain.WebhookEventRepository, repo domain.LeadRepository, validator domain.BenefValidator, partner domain.PartnerService, secret secrets.Secret, cfg *config.Config, jobs *lifecycle.Group) *WebhookService {
+	if cfg == nil {
+		cfg = config.Get()
+	}
+	return &WebhookService{
+		events:    events,
+		repo:      repo,
+		validator: validator,
+		partner:   partner,
+		secret:    secret,
+		cfg:       cfg,
+		jobs:      jobs,
+	}
+}
+
+// SetClubs liga a resolução dos clubes por condomínio (tenants[].clubs)
+func (s *WebhookService) SetClubs(clubs domain.ClubRegistry) {
+	s.clubs = clubs
+}
+
+// SetPublisher liga a emissão de eventos para os webhooks de saída
+func (s *WebhookService) SetPublisher(events domain.EventPublisher) {
+	s.publisher = events
+}
+
+// Settings config do webhook da Superlógica em uso. É a da inicialização
+// (webhooks.superlogica.* só muda com restart): o handler lê daqui os
+// headers da credencial, para coletar a mesma que Authenticate confere.
+func (s *WebhookService) Settings() config.SuperlogicaWebhook {
+	return s.cfg.Webhooks.Superlogica
+}
+
+// Authenticate confere a credencial enviada com o corpo: HMAC-SHA256 em hex
+// (aceita o prefixo "sha256=") ou o próprio segredo, conforme a config. Com
+// timestamp_header configurado, timestamp (unix) entra no HMAC e precisa
+// estar dentro da tolerância.
+func (s *WebhookService) Authenticate(ctx context.Context, body []byte, credential, timestamp string) error {
+	if credential == "" {
+		return ErrWebhookUnauthorized
+	}
+	secret, err := s.secret.Value(ctx)
+	if err != nil {
+		return fmt.Errorf("segredo do webhook (%s) indisponível: %w", s.secret.Ref(), err)
+	}
+
+	settings := s.Settings()
+	if settings.Auth == "token" {
+		if subtle.ConstantTimeCompare([]byte(credential), []byte(secret)) != 1 {
+			return ErrWebhookUnauthorized
+		}
+		return nil
+	}
+
+	got, err := hex.DecodeString(strings.TrimPrefix(strings.ToLower(credential), "sha256="))
+	if err != nil {
+		return ErrWebhookUnauthorized
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	if settings.TimestampHeader != "" {
+		sent, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return ErrWebhookUnauthorized
+		}
+		skew := time.Since(time.Unix(sent, 0))
+		if skew < 0 {
+			skew = -skew
+		}
+		if skew > time.Duration(settings.ToleranceSeconds)*time.Second {
+			log.Printf("[WEBHOOK] Notificação recusada: timestamp fora da tolerância (%s)", skew.Round(time.Second))
+			return ErrWebhookUnauthorized
+		}
+		mac.Write([]byte(timestamp + "."))
+	}
+	mac.Write(body)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return ErrWebhookUnauthorized
+	}
+	return nil
+}
+
+// Receive grava a notificação e dispara o processamento. A chave de
+// idempotência é o id enviado pela Superlógica ou, sem ele, o hash do corpo;
+// uma entrega repetida retorna o evento já gravado com duplicate=true.
+func (s *WebhookService) Receive(ctx context.Context, body []byte) (event *domain.WebhookEvent, duplicate bool, err error) {
+	var n superlogicaNotification
+	if err := json.Unmarshal(body, &n); err != nil {
+		return nil, false, fmt.Errorf("%w: %v", ErrWebhookPayload, err)
+	}
+
+	key := n.ID
+	if key == "" {
+		sum := sha256.Sum256(body)
+		key = hex.EncodeToString(sum[:])
+	}
+	event = &domain.WebhookEvent{
+		ID:         webhookSourceSuperlogica + "_" + key,
+		Source:     webhookSourceSuperlogica,
+		Type:       n.Event,
+		Payload:    string(body),
+		CPFs:       []string{n.Data.CPF, n.Data.PreviousCPF},
+		Status:     domain.WebhookStatusReceived,
+		ReceivedAt: time.Now(),
+	}
+
+	if tenant := s.tenant(ctx, n.Data.CondoID); tenant != "" {
+		event.TenantID = tenant
+	} else {
+		event.Status = domain.WebhookStatusIgnored
+		event.Error = fmt.Sprintf("condomínio %q sem tenant configurado", n.Data.CondoID)
+	}
+
+	if err := s.events.CreateWebhookEvent(ctx, *event); err != nil {
+		if errors.Is(err, domain.ErrAlreadyExists) {
+			log.Printf("[WEBHOOK] Evento %s já recebido, ignorando entrega repetida", event.ID)
+			if existing, getErr := s.events.GetWebhookEvent(ctx, event.ID); getErr == nil {
+				event = existing
+			}
+			return event, true, nil
+		}
+		return nil, false, err
+	}
+	log.Printf("[WEBHOOK] Evento %s (%s) recebido - tenant %q, status %s", event.ID, event.Type, event.TenantID, event.Status)
+
+	if event.Status != domain.WebhookStatusReceived {
+		return event, false, nil
+	}
+	if s.jobs == nil {
+		err = s.Process(ctx, event)
+		return event, false, err
+	}
+	// Fora da requisição, para responder rápido; eventos que não chegarem a
+	// ser processados (encerramento) continuam "received" para o replay
+	queued := *event
+	s.jobs.Go("superlogica-webhook", func(ctx context.Context) {
+		s.Process(ctx, &queued)
+	})
+	return event, false, nil
+}
+
+// Process aplica a notificação e grava o resultado no evento. Pode ser
+// chamado de novo em eventos que falharam (replay): as ações são
+// idempotentes, pois cada uma confere o estado atual antes de agir.
+func (s *WebhookService) Process(ctx context.Context, event *domain.WebhookEvent) error {
+	event.Attempts++
+
+	var n superlogicaNotification
+	err := json.Unmarshal([]byte(event.Payload), &n)
+	if err != nil {
+		err = fmt.Errorf("%w: %v", ErrWebhookPayload, err)
+	} else {
+		event.Actions, err = s.apply(ctx, n)
+	}
+
+	event.ProcessedAt = time.Now()
+	event.Status = domain.WebhookStatusProcessed
+	event.Error = ""
+	if err != nil {
+		event.Status = domain.WebhookStatusFailed
+		event.Error = err.Error()
+		log.Printf("[WEBHOOK] Evento %s falhou (tentativa %d): %v", event.ID, event.Attempts, err)
+	} else {
+		log.Printf("[WEBHOOK] Evento %s processado: %v", event.ID, event.Actions)
+	}
+
+	if saveErr := s.events.UpdateWebhookEvent(ctx, *event); saveErr != nil {
+		log.Printf("[WEBHOOK] Erro gravando resultado do evento %s: %v", event.ID, saveErr)
+		if err == nil {
+			err = saveErr
+		}
+	}
+	return err
+}
+
+// apply trata a saída do proprietário anterior e a entrada do atual
+func (s *WebhookService) apply(ctx context.Context, n superlogicaNotification) ([]string, error) {
+	var actions []string
+	current := onlyDigits(n.Data.CPF)
+	previous := onlyDigits(n.Data.PreviousCPF)
+
+	if previous != "" && previous != current {
+		result, err := s.moveOut(ctx, n.Data.CondoID, previous)
+		actions = append(actions, fmt.Sprintf("%s:%s:%s", domain.ResidentMoveOut, maskCPF(previous), result))
+		if err != nil {
+			return actions, err
+		}
+	}
+	if current != "" {
+		result, err := s.moveIn(ctx, n.Data.CondoID, current)
+		actions = append(actions, fmt.Sprintf("%s:%s:%s", domain.ResidentMoveIn, maskCPF(current), result))
+		if err != nil {
+			return actions, err
+		}
+	}
+	return actions, nil
+}
+
+// moveIn cadastra em cada clube do condomínio o proprietário confirmado na
+// Superlógica. O lead gravado fica com o resultado do primeiro clube e,
+// com mais de um, o status de cada um em Metadata["clubs"].
+func (s *WebhookService) moveIn(ctx context.Context, condoID, cpf string) (string, error) {
+	found, data, err := s.validator.ValidateMember(ctx, condoID, cpf)
+	if err != nil {
+		return "error", fmt.Errorf("superlógica: %w", err)
+	}
+	if !found || data == nil {
+		// Notificação à frente da API ou já desfeita
+		return "not_found", nil
+	}
+
+	clubs := resolveClubs(ctx, s.clubs, s.partner, condoID)
+	results := make([]string, len(clubs))
+	statuses := map[string]interface{}{}
+	leads := make([]domain.Lead, len(clubs))
+	var saved *domain.Lead
+	var errs []error
+	for i, club := range clubs {
+		lead := *data
+		lead.CPF = cpf
+		lead.Origin = "superlogica_webhook"
+		lead.CreatedAt = time.Now()
+		lead.UpdatedAt = time.Now()
+		results[i], err = s.register(ctx, club, &lead)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		statuses[club.ID] = lead.RedeParceriasStatus
+		leads[i] = lead
+		if saved == nil && lead.Status != "" {
+			saved = &lead
+		}
+	}
+
+	if saved != nil {
+		if len(clubs) > 1 {
+			metadata := map[string]interface{}{}
+			for k, v := range saved.Metadata {
+				metadata[k] = v
+			}
+			metadata["clubs"] = statuses
+			saved.Metadata = metadata
+		}
+		s.save(ctx, *saved)
+	}
+	// Um evento por clube em que o morador passou a ter acesso
+	for i := range leads {
+		if leads[i].Status == domain.StatusApproved {
+			publishLead(ctx, s.publisher, domain.EventLeadActivated, &leads[i], map[string]interface{}{"club_id": clubs[i].ID})
+		}
+	}
+	return clubResults(clubs, results), errors.Join(errs...)
+}
+
+// register cadastra o lead no clube; sem Status no lead quando o usuário
+// já estava lá (nada a gravar)
+func (s *WebhookService) register(ctx context.Context, club domain.Club, lead *domain.Lead) (string, error) {
+	user, err := club.Partner.FindUserByCPF(ctx, lead.CPF)
+	if err != nil {
+		return "error", fmt.Errorf("clube %s: %w", club.ID, err)
+	}
+	if user != nil {
+		lead.RedeParceriasStatus = domain.PartnerStatusRegistered
+		return "already_registered", nil
+	}
+
+	start := time.Now()
+	err = club.Partner.RegisterUser(ctx, lead)
+	lead.RedeParceriasResponseMs = time.Since(start).Milliseconds()
+	if errors.Is(err, domain.ErrPartnerUserExists) {
+		// Cadastrado entre a busca e o cadastro
+		lead.Status = domain.StatusApproved
+		return "already_registered", nil
+	}
+	if err != nil {
+		lead.Status = domain.StatusError
+		lead.RedeParceriasStatus = domain.PartnerStatusFailed
+		lead.RedeParceriasError = err.Error()
+		return "error", fmt.Errorf("cadastro no clube %s: %w", club.ID, err)
+	}
+
+	lead.Status = domain.StatusApproved
+	lead.RedeParceriasStatus = domain.PartnerStatusRegistered
+	return "registered", nil
+}
+
+// moveOut revoga o antigo proprietário em cada clube do condomínio, a menos
+// que ele ainda tenha unidade em algum condomínio (busca global)
+func (s *WebhookService) moveOut(ctx context.Context, condoID, cpf string) (string, error) {
+	found, _, err := s.validator.ValidateMember(ctx, "-1", cpf)
+	if err != nil {
+		return "error", fmt.Errorf("superlógica: %w", err)
+	}
+	if found {
+		return "still_resident", nil
+	}
+
+	clubs := resolveClubs(ctx, s.clubs, s.partner, condoID)
+	results := make([]string, len(clubs))
+	var revokedID string
+	var errs []error
+	for i, club := range clubs {
+		user, err := club.Partner.FindUserByCPF(ctx, cpf)
+		switch {
+		case err != nil:
+			results[i] = "error"
+			errs = append(errs, fmt.Errorf("clube %s: %w", club.ID, err))
+		case user == nil:
+			results[i] = "not_registered"
+		default:
+			if err := club.Partner.DeleteUser(ctx, user.ID); err != nil {
+				results[i] = "error"
+				errs = append(errs, fmt.Errorf("revogação no clube %s: %w", club.ID, err))
+				continue
+			}
+			results[i] = "revoked"
+			if revokedID == "" {
+				revokedID = user.ID
+			}
+		}
+	}
+
+	if revokedID != "" {
+		lead := domain.Lead{
+			CPF:                 cpf,
+			CondoID:             condoID,
+			Status:              domain.StatusRejected,
+			Origin:              "superlogica_webhook",
+			RedeParceriasStatus: domain.PartnerStatusRevoked,
+			RedeParceriasUserID: revokedID,
+			CreatedAt:           time.Now(),
+			UpdatedAt:           time.Now(),
+		}
+		s.save(ctx, lead)
+		publishLead(ctx, s.publisher, domain.EventLeadRevoked, &lead, nil)
+	}
+	return clubResults(clubs, results), errors.Join(errs...)
+}
+
+// clubResults resultado da ação: o do clube, com um só, ou "<clube>=<resultado>"
+// de cada um
+func clubResults(clubs []domain.Club, results []string) string {
+	if len(clubs) == 1 {
+		return results[0]
+	}
+	parts := make([]string, len(clubs))
+	for i, club := range clubs {
+		parts[i] = club.ID + "=" + results[i]
+	}
+	return strings.Join(parts, ",")
+}
+
+func (s *WebhookService) save(ctx context.Context, lead domain.Lead) {
+	if s.repo == nil {
+		return
+	}
+	if err := s.repo.Save(ctx, lead); err != nil {
+		log.Printf("[WARN] Erro ao salvar lead do webhook: %v", err)
+	}
+}
+
+// tenant retorna o tenant que atende o condomínio: o do próprio condomínio
+// ou, na falta dele, o de busca global (-1). "" se nenhum domínio atende.
+// Lê a config da requisição ou a atual, pois tenants acompanham o hot reload.
+func (s *WebhookService) tenant(ctx context.Context, condoID string) string {
+	if condoID == "" {
+		return ""
+	}
+	cfg := config.FromContext(ctx)
+	if cfg == nil {
+		cfg = config.Get()
+	}
+	global := ""
+	for _, tenant := range cfg.Tenants {
+		if tenant.ID == condoID {
+			return tenant.ID
+		}
+		if tenant.ID == "-1" {
+			global = tenant.ID
+		}
+	}
+	return global
+}
+
+var nonDigitRegex = regexp.MustCompile(`\D`)
+
+func onlyDigits(s string) string {
+	return nonDigitRegex.ReplaceAllString(s, "")
+}
+
+// Replay reprocessa eventos gravados, mais antigos primeiro. Sem status no
+// filtro, pega os que falharam e os que ficaram sem processar. Retorna a
+// contagem de eventos por situação final.
+func (s *WebhookService) Replay(ctx context.Context, filter domain.WebhookEventFilter) (map[string]int, error) {
+	filter.Source = webhookSourceSuperlogica
+	statuses := []string{filter.Status}
+	if filter.Status == "" {
+		statuses = []string{domain.WebhookStatusReceived, domain.WebhookStatusFailed}
+	}
+
+	summary := make(map[string]int)
+	for _, status := range statuses {
+		filter.Status = status
+		events, err := s.events.ListWebhookEvents(ctx, filter)
+		if err != nil {
+			return summary, err
+		}
+		for i := range events {
+			if ctx.Err() != nil {
+				return summary, ctx.Err()
+			}
+			s.Process(ctx, &events[i])
+			summary[events[i].Status]++
+		}
+	}
+	return summary, nil
+}
@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/viplounge/platform/internal/config"
+	"github.com/viplounge/platform/internal/domain"
+	"github.com/viplounge/platform/internal/repository"
+	"github.com/viplounge/platform/internal/secrets"
+)
+
+const webhookSecret = "segredo-da-superlogica"
+
+// residents BenefValidator com os proprietários por CPF
+type residents map[string]domain.Lead
+
+func (r residents) ValidateMember(ctx context.Context, condoID, cpf string) (bool, *domain.Lead, error) {
+	lead, ok := r[cpf]
+	if !ok || (condoID != "-1" && lead.CondoID != condoID) {
+		return false, nil, nil
+	}
+	return true, &lead, nil
+}
+
+// clubStub PartnerService em memória que registra cadastros e revogações
+type clubStub struct {
+	users      map[string]*domain.PartnerUser
+	registered []string
+	deleted    []string
+}
+
+func newClubStub(cpfs ...string) *clubStub {
+	club := &clubStub{users: map[string]*domain.PartnerUser{}}
+	for _, cpf := range cpfs {
+		club.users[cpf] = &domain.PartnerUser{ID: "u-" + cpf, CPF: cpf, Active: true}
+	}
+	return club
+}
+
+func (c *clubStub) FindUserByCPF(ctx context.Context, cpf string) (*domain.PartnerUser, error) {
+	return c.users[cpf], nil
+}
+
+func (c *clubStub) RegisterUser(ctx context.Context, lead *domain.Lead) error {
+	c.registered = append(c.registered, lead.CPF)
+	c.users[lead.CPF] = &domain.PartnerUser{ID: "u-" + lead.CPF, CPF: lead.CPF, Active: true}
+	return nil
+}
+
+func (c *clubStub) DeleteUser(ctx context.Context, userID string) error {
+	c.deleted = append(c.deleted, userID)
+	delete(c.users, strings.TrimPrefix(userID, "u-"))
+	return nil
+}
+
+func (c *clubStub) GetSSOToken(ctx context.Context, userIdentifier string) (*domain.SSOToken, error) {
+	return nil, errors.New("não usado")
+}
+
+func (c *clubStub) RegisterAndGetSSO(ctx context.Context, lead *domain.Lead) (*domain.SSOToken, error) {
+	if err := c.RegisterUser(ctx, lead); err != nil {
+		return nil, err
+	}
+	lead.RedeParceriasUserID = "u-" + lead.CPF
+	return &domain.SSOToken{Redirect: "https://clube.example.com/sso/u-" + lead.CPF}, nil
+}
+
+// webhookConfig webhook da Superlógica em hmac e os condomínios 4 e 7
+func webhookConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.Webhooks.Superlogica = config.SuperlogicaWebhook{
+		Enabled:          true,
+		Auth:             "hmac",
+		SecretRef:        "SUPERLOGICA_WEBHOOK_SECRET",
+		SignatureHeader:  "X-Superlogica-Signature",
+		ToleranceSeconds: 300,
+	}
+	cfg.Tenants = []config.Tenant{{ID: "4"}, {ID: "7"}}
+	return cfg
+}
+
+func signBody(body []byte, prefix string) string {
+	mac := hmac.New(sha256.New, []byte(webhookSecret))
+	mac.Write([]byte(prefix))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestWebhookAuthenticate(t *testing.T) {
+	body := []byte(`{"id":"n1","evento":"alteracao_proprietario"}`)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	old := strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10)
+	future := strconv.FormatInt(time.Now().Add(10*time.Minute).Unix(), 10)
+
+	tests := []struct {
+		name       string
+		auth       string
+		timestamps bool // timestamp_header configurado
+		credential string
+		timestamp  string
+		wantErr    bool
+	}{
+		{name: "HMAC válido", credential: signBody(body, "")},
+		{name: "HMAC com prefixo e maiúsculas", credential: "SHA256=" + strings.ToUpper(signBody(body, ""))},
+		{name: "HMAC de outro corpo", credential: signBody([]byte(`{"id":"n2"}`), ""), wantErr: true},
+		{name: "assinatura adulterada", credential: "00" + signBody(body, "")[2:], wantErr: true},
+		{name: "credencial que não é hex", credential: "assinatura", wantErr: true},
+		{name: "sem credencial", wantErr: true},
+		{name: "token válido", auth: "token", credential: webhookSecret},
+		{name: "token inválido", auth: "token", credential: "outro", wantErr: true},
+		{name: "HMAC em modo token", auth: "token", credential: signBody(body, ""), wantErr: true},
+		{name: "timestamp dentro da tolerância", timestamps: true, credential: signBody(body, now+"."), timestamp: now},
+		{name: "timestamp antigo", timestamps: true, credential: signBody(body, old+"."), timestamp: old, wantErr: true},
+		{name: "timestamp no futuro", timestamps: true, credential: signBody(body, future+"."), timestamp: future, wantErr: true},
+		{name: "sem timestamp", timestamps: true, credential: signBody(body, ""), wantErr: true},
+		{name: "timestamp trocado", timestamps: true, credential: signBody(body, old+"."), timestamp: now, wantErr: true},
+		{name: "timestamp inválido", timestamps: true, credential: signBody(body, "agora."), timestamp: "agora", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := webhookConfig()
+			if tt.auth != "" {
+				cfg.Webhooks.Superlogica.Auth = tt.auth
+			}
+			if tt.timestamps {
+				cfg.Webhooks.Superlogica.TimestampHeader = "X-Superlogica-Timestamp"
+			}
+			svc := NewWebhookService(nil, nil, nil, nil, secrets.Static(webhookSecret), cfg, nil)
+
+			err := svc.Authenticate(context.Background(), body, tt.credential, tt.timestamp)
+			if tt.wantErr {
+				if !errors.Is(err, ErrWebhookUnauthorized) {
+					t.Errorf("erro %v, esperado ErrWebhookUnauthorized", err)
+				}
+			} else if err != nil {
+				t.Errorf("Authenticate: %v", err)
+			}
+		})
+	}
+}
+
+// TestWebhookReceiveDeduplicates uma entrega repetida não aplica a
+// notificação de novo, com ou sem id
+func TestWebhookReceiveDeduplicates(t *testing.T) {
+	cfg := webhookConfig()
+	ctx := config.WithSnapshot(context.Background(), cfg)
+	repo := repository.NewMemoryRepository()
+	club := newClubStub("11144477735")
+	validator := residents{"52998224725": {CondoID: "4", Name: "Nova Proprietária", Email: "nova@example.com"}}
+	svc := NewWebhookService(repo, repo, validator, club, secrets.Static(webhookSecret), cfg, nil)
+
+	tests := []struct {
+		name          string
+		body          string
+		wantID        string
+		wantDuplicate bool
+		wantStatus    string
+	}{
+		{
+			name:       "troca de proprietário",
+			body:       `{"id":"n1","evento":"alteracao_proprietario","data":{"id_condominio_cond":"4","cpf_proprietario":"529.982.247-25","cpf_proprietario_anterior":"111.444.777-35"}}`,
+			wantID:     "superlogica_n1",
+			wantStatus: domain.WebhookStatusProcessed,
+		},
+		{
+			name:          "mesmo id",
+			body:          `{"id":"n1","evento":"alteracao_proprietario","data":{"id_condominio_cond":"4","cpf_proprietario":"529.982.247-25"}}`,
+			wantID:        "superlogica_n1",
+			wantDuplicate: true,
+			wantStatus:    domain.WebhookStatusProcessed,
+		},
+		{
+			name:       "sem id",
+			body:       `{"evento":"alteracao_proprietario","data":{"id_condominio_cond":"7","cpf_proprietario":"52998224725"}}`,
+			wantStatus: domain.WebhookStatusProcessed,
+		},
+		{
+			name:          "mesmo corpo sem id",
+			body:          `{"evento":"alteracao_proprietario","data":{"id_condominio_cond":"7","cpf_proprietario":"52998224725"}}`,
+			wantDuplicate: true,
+			wantStatus:    domain.WebhookStatusProcessed,
+		},
+		{
+			name:       "condomínio sem tenant",
+			body:       `{"id":"n2","evento":"alteracao_proprietario","data":{"id_condominio_cond":"99","cpf_proprietario":"52998224725"}}`,
+			wantID:     "superlogica_n2",
+			wantStatus: domain.WebhookStatusIgnored,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, duplicate, err := svc.Receive(ctx, []byte(tt.body))
+			if err != nil {
+				t.Fatalf("Receive: %v", err)
+			}
+			if duplicate != tt.wantDuplicate || event.Status != tt.wantStatus {
+				t.Errorf("evento %s: duplicate=%v status=%s (%s)", event.ID, duplicate, event.Status, event.Error)
+			}
+			if tt.wantID != "" && event.ID != tt.wantID {
+				t.Errorf("ID %s, esperado %s", event.ID, tt.wantID)
+			}
+		})
+	}
+
+	// Cadastro e revogação aplicados uma vez só
+	if len(club.registered) != 1 || club.registered[0] != "52998224725" {
+		t.Errorf("cadastros: %v", club.registered)
+	}
+	if len(club.deleted) != 1 || club.deleted[0] != "u-11144477735" {
+		t.Errorf("revogações: %v", club.deleted)
+	}
+	events, _ := repo.ListWebhookEvents(context.Background(), domain.WebhookEventFilter{})
+	if len(events) != 3 {
+		t.Errorf("%d eventos gravados, esperados 3", len(events))
+	}
+}
+
+func TestWebhookReceiveInvalidPayload(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	svc := NewWebhookService(repo, repo, residents{}, newClubStub(), secrets.Static(webhookSecret), webhookConfig(), nil)
+
+	if _, _, err := svc.Receive(context.Background(), []byte(`{"id":`)); !errors.Is(err, ErrWebhookPayload) {
+		t.Errorf("erro %v, esperado ErrWebhookPayload", err)
+	}
+}
+
+// condoClubs ClubRegistry com os clubes de cada condomínio
+// eventRecorder guarda os eventos publicados para os webhooks de saída
+type eventRecorder []domain.OutboundEvent
+
+func (r *eventRecorder) Publish(ctx context.Context, event domain.OutboundEvent) {
+	*r = append(*r, event)
+}
+
+type condoClubs map[string][]domain.Club
+
+func (c condoClubs) ClubsFor(ctx context.Context, condoID string) []domain.Club {
+	return c[condoID]
+}
+
+func (c condoClubs) Clubs() []domain.Club {
+	var clubs []domain.Club
+	for _, condo := range c {
+		clubs = append(clubs, condo...)
+	}
+	return clubs
+}
+
+// TestWebhookAppliesCondoClubs a troca de proprietário vale para todos os
+// clubes do condomínio, não só o padrão
+func TestWebhookAppliesCondoClubs(t *testing.T) {
+	cfg := webhookConfig()
+	repo := repository.NewMemoryRepository()
+	standard, gold := newClubStub("11144477735"), newClubStub("11144477735")
+	validator := residents{"52998224725": {CondoID: "7", Name: "Nova Proprietária", Email: "nova@example.com"}}
+	svc := NewWebhookService(repo, repo, validator, newClubStub(), secrets.Static(webhookSecret), cfg, nil)
+	svc.SetClubs(condoClubs{"7": {{ID: domain.DefaultClubID, Partner: standard}, {ID: "ouro", Partner: gold}}})
+	events := &eventRecorder{}
+	svc.SetPublisher(events)
+
+	body := `{"id":"n1","evento":"alteracao_proprietario","data":{"id_condominio_cond":"7","cpf_proprietario":"52998224725","cpf_proprietario_anterior":"11144477735"}}`
+	event, _, err := svc.Receive(config.WithSnapshot(context.Background(), cfg), []byte(body))
+	if err != nil || event.Status != domain.WebhookStatusProcessed {
+		t.Fatalf("Receive: %v (%+v)", err, event)
+	}
+
+	for name, club := range map[string]*clubStub{"padrão": standard, "ouro": gold} {
+		if len(club.registered) != 1 || len(club.deleted) != 1 {
+			t.Errorf("clube %s: cadastros %v, revogações %v", name, club.registered, club.deleted)
+		}
+	}
+	want := []string{"move_out:111.***.***-35:default=revoked,ouro=revoked", "move_in:529.***.***-25:default=registered,ouro=registered"}
+	if strings.Join(event.Actions, " ") != strings.Join(want, " ") {
+		t.Errorf("ações %v, esperadas %v", event.Actions, want)
+	}
+
+	leads, _ := repo.SearchLeads(context.Background(), domain.LeadFilter{CPF: "52998224725"})
+	if len(leads) != 1 || leads[0].Metadata["clubs"] == nil {
+		t.Errorf("lead gravado sem o status de cada clube: %+v", leads)
+	}
+
+	// Uma revogação pela saída e uma ativação por clube pela entrada
+	var got []string
+	for _, event := range *events {
+		got = append(got, fmt.Sprintf("%s:%v:%v", event.Type, event.Data["cpf"], event.Data["club_id"]))
+		if event.TenantID != "7" {
+			t.Errorf("evento %s no tenant %q, esperado 7", event.Type, event.TenantID)
+		}
+	}
+	wantEvents := []string{
+		domain.EventLeadRevoked + ":11144477735:<nil>",
+		domain.EventLeadActivated + ":52998224725:default",
+		domain.EventLeadActivated + ":52998224725:ouro",
+	}
+	if strings.Join(got, " ") != strings.Join(wantEvents, " ") {
+		t.Errorf("eventos %v, esperados %v", got, wantEvents)
+	}
+}
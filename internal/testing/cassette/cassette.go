@@ -0,0 +1,110 @@
+// Package cassette grava pares requisição/resposta de uma API real em
+// arquivos sanitizados e os reproduz offline, para testes de contrato.
+//
+// Na gravação um Recorder fica entre o client e a API: repassa cada
+// requisição, sanitiza a interação (credenciais, tokens e dados pessoais) e a
+// acumula no cassete. Na reprodução um Player responde com as interações
+// gravadas, na ordem, e registra o que o client pediu fora do cassete.
+// Schemas (ver Schema) descrevem cada endpoint e acusam mudanças no formato
+// das respostas.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cassette conjunto de interações gravadas de uma API
+type Cassette struct {
+	Name string `json:"name"`
+	// Source descreve de onde veio a gravação (nunca a URL com credenciais)
+	Source       string        `json:"source,omitempty"`
+	RecordedAt   time.Time     `json:"recorded_at"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction uma requisição e a resposta recebida
+type Interaction struct {
+	// Endpoint nome lógico (ex.: "users.create"), usado para achar o schema
+	Endpoint string   `json:"endpoint"`
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request requisição sanitizada. Query fica com os parâmetros ordenados.
+type Request struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Query  string          `json:"query,omitempty"`
+	Body   json.RawMessage `json:"body,omitempty"`
+	// BodyText corpo que não é JSON
+	BodyText string `json:"body_text,omitempty"`
+}
+
+// Response resposta sanitizada
+type Response struct {
+	Status      int             `json:"status"`
+	ContentType string          `json:"content_type,omitempty"`
+	Body        json.RawMessage `json:"body,omitempty"`
+	BodyText    string          `json:"body_text,omitempty"`
+}
+
+// RawBody corpo da resposta como enviado pela API
+func (r Response) RawBody() []byte {
+	if len(r.Body) > 0 {
+		return r.Body
+	}
+	return []byte(r.BodyText)
+}
+
+// SchemaName nome do schema da interação: "<endpoint>.<status>"
+func (i Interaction) SchemaName() string {
+	return fmt.Sprintf("%s.%d", i.Endpoint, i.Response.Status)
+}
+
+// Load lê um cassete gravado
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("cassete %s inválido: %w", path, err)
+	}
+	return &c, nil
+}
+
+// Save grava o cassete indentado, para revisão no diff
+func (c *Cassette) Save(path string) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(c); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// splitBody guarda o corpo como JSON quando possível
+func splitBody(body []byte) (json.RawMessage, string) {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil, ""
+	}
+	if json.Valid(body) {
+		var buf bytes.Buffer
+		if json.Compact(&buf, body) == nil {
+			return json.RawMessage(buf.Bytes()), ""
+		}
+	}
+	return nil, string(body)
+}
@@ -0,0 +1,186 @@
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Redacted marcador que substitui valores sensíveis
+const Redacted = "<redacted>"
+
+// Sanitizer remove dados sensíveis antes de gravar (e normaliza as
+// requisições da reprodução da mesma forma, para que casem com as gravadas)
+type Sanitizer struct {
+	// Replace troca literais (valor real → marcador) em path, query e corpos.
+	// Na gravação mapeia as identidades de teste para as do cassete.
+	Replace map[string]string
+	// SecretKeys campos JSON sempre trocados por Redacted. Os valores
+	// encontrados também são trocados onde mais aparecerem (ex.: o token
+	// dentro da URL de redirect).
+	SecretKeys []string
+	// PIIKeys campos JSON com dados pessoais, trocados por Redacted exceto
+	// quando o valor está em Keep
+	PIIKeys []string
+	// Keep valores que podem ficar no cassete (as identidades de teste)
+	Keep []string
+
+	mu      sync.Mutex
+	learned map[string]bool
+}
+
+// Request sanitiza uma requisição
+func (s *Sanitizer) Request(method, path, rawQuery string, body []byte) Request {
+	req := Request{
+		Method: method,
+		Path:   s.replace(path),
+		Query:  s.query(rawQuery),
+	}
+	req.Body, req.BodyText = splitBody(s.body(body))
+	return req
+}
+
+// Response sanitiza uma resposta
+func (s *Sanitizer) Response(status int, contentType string, body []byte) Response {
+	resp := Response{Status: status, ContentType: contentType}
+	resp.Body, resp.BodyText = splitBody(s.body(body))
+	return resp
+}
+
+// Finish reaplica os segredos aprendidos em todo o cassete: um token só
+// descoberto numa resposta pode ter aparecido antes em outra interação
+func (s *Sanitizer) Finish(c *Cassette) {
+	for i := range c.Interactions {
+		in := &c.Interactions[i]
+		in.Request.Path = s.replace(in.Request.Path)
+		in.Request.Query = s.query(in.Request.Query)
+		in.Request.Body = json.RawMessage(s.replace(string(in.Request.Body)))
+		in.Request.BodyText = s.replace(in.Request.BodyText)
+		in.Response.Body = json.RawMessage(s.replace(string(in.Response.Body)))
+		in.Response.BodyText = s.replace(in.Response.BodyText)
+	}
+}
+
+func (s *Sanitizer) query(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return s.replace(rawQuery)
+	}
+	clean := url.Values{}
+	for k, vs := range values {
+		for _, v := range vs {
+			clean.Add(k, s.replace(v))
+		}
+	}
+	// Encode ordena pelas chaves
+	return clean.Encode()
+}
+
+func (s *Sanitizer) body(body []byte) []byte {
+	text := s.replace(string(body))
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(text), &decoded); err != nil {
+		return []byte(text)
+	}
+	decoded = s.walk(decoded)
+	// Sem escape de HTML, para o marcador ficar legível no cassete
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(decoded); err != nil {
+		return []byte(text)
+	}
+	return []byte(s.replace(out.String()))
+}
+
+// walk troca os campos sensíveis em qualquer nível do JSON
+func (s *Sanitizer) walk(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			switch {
+			case containsFold(s.SecretKeys, k):
+				if str, ok := item.(string); ok && str != "" && str != Redacted {
+					s.learn(str)
+				}
+				val[k] = Redacted
+			case containsFold(s.PIIKeys, k):
+				if str, ok := item.(string); ok && str != "" && !contains(s.Keep, str) {
+					val[k] = Redacted
+				}
+			default:
+				val[k] = s.walk(item)
+			}
+		}
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = s.walk(item)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+func (s *Sanitizer) learn(secret string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.learned == nil {
+		s.learned = make(map[string]bool)
+	}
+	s.learned[secret] = true
+}
+
+// replace aplica Replace e os segredos aprendidos, os mais longos primeiro
+func (s *Sanitizer) replace(text string) string {
+	if text == "" {
+		return text
+	}
+	s.mu.Lock()
+	pairs := make([][2]string, 0, len(s.Replace)+len(s.learned))
+	for from, to := range s.Replace {
+		pairs = append(pairs, [2]string{from, to})
+	}
+	for secret := range s.learned {
+		pairs = append(pairs, [2]string{secret, Redacted})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(pairs, func(i, j int) bool { return len(pairs[i][0]) > len(pairs[j][0]) })
+	for _, p := range pairs {
+		if p[0] == "" || p[0] == p[1] {
+			continue
+		}
+		text = strings.ReplaceAll(text, p[0], p[1])
+		// Valores em query string chegam codificados
+		if escaped := url.QueryEscape(p[0]); escaped != p[0] {
+			text = strings.ReplaceAll(text, escaped, url.QueryEscape(p[1]))
+		}
+	}
+	return text
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, v string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, v) {
+			return true
+		}
+	}
+	return false
+}
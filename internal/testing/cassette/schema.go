@@ -0,0 +1,182 @@
+package cassette
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Schema subconjunto do JSON Schema usado nos contratos: type (um ou
+// lista), properties, required, additionalProperties (booleano ou schema),
+// items, enum, pattern e minLength
+type Schema struct {
+	Type                 typeList           `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties json.RawMessage    `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+}
+
+// typeList aceita "type": "string" e "type": ["string", "null"]
+type typeList []string
+
+func (t *typeList) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = typeList{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return fmt.Errorf("type deve ser string ou lista: %s", data)
+	}
+	*t = many
+	return nil
+}
+
+// LoadSchema lê um schema de arquivo
+func LoadSchema(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("schema %s inválido: %w", path, err)
+	}
+	return &s, nil
+}
+
+// Validate confere o documento JSON e retorna as divergências, com o
+// caminho de cada uma (vazio = válido)
+func (s *Schema) Validate(data []byte) []string {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return []string{fmt.Sprintf("$: JSON inválido: %v", err)}
+	}
+	var problems []string
+	s.validate("$", doc, &problems)
+	return problems
+}
+
+func (s *Schema) validate(path string, v interface{}, problems *[]string) {
+	report := func(format string, args ...interface{}) {
+		*problems = append(*problems, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if len(s.Type) > 0 && !s.Type.matches(v) {
+		report("esperado %s, recebido %s", strings.Join(s.Type, " ou "), typeOf(v))
+		return
+	}
+	if len(s.Enum) > 0 {
+		found := false
+		for _, option := range s.Enum {
+			if fmt.Sprint(option) == fmt.Sprint(v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			report("valor %v fora de %v", v, s.Enum)
+		}
+	}
+
+	switch val := v.(type) {
+	case string:
+		if s.MinLength != nil && len([]rune(val)) < *s.MinLength {
+			report("menos de %d caracteres", *s.MinLength)
+		}
+		if s.Pattern != "" {
+			re, err := regexp.Compile(s.Pattern)
+			if err != nil {
+				report("pattern inválido no schema: %v", err)
+			} else if !re.MatchString(val) {
+				report("%q não segue %s", val, s.Pattern)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := val[name]; !ok {
+				report("campo obrigatório %q ausente", name)
+			}
+		}
+		extra, allowExtra := s.additional()
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := path + "." + k
+			if prop, ok := s.Properties[k]; ok {
+				prop.validate(child, val[k], problems)
+			} else if extra != nil {
+				extra.validate(child, val[k], problems)
+			} else if !allowExtra {
+				*problems = append(*problems, child+": campo não previsto no contrato")
+			}
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range val {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, problems)
+			}
+		}
+	}
+}
+
+// additional interpreta additionalProperties: ausente ou true = livre,
+// false = proibido, objeto = schema dos campos extras
+func (s *Schema) additional() (*Schema, bool) {
+	raw := strings.TrimSpace(string(s.AdditionalProperties))
+	switch raw {
+	case "", "true":
+		return nil, true
+	case "false":
+		return nil, false
+	}
+	var extra Schema
+	if err := json.Unmarshal(s.AdditionalProperties, &extra); err != nil {
+		return nil, true
+	}
+	return &extra, true
+}
+
+func (t typeList) matches(v interface{}) bool {
+	actual := typeOf(v)
+	for _, want := range t {
+		if want == actual || (want == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func typeOf(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if val == math.Trunc(val) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
@@ -0,0 +1,188 @@
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Classifier dá o nome lógico do endpoint de uma requisição
+type Classifier func(method, path string) string
+
+// Recorder proxy que repassa as requisições para a API real e grava as
+// interações sanitizadas. O client deve usar a URL do servidor de teste
+// no lugar da URL da API.
+type Recorder struct {
+	upstream  *url.URL
+	client    *http.Client
+	sanitizer *Sanitizer
+	classify  Classifier
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder cria o proxy para upstream (URL base da API, com o path de versão)
+func NewRecorder(name, upstream string, sanitizer *Sanitizer, classify Classifier) (*Recorder, error) {
+	u, err := url.Parse(strings.TrimRight(upstream, "/"))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("URL da API inválida: %q", upstream)
+	}
+	return &Recorder{
+		upstream:  u,
+		client:    &http.Client{Timeout: 30 * time.Second},
+		sanitizer: sanitizer,
+		classify:  classify,
+		cassette:  Cassette{Name: name, Source: u.Host, RecordedAt: time.Now().UTC().Truncate(time.Second)},
+	}, nil
+}
+
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	target := *r.upstream
+	target.Path = r.upstream.Path + req.URL.Path
+	target.RawQuery = req.URL.RawQuery
+	out, err := http.NewRequestWithContext(req.Context(), req.Method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	for _, h := range []string{"Authorization", "Content-Type", "Accept"} {
+		if v := req.Header.Get(h); v != "" {
+			out.Header.Set(h, v)
+		}
+	}
+
+	resp, err := r.client.Do(out)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(resp.Body)
+
+	interaction := Interaction{
+		Endpoint: r.classify(req.Method, req.URL.Path),
+		Request:  r.sanitizer.Request(req.Method, req.URL.Path, req.URL.RawQuery, body),
+		Response: r.sanitizer.Response(resp.StatusCode, resp.Header.Get("Content-Type"), respBody),
+	}
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+
+	// O client recebe a resposta original
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		w.Header().Set("Content-Type", ct)
+	}
+	w.WriteHeader(resp.StatusCode)
+	w.Write(respBody)
+}
+
+// Cassette retorna o que foi gravado, com os segredos aprendidos aplicados
+func (r *Recorder) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := r.cassette
+	c.Interactions = append([]Interaction(nil), r.cassette.Interactions...)
+	r.sanitizer.Finish(&c)
+	return &c
+}
+
+// Player reproduz um cassete. Cada requisição consome a primeira interação
+// ainda não usada com mesmo método, path, query e corpo (JSON comparado
+// por valor); sem correspondência responde 501 e registra a falta.
+type Player struct {
+	cassette  *Cassette
+	sanitizer *Sanitizer
+
+	mu     sync.Mutex
+	used   []bool
+	misses []string
+}
+
+func NewPlayer(c *Cassette, sanitizer *Sanitizer) *Player {
+	return &Player{cassette: c, sanitizer: sanitizer, used: make([]bool, len(c.Interactions))}
+}
+
+func (p *Player) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	got := p.sanitizer.Request(req.Method, req.URL.Path, req.URL.RawQuery, body)
+
+	p.mu.Lock()
+	match := -1
+	for i, in := range p.cassette.Interactions {
+		if !p.used[i] && sameRequest(in.Request, got) {
+			match = i
+			p.used[i] = true
+			break
+		}
+	}
+	if match < 0 {
+		p.misses = append(p.misses, describe(got))
+	}
+	p.mu.Unlock()
+
+	if match < 0 {
+		http.Error(w, "requisição fora do cassete: "+describe(got), http.StatusNotImplemented)
+		return
+	}
+	resp := p.cassette.Interactions[match].Response
+	if resp.ContentType != "" {
+		w.Header().Set("Content-Type", resp.ContentType)
+	}
+	w.WriteHeader(resp.Status)
+	w.Write(resp.RawBody())
+}
+
+// Misses requisições que não estavam no cassete
+func (p *Player) Misses() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.misses...)
+}
+
+// Unused interações gravadas que o client não pediu
+func (p *Player) Unused() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var out []string
+	for i, used := range p.used {
+		if !used {
+			out = append(out, describe(p.cassette.Interactions[i].Request))
+		}
+	}
+	return out
+}
+
+func sameRequest(want, got Request) bool {
+	if want.Method != got.Method || want.Path != got.Path || want.Query != got.Query || want.BodyText != got.BodyText {
+		return false
+	}
+	if len(want.Body) == 0 || len(got.Body) == 0 {
+		return len(want.Body) == len(got.Body)
+	}
+	var a, b interface{}
+	if json.Unmarshal(want.Body, &a) != nil || json.Unmarshal(got.Body, &b) != nil {
+		return bytes.Equal(want.Body, got.Body)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func describe(r Request) string {
+	s := r.Method + " " + r.Path
+	if r.Query != "" {
+		s += "?" + r.Query
+	}
+	if len(r.Body) > 0 {
+		s += " " + string(r.Body)
+	}
+	return s
+}
@@ -0,0 +1,137 @@
+// Package fakes simula em memória as APIs externas (Superlógica e Rede
+// Parcerias) para rodar o servidor offline e para testes de ponta a ponta.
+//
+// Um Server atende as duas APIs sob prefixos próprios e expõe uma API de
+// controle em /_fakes para injetar falhas (latência, 5xx, 422 "já cadastrado",
+// token expirado) e inspecionar o estado:
+//
+//	GET    /_fakes/state                estado, falhas ativas e contagem de chamadas
+//	PUT    /_fakes/faults/{endpoint}    ativa uma falha (corpo: Fault)
+//	DELETE /_fakes/faults[/{endpoint}]  remove uma ou todas as falhas
+//	POST   /_fakes/expire-tokens        expira os tokens emitidos por /auth
+//	POST   /_fakes/reset                volta às fixtures e limpa falhas e contadores
+package fakes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Prefixos das APIs no Server
+const (
+	SuperlogicaPrefix   = "/superlogica"
+	RedeParceriasPrefix = "/rede-parcerias"
+	ControlPrefix       = "/_fakes"
+)
+
+// Server reúne os fakes e as falhas compartilhadas
+type Server struct {
+	Superlogica   *Superlogica
+	RedeParcerias *RedeParcerias
+	Faults        *Faults
+
+	fixtures Fixtures
+	mux      *http.ServeMux
+}
+
+func NewServer(fx Fixtures) *Server {
+	faults := NewFaults()
+	s := &Server{
+		Superlogica:   NewSuperlogica(fx.Superlogica, faults),
+		RedeParcerias: NewRedeParcerias(fx.RedeParcerias, faults),
+		Faults:        faults,
+		fixtures:      fx,
+		mux:           http.NewServeMux(),
+	}
+	s.mux.Handle(SuperlogicaPrefix+"/", http.StripPrefix(SuperlogicaPrefix, s.Superlogica))
+	s.mux.Handle(RedeParceriasPrefix+"/", http.StripPrefix(RedeParceriasPrefix, s.RedeParcerias))
+	s.mux.HandleFunc(ControlPrefix+"/", s.control)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Reset volta às fixtures iniciais e limpa falhas e contadores
+func (s *Server) Reset() {
+	s.Superlogica.Reset(s.fixtures.Superlogica)
+	s.RedeParcerias.Reset(s.fixtures.RedeParcerias)
+	s.Faults.Clear("")
+	s.Faults.ResetCalls()
+}
+
+// Env retorna as variáveis de ambiente que apontam o servidor para os fakes
+// publicados em baseURL (ex.: "http://127.0.0.1:9090")
+func (s *Server) Env(baseURL string) map[string]string {
+	baseURL = strings.TrimRight(baseURL, "/")
+	sl, rp := s.fixtures.Superlogica, s.fixtures.RedeParcerias
+	// Credenciais vazias nas fixtures aceitam qualquer valor
+	return map[string]string{
+		"SUPERLOGICA_URL":              baseURL + SuperlogicaPrefix,
+		"SUPERLOGICA_APP_TOKEN":        orDefault(sl.AppToken, "fake"),
+		"SUPERLOGICA_ACCESS_TOKEN":     orDefault(sl.AccessToken, "fake"),
+		"REDE_PARCERIAS_URL":           baseURL + RedeParceriasPrefix,
+		"REDE_PARCERIAS_CLIENT_ID":     rp.ClientID,
+		"REDE_PARCERIAS_CLIENT_SECRET": rp.ClientSecret,
+		"REDE_PARCERIAS_BEARER_TOKEN":  rp.BearerToken,
+	}
+}
+
+// state é a resposta de GET /_fakes/state
+type state struct {
+	Faults map[string]Fault `json:"faults"`
+	Calls  map[string]int   `json:"calls"`
+	Units  []Unit           `json:"units"`
+	Users  []User           `json:"users"`
+}
+
+func (s *Server) control(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, ControlPrefix), "/")
+
+	switch {
+	case path == "state" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, state{
+			Faults: s.Faults.Active(),
+			Calls:  s.Faults.Calls(),
+			Units:  s.Superlogica.Units(),
+			Users:  s.RedeParcerias.Users(),
+		})
+	case strings.HasPrefix(path, "faults/") && r.Method == http.MethodPut:
+		var fault Fault
+		if err := json.NewDecoder(r.Body).Decode(&fault); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "JSON inválido: " + err.Error()})
+			return
+		}
+		if err := s.Faults.Set(strings.TrimPrefix(path, "faults/"), fault); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": err.Error(), "endpoints": Endpoints})
+			return
+		}
+		writeJSON(w, http.StatusOK, s.Faults.Active())
+	case (path == "faults" || strings.HasPrefix(path, "faults/")) && r.Method == http.MethodDelete:
+		s.Faults.Clear(strings.TrimPrefix(strings.TrimPrefix(path, "faults"), "/"))
+		writeJSON(w, http.StatusOK, s.Faults.Active())
+	case path == "expire-tokens" && r.Method == http.MethodPost:
+		s.RedeParcerias.ExpireTokens()
+		w.WriteHeader(http.StatusNoContent)
+	case path == "reset" && r.Method == http.MethodPost:
+		s.Reset()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "rota de controle desconhecida"})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func orDefault(val, def string) string {
+	if val == "" {
+		return def
+	}
+	return val
+}
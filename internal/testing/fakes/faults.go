@@ -0,0 +1,199 @@
+package fakes
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Endpoints em que se pode injetar falhas
+const (
+	EndpointUnits      = "superlogica.units"
+	EndpointAuth       = "rede_parcerias.auth"
+	EndpointFindUser   = "rede_parcerias.find_user"
+	EndpointGetUser    = "rede_parcerias.get_user"
+	EndpointCreateUser = "rede_parcerias.create_user"
+	EndpointDeleteUser = "rede_parcerias.delete_user"
+	EndpointSSO        = "rede_parcerias.sso"
+)
+
+// Endpoints lista os endpoints conhecidos
+var Endpoints = []string{
+	EndpointUnits,
+	EndpointAuth,
+	EndpointFindUser,
+	EndpointGetUser,
+	EndpointCreateUser,
+	EndpointDeleteUser,
+	EndpointSSO,
+}
+
+// Fault falha injetada em um endpoint. A latência se soma a qualquer das
+// respostas; Status, AlreadyRegistered e ExpiredToken substituem a resposta
+// normal (nessa ordem de prioridade).
+type Fault struct {
+	LatencyMs int `json:"latency_ms,omitempty"`
+	// Status responde com este código (ex.: 500, 503) e corpo de erro genérico
+	Status int `json:"status,omitempty"`
+	// AlreadyRegistered responde 422 "já cadastrado" (só em create_user)
+	AlreadyRegistered bool `json:"already_registered,omitempty"`
+	// ExpiredToken responde 401 como se o token tivesse expirado
+	ExpiredToken bool `json:"expired_token,omitempty"`
+	// Times quantas requisições a falha afeta (0 = todas, até ser removida)
+	Times int `json:"times,omitempty"`
+}
+
+// Request requisição recebida por um fake, na ordem de chegada
+type Request struct {
+	Endpoint string     `json:"endpoint"`
+	Method   string     `json:"method"`
+	Path     string     `json:"path"`
+	Query    url.Values `json:"query,omitempty"`
+}
+
+// Faults falhas ativas por endpoint, compartilhadas pelos fakes. Também
+// registra as requisições recebidas, para os testes conferirem as chamadas.
+type Faults struct {
+	mu       sync.Mutex
+	rules    map[string]Fault
+	counts   map[string]int
+	requests []Request
+}
+
+func NewFaults() *Faults {
+	return &Faults{rules: make(map[string]Fault), counts: make(map[string]int)}
+}
+
+// Set ativa (ou substitui) a falha do endpoint
+func (f *Faults) Set(endpoint string, fault Fault) error {
+	if !knownEndpoint(endpoint) {
+		return fmt.Errorf("endpoint desconhecido: %q", endpoint)
+	}
+	if fault.Status != 0 && (fault.Status < 400 || fault.Status > 599) {
+		return fmt.Errorf("status deve ser 4xx ou 5xx: %d", fault.Status)
+	}
+	if fault.AlreadyRegistered && endpoint != EndpointCreateUser {
+		return fmt.Errorf("already_registered só se aplica a %s", EndpointCreateUser)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules[endpoint] = fault
+	return nil
+}
+
+// Clear remove a falha do endpoint ("" = todas)
+func (f *Faults) Clear(endpoint string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if endpoint == "" {
+		f.rules = make(map[string]Fault)
+		return
+	}
+	delete(f.rules, endpoint)
+}
+
+// Active retorna as falhas ativas
+func (f *Faults) Active() map[string]Fault {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make(map[string]Fault, len(f.rules))
+	for k, v := range f.rules {
+		out[k] = v
+	}
+	return out
+}
+
+// Calls retorna quantas requisições cada endpoint recebeu
+func (f *Faults) Calls() map[string]int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make(map[string]int, len(f.counts))
+	for k, v := range f.counts {
+		out[k] = v
+	}
+	return out
+}
+
+// Requests retorna as requisições recebidas (do endpoint, ou todas se "")
+func (f *Faults) Requests(endpoint string) []Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []Request
+	for _, req := range f.requests {
+		if endpoint == "" || req.Endpoint == endpoint {
+			out = append(out, req)
+		}
+	}
+	return out
+}
+
+// ResetCalls zera os contadores e o registro de requisições
+func (f *Faults) ResetCalls() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.counts = make(map[string]int)
+	f.requests = nil
+}
+
+// take registra a requisição e consome uma ocorrência da falha do endpoint
+func (f *Faults) take(endpoint string, r *http.Request) (Fault, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.counts[endpoint]++
+	f.requests = append(f.requests, Request{
+		Endpoint: endpoint,
+		Method:   r.Method,
+		Path:     r.URL.Path,
+		Query:    r.URL.Query(),
+	})
+	fault, ok := f.rules[endpoint]
+	if !ok {
+		return Fault{}, false
+	}
+	if fault.Times > 0 {
+		if fault.Times == 1 {
+			delete(f.rules, endpoint)
+		} else {
+			next := fault
+			next.Times--
+			f.rules[endpoint] = next
+		}
+	}
+	return fault, true
+}
+
+// inject aplica a falha do endpoint. Retorna true se já respondeu; a
+// latência sozinha não responde. A falha 422 é tratada por quem chama.
+func (f *Faults) inject(w http.ResponseWriter, r *http.Request, endpoint string) (Fault, bool) {
+	fault, ok := f.take(endpoint, r)
+	if !ok {
+		return Fault{}, false
+	}
+	if fault.LatencyMs > 0 {
+		select {
+		case <-time.After(time.Duration(fault.LatencyMs) * time.Millisecond):
+		case <-r.Context().Done():
+			return fault, true
+		}
+	}
+	switch {
+	case fault.Status != 0:
+		writeJSON(w, fault.Status, map[string]string{"message": fmt.Sprintf("Falha simulada (%d)", fault.Status)})
+		return fault, true
+	case fault.ExpiredToken:
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "Token expirado."})
+		return fault, true
+	}
+	return fault, false
+}
+
+func knownEndpoint(endpoint string) bool {
+	for _, known := range Endpoints {
+		if known == endpoint {
+			return true
+		}
+	}
+	return false
+}
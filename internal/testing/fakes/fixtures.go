@@ -0,0 +1,89 @@
+package fakes
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+//go:embed fixtures/default.json
+var defaultFixtures []byte
+
+// Fixtures dados iniciais dos fakes
+type Fixtures struct {
+	Superlogica   SuperlogicaFixtures   `json:"superlogica"`
+	RedeParcerias RedeParceriasFixtures `json:"rede_parcerias"`
+}
+
+// SuperlogicaFixtures credenciais aceitas (vazias = qualquer valor não vazio)
+// e as unidades do condomínio
+type SuperlogicaFixtures struct {
+	AppToken    string `json:"app_token"`
+	AccessToken string `json:"access_token"`
+	Units       []Unit `json:"units"`
+}
+
+// Unit unidade no formato de unidades/index
+type Unit struct {
+	IDUnidade            string `json:"id_unidade_uni"`
+	IDCondominio         string `json:"id_condominio_cond"`
+	NomeProprietario     string `json:"nome_proprietario"`
+	EmailProprietario    string `json:"email_proprietario"`
+	CelularProprietario  string `json:"celular_proprietario"`
+	TelefoneProprietario string `json:"telefone_proprietario"`
+	CPFProprietario      string `json:"cpf_proprietario"`
+}
+
+// RedeParceriasFixtures credenciais OAuth2, token fixo (REDE_PARCERIAS_BEARER_TOKEN)
+// e os usuários já cadastrados no clube
+type RedeParceriasFixtures struct {
+	ClientID        string `json:"client_id"`
+	ClientSecret    string `json:"client_secret"`
+	BearerToken     string `json:"bearer_token"`
+	TokenTTLSeconds int    `json:"token_ttl_seconds"`
+	SSOBaseURL      string `json:"sso_base_url"`
+	Users           []User `json:"users"`
+}
+
+// User usuário no formato de /users
+type User struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	CPF       string `json:"cpf"`
+	Cellphone string `json:"cellphone"`
+	Active    bool   `json:"active"`
+}
+
+// DefaultFixtures cobre os cenários da árvore de decisão no condomínio 4:
+// morador novo, já cadastrado ativo, cadastrado inativo, sem e-mail e um
+// usuário do clube que não é mais morador
+func DefaultFixtures() Fixtures {
+	fx, err := parseFixtures(defaultFixtures)
+	if err != nil {
+		panic(fmt.Sprintf("fakes: fixtures padrão inválidas: %v", err))
+	}
+	return fx
+}
+
+// LoadFixtures lê as fixtures de um arquivo JSON no formato de fixtures/default.json
+func LoadFixtures(path string) (Fixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Fixtures{}, err
+	}
+	fx, err := parseFixtures(data)
+	if err != nil {
+		return Fixtures{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return fx, nil
+}
+
+func parseFixtures(data []byte) (Fixtures, error) {
+	var fx Fixtures
+	if err := json.Unmarshal(data, &fx); err != nil {
+		return Fixtures{}, fmt.Errorf("erro lendo fixtures: %w", err)
+	}
+	return fx, nil
+}
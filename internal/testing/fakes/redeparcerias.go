@@ -0,0 +1,337 @@
+package fakes
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RedeParcerias simula a API client da Rede Parcerias: POST /auth
+// (client_credentials), GET/POST /users, GET/DELETE /users/{id} e
+// GET /sso-token. As rotas autenticadas aceitam o token fixo das fixtures ou
+// um emitido por /auth dentro da validade.
+type RedeParcerias struct {
+	faults *Faults
+
+	mu           sync.Mutex
+	clientID     string
+	clientSecret string
+	bearerToken  string
+	tokenTTL     time.Duration
+	ssoBaseURL   string
+	tokens       map[string]time.Time
+	users        []User
+	nextID       int
+}
+
+func NewRedeParcerias(fx RedeParceriasFixtures, faults *Faults) *RedeParcerias {
+	rp := &RedeParcerias{faults: faults}
+	rp.Reset(fx)
+	return rp
+}
+
+// Reset substitui credenciais e usuários. Os tokens já emitidos continuam
+// válidos, como no clube real; para expirá-los use ExpireTokens.
+func (rp *RedeParcerias) Reset(fx RedeParceriasFixtures) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	rp.clientID, rp.clientSecret, rp.bearerToken = fx.ClientID, fx.ClientSecret, fx.BearerToken
+	rp.tokenTTL = time.Duration(fx.TokenTTLSeconds) * time.Second
+	if rp.tokenTTL <= 0 {
+		rp.tokenTTL = time.Hour
+	}
+	rp.ssoBaseURL = fx.SSOBaseURL
+	if rp.ssoBaseURL == "" {
+		rp.ssoBaseURL = "https://clube.example/sso"
+	}
+	if rp.tokens == nil {
+		rp.tokens = make(map[string]time.Time)
+	}
+	rp.users = append([]User(nil), fx.Users...)
+	rp.nextID = len(rp.users)
+}
+
+// ExpireTokens faz os tokens emitidos por /auth expirarem; o token fixo continua válido
+func (rp *RedeParcerias) ExpireTokens() {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	for token := range rp.tokens {
+		rp.tokens[token] = time.Time{}
+	}
+}
+
+// AddUser inclui um usuário (ID gerado se vazio) e o retorna
+func (rp *RedeParcerias) AddUser(user User) User {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	if user.ID == "" {
+		user.ID = rp.newUserID()
+	}
+	rp.users = append(rp.users, user)
+	return user
+}
+
+// Users retorna os usuários atuais
+func (rp *RedeParcerias) Users() []User {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	return append([]User(nil), rp.users...)
+}
+
+// FindCPF retorna o usuário com o CPF, se houver
+func (rp *RedeParcerias) FindCPF(cpf string) (User, bool) {
+	cpf = nonDigitRegex.ReplaceAllString(cpf, "")
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	for _, user := range rp.users {
+		if nonDigitRegex.ReplaceAllString(user.CPF, "") == cpf {
+			return user, true
+		}
+	}
+	return User{}, false
+}
+
+func (rp *RedeParcerias) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+
+	switch {
+	case path == "/auth" && r.Method == http.MethodPost:
+		rp.auth(w, r)
+	case path == "/users" && r.Method == http.MethodGet:
+		rp.withToken(w, r, EndpointFindUser, rp.findUsers)
+	case path == "/users" && r.Method == http.MethodPost:
+		rp.withToken(w, r, EndpointCreateUser, rp.createUser)
+	case strings.HasPrefix(path, "/users/") && r.Method == http.MethodGet:
+		rp.withToken(w, r, EndpointGetUser, rp.getUser)
+	case strings.HasPrefix(path, "/users/") && r.Method == http.MethodDelete:
+		rp.withToken(w, r, EndpointDeleteUser, rp.deleteUser)
+	case path == "/sso-token" && r.Method == http.MethodGet:
+		rp.withToken(w, r, EndpointSSO, rp.ssoToken)
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found."})
+	}
+}
+
+func (rp *RedeParcerias) auth(w http.ResponseWriter, r *http.Request) {
+	if _, done := rp.faults.inject(w, r, EndpointAuth); done {
+		return
+	}
+	var req struct {
+		GrantType    string `json:"grant_type"`
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.GrantType != "client_credentials" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	if req.ClientID != rp.clientID || req.ClientSecret != rp.clientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client", "message": "Client authentication failed"})
+		return
+	}
+	token := "fake-" + randomHex(16)
+	rp.tokens[token] = time.Now().Add(rp.tokenTTL)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"token_type":   "Bearer",
+		"expires_in":   int(rp.tokenTTL.Seconds()),
+		"access_token": token,
+	})
+}
+
+// withToken aplica a falha do endpoint, valida o Bearer e chama a rota
+func (rp *RedeParcerias) withToken(w http.ResponseWriter, r *http.Request, endpoint string, next func(http.ResponseWriter, *http.Request, Fault)) {
+	fault, done := rp.faults.inject(w, r, endpoint)
+	if done {
+		return
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !rp.validToken(token) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "Unauthenticated."})
+		return
+	}
+	next(w, r, fault)
+}
+
+func (rp *RedeParcerias) validToken(token string) bool {
+	if token == "" {
+		return false
+	}
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	if rp.bearerToken != "" && token == rp.bearerToken {
+		return true
+	}
+	exp, ok := rp.tokens[token]
+	return ok && time.Now().Before(exp)
+}
+
+// findUsers busca por CPF (só dígitos), e-mail ou parte do nome
+func (rp *RedeParcerias) findUsers(w http.ResponseWriter, r *http.Request, _ Fault) {
+	search := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("search")))
+	limit := atoiDefault(r.URL.Query().Get("limit"), 15)
+	digits := nonDigitRegex.ReplaceAllString(search, "")
+
+	rp.mu.Lock()
+	data := []User{}
+	for _, user := range rp.users {
+		if len(data) >= limit {
+			break
+		}
+		if search == "" ||
+			(digits != "" && digits == search && nonDigitRegex.ReplaceAllString(user.CPF, "") == digits) ||
+			strings.ToLower(user.Email) == search ||
+			strings.Contains(strings.ToLower(user.Name), search) {
+			data = append(data, user)
+		}
+	}
+	rp.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+}
+
+func (rp *RedeParcerias) createUser(w http.ResponseWriter, r *http.Request, fault Fault) {
+	if fault.AlreadyRegistered {
+		alreadyRegistered(w, "cpf")
+		return
+	}
+	var req struct {
+		Name       string `json:"name"`
+		Email      string `json:"email"`
+		CPF        string `json:"cpf"`
+		Cellphone  string `json:"cellphone"`
+		Authorized *bool  `json:"authorized"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "JSON inválido."})
+		return
+	}
+	required := []struct{ field, value string }{{"name", req.Name}, {"email", req.Email}, {"cpf", req.CPF}}
+	for _, f := range required {
+		if strings.TrimSpace(f.value) == "" {
+			msg := fmt.Sprintf("O campo %s é obrigatório.", f.field)
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+				"message": msg,
+				"errors":  map[string][]string{f.field: {msg}},
+			})
+			return
+		}
+	}
+
+	cpf := nonDigitRegex.ReplaceAllString(req.CPF, "")
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	for _, user := range rp.users {
+		if nonDigitRegex.ReplaceAllString(user.CPF, "") == cpf {
+			alreadyRegistered(w, "cpf")
+			return
+		}
+		if strings.EqualFold(user.Email, req.Email) {
+			alreadyRegistered(w, "email")
+			return
+		}
+	}
+
+	user := User{
+		ID:        rp.newUserID(),
+		Name:      req.Name,
+		Email:     req.Email,
+		CPF:       cpf,
+		Cellphone: req.Cellphone,
+		Active:    req.Authorized == nil || *req.Authorized,
+	}
+	rp.users = append(rp.users, user)
+	writeJSON(w, http.StatusCreated, user)
+}
+
+func (rp *RedeParcerias) getUser(w http.ResponseWriter, r *http.Request, _ Fault) {
+	id := userIDFromPath(r)
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	for _, user := range rp.users {
+		if user.ID == id {
+			writeJSON(w, http.StatusOK, user)
+			return
+		}
+	}
+	writeJSON(w, http.StatusNotFound, map[string]string{"message": "Usuário não encontrado."})
+}
+
+func (rp *RedeParcerias) deleteUser(w http.ResponseWriter, r *http.Request, _ Fault) {
+	id := userIDFromPath(r)
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	for i, user := range rp.users {
+		if user.ID == id {
+			rp.users = append(rp.users[:i], rp.users[i+1:]...)
+			writeJSON(w, http.StatusOK, map[string]string{"message": "Usuário removido."})
+			return
+		}
+	}
+	writeJSON(w, http.StatusNotFound, map[string]string{"message": "Usuário não encontrado."})
+}
+
+// ssoToken aceita o ID ou o e-mail do usuário em user_id
+func (rp *RedeParcerias) ssoToken(w http.ResponseWriter, r *http.Request, _ Fault) {
+	identifier := r.URL.Query().Get("user_id")
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	for _, user := range rp.users {
+		if user.ID != identifier && !strings.EqualFold(user.Email, identifier) {
+			continue
+		}
+		token := randomHex(20)
+		writeJSON(w, http.StatusOK, map[string]string{
+			"token":    token,
+			"redirect": rp.ssoBaseURL + "?token=" + url.QueryEscape(token),
+		})
+		return
+	}
+	writeJSON(w, http.StatusNotFound, map[string]string{"message": "Usuário não encontrado."})
+}
+
+// newUserID gera um UUID sequencial que não colide com os das fixtures
+func (rp *RedeParcerias) newUserID() string {
+	for {
+		rp.nextID++
+		id := fmt.Sprintf("00000000-0000-4000-8000-%012d", rp.nextID)
+		taken := false
+		for _, user := range rp.users {
+			if user.ID == id {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			return id
+		}
+	}
+}
+
+// alreadyRegistered responde como a Rede Parcerias quando o CPF ou o e-mail já existe
+func alreadyRegistered(w http.ResponseWriter, field string) {
+	msg := fmt.Sprintf("O campo %s já está cadastrado.", field)
+	writeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+		"message": msg,
+		"errors":  map[string][]string{field: {msg}},
+	})
+}
+
+func userIDFromPath(r *http.Request) string {
+	id, _ := url.PathUnescape(strings.TrimPrefix(strings.TrimSuffix(r.URL.Path, "/"), "/users/"))
+	return id
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
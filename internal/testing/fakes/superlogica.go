@@ -0,0 +1,130 @@
+package fakes
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var nonDigitRegex = regexp.MustCompile(`\D`)
+
+// Superlogica simula GET /unidades/index da API Condor: exige os cabeçalhos
+// app_token e access_token, filtra por idCondominio ("-1" = todos) e por
+// pesquisa (CPF, com ou sem pontuação) e pagina com pagina/itensPorPagina
+type Superlogica struct {
+	faults *Faults
+
+	mu          sync.RWMutex
+	appToken    string
+	accessToken string
+	units       []Unit
+}
+
+func NewSuperlogica(fx SuperlogicaFixtures, faults *Faults) *Superlogica {
+	s := &Superlogica{faults: faults}
+	s.Reset(fx)
+	return s
+}
+
+// Reset substitui credenciais e unidades
+func (s *Superlogica) Reset(fx SuperlogicaFixtures) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.appToken, s.accessToken = fx.AppToken, fx.AccessToken
+	s.units = append([]Unit(nil), fx.Units...)
+}
+
+// AddUnit inclui uma unidade (ex.: morador que acabou de se mudar)
+func (s *Superlogica) AddUnit(unit Unit) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.units = append(s.units, unit)
+}
+
+// RemoveCPF remove as unidades do proprietário (ex.: morador que saiu) e
+// retorna quantas foram removidas
+func (s *Superlogica) RemoveCPF(cpf string) int {
+	cpf = nonDigitRegex.ReplaceAllString(cpf, "")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.units[:0]
+	for _, unit := range s.units {
+		if nonDigitRegex.ReplaceAllString(unit.CPFProprietario, "") != cpf {
+			kept = append(kept, unit)
+		}
+	}
+	removed := len(s.units) - len(kept)
+	s.units = kept
+	return removed
+}
+
+// Units retorna as unidades atuais
+func (s *Superlogica) Units() []Unit {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Unit(nil), s.units...)
+}
+
+func (s *Superlogica) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet || strings.TrimSuffix(r.URL.Path, "/") != "/unidades/index" {
+		writeJSON(w, http.StatusNotFound, map[string]string{"msg": "Recurso não encontrado"})
+		return
+	}
+	if _, done := s.faults.inject(w, r, EndpointUnits); done {
+		return
+	}
+	if !s.authorized(r) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"msg": "Credenciais inválidas"})
+		return
+	}
+
+	q := r.URL.Query()
+	condoID := q.Get("idCondominio")
+	search := nonDigitRegex.ReplaceAllString(q.Get("pesquisa"), "")
+	page := atoiDefault(q.Get("pagina"), 1)
+	perPage := atoiDefault(q.Get("itensPorPagina"), 50)
+
+	s.mu.RLock()
+	var matches []Unit
+	for _, unit := range s.units {
+		if condoID != "" && condoID != "-1" && unit.IDCondominio != condoID {
+			continue
+		}
+		if q.Get("pesquisa") != "" && nonDigitRegex.ReplaceAllString(unit.CPFProprietario, "") != search {
+			continue
+		}
+		matches = append(matches, unit)
+	}
+	s.mu.RUnlock()
+
+	start := (page - 1) * perPage
+	if start > len(matches) {
+		start = len(matches)
+	}
+	end := start + perPage
+	if end > len(matches) {
+		end = len(matches)
+	}
+	// A API responde lista vazia (e não null) quando não há unidades
+	writeJSON(w, http.StatusOK, append([]Unit{}, matches[start:end]...))
+}
+
+func (s *Superlogica) authorized(r *http.Request) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	app, access := r.Header.Get("app_token"), r.Header.Get("access_token")
+	if app == "" || access == "" {
+		return false
+	}
+	return (s.appToken == "" || app == s.appToken) && (s.accessToken == "" || access == s.accessToken)
+}
+
+func atoiDefault(v string, def int) int {
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return def
+	}
+	return n
+}
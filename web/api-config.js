@@ -81,7 +81,7 @@ async function callBackendAPI(endpoint, options = {}) {
         ...options.headers
       },
       mode: 'cors',
-      credentials: 'omit'
+      credentials: 'include' // cookie httpOnly do "lembrar de mim"
     });
 
     console.log(`📊 Status: ${response.status} ${response.statusText}`);
@@ -91,11 +91,22 @@ async function callBackendAPI(endpoint, options = {}) {
     const contentType = response.headers.get('content-type');
     
     if (!response.ok) {
-      // Tentar ler como texto para ver o erro
+      // Erros da API vêm como {"error": {code, message, fields, request_id}}
       const errorText = await response.text();
       console.error(`❌ Erro HTTP ${response.status}`);
       console.error(`Resposta: ${errorText.substring(0, 200)}`);
-      throw new Error(`HTTP ${response.status}: ${errorText.substring(0, 100)}`);
+      const error = new Error(`HTTP ${response.status}: ${errorText.substring(0, 100)}`);
+      error.status = response.status;
+      try {
+        const body = JSON.parse(errorText).error || {};
+        error.code = body.code;            // ex.: VALIDATION_ERROR, RATE_LIMITED, UNAVAILABLE
+        error.apiMessage = body.message;   // mensagem pronta para o usuário
+        error.fields = body.fields || {};  // mensagens por campo
+        error.requestId = body.request_id || response.headers.get('X-Request-Id');
+      } catch (e) {
+        // Corpo fora do formato (proxy, página de erro): fica só o status
+      }
+      throw error;
     }
 
     if (!contentType || !contentType.includes('application/json')) {